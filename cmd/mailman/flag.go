@@ -2,12 +2,15 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"strings"
 )
 
 type argsConfig struct {
 	configFiles []string
 	logLevel    string
+	command     string   // Command to run instead of the servers, e.g. "migrate"
+	commandArgs []string // Arguments of the command
 }
 
 func commandLineArgsConfig() argsConfig {
@@ -15,10 +18,20 @@ func commandLineArgsConfig() argsConfig {
 		"Comma-separated paths to configuration file(s), the last one has the highest priority")
 	logLevel := flag.String("log-level", "INFO", "Logging level: DEBUG, INFO, WARN, ERROR or FATAL")
 
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] [migrate up | migrate down [steps] | migrate version]\n", flag.CommandLine.Name())
+		flag.PrintDefaults()
+	}
+
 	flag.Parse()
 
-	return argsConfig{
+	argsCfg := argsConfig{
 		configFiles: strings.Split(*configFiles, ","),
 		logLevel:    *logLevel,
 	}
+	if flag.NArg() > 0 {
+		argsCfg.command = flag.Arg(0)
+		argsCfg.commandArgs = flag.Args()[1:]
+	}
+	return argsCfg
 }
@@ -1,12 +1,26 @@
 package main
 
 import (
+	"context"
+	"fmt"
+	"github.com/GeneralKenobi/mailman/internal/api"
+	"github.com/GeneralKenobi/mailman/internal/api/grpcapi"
 	"github.com/GeneralKenobi/mailman/internal/api/httpgin"
+	"github.com/GeneralKenobi/mailman/internal/auth"
+	"github.com/GeneralKenobi/mailman/internal/auth/apikey"
+	"github.com/GeneralKenobi/mailman/internal/auth/jwt"
 	"github.com/GeneralKenobi/mailman/internal/config"
+	"github.com/GeneralKenobi/mailman/internal/db"
+	"github.com/GeneralKenobi/mailman/internal/db/memory"
 	"github.com/GeneralKenobi/mailman/internal/db/postgres"
+	"github.com/GeneralKenobi/mailman/internal/db/sqlite"
 	"github.com/GeneralKenobi/mailman/internal/email/mock"
 	"github.com/GeneralKenobi/mailman/internal/job/mailingentry"
+	"github.com/GeneralKenobi/mailman/internal/job/webhook"
+	"github.com/GeneralKenobi/mailman/internal/service/mailingentry/progress"
+	"github.com/GeneralKenobi/mailman/internal/service/webhook/destination"
 	"github.com/GeneralKenobi/mailman/pkg/mdctx"
+	"github.com/GeneralKenobi/mailman/pkg/scheduler"
 	"github.com/GeneralKenobi/mailman/pkg/shutdown"
 	"os"
 	"os/signal"
@@ -15,13 +29,23 @@ import (
 )
 
 func main() {
-	configure()
-	parentCtx := shutdown.NewParentContext(time.Duration(config.Get().Global.ShutdownTimeoutSeconds) * time.Second)
-	bootstrap(parentCtx)
-	shutdownAfterStopSignal(parentCtx)
+	argsCfg := configure()
+	switch argsCfg.command {
+	case "":
+		parentCtx := shutdown.NewParentContext(time.Duration(config.Get().Global.ShutdownTimeoutSeconds) * time.Second)
+		bootstrap(parentCtx)
+		shutdownAfterStopSignal(parentCtx)
+	case "migrate":
+		err := migrate(context.Background(), argsCfg.commandArgs)
+		if err != nil {
+			mdctx.Fatalf(nil, "Error migrating DB: %v", err)
+		}
+	default:
+		mdctx.Fatalf(nil, "Unknown command %q", argsCfg.command)
+	}
 }
 
-func configure() {
+func configure() argsConfig {
 	argsCfg := commandLineArgsConfig()
 
 	err := config.Load(argsCfg.configFiles)
@@ -33,11 +57,12 @@ func configure() {
 	if err != nil {
 		mdctx.Fatalf(nil, "Error setting log level: %v", err)
 	}
+	return argsCfg
 }
 
 func bootstrap(parentCtx shutdown.ParentContext) {
 	// DB
-	dbCtx, err := postgres.NewContext(parentCtx.NewContext("postgres"))
+	dbCtx, err := newDbContext(parentCtx)
 	if err != nil {
 		mdctx.Fatalf(nil, "Error connecting to DB: %v", err)
 	}
@@ -45,17 +70,133 @@ func bootstrap(parentCtx shutdown.ParentContext) {
 	// Email service
 	emailer := mock.NewEmailer()
 
-	// Scheduled jobs
-	mailingEntryCleanupJob := mailingentry.NewCleanupJob(dbCtx)
-	go mailingEntryCleanupJob.RunScheduled(parentCtx.NewContext("scheduled stale mailing entry cleanup"))
+	// Scheduled jobs, each running on one replica at a time if the DB supports locks shared by replicas
+	locker := newSchedulerLocker(dbCtx)
+	partitioner, partitioned := dbCtx.(db.Partitioner)
+	mailingEntryCleanupJob := mailingentry.NewCleanupJob(dbCtx, partitioner)
+	go mailingEntryCleanupJob.RunScheduled(parentCtx.NewContext("scheduled stale mailing entry cleanup"), locker)
+	if partitioned {
+		partitionMaintenanceJob := mailingentry.NewPartitionMaintenanceJob(partitioner)
+		go partitionMaintenanceJob.RunScheduled(parentCtx.NewContext("scheduled mailing entry partition maintenance"), locker)
+	}
+	mailingEntryArchiveCleanupJob := mailingentry.NewArchiveCleanupJob(dbCtx)
+	go mailingEntryArchiveCleanupJob.RunScheduled(parentCtx.NewContext("scheduled mailing entry archive cleanup"), locker)
+	webhookDispatchJob := webhook.NewDispatchJob(dbCtx, destination.NewHttpClient())
+	go webhookDispatchJob.RunScheduled(parentCtx.NewContext("scheduled webhook dispatch"), locker)
+	webhookCleanupJob := webhook.NewCleanupJob(dbCtx)
+	go webhookCleanupJob.RunScheduled(parentCtx.NewContext("scheduled webhook event cleanup"), locker)
+
+	// Authentication
+	authenticator, err := newAuthenticator()
+	if err != nil {
+		mdctx.Fatalf(nil, "Error configuring authentication: %v", err)
+	}
+
+	// Progress of sending mailings, published by both servers and streamed by the HTTP server
+	progressBus := progress.NewBus()
+
+	// Rate limits, shared by both servers
+	rateLimiters := api.NewRateLimiters(config.Get().RateLimit.Groups)
 
 	// HTTP server
-	httpServer := httpgin.NewServer(dbCtx, emailer)
+	httpServer := httpgin.NewServer(dbCtx, emailer, authenticator, progressBus, rateLimiters)
 	go httpServer.Run(parentCtx.NewContext("http server"))
+
+	// gRPC server
+	grpcServer := grpcapi.NewServer(dbCtx, emailer, authenticator, progressBus, rateLimiters)
+	go grpcServer.Run(parentCtx.NewContext("grpc server"))
+}
+
+// newSchedulerLocker returns the DB context if leader election is enabled and the DB supports it, otherwise nil, which runs scheduled jobs
+// on every replica.
+func newSchedulerLocker(dbCtx db.Context) scheduler.Locker {
+	if !config.Get().LeaderElection.Enabled {
+		return nil
+	}
+	locker, ok := dbCtx.(scheduler.Locker)
+	if !ok {
+		return nil
+	}
+	mdctx.Infof(nil, "Leader election of scheduled jobs is enabled")
+	return locker
+}
+
+// newDbContext creates a context of the configured DB. Postgres and SQLite schemas are migrated first if it's configured.
+func newDbContext(parentCtx shutdown.ParentContext) (db.Context, error) {
+	switch driver := config.Get().Database.Driver; driver {
+	case "postgres":
+		postgresCtx, err := postgres.NewContext(parentCtx.NewContext("postgres"))
+		if err != nil {
+			return nil, err
+		}
+		if err = waitForDb(postgresCtx); err != nil {
+			return nil, err
+		}
+		if config.Get().Postgres.MigrateOnStartup {
+			err = postgresCtx.Migrate(context.Background())
+			if err != nil {
+				return nil, fmt.Errorf("error migrating DB: %w", err)
+			}
+		}
+		return postgresCtx, nil
+	case "sqlite":
+		sqliteCtx, err := sqlite.NewContext(parentCtx.NewContext("sqlite"))
+		if err != nil {
+			return nil, err
+		}
+		if err = waitForDb(sqliteCtx); err != nil {
+			return nil, err
+		}
+		if config.Get().Sqlite.MigrateOnStartup {
+			err = sqliteCtx.Migrate(context.Background())
+			if err != nil {
+				return nil, fmt.Errorf("error migrating DB: %w", err)
+			}
+		}
+		return sqliteCtx, nil
+	case "memory":
+		mdctx.Warnf(nil, "Using the in-memory DB, data will be lost on exit")
+		return memory.New(), nil
+	default:
+		return nil, fmt.Errorf("unknown DB driver %q", driver)
+	}
+}
+
+// waitForDb pings the DB until it's reachable, so that the application doesn't fail when it starts before the DB, e.g. when both are
+// deployed at the same time.
+func waitForDb(pinger db.Pinger) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(config.Get().Database.StartupTimeoutSeconds)*time.Second)
+	defer cancel()
+	return db.WaitUntilReachable(ctx, pinger, 500*time.Millisecond, 5*time.Second)
+}
+
+// newAuthenticator creates an authenticator for the configured authentication modes.
+func newAuthenticator() (auth.Authenticator, error) {
+	cfg := config.Get().Auth
+	authenticators := make([]auth.Authenticator, len(cfg.Modes))
+	for i, mode := range cfg.Modes {
+		var err error
+		switch mode {
+		case "apiKey":
+			authenticators[i], err = apikey.New(cfg.ApiKeys)
+		case "jwt":
+			authenticators[i], err = jwt.New(cfg.Jwt)
+		default:
+			err = fmt.Errorf("unknown mode %q", mode)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error configuring %s authentication: %w", mode, err)
+		}
+	}
+
+	if len(authenticators) == 0 {
+		mdctx.Warnf(nil, "Authentication is disabled")
+	}
+	return auth.NewChain(authenticators...), nil
 }
 
 func shutdownAfterStopSignal(parentCtx shutdown.ParentContext) {
-	stopSignalChannel := make(chan os.Signal)
+	stopSignalChannel := make(chan os.Signal, 1)
 	// SIGINT for ctrl+c, SIGTERM for k8s stopping the container.
 	signal.Notify(stopSignalChannel, syscall.SIGINT, syscall.SIGTERM)
 
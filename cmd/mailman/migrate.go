@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/GeneralKenobi/mailman/internal/config"
+	"github.com/GeneralKenobi/mailman/internal/db/migration"
+	"github.com/GeneralKenobi/mailman/internal/db/postgres"
+	"github.com/GeneralKenobi/mailman/internal/db/sqlite"
+	"strconv"
+)
+
+// migrate runs the migrate command: "up" applies pending migrations, "down [steps]" reverts the given number of the latest migrations
+// (1 by default) and "version" prints the version of the DB schema. The configured DB is migrated.
+func migrate(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing migrate subcommand: up, down or version")
+	}
+
+	db, migrator, err := newMigrator()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	switch args[0] {
+	case "up":
+		return migrator.Up(ctx)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil {
+				return fmt.Errorf("invalid number of migrations to revert %q", args[1])
+			}
+		}
+		return migrator.Down(ctx, steps)
+	case "version":
+		version, err := migrator.Version(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Println(version)
+		return nil
+	default:
+		return fmt.Errorf("unknown migrate subcommand %q", args[0])
+	}
+}
+
+// newMigrator creates a migrator of the configured DB. The caller is responsible for closing the DB client.
+func newMigrator() (*sql.DB, *migration.Migrator, error) {
+	var open func() (*sql.DB, error)
+	var newMigrator func(db *sql.DB) (*migration.Migrator, error)
+	switch driver := config.Get().Database.Driver; driver {
+	case "postgres":
+		open, newMigrator = postgres.Open, postgres.NewMigrator
+	case "sqlite":
+		open, newMigrator = sqlite.Open, sqlite.NewMigrator
+	default:
+		return nil, nil, fmt.Errorf("DB driver %q has no migrations", driver)
+	}
+
+	db, err := open()
+	if err != nil {
+		return nil, nil, err
+	}
+	migrator, err := newMigrator(db)
+	if err != nil {
+		db.Close()
+		return nil, nil, err
+	}
+	return db, migrator, nil
+}
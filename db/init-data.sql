@@ -0,0 +1,27 @@
+-- Sample data of the default tenant for local deployments, inserted into a migrated DB with make postgres-init-data. Rows that exist
+-- already are skipped.
+SET search_path TO mailmandb;
+
+INSERT INTO customer(tenant_id, email)
+VALUES ('default', 'john.smith@yahoo.com'),
+       ('default', 'anna@gmail.com')
+ON CONFLICT DO NOTHING;
+
+-- Mails for John and Anna. Content hashes are computed as by model.MailingEntry ContentHash.
+INSERT INTO mailing_entry(tenant_id, customer_id, mailing_id, title, content, insert_time, content_hash)
+SELECT 'default',
+       customer.id,
+       entry.mailing_id,
+       entry.title,
+       entry.content,
+       entry.insert_time,
+       encode(sha256(convert_to(octet_length(entry.title) || ':' || entry.title || entry.content, 'UTF8')), 'hex')
+FROM (VALUES ('john.smith@yahoo.com', 1, 'Welcome to mailman', 'Hi John\n\n, Welcome to mailman!\n\n See you around',
+              TIMESTAMP '2022-03-12T10:16:38.725412916Z'),
+             ('john.smith@yahoo.com', 2, 'Terms of usage', 'Hi John\n\n, Here are the terms of usage\n\n...',
+              TIMESTAMP '2022-03-12T10:19:01.123456789Z'),
+             ('anna@gmail.com', 1, 'Welcome to mailman', 'Hi Anna\n\n, Welcome to mailman!\n\n See you around',
+              TIMESTAMP '2022-03-12T10:19:01.123456789Z'))
+         AS entry(email, mailing_id, title, content, insert_time)
+         JOIN customer ON customer.tenant_id = 'default' AND customer.email = entry.email
+ON CONFLICT DO NOTHING;
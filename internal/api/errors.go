@@ -1,6 +1,7 @@
 package api
 
 import (
+	"errors"
 	"fmt"
 )
 
@@ -15,10 +16,14 @@ type StatusError interface {
 type Status string
 
 const (
-	StatusBadInput      Status = "bad input"
-	StatusNotFound      Status = "not found"
-	StatusUnauthorized  Status = "unauthorized"
-	StatusInternalError Status = "internal error"
+	StatusBadInput           Status = "bad input"
+	StatusNotFound           Status = "not found"
+	StatusConflict           Status = "conflict"
+	StatusPreconditionFailed Status = "precondition failed"
+	StatusUnauthorized       Status = "unauthorized"
+	StatusForbidden          Status = "forbidden"
+	StatusTooManyRequests    Status = "too many requests"
+	StatusInternalError      Status = "internal error"
 )
 
 func (status Status) Error() string {
@@ -38,6 +43,16 @@ func (status Status) WithMessageAndCause(cause error, messageFormat string, args
 	return ErrorWithCause(status, cause, messageFormat, args...)
 }
 
+// WithFields creates an error describing invalid fields of a request, e.g. with StatusBadInput.
+func (status Status) WithFields(cause error, fields []FieldError, messageFormat string, args ...any) StatusError {
+	return statusError{
+		status:  status,
+		message: fmt.Sprintf(messageFormat, args...),
+		cause:   cause,
+		fields:  fields,
+	}
+}
+
 func Error(status Status, messageFormat string, args ...any) StatusError {
 	return statusError{
 		status:  status,
@@ -57,6 +72,7 @@ type statusError struct {
 	status  Status
 	message string
 	cause   error
+	fields  []FieldError
 }
 
 func (statusErr statusError) Error() string {
@@ -77,3 +93,24 @@ func (statusErr statusError) Message() string {
 func (statusErr statusError) Unwrap() error {
 	return statusErr.cause
 }
+
+func (statusErr statusError) FieldErrors() []FieldError {
+	return statusErr.fields
+}
+
+// FieldError describes a field of a request that failed validation.
+type FieldError struct {
+	Path          string // Path of the field in the request, e.g. "entries[0].email"
+	Rule          string // Validation rule the field doesn't satisfy, e.g. "email"
+	Message       string // Description of the problem for humans
+	RejectedValue any    // Value of the field, nil for sensitive fields, long strings are truncated
+}
+
+// FieldErrors returns invalid fields described by the StatusError wrapped in err, or nil if there's none or it doesn't describe fields.
+func FieldErrors(err error) []FieldError {
+	var fieldsErr interface{ FieldErrors() []FieldError }
+	if errors.As(err, &fieldsErr) {
+		return fieldsErr.FieldErrors()
+	}
+	return nil
+}
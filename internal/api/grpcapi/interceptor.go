@@ -0,0 +1,227 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"github.com/GeneralKenobi/mailman/internal/api"
+	"github.com/GeneralKenobi/mailman/internal/auth"
+	"github.com/GeneralKenobi/mailman/pkg/api/mailmanpb"
+	"github.com/GeneralKenobi/mailman/pkg/mdctx"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"math"
+	"net"
+	"net/http"
+)
+
+const (
+	// correlationIdMetadataKey carries the correlation ID in request metadata, like the X-Correlation-ID HTTP header.
+	correlationIdMetadataKey = "x-correlation-id"
+	// operationIdMetadataKey carries the operation ID of the request in response header metadata, for finding relevant logs.
+	operationIdMetadataKey = "x-operation-id"
+)
+
+// Metadata keys with credentials, translated into HTTP headers for auth.Authenticator.
+var credentialsMetadataKeys = map[string]string{
+	"x-api-key":     "X-API-Key",
+	"authorization": "Authorization",
+}
+
+// methodScopes lists the scope required by each method. Methods that aren't listed are rejected.
+var methodScopes = map[string]auth.Scope{
+	mailmanpb.MailingEntryService_CreateMailingEntry_FullMethodName:   auth.ScopeMessagesWrite,
+	mailmanpb.MailingEntryService_CreateMailingEntries_FullMethodName: auth.ScopeMessagesWrite,
+	mailmanpb.MailingEntryService_DeleteMailingEntry_FullMethodName:   auth.ScopeMessagesWrite,
+	mailmanpb.MailingEntryService_SendMailing_FullMethodName:          auth.ScopeMessagesSend,
+	mailmanpb.MailingEntryService_ListMailingEntries_FullMethodName:   auth.ScopeMessagesRead,
+}
+
+// methodRateLimitGroups lists the rate limit group of each method, the group of the corresponding HTTP route.
+var methodRateLimitGroups = map[string]string{
+	mailmanpb.MailingEntryService_CreateMailingEntry_FullMethodName:   api.RateLimitGroupMessages,
+	mailmanpb.MailingEntryService_CreateMailingEntries_FullMethodName: api.RateLimitGroupMessages,
+	mailmanpb.MailingEntryService_DeleteMailingEntry_FullMethodName:   api.RateLimitGroupMessages,
+	mailmanpb.MailingEntryService_SendMailing_FullMethodName:          api.RateLimitGroupSend,
+	mailmanpb.MailingEntryService_ListMailingEntries_FullMethodName:   api.RateLimitGroupMessages,
+}
+
+// newUnaryInterceptor creates an interceptor doing for unary calls what the HTTP middleware does for requests - see prepareCall.
+func newUnaryInterceptor(authenticator auth.Authenticator, rateLimiters api.RateLimiters) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, request any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := prepareCall(ctx, info.FullMethod, authenticator, rateLimiters)
+		if err != nil {
+			return nil, statusError(ctx, err)
+		}
+
+		response, err := handler(ctx, request)
+		if err != nil {
+			return nil, statusError(ctx, err)
+		}
+		mdctx.Debugf(ctx, "End processing")
+		return response, nil
+	}
+}
+
+// newStreamInterceptor creates an interceptor doing for streaming calls what the HTTP middleware does for requests - see prepareCall.
+func newStreamInterceptor(authenticator auth.Authenticator, rateLimiters api.RateLimiters) grpc.StreamServerInterceptor {
+	return func(server any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := prepareCall(stream.Context(), info.FullMethod, authenticator, rateLimiters)
+		if err != nil {
+			return statusError(ctx, err)
+		}
+
+		err = handler(server, &contextServerStream{ServerStream: stream, ctx: ctx})
+		if err != nil {
+			return statusError(ctx, err)
+		}
+		mdctx.Debugf(ctx, "End processing")
+		return nil
+	}
+}
+
+// prepareCall creates an MDC-enhanced context for the call with the correlation ID from metadata and sends the operation ID in response
+// header metadata. Then it authenticates the caller and checks that it was granted the method's scope. Calls are rate limited with the
+// same limiters as HTTP requests, by IP address before authentication and by the method's group after it. The principal is saved in the
+// returned context even if there's an error.
+func prepareCall(ctx context.Context, fullMethod string, authenticator auth.Authenticator, rateLimiters api.RateLimiters) (
+	context.Context, error) {
+
+	incomingMetadata, _ := metadata.FromIncomingContext(ctx)
+	ctx = mdctx.NewFromContext(ctx)
+	if correlationIds := incomingMetadata.Get(correlationIdMetadataKey); len(correlationIds) > 0 && correlationIds[0] != "" {
+		ctx = mdctx.WithCorrelationId(ctx, correlationIds[0])
+	}
+	ctx = mdctx.WithRequestMethod(ctx, "gRPC")
+	ctx = mdctx.WithRequestUri(ctx, fullMethod)
+	var clientIp string
+	if callPeer, found := peer.FromContext(ctx); found {
+		ctx = mdctx.WithClientIp(ctx, callPeer.Addr.String())
+		clientIp = callPeer.Addr.String()
+		if host, _, err := net.SplitHostPort(clientIp); err == nil {
+			clientIp = host
+		}
+	}
+	if err := grpc.SetHeader(ctx, metadata.Pairs(operationIdMetadataKey, mdctx.OperationId(ctx))); err != nil {
+		mdctx.Warnf(ctx, "Error setting operation ID header: %v", err)
+	}
+	mdctx.Infof(ctx, "Begin processing")
+
+	if err := checkRateLimit(rateLimiters, api.RateLimitGroupAuthentication, auth.Principal{}.ClientKey(clientIp)); err != nil {
+		return ctx, err
+	}
+	principal, err := authenticator.Authenticate(ctx, credentialsRequest(incomingMetadata))
+	if err != nil {
+		return ctx, err
+	}
+	ctx = mdctx.WithSubject(ctx, principal.Subject)
+	ctx = mdctx.WithTenantId(ctx, principal.TenantId)
+	ctx = context.WithValue(ctx, principalKey{}, principal)
+
+	scope, found := methodScopes[fullMethod]
+	if !found {
+		return ctx, api.StatusForbidden.WithMessage("method %s doesn't have a required scope", fullMethod)
+	}
+	if !principal.HasScope(scope) {
+		return ctx, api.StatusForbidden.WithMessage("missing scope %s", scope)
+	}
+	if err = checkRateLimit(rateLimiters, methodRateLimitGroups[fullMethod], principal.ClientKey(clientIp)); err != nil {
+		return ctx, err
+	}
+	return ctx, nil
+}
+
+// checkRateLimit returns an api.StatusTooManyRequests error if the client exceeded the limit of the group. Unlike in HTTP there's no
+// Retry-After, the message says how long to wait.
+func checkRateLimit(rateLimiters api.RateLimiters, group, clientKey string) error {
+	allowed, retryAfter := rateLimiters.Allow(group, clientKey)
+	if !allowed {
+		return api.StatusTooManyRequests.WithMessage("rate limit exceeded, retry after %d seconds", int(math.Ceil(retryAfter.Seconds())))
+	}
+	return nil
+}
+
+// credentialsRequest creates an HTTP request carrying credentials from metadata, so that the same authenticators can be used for HTTP and
+// gRPC.
+func credentialsRequest(incomingMetadata metadata.MD) *http.Request {
+	request := &http.Request{Header: make(http.Header)}
+	for metadataKey, header := range credentialsMetadataKeys {
+		for _, value := range incomingMetadata.Get(metadataKey) {
+			request.Header.Add(header, value)
+		}
+	}
+	return request
+}
+
+// principalKey is the context key of the authenticated auth.Principal.
+type principalKey struct{}
+
+// principal extracts the authenticated principal from the context. An empty principal (without any scopes) is returned if the call
+// wasn't authenticated.
+func principal(ctx context.Context) auth.Principal {
+	principal, _ := ctx.Value(principalKey{}).(auth.Principal)
+	return principal
+}
+
+// statusError looks for a wrapped api.StatusError in err and converts it into a gRPC status error. If it's not found then it returns a
+// generic internal error. Invalid fields described by the error are attached in errdetails.BadRequest. The error is also logged.
+func statusError(ctx context.Context, err error) error {
+	var apiError api.StatusError
+	if !errors.As(err, &apiError) {
+		apiError = api.StatusInternalError.WithMessageAndCause(err, "Request processing failed")
+	}
+
+	mdctx.Errorf(ctx, "Error processing request: %v", apiError)
+	grpcStatus := status.New(apiStatusToGrpcCode(apiError.Status()), apiError.Message())
+	fieldErrs := api.FieldErrors(apiError)
+	if len(fieldErrs) == 0 {
+		return grpcStatus.Err()
+	}
+
+	badRequest := errdetails.BadRequest{FieldViolations: make([]*errdetails.BadRequest_FieldViolation, len(fieldErrs))}
+	for i, fieldErr := range fieldErrs {
+		badRequest.FieldViolations[i] = &errdetails.BadRequest_FieldViolation{Field: fieldErr.Path, Description: fieldErr.Message}
+	}
+	grpcStatusWithDetails, detailsErr := grpcStatus.WithDetails(&badRequest)
+	if detailsErr != nil {
+		mdctx.Warnf(ctx, "Error attaching invalid fields to the status: %v", detailsErr)
+		return grpcStatus.Err()
+	}
+	return grpcStatusWithDetails.Err()
+}
+
+func apiStatusToGrpcCode(apiStatus api.Status) codes.Code {
+	switch apiStatus {
+	case api.StatusBadInput:
+		return codes.InvalidArgument
+	case api.StatusUnauthorized:
+		return codes.Unauthenticated
+	case api.StatusForbidden:
+		return codes.PermissionDenied
+	case api.StatusNotFound:
+		return codes.NotFound
+	case api.StatusConflict:
+		return codes.Aborted
+	case api.StatusPreconditionFailed:
+		return codes.FailedPrecondition
+	case api.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case api.StatusInternalError:
+		return codes.Internal
+	default:
+		return codes.Internal
+	}
+}
+
+// contextServerStream overrides the context of a stream.
+type contextServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (stream *contextServerStream) Context() context.Context {
+	return stream.ctx
+}
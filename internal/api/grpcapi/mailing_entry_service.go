@@ -0,0 +1,171 @@
+package grpcapi
+
+import (
+	"context"
+	"fmt"
+	"github.com/GeneralKenobi/mailman/internal/api/validation"
+	"github.com/GeneralKenobi/mailman/internal/db"
+	"github.com/GeneralKenobi/mailman/internal/db/model"
+	"github.com/GeneralKenobi/mailman/internal/service/mailingentry/finder"
+	"github.com/GeneralKenobi/mailman/internal/service/mailingentry/mailing"
+	"github.com/GeneralKenobi/mailman/internal/service/mailingentry/progress"
+	"github.com/GeneralKenobi/mailman/internal/service/mailingentry/remover"
+	"github.com/GeneralKenobi/mailman/internal/service/mailingentry/sender"
+	"github.com/GeneralKenobi/mailman/pkg/api/apimodel"
+	"github.com/GeneralKenobi/mailman/pkg/api/mailmanpb"
+	"github.com/GeneralKenobi/mailman/pkg/mdctx"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"strings"
+)
+
+func newMailingEntryService(transactioner db.Transactioner, emailer sender.Emailer, progressBus *progress.Bus) *mailingEntryService {
+	return &mailingEntryService{
+		transactioner:  transactioner,
+		mailingService: mailing.New(transactioner, emailer, progressBus),
+	}
+}
+
+// mailingEntryService implements the gRPC counterpart of the /api/messages routes with the same services as the HTTP handler.
+type mailingEntryService struct {
+	mailmanpb.UnimplementedMailingEntryServiceServer
+	transactioner  db.Transactioner
+	mailingService *mailing.Service
+}
+
+var _ mailmanpb.MailingEntryServiceServer = (*mailingEntryService)(nil) // Interface guard
+
+func (service *mailingEntryService) CreateMailingEntry(
+	ctx context.Context, request *mailmanpb.CreateMailingEntryRequest) (*mailmanpb.CreateMailingEntryResponse, error) {
+
+	ctx = mdctx.WithOperationName(ctx, "create mailing entry")
+	tenantId := principal(ctx).TenantId
+	mailingEntryDto := mailingEntryDtoFromProto(request.GetEntry())
+	if err := validateRequest(ctx, mailingEntryDto); err != nil {
+		return nil, err
+	}
+
+	mailingEntry, err := service.mailingService.Create(ctx, tenantId, mailingEntryDto)
+	if err != nil {
+		return nil, err
+	}
+	return &mailmanpb.CreateMailingEntryResponse{Id: int32(mailingEntry.Id)}, nil
+}
+
+func (service *mailingEntryService) CreateMailingEntries(
+	ctx context.Context, request *mailmanpb.CreateMailingEntriesRequest) (*mailmanpb.CreateMailingEntriesResponse, error) {
+
+	ctx = mdctx.WithOperationName(ctx, "create mailing entry batch")
+	tenantId := principal(ctx).TenantId
+	batchDto := apimodel.MailingEntryBatch{Entries: make([]apimodel.MailingEntry, len(request.GetEntries()))}
+	for i, entry := range request.GetEntries() {
+		batchDto.Entries[i] = mailingEntryDtoFromProto(entry)
+	}
+	if err := validateRequest(ctx, batchDto); err != nil {
+		return nil, err
+	}
+
+	mailingEntries, err := service.mailingService.CreateBatch(ctx, tenantId, batchDto.Entries)
+	if err != nil {
+		return nil, err
+	}
+	response := mailmanpb.CreateMailingEntriesResponse{Ids: make([]int32, len(mailingEntries))}
+	for i, mailingEntry := range mailingEntries {
+		response.Ids[i] = int32(mailingEntry.Id)
+	}
+	return &response, nil
+}
+
+func (service *mailingEntryService) DeleteMailingEntry(
+	ctx context.Context, request *mailmanpb.DeleteMailingEntryRequest) (*mailmanpb.DeleteMailingEntryResponse, error) {
+
+	ctx = mdctx.WithOperationName(ctx, "delete mailing entry with ID")
+	tenantId := principal(ctx).TenantId
+	id := int(request.GetId())
+
+	err := db.InTransaction(ctx, service.transactioner, func(repository db.Repository) error {
+		mailingEntryRemover := remover.New(repository)
+		err := mailingEntryRemover.Remove(ctx, tenantId, id)
+		if err != nil {
+			return fmt.Errorf("error deleting mailing entry %d: %w", id, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &mailmanpb.DeleteMailingEntryResponse{}, nil
+}
+
+func (service *mailingEntryService) SendMailing(ctx context.Context, request *mailmanpb.SendMailingRequest) (*mailmanpb.SendMailingResponse, error) {
+	ctx = mdctx.WithOperationName(ctx, "send mailing entries with mailing ID")
+	tenantId := principal(ctx).TenantId
+	mailingRequest := apimodel.MailingRequest{MailingId: int(request.GetMailingId())}
+	if err := validateRequest(ctx, mailingRequest); err != nil {
+		return nil, err
+	}
+
+	if err := service.mailingService.Send(ctx, tenantId, mailingRequest); err != nil {
+		return nil, err
+	}
+	return &mailmanpb.SendMailingResponse{}, nil
+}
+
+func (service *mailingEntryService) ListMailingEntries(
+	request *mailmanpb.ListMailingEntriesRequest, stream mailmanpb.MailingEntryService_ListMailingEntriesServer) error {
+
+	ctx := mdctx.WithOperationName(stream.Context(), "list mailing entries")
+	tenantId := principal(ctx).TenantId
+	var query finder.Query
+	switch filter := request.GetFilter().(type) {
+	case *mailmanpb.ListMailingEntriesRequest_MailingId:
+		mailingId := int(filter.MailingId)
+		query.MailingId = &mailingId
+	case *mailmanpb.ListMailingEntriesRequest_CustomerId:
+		customerId := int(filter.CustomerId)
+		query.CustomerId = &customerId
+	}
+
+	mailingEntries, err := db.InTransactionRetV(ctx, service.transactioner, func(repository db.Repository) ([]model.MailingEntry, error) {
+		mailingEntryFinder := finder.New(repository)
+		return mailingEntryFinder.Find(ctx, tenantId, query)
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, mailingEntry := range mailingEntries {
+		err = stream.Send(&mailmanpb.MailingEntryDetails{
+			Id:         int32(mailingEntry.Id),
+			CustomerId: int32(mailingEntry.CustomerId),
+			MailingId:  int32(mailingEntry.MailingId),
+			Title:      mailingEntry.Title,
+			Content:    mailingEntry.Content,
+			InsertTime: timestamppb.New(mailingEntry.InsertTime),
+		})
+		if err != nil {
+			return fmt.Errorf("error sending mailing entry %d: %w", mailingEntry.Id, err)
+		}
+	}
+	return nil
+}
+
+func mailingEntryDtoFromProto(entry *mailmanpb.MailingEntry) apimodel.MailingEntry {
+	mailingEntryDto := apimodel.MailingEntry{
+		MailingId: int(entry.GetMailingId()),
+		Email:     entry.GetEmail(),
+		Title:     entry.GetTitle(),
+		Content:   entry.GetContent(),
+	}
+	if entry.GetInsertTime() != nil {
+		mailingEntryDto.InsertTime = entry.GetInsertTime().AsTime()
+	}
+	return mailingEntryDto
+}
+
+// validateRequest validates a request converted into an API model with the same rules as HTTP request bodies. If there were validation
+// errors it converts them into a api.StatusBadInput error with messages in the language requested in accept-language metadata.
+func validateRequest(ctx context.Context, toValidate any) error {
+	incomingMetadata, _ := metadata.FromIncomingContext(ctx)
+	return validation.Validate(toValidate, strings.Join(incomingMetadata.Get("accept-language"), ","), "request")
+}
@@ -0,0 +1,80 @@
+package grpcapi
+
+import (
+	"fmt"
+	"github.com/GeneralKenobi/mailman/internal/api"
+	"github.com/GeneralKenobi/mailman/internal/auth"
+	"github.com/GeneralKenobi/mailman/internal/config"
+	"github.com/GeneralKenobi/mailman/internal/db"
+	"github.com/GeneralKenobi/mailman/internal/email"
+	"github.com/GeneralKenobi/mailman/internal/service/mailingentry/progress"
+	"github.com/GeneralKenobi/mailman/pkg/api/mailmanpb"
+	"github.com/GeneralKenobi/mailman/pkg/mdctx"
+	"github.com/GeneralKenobi/mailman/pkg/shutdown"
+	"google.golang.org/grpc"
+	"net"
+	"time"
+)
+
+// NewServer creates a gRPC server. Calls are rate limited with rateLimiters, which should be shared with the HTTP server.
+func NewServer(dbCtx db.Context, emailer email.Service, authenticator auth.Authenticator, progressBus *progress.Bus,
+	rateLimiters api.RateLimiters) *Server {
+
+	server := Server{
+		address: fmt.Sprintf(":%d", config.Get().GrpcServer.Port),
+		grpcServer: grpc.NewServer(
+			grpc.ChainUnaryInterceptor(newUnaryInterceptor(authenticator, rateLimiters)),
+			grpc.ChainStreamInterceptor(newStreamInterceptor(authenticator, rateLimiters)),
+		),
+	}
+	mailmanpb.RegisterMailingEntryServiceServer(server.grpcServer, newMailingEntryService(dbCtx, emailer, progressBus))
+	return &server
+}
+
+type Server struct {
+	address    string
+	grpcServer *grpc.Server
+}
+
+// Run starts the gRPC server and shuts it down gracefully when ctx is cancelled.
+func (server *Server) Run(ctx shutdown.Context) {
+	listener, err := net.Listen("tcp", server.address)
+	if err != nil {
+		mdctx.Errorf(nil, "Error listening on address %s for gRPC server: %v", server.address, err)
+		ctx.Notify()
+		return
+	}
+	go server.serve(listener)
+	server.shutdownOnContextCancellation(ctx)
+}
+
+func (server *Server) serve(listener net.Listener) {
+	mdctx.Infof(nil, "Starting gRPC server on address %s", server.address)
+	err := server.grpcServer.Serve(listener)
+	if err != nil {
+		mdctx.Errorf(nil, "gRPC server exited with error: %v", err)
+	} else {
+		mdctx.Infof(nil, "gRPC server exited")
+	}
+}
+
+func (server *Server) shutdownOnContextCancellation(ctx shutdown.Context) {
+	defer ctx.Notify()
+
+	<-ctx.Done()
+	mdctx.Infof(nil, "Context canceled - shutting down gRPC server")
+
+	stopped := make(chan struct{})
+	go func() {
+		server.grpcServer.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		mdctx.Infof(nil, "gRPC server shutdown completed")
+	case <-time.After(ctx.Timeout()):
+		mdctx.Errorf(nil, "gRPC server didn't shut down gracefully within %v - closing remaining connections", ctx.Timeout())
+		server.grpcServer.Stop()
+	}
+}
@@ -0,0 +1,215 @@
+package grpcapi
+
+import (
+	"context"
+	"github.com/GeneralKenobi/mailman/internal/api"
+	"github.com/GeneralKenobi/mailman/internal/auth"
+	"github.com/GeneralKenobi/mailman/internal/auth/apikey"
+	"github.com/GeneralKenobi/mailman/internal/config"
+	"github.com/GeneralKenobi/mailman/internal/db/memory"
+	"github.com/GeneralKenobi/mailman/internal/service/mailingentry/progress"
+	"github.com/GeneralKenobi/mailman/pkg/api/mailmanpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestMailingEntryService(t *testing.T) {
+	client := newTestClient(t, nil)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-api-key", "writer-key")
+
+	var header metadata.MD
+	created, err := client.CreateMailingEntry(ctx, &mailmanpb.CreateMailingEntryRequest{Entry: mailingEntry("first")}, grpc.Header(&header))
+	if err != nil {
+		t.Fatalf("Error creating mailing entry: %v", err)
+	}
+	if operationIds := header.Get(operationIdMetadataKey); len(operationIds) != 1 || operationIds[0] == "" {
+		t.Errorf("Expected operation ID in response header, got %v", header)
+	}
+
+	batchCreated, err := client.CreateMailingEntries(ctx, &mailmanpb.CreateMailingEntriesRequest{
+		Entries: []*mailmanpb.MailingEntry{mailingEntry("second"), mailingEntry("third")},
+	})
+	if err != nil {
+		t.Fatalf("Error creating mailing entries: %v", err)
+	}
+
+	stream, err := client.ListMailingEntries(ctx, &mailmanpb.ListMailingEntriesRequest{
+		Filter: &mailmanpb.ListMailingEntriesRequest_MailingId{MailingId: 4},
+	})
+	if err != nil {
+		t.Fatalf("Error listing mailing entries: %v", err)
+	}
+	var listedIds []int32
+	for {
+		entry, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Error receiving mailing entry: %v", err)
+		}
+		listedIds = append(listedIds, entry.Id)
+	}
+	expectedIds := append([]int32{created.Id}, batchCreated.Ids...)
+	if len(listedIds) != 3 || listedIds[0] != expectedIds[0] || listedIds[1] != expectedIds[1] || listedIds[2] != expectedIds[2] {
+		t.Errorf("Expected entries %v, got %v", expectedIds, listedIds)
+	}
+
+	if _, err = client.DeleteMailingEntry(ctx, &mailmanpb.DeleteMailingEntryRequest{Id: created.Id}); err != nil {
+		t.Errorf("Error deleting mailing entry: %v", err)
+	}
+	if _, err = client.SendMailing(ctx, &mailmanpb.SendMailingRequest{MailingId: 4}); err != nil {
+		t.Errorf("Error sending mailing: %v", err)
+	}
+}
+
+func TestMailingEntryServiceErrors(t *testing.T) {
+	tests := map[string]struct {
+		apiKey       string
+		call         func(ctx context.Context, client mailmanpb.MailingEntryServiceClient) error
+		expectedCode codes.Code
+	}{
+		"Should return NotFound for missing mailing entry": {
+			apiKey: "writer-key",
+			call: func(ctx context.Context, client mailmanpb.MailingEntryServiceClient) error {
+				_, err := client.DeleteMailingEntry(ctx, &mailmanpb.DeleteMailingEntryRequest{Id: 123})
+				return err
+			},
+			expectedCode: codes.NotFound,
+		},
+		"Should return InvalidArgument for invalid mailing entry": {
+			apiKey: "writer-key",
+			call: func(ctx context.Context, client mailmanpb.MailingEntryServiceClient) error {
+				entry := mailingEntry("title")
+				entry.Email = "not an email"
+				_, err := client.CreateMailingEntry(ctx, &mailmanpb.CreateMailingEntryRequest{Entry: entry})
+				return err
+			},
+			expectedCode: codes.InvalidArgument,
+		},
+		"Should return InvalidArgument for list without filter": {
+			apiKey: "writer-key",
+			call: func(ctx context.Context, client mailmanpb.MailingEntryServiceClient) error {
+				stream, err := client.ListMailingEntries(ctx, &mailmanpb.ListMailingEntriesRequest{})
+				if err != nil {
+					return err
+				}
+				_, err = stream.Recv()
+				return err
+			},
+			expectedCode: codes.InvalidArgument,
+		},
+		"Should return Unauthenticated for invalid credentials": {
+			apiKey: "invalid-key",
+			call: func(ctx context.Context, client mailmanpb.MailingEntryServiceClient) error {
+				_, err := client.SendMailing(ctx, &mailmanpb.SendMailingRequest{MailingId: 1})
+				return err
+			},
+			expectedCode: codes.Unauthenticated,
+		},
+		"Should return PermissionDenied for missing scope": {
+			apiKey: "reader-key",
+			call: func(ctx context.Context, client mailmanpb.MailingEntryServiceClient) error {
+				_, err := client.SendMailing(ctx, &mailmanpb.SendMailingRequest{MailingId: 1})
+				return err
+			},
+			expectedCode: codes.PermissionDenied,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			client := newTestClient(t, nil)
+			ctx := metadata.AppendToOutgoingContext(context.Background(), "x-api-key", test.apiKey)
+
+			err := test.call(ctx, client)
+			if status.Code(err) != test.expectedCode {
+				t.Errorf("Expected code %v, got %v", test.expectedCode, err)
+			}
+		})
+	}
+}
+
+func TestCallsAreRateLimited(t *testing.T) {
+	tests := map[string]struct {
+		group  string
+		apiKey string
+	}{
+		"Should limit calls of the method's group": {
+			group:  api.RateLimitGroupSend,
+			apiKey: "writer-key",
+		},
+		"Should limit calls failing authentication": {
+			group:  api.RateLimitGroupAuthentication,
+			apiKey: "guessed-key",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			rateLimiters := api.NewRateLimiters(map[string]config.RateLimitGroup{test.group: {RequestsPerSecond: 0.001, Burst: 1}})
+			client := newTestClient(t, rateLimiters)
+			ctx := metadata.AppendToOutgoingContext(context.Background(), "x-api-key", test.apiKey)
+
+			if _, err := client.SendMailing(ctx, &mailmanpb.SendMailingRequest{MailingId: 4}); status.Code(err) == codes.ResourceExhausted {
+				t.Errorf("Expected the first call not to be limited but got %v", err)
+			}
+			if _, err := client.SendMailing(ctx, &mailmanpb.SendMailingRequest{MailingId: 4}); status.Code(err) != codes.ResourceExhausted {
+				t.Errorf("Expected code %v, got %v", codes.ResourceExhausted, err)
+			}
+		})
+	}
+}
+
+func newTestClient(t *testing.T, rateLimiters api.RateLimiters) mailmanpb.MailingEntryServiceClient {
+	authenticator, err := apikey.New([]config.ApiKey{
+		{Name: "writer", Key: "writer-key", Scopes: []string{"messages:read", "messages:write", "messages:send"}},
+		{Name: "reader", Key: "reader-key", Scopes: []string{"messages:read"}},
+	})
+	if err != nil {
+		t.Fatalf("Error creating authenticator: %v", err)
+	}
+	server := NewServer(memory.New(), emailerMock{}, auth.NewChain(authenticator), progress.NewBus(), rateLimiters)
+
+	listener := bufconn.Listen(1024 * 1024)
+	go server.serve(listener)
+	t.Cleanup(server.grpcServer.Stop)
+
+	connection, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Error connecting to gRPC server: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = connection.Close()
+	})
+	return mailmanpb.NewMailingEntryServiceClient(connection)
+}
+
+func mailingEntry(title string) *mailmanpb.MailingEntry {
+	return &mailmanpb.MailingEntry{
+		MailingId:  4,
+		Email:      "test@example.com",
+		Title:      title,
+		Content:    "content",
+		InsertTime: timestamppb.New(time.Now()),
+	}
+}
+
+type emailerMock struct{}
+
+func (emailerMock) Send(context.Context, string, string, string) error {
+	return nil
+}
@@ -0,0 +1,82 @@
+package customer
+
+import (
+	"context"
+	"fmt"
+	apirequest "github.com/GeneralKenobi/mailman/internal/api/httpgin/request"
+	"github.com/GeneralKenobi/mailman/internal/api/httpgin/wrapper"
+	"github.com/GeneralKenobi/mailman/internal/db"
+	"github.com/GeneralKenobi/mailman/internal/db/model"
+	"github.com/GeneralKenobi/mailman/internal/service/customer/creator"
+	"github.com/GeneralKenobi/mailman/internal/service/customer/finder"
+	"github.com/GeneralKenobi/mailman/internal/service/customer/remover"
+	"github.com/GeneralKenobi/mailman/pkg/api/apimodel"
+	"github.com/GeneralKenobi/mailman/pkg/mdctx"
+	"github.com/gin-gonic/gin"
+)
+
+func NewHandler(transactioner db.Transactioner) *Handler {
+	return &Handler{transactioner: transactioner}
+}
+
+type Handler struct {
+	transactioner db.Transactioner
+}
+
+func (handler *Handler) CreateHandlerFunc(request *gin.Context) {
+	wrapper.ForRequestRetV[apimodel.CustomerDetails](request).Handle(func(ctx context.Context) (apimodel.CustomerDetails, error) {
+		ctx = mdctx.WithOperationName(ctx, "create customer")
+		tenantId := apirequest.Principal(request).TenantId
+		return wrapper.WithBoundRequestBodyRetV(request, func(customerDto apimodel.Customer) (apimodel.CustomerDetails, error) {
+			return db.InTransactionRetV(ctx, handler.transactioner, func(repository db.Repository) (apimodel.CustomerDetails, error) {
+				customerCreator := creator.New(repository)
+				customer, err := customerCreator.CreateFromEmail(ctx, tenantId, customerDto.Email)
+				if err != nil {
+					return apimodel.CustomerDetails{}, fmt.Errorf("error creating customer: %w", err)
+				}
+				return customerDetailsDto(customer), nil
+			})
+		})
+	})
+}
+
+func (handler *Handler) GetHandlerFunc(request *gin.Context) {
+	wrapper.ForRequestRetV[apimodel.CustomerDetails](request).Handle(func(ctx context.Context) (apimodel.CustomerDetails, error) {
+		ctx = mdctx.WithOperationName(ctx, "get customer with ID")
+		tenantId := apirequest.Principal(request).TenantId
+		return wrapper.WithRequiredIntPathParamRetV(request, "id", func(id int) (apimodel.CustomerDetails, error) {
+			return db.InTransactionRetV(ctx, handler.transactioner, func(repository db.Repository) (apimodel.CustomerDetails, error) {
+				customerFinder := finder.New(repository)
+				customer, err := customerFinder.FindById(ctx, tenantId, id)
+				if err != nil {
+					return apimodel.CustomerDetails{}, err
+				}
+				return customerDetailsDto(customer), nil
+			})
+		})
+	})
+}
+
+func (handler *Handler) DeleteHandlerFunc(request *gin.Context) {
+	wrapper.ForRequest(request).Handle(func(ctx context.Context) error {
+		ctx = mdctx.WithOperationName(ctx, "delete customer with ID")
+		tenantId := apirequest.Principal(request).TenantId
+		return wrapper.WithRequiredIntPathParam(request, "id", func(id int) error {
+			return db.InTransaction(ctx, handler.transactioner, func(repository db.Repository) error {
+				customerRemover := remover.New(repository)
+				err := customerRemover.Remove(ctx, tenantId, id)
+				if err != nil {
+					return fmt.Errorf("error deleting customer %d: %w", id, err)
+				}
+				return nil
+			})
+		})
+	})
+}
+
+func customerDetailsDto(customer model.Customer) apimodel.CustomerDetails {
+	return apimodel.CustomerDetails{
+		Id:    customer.Id,
+		Email: customer.Email,
+	}
+}
@@ -3,14 +3,54 @@ package health
 import (
 	"context"
 	"github.com/GeneralKenobi/mailman/internal/api/httpgin/wrapper"
+	"github.com/GeneralKenobi/mailman/internal/service/health"
+	"github.com/GeneralKenobi/mailman/pkg/api/apimodel"
 	"github.com/GeneralKenobi/mailman/pkg/mdctx"
 	"github.com/gin-gonic/gin"
+	"net/http"
 )
 
-// HandlerFunc always responds with HTTP200 (if the server is up the application is healthy).
-func HandlerFunc(request *gin.Context) {
-	wrapper.ForRequest(request).Handle(func(ctx context.Context) error {
-		mdctx.Debugf(ctx, "Health probe")
-		return nil
+func NewHandler(checker *health.Checker) *Handler {
+	return &Handler{checker: checker}
+}
+
+type Handler struct {
+	checker *health.Checker
+}
+
+// LiveHandlerFunc always responds with HTTP200 (if the server is up the application is alive). Dependencies aren't checked, so that
+// the application isn't restarted when they are down.
+func (handler *Handler) LiveHandlerFunc(request *gin.Context) {
+	wrapper.ForRequestRetV[apimodel.Health](request).Handle(func(ctx context.Context) (apimodel.Health, error) {
+		mdctx.Debugf(ctx, "Liveness probe")
+		return apimodel.Health{Status: health.StatusUp}, nil
 	})
 }
+
+// ReadyHandlerFunc checks the dependencies and responds with HTTP200 if all of them are up, or HTTP503 otherwise. The status of each
+// dependency is included in the response.
+func (handler *Handler) ReadyHandlerFunc(request *gin.Context) {
+	wrapper.ForRequestRetV[apimodel.Health](request).
+		OnSuccess(func(_ context.Context, healthDto apimodel.Health) {
+			status := http.StatusOK
+			if healthDto.Status != health.StatusUp {
+				status = http.StatusServiceUnavailable
+			}
+			request.JSON(status, healthDto)
+		}).
+		Handle(func(ctx context.Context) (apimodel.Health, error) {
+			mdctx.Debugf(ctx, "Readiness probe")
+			return healthDto(handler.checker.Check(ctx)), nil
+		})
+}
+
+func healthDto(report health.Report) apimodel.Health {
+	dto := apimodel.Health{Status: report.Status}
+	if len(report.Dependencies) > 0 {
+		dto.Dependencies = make(map[string]apimodel.DependencyHealth, len(report.Dependencies))
+	}
+	for name, dependency := range report.Dependencies {
+		dto.Dependencies[name] = apimodel.DependencyHealth{Status: dependency.Status, Error: dependency.Error, Details: dependency.Details}
+	}
+	return dto
+}
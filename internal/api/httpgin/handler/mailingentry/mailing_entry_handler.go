@@ -3,45 +3,187 @@ package mailingentry
 import (
 	"context"
 	"fmt"
+	apirequest "github.com/GeneralKenobi/mailman/internal/api/httpgin/request"
 	"github.com/GeneralKenobi/mailman/internal/api/httpgin/wrapper"
+	"github.com/GeneralKenobi/mailman/internal/config"
 	"github.com/GeneralKenobi/mailman/internal/db"
+	"github.com/GeneralKenobi/mailman/internal/db/model"
 	customercreator "github.com/GeneralKenobi/mailman/internal/service/customer/creator"
+	"github.com/GeneralKenobi/mailman/internal/service/mailingentry/archivefinder"
 	mailingentrycreator "github.com/GeneralKenobi/mailman/internal/service/mailingentry/creator"
+	"github.com/GeneralKenobi/mailman/internal/service/mailingentry/finder"
+	"github.com/GeneralKenobi/mailman/internal/service/mailingentry/mailing"
+	"github.com/GeneralKenobi/mailman/internal/service/mailingentry/progress"
+	"github.com/GeneralKenobi/mailman/internal/service/mailingentry/purger"
 	"github.com/GeneralKenobi/mailman/internal/service/mailingentry/remover"
 	"github.com/GeneralKenobi/mailman/internal/service/mailingentry/sender"
-	"github.com/GeneralKenobi/mailman/internal/service/mailingentry/staleremover"
+	"github.com/GeneralKenobi/mailman/internal/service/mailingentry/updater"
 	"github.com/GeneralKenobi/mailman/pkg/api/apimodel"
 	"github.com/GeneralKenobi/mailman/pkg/mdctx"
 	"github.com/gin-gonic/gin"
+	"time"
 )
 
-func NewHandler(transactioner db.Transactioner, emailer sender.Emailer) *Handler {
+// NewHandler creates a handler of mailing entry routes. Mailing event streams are closed when streamsClosed is closed.
+func NewHandler(transactioner db.Transactioner, emailer sender.Emailer, progressBus *progress.Bus, streamsClosed <-chan struct{}) *Handler {
 	return &Handler{
-		transactioner: transactioner,
-		emailer:       emailer,
+		transactioner:  transactioner,
+		mailingService: mailing.New(transactioner, emailer, progressBus),
+		progressBus:    progressBus,
+		streamsClosed:  streamsClosed,
 	}
 }
 
 type Handler struct {
-	transactioner db.Transactioner
-	emailer       sender.Emailer
+	transactioner  db.Transactioner
+	mailingService *mailing.Service
+	progressBus    *progress.Bus
+	streamsClosed  <-chan struct{}
 }
 
 func (handler *Handler) CreateHandlerFunc(request *gin.Context) {
 	wrapper.ForRequestRetV[apimodel.MailingEntryCreated](request).Handle(func(ctx context.Context) (apimodel.MailingEntryCreated, error) {
 		ctx = mdctx.WithOperationName(ctx, "create mailing entry")
+		tenantId := apirequest.Principal(request).TenantId
 		return wrapper.WithBoundRequestBodyRetV(request, func(mailingEntryDto apimodel.MailingEntry) (apimodel.MailingEntryCreated, error) {
-			return db.InTransactionRetV(ctx, handler.transactioner, func(repository db.Repository) (apimodel.MailingEntryCreated, error) {
-				customerCreator := customercreator.New(repository)
-				mailingEntryCreator := mailingentrycreator.New(repository, customerCreator)
+			mailingEntry, err := handler.mailingService.Create(ctx, tenantId, mailingEntryDto)
+			if err != nil {
+				return apimodel.MailingEntryCreated{}, err
+			}
+
+			mailingEntryCreatedDto := apimodel.MailingEntryCreated{Id: mailingEntry.Id}
+			return mailingEntryCreatedDto, nil
+		})
+	})
+}
+
+func (handler *Handler) CreateBatchHandlerFunc(request *gin.Context) {
+	wrapper.ForRequestRetV[apimodel.MailingEntryBatchCreated](request).Handle(func(ctx context.Context) (apimodel.MailingEntryBatchCreated, error) {
+		ctx = mdctx.WithOperationName(ctx, "create mailing entry batch")
+		tenantId := apirequest.Principal(request).TenantId
+		return wrapper.WithBoundRequestBodyRetV(request, func(batchDto apimodel.MailingEntryBatch) (apimodel.MailingEntryBatchCreated, error) {
+			mailingEntries, err := handler.mailingService.CreateBatch(ctx, tenantId, batchDto.Entries)
+			if err != nil {
+				return apimodel.MailingEntryBatchCreated{}, err
+			}
+
+			batchCreatedDto := apimodel.MailingEntryBatchCreated{Ids: make([]int, len(mailingEntries))}
+			for i, mailingEntry := range mailingEntries {
+				batchCreatedDto.Ids[i] = mailingEntry.Id
+			}
+			return batchCreatedDto, nil
+		})
+	})
+}
+
+func (handler *Handler) ListHandlerFunc(request *gin.Context) {
+	wrapper.ForRequestRetV[apimodel.MailingEntryList](request).Handle(func(ctx context.Context) (apimodel.MailingEntryList, error) {
+		ctx = mdctx.WithOperationName(ctx, "list mailing entries")
+		tenantId := apirequest.Principal(request).TenantId
+
+		mailingId, err := wrapper.OptionalIntQueryParam(request, "mailing_id")
+		if err != nil {
+			return apimodel.MailingEntryList{}, err
+		}
+		customerId, err := wrapper.OptionalIntQueryParam(request, "customer_id")
+		if err != nil {
+			return apimodel.MailingEntryList{}, err
+		}
+
+		return db.InTransactionRetV(ctx, handler.transactioner, func(repository db.Repository) (apimodel.MailingEntryList, error) {
+			mailingEntryFinder := finder.New(repository)
+			mailingEntries, err := mailingEntryFinder.Find(ctx, tenantId, finder.Query{MailingId: mailingId, CustomerId: customerId})
+			if err != nil {
+				return apimodel.MailingEntryList{}, err
+			}
+
+			listDto := apimodel.MailingEntryList{Entries: make([]apimodel.MailingEntryDetails, len(mailingEntries))}
+			for i, mailingEntry := range mailingEntries {
+				listDto.Entries[i] = mailingEntryDetailsDto(mailingEntry)
+			}
+			return listDto, nil
+		})
+	})
+}
 
-				mailingEntry, err := mailingEntryCreator.CreateFromDto(ctx, mailingEntryDto)
+// ListArchivedHandlerFunc lists archived mailing entries matching the query parameters, most recently archived first.
+func (handler *Handler) ListArchivedHandlerFunc(request *gin.Context) {
+	wrapper.ForRequestRetV[apimodel.ArchivedMailingEntryList](request).Handle(func(ctx context.Context) (apimodel.ArchivedMailingEntryList, error) {
+		ctx = mdctx.WithOperationName(ctx, "list archived mailing entries")
+		tenantId := apirequest.Principal(request).TenantId
+		var query archivefinder.Query
+		var err error
+		if query.Filter.MailingId, err = wrapper.OptionalIntQueryParam(request, "mailing_id"); err != nil {
+			return apimodel.ArchivedMailingEntryList{}, err
+		}
+		if email, ok := request.GetQuery("email"); ok {
+			query.Filter.CustomerEmail = &email
+		}
+		if query.Filter.ArchivedAfter, err = wrapper.OptionalTimeQueryParam(request, "archived_after"); err != nil {
+			return apimodel.ArchivedMailingEntryList{}, err
+		}
+		if query.Filter.ArchivedBefore, err = wrapper.OptionalTimeQueryParam(request, "archived_before"); err != nil {
+			return apimodel.ArchivedMailingEntryList{}, err
+		}
+		if query.Limit, err = wrapper.OptionalIntQueryParam(request, "limit"); err != nil {
+			return apimodel.ArchivedMailingEntryList{}, err
+		}
+
+		return db.InTransactionRetV(ctx, handler.transactioner, func(repository db.Repository) (apimodel.ArchivedMailingEntryList, error) {
+			archivedEntryFinder := archivefinder.New(repository)
+			archivedEntries, err := archivedEntryFinder.Find(ctx, tenantId, query)
+			if err != nil {
+				return apimodel.ArchivedMailingEntryList{}, err
+			}
+
+			listDto := apimodel.ArchivedMailingEntryList{Entries: make([]apimodel.ArchivedMailingEntryDetails, len(archivedEntries))}
+			for i, archivedEntry := range archivedEntries {
+				listDto.Entries[i] = archivedMailingEntryDetailsDto(archivedEntry)
+			}
+			return listDto, nil
+		})
+	})
+}
+
+func (handler *Handler) GetHandlerFunc(request *gin.Context) {
+	wrapper.ForRequestRetV[apimodel.MailingEntryDetails](request).Handle(func(ctx context.Context) (apimodel.MailingEntryDetails, error) {
+		ctx = mdctx.WithOperationName(ctx, "get mailing entry with ID")
+		tenantId := apirequest.Principal(request).TenantId
+		return wrapper.WithRequiredIntPathParamRetV(request, "id", func(id int) (apimodel.MailingEntryDetails, error) {
+			return db.InTransactionRetV(ctx, handler.transactioner, func(repository db.Repository) (apimodel.MailingEntryDetails, error) {
+				mailingEntryFinder := finder.New(repository)
+				mailingEntry, err := mailingEntryFinder.FindById(ctx, tenantId, id)
 				if err != nil {
-					return apimodel.MailingEntryCreated{}, fmt.Errorf("error creating mailing entry: %w", err)
+					return apimodel.MailingEntryDetails{}, err
 				}
 
-				mailingEntryCreatedDto := apimodel.MailingEntryCreated{Id: mailingEntry.Id}
-				return mailingEntryCreatedDto, nil
+				apirequest.SetETag(request, mailingEntry.Version)
+				return mailingEntryDetailsDto(mailingEntry), nil
+			})
+		})
+	})
+}
+
+// UpdateHandlerFunc updates a mailing entry that wasn't sent yet. The update is conditional if the If-Match header is set.
+func (handler *Handler) UpdateHandlerFunc(request *gin.Context) {
+	wrapper.ForRequestRetV[apimodel.MailingEntryDetails](request).Handle(func(ctx context.Context) (apimodel.MailingEntryDetails, error) {
+		ctx = mdctx.WithOperationName(ctx, "update mailing entry with ID")
+		tenantId := apirequest.Principal(request).TenantId
+		ifMatchVersions := apirequest.IfMatchVersions(request)
+		return wrapper.WithRequiredIntPathParamRetV(request, "id", func(id int) (apimodel.MailingEntryDetails, error) {
+			return wrapper.WithBoundRequestBodyRetV(request, func(updateDto apimodel.MailingEntryUpdate) (apimodel.MailingEntryDetails, error) {
+				return db.InTransactionRetV(ctx, handler.transactioner, func(repository db.Repository) (apimodel.MailingEntryDetails, error) {
+					customerCreator := customercreator.New(repository)
+					mailingEntryUpdater := updater.New(repository, mailingentrycreator.New(repository, customerCreator))
+
+					mailingEntry, err := mailingEntryUpdater.UpdateFromDto(ctx, tenantId, id, ifMatchVersions, updateDto)
+					if err != nil {
+						return apimodel.MailingEntryDetails{}, fmt.Errorf("error updating mailing entry %d: %w", id, err)
+					}
+
+					apirequest.SetETag(request, mailingEntry.Version)
+					return mailingEntryDetailsDto(mailingEntry), nil
+				})
 			})
 		})
 	})
@@ -50,10 +192,11 @@ func (handler *Handler) CreateHandlerFunc(request *gin.Context) {
 func (handler *Handler) DeleteHandlerFunc(request *gin.Context) {
 	wrapper.ForRequest(request).Handle(func(ctx context.Context) error {
 		ctx = mdctx.WithOperationName(ctx, "delete mailing entry with ID")
+		tenantId := apirequest.Principal(request).TenantId
 		return wrapper.WithRequiredIntPathParam(request, "id", func(id int) error {
 			return db.InTransaction(ctx, handler.transactioner, func(repository db.Repository) error {
 				mailingEntryRemover := remover.New(repository)
-				err := mailingEntryRemover.Remove(ctx, id)
+				err := mailingEntryRemover.Remove(ctx, tenantId, id)
 				if err != nil {
 					return fmt.Errorf("error deleting mailing entry %d: %w", id, err)
 				}
@@ -66,29 +209,186 @@ func (handler *Handler) DeleteHandlerFunc(request *gin.Context) {
 func (handler *Handler) SendMailingIdHandlerFunc(request *gin.Context) {
 	wrapper.ForRequest(request).Handle(func(ctx context.Context) error {
 		ctx = mdctx.WithOperationName(ctx, "send mailing entries with mailing ID")
+		tenantId := apirequest.Principal(request).TenantId
 
 		return wrapper.WithBoundRequestBody(request, func(mailingRequest apimodel.MailingRequest) error {
-			mdctx.Debugf(ctx, "Sending mailing entries with mailing ID %d", mailingRequest.MailingId)
+			return handler.mailingService.Send(ctx, tenantId, mailingRequest)
+		})
+	})
+}
 
-			// Use a separate transaction for stale entry cleanup because it can be committed even if sending fails later on.
-			err := db.InTransaction(ctx, handler.transactioner, func(repository db.Repository) error {
-				staleEntryRemover := staleremover.New(repository)
-				return staleEntryRemover.RemoveByMailingId(ctx, mailingRequest.MailingId)
-			})
-			if err != nil {
-				return fmt.Errorf("can't proceed with sending mailing entries with ID %d - error cleaning up stale entries: %w",
-					mailingRequest.MailingId, err)
-			}
+// PurgeHandlerFunc deletes mailing entries matching the query parameters at once, or only counts them in a dry run.
+func (handler *Handler) PurgeHandlerFunc(request *gin.Context) {
+	wrapper.ForRequestRetV[apimodel.MailingEntriesDeleted](request).Handle(func(ctx context.Context) (apimodel.MailingEntriesDeleted, error) {
+		ctx = mdctx.WithOperationName(ctx, "purge mailing entries")
+		var filter model.MailingEntryFilter
+		var err error
+		if filter.MailingId, err = wrapper.OptionalIntQueryParam(request, "mailing_id"); err != nil {
+			return apimodel.MailingEntriesDeleted{}, err
+		}
+		if filter.CustomerId, err = wrapper.OptionalIntQueryParam(request, "customer_id"); err != nil {
+			return apimodel.MailingEntriesDeleted{}, err
+		}
+		if filter.InsertedAfter, err = wrapper.OptionalTimeQueryParam(request, "inserted_after"); err != nil {
+			return apimodel.MailingEntriesDeleted{}, err
+		}
+		if filter.InsertedBefore, err = wrapper.OptionalTimeQueryParam(request, "inserted_before"); err != nil {
+			return apimodel.MailingEntriesDeleted{}, err
+		}
+		return handler.purge(ctx, request, filter)
+	})
+}
 
-			err = db.InTransaction(ctx, handler.transactioner, func(repository db.Repository) error {
-				mailer := sender.New(repository, handler.emailer)
-				return mailer.SendMailingRequest(ctx, mailingRequest)
+// DeleteMailingHandlerFunc deletes all mailing entries with the mailing ID at once, or only counts them in a dry run.
+func (handler *Handler) DeleteMailingHandlerFunc(request *gin.Context) {
+	wrapper.ForRequestRetV[apimodel.MailingEntriesDeleted](request).Handle(func(ctx context.Context) (apimodel.MailingEntriesDeleted, error) {
+		ctx = mdctx.WithOperationName(ctx, "delete mailing entries with mailing ID")
+		return wrapper.WithRequiredIntPathParamRetV(request, "id", func(mailingId int) (apimodel.MailingEntriesDeleted, error) {
+			return handler.purge(ctx, request, model.MailingEntryFilter{MailingId: &mailingId})
+		})
+	})
+}
+
+func (handler *Handler) purge(ctx context.Context, request *gin.Context, filter model.MailingEntryFilter) (apimodel.MailingEntriesDeleted, error) {
+	tenantId := apirequest.Principal(request).TenantId
+	dryRun, err := wrapper.BoolQueryParam(request, "dry_run")
+	if err != nil {
+		return apimodel.MailingEntriesDeleted{}, err
+	}
+
+	return db.InTransactionRetV(ctx, handler.transactioner, func(repository db.Repository) (apimodel.MailingEntriesDeleted, error) {
+		mailingEntryPurger := purger.New(repository)
+		count, err := mailingEntryPurger.Purge(ctx, tenantId, filter, dryRun)
+		if err != nil {
+			return apimodel.MailingEntriesDeleted{}, err
+		}
+		return apimodel.MailingEntriesDeleted{DeletedCount: count, DryRun: dryRun}, nil
+	})
+}
+
+func (handler *Handler) GetMailingHandlerFunc(request *gin.Context) {
+	wrapper.ForRequestRetV[apimodel.Mailing](request).Handle(func(ctx context.Context) (apimodel.Mailing, error) {
+		ctx = mdctx.WithOperationName(ctx, "get mailing")
+		tenantId := apirequest.Principal(request).TenantId
+		return wrapper.WithRequiredIntPathParamRetV(request, "id", func(mailingId int) (apimodel.Mailing, error) {
+			return db.InTransactionRetV(ctx, handler.transactioner, func(repository db.Repository) (apimodel.Mailing, error) {
+				mailingEntryFinder := finder.New(repository)
+				return mailingEntryFinder.SummarizeMailing(ctx, tenantId, mailingId)
 			})
+		})
+	})
+}
+
+// EventsHandlerFunc streams progress of sending the mailing as server-sent events. Every sent, failed or bounced mailing entry and every
+// completed send request is reported with an event named after the webhook event type, and counters are reported periodically in
+// progress events. The stream lasts until the client disconnects or the server shuts down.
+func (handler *Handler) EventsHandlerFunc(request *gin.Context) {
+	wrapper.ForRequest(request).OnSuccess(func(_ context.Context) {
+		// The response has already been streamed.
+	}).Handle(func(ctx context.Context) error {
+		ctx = mdctx.WithOperationName(ctx, "stream mailing events")
+		tenantId := apirequest.Principal(request).TenantId
+		return wrapper.WithRequiredIntPathParam(request, "id", func(mailingId int) error {
+			// Subscribe before counting pending entries so that no progress is missed.
+			subscription := handler.progressBus.Subscribe(tenantId, mailingId)
+			defer subscription.Close()
+
+			progressDto := apimodel.MailingProgress{MailingId: mailingId}
+			err := handler.writeProgress(ctx, request, tenantId, &progressDto)
 			if err != nil {
-				return fmt.Errorf("error sending mailing entries with mailing ID %d: %w", mailingRequest.MailingId, err)
+				return err
 			}
 
-			return nil
+			ticker := time.NewTicker(progressPeriod())
+			defer ticker.Stop()
+			for {
+				select {
+				case <-request.Request.Context().Done():
+					mdctx.Debugf(ctx, "Client disconnected - closing mailing event stream")
+					return nil
+				case <-handler.streamsClosed:
+					mdctx.Infof(ctx, "Server is shutting down - closing mailing event stream")
+					return nil
+				case event := <-subscription.Events():
+					countProgress(&progressDto, event)
+					request.SSEvent(event.Type, event.Data)
+					request.Writer.Flush()
+				case <-ticker.C:
+					err = handler.writeProgress(ctx, request, tenantId, &progressDto)
+					if err != nil {
+						// The response is already being streamed, so the error can only be logged.
+						mdctx.Errorf(ctx, "Closing mailing event stream after error reporting progress: %v", err)
+						return nil
+					}
+				}
+			}
 		})
 	})
 }
+
+// writeProgress updates the number of pending entries in progressDto and writes it as a progress event.
+func (handler *Handler) writeProgress(ctx context.Context, request *gin.Context, tenantId string, progressDto *apimodel.MailingProgress) error {
+	pendingCount, err := db.InTransactionRetV(ctx, handler.transactioner, func(repository db.Repository) (int, error) {
+		mailingEntryFinder := finder.New(repository)
+		return mailingEntryFinder.CountByMailingId(ctx, tenantId, progressDto.MailingId)
+	})
+	if err != nil {
+		return fmt.Errorf("error counting pending mailing entries: %w", err)
+	}
+
+	progressDto.PendingCount = pendingCount
+	request.SSEvent(progressEventName, *progressDto)
+	request.Writer.Flush()
+	return nil
+}
+
+// progressPeriod returns the configured period of progress events. Periods shorter than minProgressPeriod, including 0, are raised to it.
+func progressPeriod() time.Duration {
+	period := time.Duration(config.Get().HttpServer.ProgressPeriodSeconds) * time.Second
+	if period < minProgressPeriod {
+		return minProgressPeriod
+	}
+	return period
+}
+
+const minProgressPeriod = time.Second
+
+// progressEventName is the name of server-sent events with apimodel.MailingProgress.
+const progressEventName = "progress"
+
+func countProgress(progressDto *apimodel.MailingProgress, event progress.Event) {
+	switch event.Type {
+	case apimodel.WebhookEventMailingEntrySent:
+		progressDto.SentCount++
+	case apimodel.WebhookEventMailingEntryFailed:
+		progressDto.FailedCount++
+	case apimodel.WebhookEventMailingEntryBounced:
+		progressDto.BouncedCount++
+	}
+}
+
+func mailingEntryDetailsDto(mailingEntry model.MailingEntry) apimodel.MailingEntryDetails {
+	return apimodel.MailingEntryDetails{
+		Id:         mailingEntry.Id,
+		CustomerId: mailingEntry.CustomerId,
+		MailingId:  mailingEntry.MailingId,
+		Title:      mailingEntry.Title,
+		Content:    mailingEntry.Content,
+		InsertTime: mailingEntry.InsertTime,
+		Version:    mailingEntry.Version,
+	}
+}
+
+func archivedMailingEntryDetailsDto(archivedEntry model.ArchivedMailingEntry) apimodel.ArchivedMailingEntryDetails {
+	return apimodel.ArchivedMailingEntryDetails{
+		Id:            archivedEntry.Id,
+		CustomerId:    archivedEntry.CustomerId,
+		CustomerEmail: archivedEntry.CustomerEmail,
+		MailingId:     archivedEntry.MailingId,
+		Title:         archivedEntry.Title,
+		Content:       archivedEntry.Content,
+		InsertTime:    archivedEntry.InsertTime,
+		ArchiveTime:   archivedEntry.ArchiveTime,
+		Reason:        string(archivedEntry.Reason),
+	}
+}
@@ -0,0 +1,14 @@
+package openapi
+
+import (
+	"github.com/GeneralKenobi/mailman/internal/api/openapi"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// NewHandlerFunc creates a handler responding with the OpenAPI document.
+func NewHandlerFunc(document *openapi.Document) gin.HandlerFunc {
+	return func(request *gin.Context) {
+		request.JSON(http.StatusOK, document)
+	}
+}
@@ -0,0 +1,125 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	apirequest "github.com/GeneralKenobi/mailman/internal/api/httpgin/request"
+	"github.com/GeneralKenobi/mailman/internal/api/httpgin/wrapper"
+	"github.com/GeneralKenobi/mailman/internal/db"
+	"github.com/GeneralKenobi/mailman/internal/db/model"
+	"github.com/GeneralKenobi/mailman/internal/service/webhook/creator"
+	"github.com/GeneralKenobi/mailman/internal/service/webhook/finder"
+	"github.com/GeneralKenobi/mailman/internal/service/webhook/remover"
+	"github.com/GeneralKenobi/mailman/pkg/api/apimodel"
+	"github.com/GeneralKenobi/mailman/pkg/mdctx"
+	"github.com/gin-gonic/gin"
+)
+
+func NewHandler(transactioner db.Transactioner) *Handler {
+	return &Handler{transactioner: transactioner}
+}
+
+type Handler struct {
+	transactioner db.Transactioner
+}
+
+func (handler *Handler) CreateHandlerFunc(request *gin.Context) {
+	wrapper.ForRequestRetV[apimodel.WebhookSubscriptionDetails](request).Handle(func(ctx context.Context) (apimodel.WebhookSubscriptionDetails, error) {
+		ctx = mdctx.WithOperationName(ctx, "create webhook subscription")
+		tenantId := apirequest.Principal(request).TenantId
+		return wrapper.WithBoundRequestBodyRetV(request, func(subscriptionDto apimodel.WebhookSubscription) (apimodel.WebhookSubscriptionDetails, error) {
+			return db.InTransactionRetV(ctx, handler.transactioner, func(repository db.Repository) (apimodel.WebhookSubscriptionDetails, error) {
+				subscriptionCreator := creator.New(repository)
+				subscription, err := subscriptionCreator.CreateFromDto(ctx, tenantId, subscriptionDto)
+				if err != nil {
+					return apimodel.WebhookSubscriptionDetails{}, fmt.Errorf("error creating webhook subscription: %w", err)
+				}
+				return subscriptionDetailsDto(subscription), nil
+			})
+		})
+	})
+}
+
+func (handler *Handler) ListHandlerFunc(request *gin.Context) {
+	wrapper.ForRequestRetV[apimodel.WebhookSubscriptionList](request).Handle(func(ctx context.Context) (apimodel.WebhookSubscriptionList, error) {
+		ctx = mdctx.WithOperationName(ctx, "list webhook subscriptions")
+		tenantId := apirequest.Principal(request).TenantId
+		return db.InTransactionRetV(ctx, handler.transactioner, func(repository db.Repository) (apimodel.WebhookSubscriptionList, error) {
+			subscriptionFinder := finder.New(repository)
+			subscriptions, err := subscriptionFinder.FindAll(ctx, tenantId)
+			if err != nil {
+				return apimodel.WebhookSubscriptionList{}, err
+			}
+
+			listDto := apimodel.WebhookSubscriptionList{Subscriptions: make([]apimodel.WebhookSubscriptionDetails, len(subscriptions))}
+			for i, subscription := range subscriptions {
+				listDto.Subscriptions[i] = subscriptionDetailsDto(subscription)
+			}
+			return listDto, nil
+		})
+	})
+}
+
+func (handler *Handler) DeleteHandlerFunc(request *gin.Context) {
+	wrapper.ForRequest(request).Handle(func(ctx context.Context) error {
+		ctx = mdctx.WithOperationName(ctx, "delete webhook subscription with ID")
+		tenantId := apirequest.Principal(request).TenantId
+		return wrapper.WithRequiredIntPathParam(request, "id", func(id int) error {
+			return db.InTransaction(ctx, handler.transactioner, func(repository db.Repository) error {
+				subscriptionRemover := remover.New(repository)
+				err := subscriptionRemover.Remove(ctx, tenantId, id)
+				if err != nil {
+					return fmt.Errorf("error deleting webhook subscription %d: %w", id, err)
+				}
+				return nil
+			})
+		})
+	})
+}
+
+func (handler *Handler) ListDeliveriesHandlerFunc(request *gin.Context) {
+	wrapper.ForRequestRetV[apimodel.WebhookDeliveryList](request).Handle(func(ctx context.Context) (apimodel.WebhookDeliveryList, error) {
+		ctx = mdctx.WithOperationName(ctx, "list webhook deliveries")
+		tenantId := apirequest.Principal(request).TenantId
+		return wrapper.WithRequiredIntPathParamRetV(request, "id", func(id int) (apimodel.WebhookDeliveryList, error) {
+			return db.InTransactionRetV(ctx, handler.transactioner, func(repository db.Repository) (apimodel.WebhookDeliveryList, error) {
+				subscriptionFinder := finder.New(repository)
+				deliveries, err := subscriptionFinder.FindDeliveries(ctx, tenantId, id)
+				if err != nil {
+					return apimodel.WebhookDeliveryList{}, err
+				}
+
+				listDto := apimodel.WebhookDeliveryList{Deliveries: make([]apimodel.WebhookDeliveryDetails, len(deliveries))}
+				for i, delivery := range deliveries {
+					listDto.Deliveries[i] = deliveryDetailsDto(delivery)
+				}
+				return listDto, nil
+			})
+		})
+	})
+}
+
+func subscriptionDetailsDto(subscription model.WebhookSubscription) apimodel.WebhookSubscriptionDetails {
+	return apimodel.WebhookSubscriptionDetails{
+		Id:         subscription.Id,
+		Url:        subscription.Url,
+		EventTypes: subscription.EventTypes,
+		CreateTime: subscription.CreateTime,
+	}
+}
+
+func deliveryDetailsDto(delivery model.WebhookDelivery) apimodel.WebhookDeliveryDetails {
+	deliveryDto := apimodel.WebhookDeliveryDetails{
+		Id:               delivery.Id,
+		EventId:          delivery.EventId,
+		Status:           delivery.Status,
+		AttemptCount:     delivery.AttemptCount,
+		LastAttemptTime:  delivery.LastAttemptTime,
+		LastResponseCode: delivery.LastResponseCode,
+		LastError:        delivery.LastError,
+	}
+	if delivery.Status == model.WebhookDeliveryStatusPending {
+		deliveryDto.NextAttemptTime = &delivery.NextAttemptTime
+	}
+	return deliveryDto
+}
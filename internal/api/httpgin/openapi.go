@@ -0,0 +1,312 @@
+package httpgin
+
+import (
+	"github.com/GeneralKenobi/mailman/internal/api/httpgin/request"
+	"github.com/GeneralKenobi/mailman/internal/api/openapi"
+	"github.com/GeneralKenobi/mailman/internal/auth"
+	"github.com/GeneralKenobi/mailman/pkg/api/apimodel"
+	"net/http"
+)
+
+// apiVersion is the version of the API published in the OpenAPI document.
+const apiVersion = "1.0.0"
+
+// apiOperations documents every route registered in setupGinEngine, except for deprecated unversioned aliases of /api/v1 routes. The
+// OpenAPI document served at /api/v1/openapi.json is generated from it, so it has to be updated together with the routes (which is
+// verified by tests).
+func apiOperations() []openapi.Operation {
+	return []openapi.Operation{
+		{
+			Method:   http.MethodGet,
+			Path:     "/health",
+			Id:       "health",
+			Summary:  "Liveness probe, alias of /health/live",
+			Tag:      "health",
+			Response: apimodel.Health{},
+		},
+		{
+			Method:   http.MethodGet,
+			Path:     "/health/live",
+			Id:       "healthLive",
+			Summary:  "Liveness probe, succeeds whenever the server is running",
+			Tag:      "health",
+			Response: apimodel.Health{},
+		},
+		{
+			Method:   http.MethodGet,
+			Path:     "/health/ready",
+			Id:       "healthReady",
+			Summary:  "Readiness probe, checks DB connectivity, DB pool saturation and email transport reachability. Responds with HTTP503 and the same body if a dependency is down",
+			Tag:      "health",
+			Response: apimodel.Health{},
+		},
+		{
+			Method:   http.MethodGet,
+			Path:     "/api/v1/openapi.json",
+			Id:       "getOpenApiDocument",
+			Summary:  "OpenAPI document describing this API",
+			Tag:      "documentation",
+			Response: map[string]any{},
+		},
+		{
+			Method:        http.MethodGet,
+			Path:          "/api/v1/messages",
+			Id:            "listMailingEntries",
+			Summary:       "List mailing entries with a mailing ID or of a customer",
+			Tag:           "messages",
+			Scope:         string(auth.ScopeMessagesRead),
+			QueryParams:   []openapi.Param{mailingIdQueryParam, customerIdQueryParam},
+			Response:      apimodel.MailingEntryList{},
+			ErrorStatuses: []int{http.StatusBadRequest, http.StatusTooManyRequests},
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/api/v1/archive/messages",
+			Id:      "listArchivedMailingEntries",
+			Summary: "List sent, bounced and stale mailing entries moved to the archive, most recently archived first",
+			Tag:     "archive",
+			Scope:   string(auth.ScopeArchiveRead),
+			QueryParams: []openapi.Param{
+				purgeMailingIdQueryParam, emailQueryParam, archivedAfterQueryParam, archivedBeforeQueryParam, archiveLimitQueryParam,
+			},
+			Response:      apimodel.ArchivedMailingEntryList{},
+			ErrorStatuses: []int{http.StatusBadRequest, http.StatusTooManyRequests},
+		},
+		{
+			Method:        http.MethodPost,
+			Path:          "/api/v1/messages",
+			Id:            "createMailingEntry",
+			Summary:       "Create a mailing entry",
+			Tag:           "messages",
+			Scope:         string(auth.ScopeMessagesWrite),
+			HeaderParams:  []openapi.Param{idempotencyKeyHeaderParam},
+			RequestBody:   apimodel.MailingEntry{},
+			Response:      apimodel.MailingEntryCreated{},
+			ErrorStatuses: []int{http.StatusBadRequest, http.StatusTooManyRequests},
+		},
+		{
+			Method:        http.MethodPost,
+			Path:          "/api/v1/messages/batch",
+			Id:            "createMailingEntryBatch",
+			Summary:       "Create multiple mailing entries at once, either all of them are created or none",
+			Tag:           "messages",
+			Scope:         string(auth.ScopeMessagesWrite),
+			HeaderParams:  []openapi.Param{idempotencyKeyHeaderParam},
+			RequestBody:   apimodel.MailingEntryBatch{},
+			Response:      apimodel.MailingEntryBatchCreated{},
+			ErrorStatuses: []int{http.StatusBadRequest, http.StatusTooManyRequests},
+		},
+		{
+			Method:  http.MethodDelete,
+			Path:    "/api/v1/messages",
+			Id:      "purgeMailingEntries",
+			Summary: "Delete all mailing entries matching the query at once, at least one filter has to be given",
+			Tag:     "messages",
+			Scope:   string(auth.ScopeMessagesWrite),
+			QueryParams: []openapi.Param{
+				purgeMailingIdQueryParam, purgeCustomerIdQueryParam, insertedAfterQueryParam, insertedBeforeQueryParam, dryRunQueryParam,
+			},
+			HeaderParams:  []openapi.Param{idempotencyKeyHeaderParam},
+			Response:      apimodel.MailingEntriesDeleted{},
+			ErrorStatuses: []int{http.StatusBadRequest, http.StatusTooManyRequests},
+		},
+		{
+			Method:        http.MethodGet,
+			Path:          "/api/v1/messages/:id",
+			Id:            "getMailingEntry",
+			Summary:       "Get a mailing entry that wasn't sent yet, its version is returned in the ETag header",
+			Tag:           "messages",
+			Scope:         string(auth.ScopeMessagesRead),
+			PathParams:    []openapi.Param{{Name: "id", Description: "Mailing entry ID", Type: 0}},
+			Response:      apimodel.MailingEntryDetails{},
+			ErrorStatuses: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusTooManyRequests},
+		},
+		{
+			Method:       http.MethodPatch,
+			Path:         "/api/v1/messages/:id",
+			Id:           "updateMailingEntry",
+			Summary:      "Update a mailing entry that wasn't sent yet, its new version is returned in the ETag header",
+			Tag:          "messages",
+			Scope:        string(auth.ScopeMessagesWrite),
+			PathParams:   []openapi.Param{{Name: "id", Description: "Mailing entry ID", Type: 0}},
+			HeaderParams: []openapi.Param{ifMatchHeaderParam, idempotencyKeyHeaderParam},
+			RequestBody:  apimodel.MailingEntryUpdate{},
+			Response:     apimodel.MailingEntryDetails{},
+			ErrorStatuses: []int{
+				http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusPreconditionFailed, http.StatusTooManyRequests,
+			},
+		},
+		{
+			Method:        http.MethodDelete,
+			Path:          "/api/v1/messages/:id",
+			Id:            "deleteMailingEntry",
+			Summary:       "Delete a mailing entry",
+			Tag:           "messages",
+			Scope:         string(auth.ScopeMessagesWrite),
+			PathParams:    []openapi.Param{{Name: "id", Description: "Mailing entry ID", Type: 0}},
+			HeaderParams:  []openapi.Param{idempotencyKeyHeaderParam},
+			ErrorStatuses: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusTooManyRequests},
+		},
+		{
+			Method:        http.MethodPost,
+			Path:          "/api/v1/messages/send",
+			Id:            "sendMailing",
+			Summary:       "Send all mailing entries with a mailing ID and delete them",
+			Tag:           "messages",
+			Scope:         string(auth.ScopeMessagesSend),
+			HeaderParams:  []openapi.Param{idempotencyKeyHeaderParam},
+			RequestBody:   apimodel.MailingRequest{},
+			ErrorStatuses: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusTooManyRequests},
+		},
+		{
+			Method:        http.MethodGet,
+			Path:          "/api/v1/mailings/:id",
+			Id:            "getMailing",
+			Summary:       "Summarize mailing entries with a mailing ID",
+			Tag:           "mailings",
+			Scope:         string(auth.ScopeMessagesRead),
+			PathParams:    []openapi.Param{{Name: "id", Description: "Mailing ID", Type: 0}},
+			Response:      apimodel.Mailing{},
+			ErrorStatuses: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusTooManyRequests},
+		},
+		{
+			Method:        http.MethodDelete,
+			Path:          "/api/v1/mailings/:id/entries",
+			Id:            "deleteMailing",
+			Summary:       "Delete all mailing entries with a mailing ID at once",
+			Tag:           "mailings",
+			Scope:         string(auth.ScopeMessagesWrite),
+			PathParams:    []openapi.Param{{Name: "id", Description: "Mailing ID", Type: 0}},
+			QueryParams:   []openapi.Param{dryRunQueryParam},
+			HeaderParams:  []openapi.Param{idempotencyKeyHeaderParam},
+			Response:      apimodel.MailingEntriesDeleted{},
+			ErrorStatuses: []int{http.StatusBadRequest, http.StatusTooManyRequests},
+		},
+		{
+			Method:        http.MethodGet,
+			Path:          "/api/v1/mailings/:id/events",
+			Id:            "streamMailingEvents",
+			Summary:       "Stream progress of sending mailing entries with a mailing ID as server-sent events",
+			Tag:           "mailings",
+			Scope:         string(auth.ScopeMessagesRead),
+			PathParams:    []openapi.Param{{Name: "id", Description: "Mailing ID", Type: 0}},
+			Response:      apimodel.MailingProgress{},
+			ResponseType:  "text/event-stream",
+			ErrorStatuses: []int{http.StatusBadRequest, http.StatusTooManyRequests},
+		},
+		{
+			Method:        http.MethodPost,
+			Path:          "/api/v1/customers",
+			Id:            "createCustomer",
+			Summary:       "Create a customer",
+			Tag:           "customers",
+			Scope:         string(auth.ScopeCustomersWrite),
+			HeaderParams:  []openapi.Param{idempotencyKeyHeaderParam},
+			RequestBody:   apimodel.Customer{},
+			Response:      apimodel.CustomerDetails{},
+			ErrorStatuses: []int{http.StatusBadRequest, http.StatusTooManyRequests},
+		},
+		{
+			Method:        http.MethodGet,
+			Path:          "/api/v1/customers/:id",
+			Id:            "getCustomer",
+			Summary:       "Get a customer",
+			Tag:           "customers",
+			Scope:         string(auth.ScopeCustomersRead),
+			PathParams:    []openapi.Param{{Name: "id", Description: "Customer ID", Type: 0}},
+			Response:      apimodel.CustomerDetails{},
+			ErrorStatuses: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusTooManyRequests},
+		},
+		{
+			Method:        http.MethodDelete,
+			Path:          "/api/v1/customers/:id",
+			Id:            "deleteCustomer",
+			Summary:       "Delete a customer without mailing entries",
+			Tag:           "customers",
+			Scope:         string(auth.ScopeCustomersWrite),
+			PathParams:    []openapi.Param{{Name: "id", Description: "Customer ID", Type: 0}},
+			HeaderParams:  []openapi.Param{idempotencyKeyHeaderParam},
+			ErrorStatuses: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusTooManyRequests},
+		},
+		{
+			Method:        http.MethodGet,
+			Path:          "/api/v1/webhooks",
+			Id:            "listWebhookSubscriptions",
+			Summary:       "List webhook subscriptions",
+			Tag:           "webhooks",
+			Scope:         string(auth.ScopeWebhooksRead),
+			Response:      apimodel.WebhookSubscriptionList{},
+			ErrorStatuses: []int{http.StatusTooManyRequests},
+		},
+		{
+			Method:        http.MethodPost,
+			Path:          "/api/v1/webhooks",
+			Id:            "createWebhookSubscription",
+			Summary:       "Subscribe a URL to events, they're sent in signed POST requests",
+			Tag:           "webhooks",
+			Scope:         string(auth.ScopeWebhooksWrite),
+			HeaderParams:  []openapi.Param{idempotencyKeyHeaderParam},
+			RequestBody:   apimodel.WebhookSubscription{},
+			Response:      apimodel.WebhookSubscriptionDetails{},
+			ErrorStatuses: []int{http.StatusBadRequest, http.StatusTooManyRequests},
+		},
+		{
+			Method:        http.MethodDelete,
+			Path:          "/api/v1/webhooks/:id",
+			Id:            "deleteWebhookSubscription",
+			Summary:       "Delete a webhook subscription, its pending deliveries are dropped",
+			Tag:           "webhooks",
+			Scope:         string(auth.ScopeWebhooksWrite),
+			PathParams:    []openapi.Param{{Name: "id", Description: "Webhook subscription ID", Type: 0}},
+			HeaderParams:  []openapi.Param{idempotencyKeyHeaderParam},
+			ErrorStatuses: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusTooManyRequests},
+		},
+		{
+			Method:        http.MethodGet,
+			Path:          "/api/v1/webhooks/:id/deliveries",
+			Id:            "listWebhookDeliveries",
+			Summary:       "List the most recent deliveries to a webhook subscription",
+			Tag:           "webhooks",
+			Scope:         string(auth.ScopeWebhooksRead),
+			PathParams:    []openapi.Param{{Name: "id", Description: "Webhook subscription ID", Type: 0}},
+			Response:      apimodel.WebhookDeliveryList{},
+			ErrorStatuses: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusTooManyRequests},
+		},
+	}
+}
+
+var (
+	idempotencyKeyHeaderParam = openapi.Param{
+		Name:        request.IdempotencyKeyHeader,
+		Description: "Client-generated key, retries with the same key get the original response instead of being processed again",
+		Type:        "",
+	}
+	ifMatchHeaderParam = openapi.Param{
+		Name:        request.IfMatchHeader,
+		Description: "ETag of the mailing entry, it's only updated if its current version matches",
+		Type:        "",
+	}
+	mailingIdQueryParam  = openapi.Param{Name: "mailing_id", Description: "Mailing ID, exclusive with customer_id", Type: 0}
+	customerIdQueryParam = openapi.Param{Name: "customer_id", Description: "Customer ID, exclusive with mailing_id", Type: 0}
+
+	purgeMailingIdQueryParam  = openapi.Param{Name: "mailing_id", Description: "Mailing ID", Type: 0}
+	purgeCustomerIdQueryParam = openapi.Param{Name: "customer_id", Description: "Customer ID", Type: 0}
+	insertedAfterQueryParam   = openapi.Param{Name: "inserted_after", Description: "RFC 3339 timestamp, entries inserted at or after it", Type: ""}
+	insertedBeforeQueryParam  = openapi.Param{Name: "inserted_before", Description: "RFC 3339 timestamp, entries inserted before it", Type: ""}
+	dryRunQueryParam          = openapi.Param{Name: "dry_run", Description: "Only count the entries that would be deleted", Type: false}
+
+	emailQueryParam          = openapi.Param{Name: "email", Description: "Email the entries were sent to", Type: ""}
+	archivedAfterQueryParam  = openapi.Param{Name: "archived_after", Description: "RFC 3339 timestamp, entries archived at or after it", Type: ""}
+	archivedBeforeQueryParam = openapi.Param{Name: "archived_before", Description: "RFC 3339 timestamp, entries archived before it", Type: ""}
+	archiveLimitQueryParam   = openapi.Param{Name: "limit", Description: "Maximum number of returned entries, 100 by default and at most 1000", Type: 0}
+)
+
+// openApiDocument generates the OpenAPI document of the API.
+func openApiDocument() (*openapi.Document, error) {
+	info := openapi.Info{
+		Title:       "mailman",
+		Description: "Microservice for sending emails",
+		Version:     apiVersion,
+	}
+	return openapi.Generate(info, openapi.ErrorResponses{Default: apimodel.Error{}, BadRequest: apimodel.ValidationError{}}, apiOperations())
+}
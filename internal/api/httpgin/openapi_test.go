@@ -0,0 +1,90 @@
+package httpgin
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"github.com/GeneralKenobi/mailman/internal/auth"
+	"github.com/gin-gonic/gin"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+)
+
+var updateOpenApiDocument = flag.Bool("update", false, "Regenerate the checked-in OpenAPI document")
+
+// openApiDocumentPath is the checked-in OpenAPI document published for clients.
+const openApiDocumentPath = "../../../api/openapi.json"
+
+// Every registered route has to be documented, and every documented operation has to be registered. Unversioned routes aren't
+// documented, but every /api/v1 route has to have an unversioned alias until they're removed.
+func TestOpenApiOperationsMatchRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	server := Server{authenticator: auth.NewChain()}
+	ginEngine := server.setupGinEngine()
+
+	var registered, documented []string
+	aliases := make(map[string]bool)
+	for _, route := range ginEngine.Routes() {
+		if strings.HasPrefix(route.Path, unversionedApiPrefix+"/") && !strings.HasPrefix(route.Path, apiV1Prefix+"/") {
+			aliases[route.Method+" "+route.Path] = true
+			continue
+		}
+		registered = append(registered, route.Method+" "+route.Path)
+	}
+	for _, route := range registered {
+		method, path, _ := strings.Cut(route, " ")
+		if strings.HasPrefix(path, apiV1Prefix+"/") {
+			alias := method + " " + unversionedApiPrefix + strings.TrimPrefix(path, apiV1Prefix)
+			if !aliases[alias] {
+				t.Errorf("Route %s has no unversioned alias %s", route, alias)
+			}
+			delete(aliases, alias)
+		}
+	}
+	for alias := range aliases {
+		t.Errorf("Unversioned route %s has no /api/v1 successor", alias)
+	}
+	for _, operation := range apiOperations() {
+		documented = append(documented, operation.Method+" "+operation.Path)
+	}
+	sort.Strings(registered)
+	sort.Strings(documented)
+
+	registeredJson, _ := json.MarshalIndent(registered, "", "  ")
+	documentedJson, _ := json.MarshalIndent(documented, "", "  ")
+	if !bytes.Equal(registeredJson, documentedJson) {
+		t.Errorf("Routes registered in setupGinEngine don't match operations in apiOperations.\nRegistered: %s\nDocumented: %s",
+			registeredJson, documentedJson)
+	}
+}
+
+// The checked-in document has to match the one generated from the current routes and DTOs. Run the test with -update to regenerate it.
+func TestOpenApiDocumentIsUpToDate(t *testing.T) {
+	document, err := openApiDocument()
+	if err != nil {
+		t.Fatalf("Error generating OpenAPI document: %v", err)
+	}
+	generated, err := json.MarshalIndent(document, "", "  ")
+	if err != nil {
+		t.Fatalf("Error marshaling OpenAPI document: %v", err)
+	}
+	generated = append(generated, '\n')
+
+	if *updateOpenApiDocument {
+		if err = os.WriteFile(openApiDocumentPath, generated, 0o644); err != nil {
+			t.Fatalf("Error writing %s: %v", openApiDocumentPath, err)
+		}
+		return
+	}
+
+	checkedIn, err := os.ReadFile(openApiDocumentPath)
+	if err != nil {
+		t.Fatalf("Error reading %s: %v", openApiDocumentPath, err)
+	}
+	if !bytes.Equal(generated, checkedIn) {
+		t.Errorf("%s is out of date - regenerate it with: go test ./internal/api/httpgin -run TestOpenApiDocumentIsUpToDate -update",
+			openApiDocumentPath)
+	}
+}
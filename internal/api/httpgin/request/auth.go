@@ -0,0 +1,58 @@
+package request
+
+import (
+	"github.com/GeneralKenobi/mailman/internal/api"
+	"github.com/GeneralKenobi/mailman/internal/auth"
+	"github.com/GeneralKenobi/mailman/pkg/mdctx"
+	"github.com/gin-gonic/gin"
+)
+
+// AuthenticationMiddleware authenticates the request and saves the principal in gin's context. The principal's subject and tenant are
+// added to the request context so that they appear in logs. Unauthenticated requests are aborted with an error response.
+func AuthenticationMiddleware(authenticator auth.Authenticator) gin.HandlerFunc {
+	return func(request *gin.Context) {
+		ctx := Context(request)
+		principal, err := authenticator.Authenticate(ctx, request.Request)
+		if err != nil {
+			WriteErrorResponse(ctx, request, err)
+			request.Abort()
+			return
+		}
+
+		ctx = mdctx.WithSubject(ctx, principal.Subject)
+		ctx = mdctx.WithTenantId(ctx, principal.TenantId)
+		request.Set(requestContextKey, ctx)
+		request.Set(principalKey, principal)
+		request.Next()
+	}
+}
+
+// RequireScope aborts requests whose principal wasn't granted the scope. It has to be used after AuthenticationMiddleware.
+func RequireScope(scope auth.Scope) gin.HandlerFunc {
+	return func(request *gin.Context) {
+		if !Principal(request).HasScope(scope) {
+			WriteErrorResponse(Context(request), request, api.StatusForbidden.WithMessage("missing scope %s", scope))
+			request.Abort()
+			return
+		}
+		request.Next()
+	}
+}
+
+// Principal extracts the authenticated principal from a gin context. An empty principal (without any scopes) is returned if the request
+// wasn't authenticated.
+func Principal(request *gin.Context) auth.Principal {
+	value, found := request.Get(principalKey)
+	if !found {
+		return auth.Principal{}
+	}
+	principal, _ := value.(auth.Principal)
+	return principal
+}
+
+const principalKey = "principal"
+
+// clientKey identifies the client making the request, see auth.Principal.ClientKey.
+func clientKey(request *gin.Context) string {
+	return Principal(request).ClientKey(request.ClientIP())
+}
@@ -0,0 +1,41 @@
+package request
+
+import (
+	"github.com/gin-gonic/gin"
+	"strconv"
+	"strings"
+)
+
+const (
+	ETagHeader    = "ETag"
+	IfMatchHeader = "If-Match"
+)
+
+// SetETag sets the ETag response header to a strong entity tag of the resource version.
+func SetETag(request *gin.Context, version int) {
+	request.Header(ETagHeader, `"`+strconv.Itoa(version)+`"`)
+}
+
+// IfMatchVersions parses resource versions from entity tags in the If-Match request header. It returns nil if the header is missing
+// or is "*", because then any existing version matches. Weak and malformed entity tags are skipped, they never match a version, so
+// the returned slice can be empty but not nil if the header is set.
+func IfMatchVersions(request *gin.Context) []int {
+	header := strings.TrimSpace(request.GetHeader(IfMatchHeader))
+	if header == "" || header == "*" {
+		return nil
+	}
+
+	versions := make([]int, 0)
+	for _, entityTag := range strings.Split(header, ",") {
+		entityTag = strings.TrimSpace(entityTag)
+		if len(entityTag) < 2 || entityTag[0] != '"' || entityTag[len(entityTag)-1] != '"' {
+			continue
+		}
+		version, err := strconv.Atoi(entityTag[1 : len(entityTag)-1])
+		if err != nil {
+			continue
+		}
+		versions = append(versions, version)
+	}
+	return versions
+}
@@ -0,0 +1,145 @@
+package request
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"github.com/GeneralKenobi/mailman/internal/api"
+	"github.com/GeneralKenobi/mailman/pkg/idempotency"
+	"github.com/GeneralKenobi/mailman/pkg/mdctx"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+)
+
+const (
+	// IdempotencyKeyHeader carries a client-generated key identifying a request. Retries of the request with the same key get the
+	// original response instead of being processed again.
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader is set in responses replayed to retried requests.
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLength = 255
+	// maxFingerprintedBodySize limits bodies read into memory to fingerprint requests with an Idempotency-Key header.
+	maxFingerprintedBodySize = 10 << 20 // 10 MiB
+)
+
+// IdempotencyMiddleware replays recorded responses to POST, PATCH and DELETE requests with an Idempotency-Key header that was already used
+// by the same client. Other methods are safe to retry and ignore the header. Responses with HTTP401, HTTP403, HTTP429 and HTTP5xx
+// statuses aren't recorded, so retries of such requests are processed again. Reusing a key for a different request (method, URI or body)
+// is rejected with HTTP400.
+//
+// It has to be used after AuthenticationMiddleware, RequireScope and rate limiting middleware, so that replayed requests are authorized
+// and limited like any other.
+func IdempotencyMiddleware(store *idempotency.Store) gin.HandlerFunc {
+	return func(request *gin.Context) {
+		idempotencyKey := request.GetHeader(IdempotencyKeyHeader)
+		if idempotencyKey == "" || !isIdempotencyKeyMethod(request.Request.Method) {
+			request.Next()
+			return
+		}
+
+		ctx := Context(request)
+		if len(idempotencyKey) > maxIdempotencyKeyLength {
+			WriteErrorResponse(ctx, request,
+				api.StatusBadInput.WithMessage("%s header can't be longer than %d characters", IdempotencyKeyHeader, maxIdempotencyKeyLength))
+			request.Abort()
+			return
+		}
+		fingerprint, err := requestFingerprint(request)
+		if err != nil {
+			WriteErrorResponse(ctx, request, api.StatusBadInput.WithMessageAndCause(err,
+				"error reading request body, requests with %s header can't be larger than %d bytes", IdempotencyKeyHeader, maxFingerprintedBodySize))
+			request.Abort()
+			return
+		}
+
+		storeKey := clientKey(request) + "/" + idempotencyKey
+		response, err := store.Begin(request.Request.Context(), storeKey, fingerprint)
+		if err != nil {
+			if errors.Is(err, idempotency.ErrFingerprintMismatch) {
+				err = api.StatusBadInput.WithMessageAndCause(err, "%s was already used for a different request", IdempotencyKeyHeader)
+			}
+			WriteErrorResponse(ctx, request, err)
+			request.Abort()
+			return
+		}
+		if response != nil {
+			mdctx.Infof(ctx, "Replaying response to request with the same idempotency key")
+			request.Header(IdempotentReplayedHeader, "true")
+			request.Data(response.Status, response.ContentType, response.Body)
+			request.Abort()
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: request.Writer}
+		request.Writer = recorder
+		completed := false
+		defer func() {
+			// Handlers panicked - let retries process the request again.
+			if !completed {
+				store.Abandon(storeKey)
+			}
+		}()
+
+		request.Next()
+
+		status := recorder.Status()
+		if !isRecordedStatus(status) {
+			store.Abandon(storeKey)
+		} else {
+			store.Complete(storeKey, idempotency.Response{
+				Status:      status,
+				ContentType: recorder.Header().Get("Content-Type"),
+				Body:        recorder.body.Bytes(),
+			})
+		}
+		completed = true
+	}
+}
+
+func isIdempotencyKeyMethod(method string) bool {
+	return method == http.MethodPost || method == http.MethodPatch || method == http.MethodDelete
+}
+
+// isRecordedStatus checks if responses with the status are replayed. Failed authentication and authorization, rate limiting and server
+// errors may succeed when retried.
+func isRecordedStatus(status int) bool {
+	switch {
+	case status == http.StatusUnauthorized, status == http.StatusForbidden, status == http.StatusTooManyRequests:
+		return false
+	default:
+		return status < http.StatusInternalServerError
+	}
+}
+
+// requestFingerprint hashes the request's method, URI and body. The body is restored so that handlers can read it.
+func requestFingerprint(request *gin.Context) (string, error) {
+	body, err := io.ReadAll(http.MaxBytesReader(request.Writer, request.Request.Body, maxFingerprintedBodySize))
+	if err != nil {
+		return "", err
+	}
+	request.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	hash := sha256.New()
+	hash.Write([]byte(request.Request.Method + " " + request.Request.URL.RequestURI() + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// responseRecorder copies the response body written by handlers.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (recorder *responseRecorder) Write(data []byte) (int, error) {
+	recorder.body.Write(data)
+	return recorder.ResponseWriter.Write(data)
+}
+
+func (recorder *responseRecorder) WriteString(data string) (int, error) {
+	recorder.body.WriteString(data)
+	return recorder.ResponseWriter.WriteString(data)
+}
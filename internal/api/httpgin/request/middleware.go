@@ -3,6 +3,7 @@ package request
 import (
 	"github.com/GeneralKenobi/mailman/pkg/mdctx"
 	"github.com/gin-gonic/gin"
+	"strings"
 )
 
 // ContextMiddleware creates and saves in gin's context an MDC-enhanced context for the request.
@@ -24,3 +25,15 @@ func LogRequestProcessingMiddleware(request *gin.Context) {
 	request.Next()
 	mdctx.Debugf(ctx, "End processing")
 }
+
+// DeprecationMiddleware marks responses of deprecated routes under deprecatedPrefix with the Deprecation header and links the same route
+// under successorPrefix, e.g. "/api/v1/messages" for "/api/messages" with prefixes "/api" and "/api/v1".
+func DeprecationMiddleware(deprecatedPrefix, successorPrefix string) gin.HandlerFunc {
+	return func(request *gin.Context) {
+		successorPath := successorPrefix + strings.TrimPrefix(request.Request.URL.Path, deprecatedPrefix)
+		mdctx.Debugf(Context(request), "Deprecated route called, its successor is %s", successorPath)
+		request.Header("Deprecation", "true")
+		request.Header("Link", "<"+successorPath+`>; rel="successor-version"`)
+		request.Next()
+	}
+}
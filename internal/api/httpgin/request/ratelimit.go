@@ -0,0 +1,26 @@
+package request
+
+import (
+	"github.com/GeneralKenobi/mailman/internal/api"
+	"github.com/gin-gonic/gin"
+	"math"
+	"strconv"
+)
+
+// RateLimitMiddleware aborts requests of clients that exceeded the limit of the group with HTTP429 and a Retry-After header. Authenticated
+// clients are identified by their tenant and subject, anonymous clients by IP address. Used before AuthenticationMiddleware, it limits
+// every client by IP address.
+func RateLimitMiddleware(limiters api.RateLimiters, group string) gin.HandlerFunc {
+	return func(request *gin.Context) {
+		allowed, retryAfter := limiters.Allow(group, clientKey(request))
+		if !allowed {
+			retryAfterSeconds := int(math.Ceil(retryAfter.Seconds()))
+			request.Header("Retry-After", strconv.Itoa(retryAfterSeconds))
+			WriteErrorResponse(Context(request), request,
+				api.StatusTooManyRequests.WithMessage("rate limit exceeded, retry after %d seconds", retryAfterSeconds))
+			request.Abort()
+			return
+		}
+		request.Next()
+	}
+}
@@ -32,7 +32,8 @@ func Context(request *gin.Context) context.Context {
 const requestContextKey = "requestContext"
 
 // WriteErrorResponse looks for a wrapped api.StatusError in err, if it's found it writes the response based on it, if it's not found then
-// it writes a generic internal server error response. The error is also logged.
+// it writes a generic internal server error response. Errors describing invalid fields are written as apimodel.ValidationError. The
+// error is also logged.
 func WriteErrorResponse(ctx context.Context, request *gin.Context, err error) {
 	var apiError api.StatusError
 	if !errors.As(err, &apiError) {
@@ -46,7 +47,22 @@ func WriteErrorResponse(ctx context.Context, request *gin.Context, err error) {
 		Message:     apiError.Message(),
 		OperationId: mdctx.OperationId(ctx),
 	}
-	request.JSON(errorDto.Status, errorDto)
+	fieldErrs := api.FieldErrors(apiError)
+	if len(fieldErrs) == 0 {
+		request.JSON(errorDto.Status, errorDto)
+		return
+	}
+
+	validationErrorDto := apimodel.ValidationError{Error: errorDto, Fields: make([]apimodel.FieldError, len(fieldErrs))}
+	for i, fieldErr := range fieldErrs {
+		validationErrorDto.Fields[i] = apimodel.FieldError{
+			Path:          fieldErr.Path,
+			Rule:          fieldErr.Rule,
+			Message:       fieldErr.Message,
+			RejectedValue: fieldErr.RejectedValue,
+		}
+	}
+	request.JSON(errorDto.Status, validationErrorDto)
 }
 
 func apiStatusToHttpStatus(status api.Status) int {
@@ -55,8 +71,16 @@ func apiStatusToHttpStatus(status api.Status) int {
 		return http.StatusBadRequest
 	case api.StatusUnauthorized:
 		return http.StatusUnauthorized
+	case api.StatusForbidden:
+		return http.StatusForbidden
 	case api.StatusNotFound:
 		return http.StatusNotFound
+	case api.StatusConflict:
+		return http.StatusConflict
+	case api.StatusPreconditionFailed:
+		return http.StatusPreconditionFailed
+	case api.StatusTooManyRequests:
+		return http.StatusTooManyRequests
 	case api.StatusInternalError:
 		return http.StatusInternalServerError
 	default:
@@ -2,40 +2,77 @@ package httpgin
 
 import (
 	"context"
+	"expvar"
 	"fmt"
+	"github.com/GeneralKenobi/mailman/internal/api"
+	"github.com/GeneralKenobi/mailman/internal/api/httpgin/handler/customer"
 	"github.com/GeneralKenobi/mailman/internal/api/httpgin/handler/health"
 	"github.com/GeneralKenobi/mailman/internal/api/httpgin/handler/mailingentry"
+	openapihandler "github.com/GeneralKenobi/mailman/internal/api/httpgin/handler/openapi"
+	"github.com/GeneralKenobi/mailman/internal/api/httpgin/handler/webhook"
 	"github.com/GeneralKenobi/mailman/internal/api/httpgin/request"
+	"github.com/GeneralKenobi/mailman/internal/auth"
 	"github.com/GeneralKenobi/mailman/internal/config"
 	"github.com/GeneralKenobi/mailman/internal/db"
 	"github.com/GeneralKenobi/mailman/internal/email"
+	healthservice "github.com/GeneralKenobi/mailman/internal/service/health"
+	"github.com/GeneralKenobi/mailman/internal/service/mailingentry/progress"
+	"github.com/GeneralKenobi/mailman/pkg/idempotency"
 	"github.com/GeneralKenobi/mailman/pkg/mdctx"
 	"github.com/GeneralKenobi/mailman/pkg/shutdown"
 	"github.com/gin-gonic/gin"
 	"net/http"
+	"time"
 )
 
-func NewServer(dbCtx db.Context, emailer email.Service) *Server {
+const (
+	apiV1Prefix          = "/api/v1"
+	unversionedApiPrefix = "/api"
+)
+
+func NewServer(dbCtx db.Context, emailer email.Service, authenticator auth.Authenticator, progressBus *progress.Bus,
+	rateLimiters api.RateLimiters) *Server {
+
 	server := Server{
-		dbCtx:   dbCtx,
-		emailer: emailer,
+		dbCtx:         dbCtx,
+		emailer:       emailer,
+		authenticator: authenticator,
+		progressBus:   progressBus,
+		rateLimiters:  rateLimiters,
+		streamsClosed: make(chan struct{}),
 	}
 	server.configure()
 	return &server
 }
 
 type Server struct {
-	dbCtx      db.Context
-	emailer    email.Service
-	httpServer *http.Server
+	dbCtx         db.Context
+	emailer       email.Service
+	authenticator auth.Authenticator
+	progressBus   *progress.Bus
+	rateLimiters  api.RateLimiters
+	streamsClosed chan struct{} // Closed when the server starts shutting down, so that streaming handlers return
+	httpServer    *http.Server
+	adminServer   *http.Server // Publishes metrics on a separate port, so that they're not exposed together with the API
 }
 
-// Run starts the HTTP server and shuts it down gracefully when ctx is cancelled.
+// Run starts the HTTP and admin servers and shuts them down gracefully when ctx is cancelled.
 func (server *Server) Run(ctx shutdown.Context) {
-	go server.listenAndServe()
+	go listenAndServe(server.httpServer, "HTTP server")
+	go listenAndServe(server.adminServer, "admin HTTP server")
 	server.shutdownOnContextCancellation(ctx)
 }
 
+// Handler returns the HTTP handler serving the API, e.g. for testing with httptest.
+func (server *Server) Handler() http.Handler {
+	return server.httpServer.Handler
+}
+
+// AdminHandler returns the HTTP handler of the admin server, e.g. for testing with httptest.
+func (server *Server) AdminHandler() http.Handler {
+	return server.adminServer.Handler
+}
+
 // configure creates a ready-to-use server and stores it in Server.httpServer.
 func (server *Server) configure() {
 	httpCfg := config.Get().HttpServer
@@ -44,6 +81,17 @@ func (server *Server) configure() {
 		Addr:    fmt.Sprintf(":%d", httpCfg.Port),
 		Handler: ginEngine,
 	}
+	// Shutdown waits for handlers to return, streaming handlers would otherwise run until the client disconnects.
+	server.httpServer.RegisterOnShutdown(func() {
+		close(server.streamsClosed)
+	})
+
+	adminMux := http.NewServeMux()
+	adminMux.Handle("/debug/vars", expvar.Handler())
+	server.adminServer = &http.Server{
+		Addr:    fmt.Sprintf(":%d", httpCfg.AdminPort),
+		Handler: adminMux,
+	}
 }
 
 // setupGinEngine configures routing, middleware and handlers.
@@ -51,23 +99,84 @@ func (server *Server) setupGinEngine() *gin.Engine {
 	ginEngine := gin.New()
 	ginEngine.Use(gin.Recovery(), request.ContextMiddleware, request.LogRequestProcessingMiddleware)
 
-	ginEngine.GET("/health", health.HandlerFunc)
+	healthCheckTimeout := time.Duration(config.Get().HttpServer.HealthCheckTimeoutSeconds) * time.Second
+	healthHandler := health.NewHandler(healthservice.NewChecker(server.dbCtx, server.emailer, healthCheckTimeout))
+	ginEngine.GET("/health", healthHandler.LiveHandlerFunc) // Kept for probes configured before /health/live was added
+	ginEngine.GET("/health/live", healthHandler.LiveHandlerFunc)
+	ginEngine.GET("/health/ready", healthHandler.ReadyHandlerFunc)
 
-	mailingEntryHandler := mailingentry.NewHandler(server.dbCtx, server.emailer)
-	ginEngine.POST("/api/messages", mailingEntryHandler.CreateHandlerFunc)
-	ginEngine.DELETE("/api/messages/:id", mailingEntryHandler.DeleteHandlerFunc)
-	ginEngine.POST("/api/messages/send", mailingEntryHandler.SendMailingIdHandlerFunc)
+	openApiDocument, err := openApiDocument()
+	if err != nil {
+		// The document is generated from static route definitions - this is a programming error caught by tests.
+		panic(fmt.Sprintf("error generating OpenAPI document: %v", err))
+	}
+	openApiHandlerFunc := openapihandler.NewHandlerFunc(openApiDocument)
+	ginEngine.GET(apiV1Prefix+"/openapi.json", openApiHandlerFunc)
+
+	authenticationMiddleware := request.AuthenticationMiddleware(server.authenticator)
+	idempotencyKeyTtl := time.Duration(config.Get().HttpServer.IdempotencyKeyTtlSeconds) * time.Second
+	idempotencyMiddleware := request.IdempotencyMiddleware(idempotency.New(idempotencyKeyTtl))
+	// Route groups share limiters between versions, so that clients can't get around limits by calling both. Requests are limited by IP
+	// address before they're authenticated too, so that guessing credentials is limited.
+	authenticationLimitMiddleware := request.RateLimitMiddleware(server.rateLimiters, api.RateLimitGroupAuthentication)
+	messagesLimitMiddleware := request.RateLimitMiddleware(server.rateLimiters, api.RateLimitGroupMessages)
+	sendLimitMiddleware := request.RateLimitMiddleware(server.rateLimiters, api.RateLimitGroupSend)
+	customersLimitMiddleware := request.RateLimitMiddleware(server.rateLimiters, api.RateLimitGroupCustomers)
+	webhooksLimitMiddleware := request.RateLimitMiddleware(server.rateLimiters, api.RateLimitGroupWebhooks)
+	mailingEntryHandler := mailingentry.NewHandler(server.dbCtx, server.emailer, server.progressBus, server.streamsClosed)
+	customerHandler := customer.NewHandler(server.dbCtx)
+	webhookHandler := webhook.NewHandler(server.dbCtx)
+
+	// mutating creates handlers of routes changing data. Idempotency keys are checked after scopes and rate limits, so that replays are
+	// authorized and limited like other requests.
+	mutating := func(scope auth.Scope, handlerFunc gin.HandlerFunc) []gin.HandlerFunc {
+		return []gin.HandlerFunc{request.RequireScope(scope), idempotencyMiddleware, handlerFunc}
+	}
+
+	registerApiRoutes := func(apiGroup *gin.RouterGroup) {
+		messagesGroup := apiGroup.Group("", messagesLimitMiddleware)
+		messagesGroup.GET("/messages", request.RequireScope(auth.ScopeMessagesRead), mailingEntryHandler.ListHandlerFunc)
+		messagesGroup.POST("/messages", mutating(auth.ScopeMessagesWrite, mailingEntryHandler.CreateHandlerFunc)...)
+		messagesGroup.POST("/messages/batch", mutating(auth.ScopeMessagesWrite, mailingEntryHandler.CreateBatchHandlerFunc)...)
+		messagesGroup.GET("/archive/messages", request.RequireScope(auth.ScopeArchiveRead), mailingEntryHandler.ListArchivedHandlerFunc)
+		messagesGroup.GET("/messages/:id", request.RequireScope(auth.ScopeMessagesRead), mailingEntryHandler.GetHandlerFunc)
+		messagesGroup.PATCH("/messages/:id", mutating(auth.ScopeMessagesWrite, mailingEntryHandler.UpdateHandlerFunc)...)
+		messagesGroup.DELETE("/messages", mutating(auth.ScopeMessagesWrite, mailingEntryHandler.PurgeHandlerFunc)...)
+		messagesGroup.DELETE("/messages/:id", mutating(auth.ScopeMessagesWrite, mailingEntryHandler.DeleteHandlerFunc)...)
+		messagesGroup.GET("/mailings/:id", request.RequireScope(auth.ScopeMessagesRead), mailingEntryHandler.GetMailingHandlerFunc)
+		messagesGroup.GET("/mailings/:id/events", request.RequireScope(auth.ScopeMessagesRead), mailingEntryHandler.EventsHandlerFunc)
+		messagesGroup.DELETE("/mailings/:id/entries", mutating(auth.ScopeMessagesWrite, mailingEntryHandler.DeleteMailingHandlerFunc)...)
+		sendGroup := apiGroup.Group("", sendLimitMiddleware)
+		sendGroup.POST("/messages/send", mutating(auth.ScopeMessagesSend, mailingEntryHandler.SendMailingIdHandlerFunc)...)
+
+		customersGroup := apiGroup.Group("", customersLimitMiddleware)
+		customersGroup.POST("/customers", mutating(auth.ScopeCustomersWrite, customerHandler.CreateHandlerFunc)...)
+		customersGroup.GET("/customers/:id", request.RequireScope(auth.ScopeCustomersRead), customerHandler.GetHandlerFunc)
+		customersGroup.DELETE("/customers/:id", mutating(auth.ScopeCustomersWrite, customerHandler.DeleteHandlerFunc)...)
+
+		webhooksGroup := apiGroup.Group("", webhooksLimitMiddleware)
+		webhooksGroup.GET("/webhooks", request.RequireScope(auth.ScopeWebhooksRead), webhookHandler.ListHandlerFunc)
+		webhooksGroup.POST("/webhooks", mutating(auth.ScopeWebhooksWrite, webhookHandler.CreateHandlerFunc)...)
+		webhooksGroup.DELETE("/webhooks/:id", mutating(auth.ScopeWebhooksWrite, webhookHandler.DeleteHandlerFunc)...)
+		webhooksGroup.GET("/webhooks/:id/deliveries", request.RequireScope(auth.ScopeWebhooksRead), webhookHandler.ListDeliveriesHandlerFunc)
+	}
+	registerApiRoutes(ginEngine.Group(apiV1Prefix, authenticationLimitMiddleware, authenticationMiddleware))
+
+	// Unversioned routes are deprecated aliases of v1 routes, kept until clients switch to versioned ones.
+	deprecationMiddleware := request.DeprecationMiddleware(unversionedApiPrefix, apiV1Prefix)
+	ginEngine.GET(unversionedApiPrefix+"/openapi.json", deprecationMiddleware, openApiHandlerFunc)
+	registerApiRoutes(ginEngine.Group(unversionedApiPrefix, deprecationMiddleware, authenticationLimitMiddleware, authenticationMiddleware))
 
 	return ginEngine
 }
 
-func (server *Server) listenAndServe() {
-	mdctx.Infof(nil, "Starting HTTP server on address %s", server.httpServer.Addr)
-	err := server.httpServer.ListenAndServe()
+func listenAndServe(httpServer *http.Server, name string) {
+	mdctx.Infof(nil, "Starting %s on address %s", name, httpServer.Addr)
+	err := httpServer.ListenAndServe()
 	if err != nil && err != http.ErrServerClosed {
-		mdctx.Errorf(nil, "HTTP server exited with error: %v", err)
+		mdctx.Errorf(nil, "%s exited with error: %v", name, err)
 	} else {
-		mdctx.Infof(nil, "HTTP server exited")
+		mdctx.Infof(nil, "%s exited", name)
 	}
 }
 
@@ -75,14 +184,19 @@ func (server *Server) shutdownOnContextCancellation(ctx shutdown.Context) {
 	defer ctx.Notify()
 
 	<-ctx.Done()
-	mdctx.Infof(nil, "Context canceled - shutting down HTTP server")
+	mdctx.Infof(nil, "Context canceled - shutting down HTTP servers")
 	serverShutdownCtx, cancel := context.WithTimeout(context.Background(), ctx.Timeout())
 	defer cancel()
 
-	err := server.httpServer.Shutdown(serverShutdownCtx)
+	shutdownServer(serverShutdownCtx, server.httpServer, "HTTP server")
+	shutdownServer(serverShutdownCtx, server.adminServer, "admin HTTP server")
+}
+
+func shutdownServer(ctx context.Context, httpServer *http.Server, name string) {
+	err := httpServer.Shutdown(ctx)
 	if err != nil {
-		mdctx.Errorf(nil, "Error shutting down HTTP server: %v", err)
+		mdctx.Errorf(nil, "Error shutting down %s: %v", name, err)
 	} else {
-		mdctx.Infof(nil, "HTTP server shutdown completed")
+		mdctx.Infof(nil, "%s shutdown completed", name)
 	}
 }
@@ -0,0 +1,417 @@
+package httpgin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/GeneralKenobi/mailman/internal/api"
+	"github.com/GeneralKenobi/mailman/internal/auth"
+	"github.com/GeneralKenobi/mailman/internal/auth/apikey"
+	"github.com/GeneralKenobi/mailman/internal/config"
+	"github.com/GeneralKenobi/mailman/internal/db/memory"
+	"github.com/GeneralKenobi/mailman/internal/service/mailingentry/progress"
+	"github.com/GeneralKenobi/mailman/pkg/api/apimodel"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMailingEventStream(t *testing.T) {
+	server := NewServer(memory.New(), emailerMock{}, auth.NewChain(), progress.NewBus(), nil)
+	testServer := httptest.NewUnstartedServer(server.Handler())
+	testServer.Config = server.httpServer
+	testServer.Start()
+	defer testServer.Close()
+
+	for _, email := range []string{"jan.kowalski@example.com", "anna.nowak@example.com"} {
+		post(t, testServer.URL+"/api/v1/messages",
+			`{"email":"`+email+`","title":"Interview","content":"simple text","mailing_id":2,"insert_time":"`+time.Now().Format(time.RFC3339)+`"}`)
+	}
+
+	response, err := http.Get(testServer.URL + "/api/v1/mailings/2/events")
+	if err != nil {
+		t.Fatalf("Error opening event stream: %v", err)
+	}
+	defer response.Body.Close()
+	if contentType := response.Header.Get("Content-Type"); contentType != "text/event-stream" {
+		t.Fatalf("Expected event stream but got %s", contentType)
+	}
+	events := readEvents(response)
+
+	expectEvent(t, events, "progress", `{"mailing_id":2,"sent_count":0,"failed_count":0,"bounced_count":0,"pending_count":2}`)
+	post(t, testServer.URL+"/api/v1/messages/send", `{"mailing_id":2}`)
+	expectEvent(t, events, apimodel.WebhookEventMailingEntrySent, `{"mailing_entry_id":1,"mailing_id":2,"customer_id":1}`)
+	expectEvent(t, events, apimodel.WebhookEventMailingEntrySent, `{"mailing_entry_id":2,"mailing_id":2,"customer_id":2}`)
+	expectEvent(t, events, apimodel.WebhookEventMailingCompleted, `{"mailing_id":2,"sent_count":2,"failed_count":0,"bounced_count":0}`)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err = server.httpServer.Shutdown(shutdownCtx)
+	if err != nil {
+		t.Fatalf("Expected the server to shut down without waiting for the stream but got %v", err)
+	}
+	if event, open := <-events; open {
+		t.Errorf("Expected the stream to be closed but got event %v", event)
+	}
+}
+
+func TestConditionalMailingEntryUpdate(t *testing.T) {
+	server := NewServer(memory.New(), emailerMock{}, auth.NewChain(), progress.NewBus(), nil)
+	testServer := httptest.NewServer(server.Handler())
+	defer testServer.Close()
+
+	post(t, testServer.URL+"/api/v1/messages",
+		`{"email":"jan.kowalski@example.com","title":"Interview","content":"simple text","mailing_id":2,"insert_time":"`+time.Now().Format(time.RFC3339)+`"}`)
+	entryUrl := testServer.URL + "/api/v1/messages/1"
+
+	tests := []struct {
+		title          string
+		ifMatch        string
+		expectedStatus int
+		expectedETag   string
+	}{
+		{title: "Should update the entry if its version matches", ifMatch: `"1"`, expectedStatus: http.StatusOK, expectedETag: `"2"`},
+		{title: "Should reject an outdated version", ifMatch: `"1"`, expectedStatus: http.StatusPreconditionFailed},
+		{title: "Should update the entry if one of the versions matches", ifMatch: `"1", "2"`, expectedStatus: http.StatusOK, expectedETag: `"3"`},
+		{title: "Should reject a weak entity tag", ifMatch: `W/"3"`, expectedStatus: http.StatusPreconditionFailed},
+		{title: "Should update any version with a wildcard", ifMatch: "*", expectedStatus: http.StatusOK, expectedETag: `"4"`},
+		{title: "Should update the entry unconditionally without If-Match", expectedStatus: http.StatusOK, expectedETag: `"5"`},
+	}
+	for _, test := range tests {
+		request, err := http.NewRequest(http.MethodPatch, entryUrl, strings.NewReader(`{"title":"`+test.title+`"}`))
+		if err != nil {
+			t.Fatalf("Error creating request: %v", err)
+		}
+		if test.ifMatch != "" {
+			request.Header.Set("If-Match", test.ifMatch)
+		}
+		response, err := http.DefaultClient.Do(request)
+		if err != nil {
+			t.Fatalf("Error calling %s: %v", entryUrl, err)
+		}
+		response.Body.Close()
+		if response.StatusCode != test.expectedStatus || response.Header.Get("ETag") != test.expectedETag {
+			t.Errorf("%s: expected status %d and ETag %s but got %d and %s",
+				test.title, test.expectedStatus, test.expectedETag, response.StatusCode, response.Header.Get("ETag"))
+		}
+	}
+
+	response, err := http.Get(entryUrl)
+	if err != nil {
+		t.Fatalf("Error calling %s: %v", entryUrl, err)
+	}
+	defer response.Body.Close()
+	var details apimodel.MailingEntryDetails
+	if err = json.NewDecoder(response.Body).Decode(&details); err != nil {
+		t.Fatalf("Error decoding mailing entry: %v", err)
+	}
+	if details.Version != 5 || details.Title != tests[len(tests)-1].title || response.Header.Get("ETag") != `"5"` {
+		t.Errorf("Expected the last update at version 5 but got %+v with ETag %s", details, response.Header.Get("ETag"))
+	}
+}
+
+func TestDeprecatedRouteValidationError(t *testing.T) {
+	server := NewServer(memory.New(), emailerMock{}, auth.NewChain(), progress.NewBus(), nil)
+	testServer := httptest.NewServer(server.Handler())
+	defer testServer.Close()
+
+	body := `{"email":"not an email","title":"Interview","content":"simple text","mailing_id":2,"insert_time":"` + time.Now().Format(time.RFC3339) + `"}`
+	request, err := http.NewRequest(http.MethodPost, testServer.URL+"/api/messages", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("Error creating request: %v", err)
+	}
+	request.Header.Set("Accept-Language", "fr-CA, en;q=0.5")
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		t.Fatalf("Error calling %s: %v", request.URL, err)
+	}
+	defer response.Body.Close()
+
+	if response.Header.Get("Deprecation") != "true" || response.Header.Get("Link") != `</api/v1/messages>; rel="successor-version"` {
+		t.Errorf("Expected deprecation headers but got Deprecation %q and Link %q", response.Header.Get("Deprecation"), response.Header.Get("Link"))
+	}
+	if response.StatusCode != http.StatusBadRequest {
+		t.Fatalf("Expected status 400 but got %d", response.StatusCode)
+	}
+	var validationErr apimodel.ValidationError
+	if err = json.NewDecoder(response.Body).Decode(&validationErr); err != nil {
+		t.Fatalf("Error decoding validation error: %v", err)
+	}
+	expectedField := apimodel.FieldError{Path: "email", Rule: "email", Message: "email doit être une adresse email valide", RejectedValue: "not an email"}
+	if len(validationErr.Fields) != 1 || validationErr.Fields[0] != expectedField {
+		t.Errorf("Expected field error %+v but got %+v", expectedField, validationErr.Fields)
+	}
+}
+
+func TestIdempotencyKeys(t *testing.T) {
+	// Both keys belong to the same client, so they share idempotency keys
+	authenticator, err := apikey.New([]config.ApiKey{
+		{Name: "service-a", Key: "writer-key", Scopes: []string{string(auth.ScopeMessagesWrite), string(auth.ScopeMessagesRead)}},
+		{Name: "service-a", Key: "reader-key", Scopes: []string{string(auth.ScopeMessagesRead)}},
+	})
+	if err != nil {
+		t.Fatalf("Error creating authenticator: %v", err)
+	}
+	server := NewServer(memory.New(), emailerMock{}, auth.NewChain(authenticator), progress.NewBus(), nil)
+	testServer := httptest.NewServer(server.Handler())
+	defer testServer.Close()
+
+	createBody := func(email string) string {
+		return `{"email":"` + email + `","title":"Interview","content":"simple text","mailing_id":2,"insert_time":"` +
+			time.Now().Format(time.RFC3339) + `"}`
+	}
+	tests := []struct {
+		title            string
+		method           string
+		path             string
+		apiKey           string
+		idempotencyKey   string
+		body             string
+		expectedStatus   int
+		expectedReplayed bool
+	}{
+		{
+			title: "Should process a new request", method: http.MethodPost, path: "/api/v1/messages", apiKey: "writer-key",
+			idempotencyKey: "create", body: createBody("jan.kowalski@example.com"), expectedStatus: http.StatusOK,
+		},
+		{
+			title: "Should replay the response to a retried request", method: http.MethodPost, path: "/api/v1/messages", apiKey: "writer-key",
+			idempotencyKey: "create", body: createBody("jan.kowalski@example.com"), expectedStatus: http.StatusOK, expectedReplayed: true,
+		},
+		{
+			title: "Should check scopes before replaying a response", method: http.MethodPost, path: "/api/v1/messages", apiKey: "reader-key",
+			idempotencyKey: "create", body: createBody("jan.kowalski@example.com"), expectedStatus: http.StatusForbidden,
+		},
+		{
+			title: "Should not record a forbidden response", method: http.MethodPost, path: "/api/v1/messages", apiKey: "reader-key",
+			idempotencyKey: "forbidden", body: createBody("anna.nowak@example.com"), expectedStatus: http.StatusForbidden,
+		},
+		{
+			title: "Should process a request retried after a forbidden response", method: http.MethodPost, path: "/api/v1/messages",
+			apiKey: "writer-key", idempotencyKey: "forbidden", body: createBody("anna.nowak@example.com"), expectedStatus: http.StatusOK,
+		},
+		{
+			title: "Should ignore the key of a GET request", method: http.MethodGet, path: "/api/v1/messages/1", apiKey: "reader-key",
+			idempotencyKey: "get", expectedStatus: http.StatusOK,
+		},
+		{
+			title: "Should ignore the key of a retried GET request", method: http.MethodGet, path: "/api/v1/messages/1", apiKey: "reader-key",
+			idempotencyKey: "get", expectedStatus: http.StatusOK,
+		},
+		{
+			title: "Should reject a body that's too large", method: http.MethodPost, path: "/api/v1/messages", apiKey: "writer-key",
+			idempotencyKey: "large", body: strings.Repeat(" ", tooLargeBodySize), expectedStatus: http.StatusBadRequest,
+		},
+	}
+	for _, test := range tests {
+		request, err := http.NewRequest(test.method, testServer.URL+test.path, strings.NewReader(test.body))
+		if err != nil {
+			t.Fatalf("Error creating request: %v", err)
+		}
+		request.Header.Set(apikey.Header, test.apiKey)
+		request.Header.Set("Idempotency-Key", test.idempotencyKey)
+		response, err := http.DefaultClient.Do(request)
+		if err != nil {
+			t.Fatalf("Error calling %s: %v", request.URL, err)
+		}
+		response.Body.Close()
+
+		replayed := response.Header.Get("Idempotent-Replayed") == "true"
+		if response.StatusCode != test.expectedStatus || replayed != test.expectedReplayed {
+			t.Errorf("%s: expected status %d and replayed %v but got %d and %v",
+				test.title, test.expectedStatus, test.expectedReplayed, response.StatusCode, replayed)
+		}
+	}
+}
+
+// tooLargeBodySize exceeds the maximum size of bodies read to fingerprint requests with idempotency keys.
+const tooLargeBodySize = 10<<20 + 1
+
+type serverSentEvent struct {
+	name string
+	data string
+}
+
+// readEvents parses server-sent events from the response in the background. The channel is closed when the response ends.
+func TestFailedAuthenticationIsRateLimited(t *testing.T) {
+	authenticator, err := apikey.New([]config.ApiKey{
+		{Name: "service-a", Key: "valid-key", Scopes: []string{string(auth.ScopeMessagesRead)}},
+	})
+	if err != nil {
+		t.Fatalf("Error creating authenticator: %v", err)
+	}
+	rateLimiters := api.NewRateLimiters(map[string]config.RateLimitGroup{
+		api.RateLimitGroupAuthentication: {RequestsPerSecond: 0.001, Burst: 2},
+	})
+	server := NewServer(memory.New(), emailerMock{}, auth.NewChain(authenticator), progress.NewBus(), rateLimiters)
+	testServer := httptest.NewServer(server.Handler())
+	defer testServer.Close()
+
+	// Versions share the limiter
+	for i, expectedStatus := range []int{http.StatusUnauthorized, http.StatusUnauthorized, http.StatusTooManyRequests} {
+		path := "/api/v1/messages"
+		if i%2 == 1 {
+			path = "/api/messages"
+		}
+		request, err := http.NewRequest(http.MethodGet, testServer.URL+path, nil)
+		if err != nil {
+			t.Fatalf("Error creating request: %v", err)
+		}
+		request.Header.Set(apikey.Header, "guessed-key")
+		response, err := http.DefaultClient.Do(request)
+		if err != nil {
+			t.Fatalf("Error sending request: %v", err)
+		}
+		response.Body.Close()
+		if response.StatusCode != expectedStatus {
+			t.Errorf("Expected status %d of request %d but got %d", expectedStatus, i, response.StatusCode)
+		}
+	}
+}
+
+func readEvents(response *http.Response) <-chan serverSentEvent {
+	events := make(chan serverSentEvent, 10)
+	go func() {
+		defer close(events)
+		var event serverSentEvent
+		scanner := bufio.NewScanner(response.Body)
+		for scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case strings.HasPrefix(line, "event:"):
+				event.name = line[len("event:"):]
+			case strings.HasPrefix(line, "data:"):
+				event.data = line[len("data:"):]
+			case line == "":
+				events <- event
+				event = serverSentEvent{}
+			}
+		}
+	}()
+	return events
+}
+
+func expectEvent(t *testing.T, events <-chan serverSentEvent, name, data string) {
+	t.Helper()
+	select {
+	case event := <-events:
+		if event.name != name || !jsonEqual(event.data, data) {
+			t.Errorf("Expected event %s with %s but got %s with %s", name, data, event.name, event.data)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Timed out waiting for event %s", name)
+	}
+}
+
+func jsonEqual(actual, expected string) bool {
+	var actualValue, expectedValue any
+	if json.Unmarshal([]byte(actual), &actualValue) != nil || json.Unmarshal([]byte(expected), &expectedValue) != nil {
+		return false
+	}
+	actualJson, _ := json.Marshal(actualValue)
+	expectedJson, _ := json.Marshal(expectedValue)
+	return string(actualJson) == string(expectedJson)
+}
+
+func post(t *testing.T, url, body string) {
+	t.Helper()
+	response, err := http.Post(url, "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("Error calling %s: %v", url, err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200 from %s but got %d", url, response.StatusCode)
+	}
+}
+
+func TestReadinessProbe(t *testing.T) {
+	tests := map[string]struct {
+		pingErr        error
+		expectedStatus int
+		expectedHealth string
+	}{
+		"Should be ready when the email transport is reachable": {
+			expectedStatus: http.StatusOK,
+			expectedHealth: "up",
+		},
+		"Should not be ready when the email transport is unreachable": {
+			pingErr:        errors.New("connection refused"),
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedHealth: "down",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			server := NewServer(memory.New(), pingingEmailerMock{pingErr: test.pingErr}, auth.NewChain(), progress.NewBus(), nil)
+			testServer := httptest.NewServer(server.Handler())
+			defer testServer.Close()
+
+			response, err := http.Get(testServer.URL + "/health/ready")
+			if err != nil {
+				t.Fatalf("Error calling readiness probe: %v", err)
+			}
+			defer response.Body.Close()
+
+			if response.StatusCode != test.expectedStatus {
+				t.Errorf("Expected status %d but got %d", test.expectedStatus, response.StatusCode)
+			}
+			var health apimodel.Health
+			if err = json.NewDecoder(response.Body).Decode(&health); err != nil {
+				t.Fatalf("Error decoding health: %v", err)
+			}
+			if health.Status != test.expectedHealth || health.Dependencies["email"].Status != test.expectedHealth {
+				t.Errorf("Expected status %s of the application and email transport but got %+v", test.expectedHealth, health)
+			}
+		})
+	}
+}
+
+type emailerMock struct{}
+
+func (emailer emailerMock) Send(context.Context, string, string, string) error {
+	return nil
+}
+
+type pingingEmailerMock struct {
+	emailerMock
+	pingErr error
+}
+
+func (emailer pingingEmailerMock) Ping(context.Context) error {
+	return emailer.pingErr
+}
+
+func TestMetricsArePublishedOnlyByAdminServer(t *testing.T) {
+	server := NewServer(memory.New(), pingingEmailerMock{}, auth.NewChain(), progress.NewBus(), nil)
+	apiServer := httptest.NewServer(server.Handler())
+	defer apiServer.Close()
+	adminServer := httptest.NewServer(server.AdminHandler())
+	defer adminServer.Close()
+
+	response, err := http.Get(apiServer.URL + "/debug/vars")
+	if err != nil {
+		t.Fatalf("Error getting metrics from the API server: %v", err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected status %d from the API server but got %d", http.StatusNotFound, response.StatusCode)
+	}
+
+	response, err = http.Get(adminServer.URL + "/debug/vars")
+	if err != nil {
+		t.Fatalf("Error getting metrics from the admin server: %v", err)
+	}
+	defer response.Body.Close()
+	var metrics map[string]any
+	if err = json.NewDecoder(response.Body).Decode(&metrics); err != nil {
+		t.Fatalf("Error decoding metrics: %v", err)
+	}
+	if response.StatusCode != http.StatusOK || metrics["memstats"] == nil {
+		t.Errorf("Expected status %d with metrics from the admin server but got %d with %v", http.StatusOK, response.StatusCode, metrics)
+	}
+}
@@ -2,11 +2,11 @@ package wrapper
 
 import (
 	"github.com/GeneralKenobi/mailman/internal/api"
+	"github.com/GeneralKenobi/mailman/internal/api/validation"
 	"github.com/GeneralKenobi/mailman/pkg/util"
 	"github.com/gin-gonic/gin"
-	"github.com/go-playground/validator/v10"
 	"strconv"
-	"strings"
+	"time"
 )
 
 // WithBoundRequestBody binds JSON request body to an instance of T and validates it. If both operations were successful calls the
@@ -26,7 +26,7 @@ func WithBoundRequestBodyRetV[T, V any](request *gin.Context, todo func(requestB
 	if err != nil {
 		return util.ZeroValue[V](), api.StatusBadInput.WithMessageAndCause(err, "malformed request body")
 	}
-	err = validateRequestBody(requestBody)
+	err = validateRequestBody(request, requestBody)
 	if err != nil {
 		return util.ZeroValue[V](), err
 	}
@@ -34,28 +34,12 @@ func WithBoundRequestBodyRetV[T, V any](request *gin.Context, todo func(requestB
 	return todo(requestBody)
 }
 
-// validateRequestBody validates a request body. If there were validation errors it converts them into a api.StatusBadInput error.
-func validateRequestBody(toValidate any) error {
-	err := validate.Struct(toValidate)
-	if err == nil {
-		return nil
-	}
-
-	validationErrs, ok := err.(validator.ValidationErrors)
-	if !ok {
-		return api.StatusBadInput.WithMessageAndCause(err, "invalid request body")
-	}
-
-	validationMessages := make([]string, len(validationErrs))
-	for i, validationErr := range validationErrs {
-		validationMessages[i] = validationErr.Namespace() + ": " + validationErr.Tag()
-	}
-	return api.StatusBadInput.WithMessageAndCause(err, "invalid request body: %s", strings.Join(validationMessages, ", "))
+// validateRequestBody validates a request body. If there were validation errors it converts them into a api.StatusBadInput error with
+// messages in the language requested in the Accept-Language header.
+func validateRequestBody(request *gin.Context, toValidate any) error {
+	return validation.Validate(toValidate, request.GetHeader("Accept-Language"), "request body")
 }
 
-// Use a single instance of Validate, it caches struct info.
-var validate = validator.New()
-
 // WithRequiredIntPathParam finds parameter paramName in the path and tries to convert it to an integer. If it succeeds then it calls the
 // given function with the converted value.
 // For example, paramName for path "/api/customer/:id" is "id".
@@ -98,3 +82,45 @@ func WithRequiredPathParamRetV[V any](request *gin.Context, paramName string, to
 
 	return todo(param)
 }
+
+// OptionalIntQueryParam finds parameter paramName in the query and tries to convert it to an integer. Returns nil if the parameter isn't
+// given. For example, paramName for URL "/api/messages?mailing_id=2" is "mailing_id".
+func OptionalIntQueryParam(request *gin.Context, paramName string) (*int, error) {
+	param, found := request.GetQuery(paramName)
+	if !found {
+		return nil, nil
+	}
+	paramAsInt, err := strconv.Atoi(param)
+	if err != nil {
+		return nil, api.StatusBadInput.WithMessage("query parameter %s has to be an integer", paramName)
+	}
+	return &paramAsInt, nil
+}
+
+// OptionalTimeQueryParam finds parameter paramName in the query and tries to parse it as an RFC 3339 timestamp. Returns nil if the
+// parameter isn't given. For example, paramName for URL "/api/messages?inserted_before=2022-03-30T15:42:38Z" is "inserted_before".
+func OptionalTimeQueryParam(request *gin.Context, paramName string) (*time.Time, error) {
+	param, found := request.GetQuery(paramName)
+	if !found {
+		return nil, nil
+	}
+	paramAsTime, err := time.Parse(time.RFC3339, param)
+	if err != nil {
+		return nil, api.StatusBadInput.WithMessage("query parameter %s has to be an RFC 3339 timestamp", paramName)
+	}
+	return &paramAsTime, nil
+}
+
+// BoolQueryParam finds parameter paramName in the query and tries to convert it to a boolean. Returns false if the parameter isn't
+// given. For example, paramName for URL "/api/messages?dry_run=true" is "dry_run".
+func BoolQueryParam(request *gin.Context, paramName string) (bool, error) {
+	param, found := request.GetQuery(paramName)
+	if !found {
+		return false, nil
+	}
+	paramAsBool, err := strconv.ParseBool(param)
+	if err != nil {
+		return false, api.StatusBadInput.WithMessage("query parameter %s has to be a boolean", paramName)
+	}
+	return paramAsBool, nil
+}
@@ -0,0 +1,461 @@
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Operation describes an API route. It's the input for generating the document.
+type Operation struct {
+	Method        string  // HTTP method, e.g. POST
+	Path          string  // Path in gin's format, e.g. /api/messages/:id
+	Id            string  // Unique operation ID, e.g. createMailingEntry
+	Summary       string  // Short description of the operation
+	Tag           string  // Groups operations in documentation
+	Scope         string  // Scope required to call the operation, empty if it doesn't require authentication
+	PathParams    []Param // Parameters in Path, each of them has to be listed
+	QueryParams   []Param // Query parameters
+	HeaderParams  []Param // Request headers
+	RequestBody   any     // Zero value of the JSON request body type, nil if the operation doesn't accept a body
+	Response      any     // Zero value of the JSON response body type, nil if the response has no body
+	ResponseType  string  // Media type of the response, application/json if empty (e.g. text/event-stream for a stream of Response)
+	SuccessStatus int     // Status of a successful response, HTTP200 if it's 0
+	ErrorStatuses []int   // Statuses of error responses specific to the operation, authentication errors are added automatically
+	Deprecated    bool    // Marks the operation as deprecated
+}
+
+// Param describes a path or query parameter.
+type Param struct {
+	Name        string
+	Description string
+	Type        any  // Zero value of the parameter's type, e.g. 0 for integers
+	Required    bool // Path parameters are always required
+}
+
+// ErrorResponses are zero values of the types of error response bodies.
+type ErrorResponses struct {
+	Default    any // Body of error responses without a more specific type
+	BadRequest any // Body of 400 Bad Request responses, Default is used if it's nil
+}
+
+// Security scheme names used in the generated document.
+const (
+	SecuritySchemeApiKey = "apiKey"
+	SecuritySchemeBearer = "bearerAuth"
+)
+
+// Generate creates an OpenAPI 3 document describing the operations. Schemas of request and response bodies are derived from their Go
+// types: properties are named after JSON tags and validator tags (validate:"...") are translated into schema constraints.
+func Generate(info Info, errorResponses ErrorResponses, operations []Operation) (*Document, error) {
+	generator := schemaGenerator{schemas: make(map[string]*Schema)}
+	errorSchema, err := generator.schemaFor(reflect.TypeOf(errorResponses.Default))
+	if err != nil {
+		return nil, fmt.Errorf("error response: %w", err)
+	}
+	errorSchemas := map[int]*Schema{}
+	if errorResponses.BadRequest != nil {
+		if errorSchemas[http.StatusBadRequest], err = generator.schemaFor(reflect.TypeOf(errorResponses.BadRequest)); err != nil {
+			return nil, fmt.Errorf("bad request error response: %w", err)
+		}
+	}
+
+	document := Document{
+		OpenApi: "3.0.3",
+		Info:    info,
+		Paths:   make(map[string]*PathItem),
+		Components: Components{
+			Schemas: generator.schemas,
+			SecuritySchemes: map[string]SecurityScheme{
+				SecuritySchemeApiKey: {Type: "apiKey", Name: "X-API-Key", In: "header"},
+				SecuritySchemeBearer: {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+			},
+		},
+	}
+
+	operationIds := make(map[string]bool)
+	for _, operation := range operations {
+		if operationIds[operation.Id] {
+			return nil, fmt.Errorf("duplicate operation ID %q", operation.Id)
+		}
+		operationIds[operation.Id] = true
+
+		path, err := convertPath(operation)
+		if err != nil {
+			return nil, err
+		}
+		operationObject, err := generator.operationObject(operation, errorSchema, errorSchemas)
+		if err != nil {
+			return nil, fmt.Errorf("operation %s: %w", operation.Id, err)
+		}
+
+		pathItem, found := document.Paths[path]
+		if !found {
+			pathItem = &PathItem{}
+			document.Paths[path] = pathItem
+		}
+		method := strings.ToLower(operation.Method)
+		if _, duplicate := (*pathItem)[method]; duplicate {
+			return nil, fmt.Errorf("duplicate operation %s %s", operation.Method, operation.Path)
+		}
+		(*pathItem)[method] = operationObject
+	}
+
+	return &document, nil
+}
+
+// convertPath converts gin's path parameters (:id) into OpenAPI's ({id}) and checks that each of them is documented.
+func convertPath(operation Operation) (string, error) {
+	documentedParams := make(map[string]bool)
+	for _, param := range operation.PathParams {
+		documentedParams[param.Name] = true
+	}
+
+	segments := strings.Split(operation.Path, "/")
+	for i, segment := range segments {
+		if !strings.HasPrefix(segment, ":") {
+			continue
+		}
+		name := segment[1:]
+		if !documentedParams[name] {
+			return "", fmt.Errorf("operation %s: path parameter %q isn't documented", operation.Id, name)
+		}
+		delete(documentedParams, name)
+		segments[i] = "{" + name + "}"
+	}
+	for name := range documentedParams {
+		return "", fmt.Errorf("operation %s: documented path parameter %q isn't in the path", operation.Id, name)
+	}
+	return strings.Join(segments, "/"), nil
+}
+
+// operationObject describes the operation. Error responses have errorSchema, unless errorSchemas has a schema for their status.
+func (generator *schemaGenerator) operationObject(operation Operation, errorSchema *Schema, errorSchemas map[int]*Schema) (*OperationObject, error) {
+	operationObject := OperationObject{
+		OperationId: operation.Id,
+		Summary:     operation.Summary,
+		Responses:   make(map[string]*ResponseObject),
+		Deprecated:  operation.Deprecated,
+	}
+	if operation.Tag != "" {
+		operationObject.Tags = []string{operation.Tag}
+	}
+
+	for _, param := range operation.PathParams {
+		param.Required = true
+		parameterObject, err := generator.parameterObject(param, "path")
+		if err != nil {
+			return nil, err
+		}
+		operationObject.Parameters = append(operationObject.Parameters, parameterObject)
+	}
+	for _, param := range operation.QueryParams {
+		parameterObject, err := generator.parameterObject(param, "query")
+		if err != nil {
+			return nil, err
+		}
+		operationObject.Parameters = append(operationObject.Parameters, parameterObject)
+	}
+	for _, param := range operation.HeaderParams {
+		parameterObject, err := generator.parameterObject(param, "header")
+		if err != nil {
+			return nil, err
+		}
+		operationObject.Parameters = append(operationObject.Parameters, parameterObject)
+	}
+
+	if operation.RequestBody != nil {
+		schema, err := generator.schemaFor(reflect.TypeOf(operation.RequestBody))
+		if err != nil {
+			return nil, fmt.Errorf("request body: %w", err)
+		}
+		operationObject.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]MediaType{"application/json": {Schema: schema}},
+		}
+	}
+
+	successStatus := operation.SuccessStatus
+	if successStatus == 0 {
+		successStatus = http.StatusOK
+	}
+	successResponse := ResponseObject{Description: http.StatusText(successStatus)}
+	if operation.Response != nil {
+		schema, err := generator.schemaFor(reflect.TypeOf(operation.Response))
+		if err != nil {
+			return nil, fmt.Errorf("response: %w", err)
+		}
+		responseType := operation.ResponseType
+		if responseType == "" {
+			responseType = "application/json"
+		}
+		successResponse.Content = map[string]MediaType{responseType: {Schema: schema}}
+	}
+	operationObject.Responses[strconv.Itoa(successStatus)] = &successResponse
+
+	errorStatuses := append([]int{http.StatusInternalServerError}, operation.ErrorStatuses...)
+	if operation.Scope != "" {
+		operationObject.Description = fmt.Sprintf("Requires scope `%s`.", operation.Scope)
+		operationObject.Security = []map[string][]string{{SecuritySchemeApiKey: {}}, {SecuritySchemeBearer: {}}}
+		errorStatuses = append(errorStatuses, http.StatusUnauthorized, http.StatusForbidden)
+	}
+	for _, status := range errorStatuses {
+		statusErrorSchema, found := errorSchemas[status]
+		if !found {
+			statusErrorSchema = errorSchema
+		}
+		errorResponse := ResponseObject{
+			Description: http.StatusText(status),
+			Content:     map[string]MediaType{"application/json": {Schema: statusErrorSchema}},
+		}
+		if status == http.StatusTooManyRequests {
+			errorResponse.Headers = map[string]HeaderObject{
+				"Retry-After": {Description: "Seconds after which the request can be retried", Schema: &Schema{Type: "integer"}},
+			}
+		}
+		operationObject.Responses[strconv.Itoa(status)] = &errorResponse
+	}
+
+	return &operationObject, nil
+}
+
+func (generator *schemaGenerator) parameterObject(param Param, in string) (ParameterObject, error) {
+	schema, err := generator.schemaFor(reflect.TypeOf(param.Type))
+	if err != nil {
+		return ParameterObject{}, fmt.Errorf("parameter %s: %w", param.Name, err)
+	}
+	return ParameterObject{
+		Name:        param.Name,
+		In:          in,
+		Description: param.Description,
+		Required:    param.Required,
+		Schema:      schema,
+	}, nil
+}
+
+// schemaGenerator converts Go types into schemas. Named struct types are added to schemas and referenced.
+type schemaGenerator struct {
+	schemas map[string]*Schema
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func (generator *schemaGenerator) schemaFor(typ reflect.Type) (*Schema, error) {
+	if typ == nil {
+		return &Schema{}, nil
+	}
+	if typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	if typ == timeType {
+		return &Schema{Type: "string", Format: "date-time"}, nil
+	}
+
+	switch typ.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Uint, reflect.Uint8, reflect.Uint16:
+		return &Schema{Type: "integer"}, nil
+	case reflect.Int32, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}, nil
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}, nil
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}, nil
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}, nil
+	case reflect.String:
+		return &Schema{Type: "string"}, nil
+	case reflect.Interface:
+		return &Schema{}, nil
+	case reflect.Slice, reflect.Array:
+		if typ.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}, nil
+		}
+		items, err := generator.schemaFor(typ.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{Type: "array", Items: items}, nil
+	case reflect.Map:
+		if typ.Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("unsupported map key type %v", typ.Key())
+		}
+		values, err := generator.schemaFor(typ.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{Type: "object", AdditionalProperties: values}, nil
+	case reflect.Struct:
+		return generator.structSchemaRef(typ)
+	default:
+		return nil, fmt.Errorf("unsupported type %v", typ)
+	}
+}
+
+// structSchemaRef adds the struct's schema to components (unless it's already there) and returns a reference to it.
+func (generator *schemaGenerator) structSchemaRef(typ reflect.Type) (*Schema, error) {
+	name := typ.Name()
+	if name == "" {
+		return nil, fmt.Errorf("anonymous structs aren't supported")
+	}
+	ref := &Schema{Ref: "#/components/schemas/" + name}
+	if _, found := generator.schemas[name]; found {
+		return ref, nil
+	}
+
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	// Register before generating properties to support recursive types.
+	generator.schemas[name] = schema
+	if err := generator.addStructProperties(schema, typ); err != nil {
+		delete(generator.schemas, name)
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	sort.Strings(schema.Required)
+	return ref, nil
+}
+
+func (generator *schemaGenerator) addStructProperties(schema *Schema, typ reflect.Type) error {
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		jsonName, jsonOptions, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if jsonName == "-" {
+			continue
+		}
+
+		// Fields of embedded structs without a JSON name are promoted to the parent, just like encoding/json does.
+		if field.Anonymous && jsonName == "" && field.Type.Kind() == reflect.Struct {
+			if err := generator.addStructProperties(schema, field.Type); err != nil {
+				return err
+			}
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		if jsonName == "" {
+			jsonName = field.Name
+		}
+		propertySchema, err := generator.schemaFor(field.Type)
+		if err != nil {
+			return fmt.Errorf("field %s: %w", field.Name, err)
+		}
+		required, err := applyValidationTags(propertySchema, field.Type, field.Tag.Get("validate"))
+		if err != nil {
+			return fmt.Errorf("field %s: %w", field.Name, err)
+		}
+		if jsonOptions == "string" {
+			propertySchema = &Schema{Type: "string"}
+		}
+
+		schema.Properties[jsonName] = propertySchema
+		if required {
+			schema.Required = append(schema.Required, jsonName)
+		}
+	}
+	return nil
+}
+
+// applyValidationTags translates validator rules into schema constraints. Rules without an equivalent are skipped.
+// Returns true if the value is required.
+func applyValidationTags(schema *Schema, typ reflect.Type, validateTag string) (required bool, err error) {
+	if typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+
+	for _, rule := range strings.Split(validateTag, ",") {
+		name, value, _ := strings.Cut(rule, "=")
+		switch name {
+		case "dive":
+			// Following rules apply to elements of the collection.
+			return required, nil
+		case "required":
+			required = true
+		}
+		if schema.Ref != "" {
+			// Constraints next to a reference are ignored by OpenAPI 3.0 tools.
+			continue
+		}
+
+		switch name {
+		case "email":
+			schema.Format = "email"
+		case "url", "uri":
+			schema.Format = "uri"
+		case "uuid", "uuid4":
+			schema.Format = "uuid"
+		case "alphanum":
+			schema.Pattern = "^[a-zA-Z0-9]*$"
+		case "oneof":
+			for _, option := range strings.Fields(value) {
+				schema.Enum = append(schema.Enum, option)
+			}
+		case "min", "max", "len", "gt", "gte", "lt", "lte":
+			if err = applyLimit(schema, typ, name, value); err != nil {
+				return false, err
+			}
+		}
+	}
+	return required, nil
+}
+
+// applyLimit translates a size rule - for strings it limits the length, for collections the number of items and for numbers the value.
+func applyLimit(schema *Schema, typ reflect.Type, rule, value string) error {
+	limit, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return fmt.Errorf("invalid %s value %q: %w", rule, value, err)
+	}
+	intLimit := int(limit)
+
+	switch typ.Kind() {
+	case reflect.String:
+		switch rule {
+		case "min", "gte":
+			schema.MinLength = &intLimit
+		case "gt":
+			intLimit++
+			schema.MinLength = &intLimit
+		case "max", "lte":
+			schema.MaxLength = &intLimit
+		case "lt":
+			intLimit--
+			schema.MaxLength = &intLimit
+		case "len":
+			schema.MinLength, schema.MaxLength = &intLimit, &intLimit
+		}
+	case reflect.Slice, reflect.Array, reflect.Map:
+		switch rule {
+		case "min", "gte":
+			schema.MinItems = &intLimit
+		case "gt":
+			intLimit++
+			schema.MinItems = &intLimit
+		case "max", "lte":
+			schema.MaxItems = &intLimit
+		case "lt":
+			intLimit--
+			schema.MaxItems = &intLimit
+		case "len":
+			schema.MinItems, schema.MaxItems = &intLimit, &intLimit
+		}
+	default:
+		switch rule {
+		case "min", "gte":
+			schema.Minimum = &limit
+		case "gt":
+			schema.Minimum, schema.ExclusiveMinimum = &limit, true
+		case "max", "lte":
+			schema.Maximum = &limit
+		case "lt":
+			schema.Maximum, schema.ExclusiveMaximum = &limit, true
+		case "len":
+			schema.Minimum, schema.Maximum = &limit, &limit
+		}
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package openapi
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+type testRequest struct {
+	Email     string    `json:"email" validate:"required,email,max=64"`
+	Kind      string    `json:"kind" validate:"omitempty,oneof=a b"`
+	Count     int       `json:"count" validate:"gte=1,lt=10"`
+	Tags      []string  `json:"tags" validate:"max=3,dive,min=1"`
+	Time      time.Time `json:"time"`
+	Ignored   string    `json:"-"`
+	unexposed string
+	testEmbedded
+}
+
+type testEmbedded struct {
+	Id int `json:"id,string" validate:"required"`
+}
+
+type testError struct {
+	Message string `json:"message"`
+}
+
+func TestGenerateSchema(t *testing.T) {
+	document, err := Generate(Info{Title: "test", Version: "1"}, ErrorResponses{Default: testError{}}, []Operation{
+		{Method: "POST", Path: "/items", Id: "createItem", RequestBody: testRequest{}},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := `{"type":"object","properties":{` +
+		`"count":{"type":"integer","minimum":1,"maximum":10,"exclusiveMaximum":true},` +
+		`"email":{"type":"string","format":"email","maxLength":64},` +
+		`"id":{"type":"string"},` +
+		`"kind":{"type":"string","enum":["a","b"]},` +
+		`"tags":{"type":"array","items":{"type":"string"},"maxItems":3},` +
+		`"time":{"type":"string","format":"date-time"}},` +
+		`"required":["email","id"]}`
+	actual, _ := json.Marshal(document.Components.Schemas["testRequest"])
+	if string(actual) != expected {
+		t.Errorf("Expected schema\n%s\nbut got\n%s", expected, actual)
+	}
+}
+
+func TestGenerateOperation(t *testing.T) {
+	tests := map[string]struct {
+		operation Operation
+		expectErr bool
+		path      string
+		responses []string
+	}{
+		"Should convert path parameters": {
+			operation: Operation{Method: "GET", Path: "/items/:id", Id: "getItem", PathParams: []Param{{Name: "id", Type: 0}}},
+			path:      "/items/{id}",
+			responses: []string{"200", "500"},
+		},
+		"Should add authentication responses to operation with scope": {
+			operation: Operation{Method: "DELETE", Path: "/items", Id: "deleteItems", Scope: "items:write", SuccessStatus: 204,
+				ErrorStatuses: []int{429}},
+			path:      "/items",
+			responses: []string{"204", "401", "403", "429", "500"},
+		},
+		"Should return error if path parameter isn't documented": {
+			operation: Operation{Method: "GET", Path: "/items/:id", Id: "getItem"},
+			expectErr: true,
+		},
+		"Should return error if documented path parameter isn't in path": {
+			operation: Operation{Method: "GET", Path: "/items", Id: "getItem", PathParams: []Param{{Name: "id", Type: 0}}},
+			expectErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			document, err := Generate(Info{Title: "test", Version: "1"}, ErrorResponses{Default: testError{}}, []Operation{test.operation})
+			if test.expectErr {
+				if err == nil {
+					t.Errorf("Expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			pathItem, found := document.Paths[test.path]
+			if !found {
+				t.Fatalf("Expected path %s in %v", test.path, document.Paths)
+			}
+			operationObject := (*pathItem)[map[string]string{"GET": "get", "DELETE": "delete"}[test.operation.Method]]
+			if operationObject == nil {
+				t.Fatalf("Expected operation %s", test.operation.Method)
+			}
+			if len(operationObject.Responses) != len(test.responses) {
+				t.Errorf("Expected responses %v, got %d responses", test.responses, len(operationObject.Responses))
+			}
+			for _, status := range test.responses {
+				if _, found := operationObject.Responses[status]; !found {
+					t.Errorf("Expected response %s", status)
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateShouldReturnErrorForDuplicateOperationId(t *testing.T) {
+	_, err := Generate(Info{Title: "test", Version: "1"}, ErrorResponses{Default: testError{}}, []Operation{
+		{Method: "GET", Path: "/a", Id: "same"},
+		{Method: "GET", Path: "/b", Id: "same"},
+	})
+	if err == nil {
+		t.Errorf("Expected an error")
+	}
+}
@@ -0,0 +1,42 @@
+package api
+
+import (
+	"github.com/GeneralKenobi/mailman/internal/config"
+	"github.com/GeneralKenobi/mailman/pkg/mdctx"
+	"github.com/GeneralKenobi/mailman/pkg/ratelimit"
+	"time"
+)
+
+// Rate limit groups of requests. Every request is limited with RateLimitGroupAuthentication before it's authenticated and then with the
+// group of its route or method.
+const (
+	RateLimitGroupAuthentication = "authentication"
+	RateLimitGroupMessages       = "messages"
+	RateLimitGroupSend           = "send"
+	RateLimitGroupCustomers      = "customers"
+	RateLimitGroupWebhooks       = "webhooks"
+)
+
+// RateLimiters limit API requests of each client per group. They're shared by the HTTP and gRPC servers, so that clients can't get
+// around limits by calling both.
+type RateLimiters map[string]*ratelimit.KeyedLimiter
+
+// NewRateLimiters creates limiters of the groups with configured limits.
+func NewRateLimiters(groups map[string]config.RateLimitGroup) RateLimiters {
+	limiters := make(RateLimiters, len(groups))
+	for group, groupCfg := range groups {
+		mdctx.Infof(nil, "Limiting %q requests to %v per second with burst %d", group, groupCfg.RequestsPerSecond, groupCfg.Burst)
+		limiters[group] = ratelimit.New(groupCfg.RequestsPerSecond, groupCfg.Burst)
+	}
+	return limiters
+}
+
+// Allow checks if the client may make a request of the group. If it may not, it returns how long the client should wait. Requests of
+// groups without limits are always allowed.
+func (limiters RateLimiters) Allow(group, clientKey string) (allowed bool, retryAfter time.Duration) {
+	limiter, found := limiters[group]
+	if !found {
+		return true, 0
+	}
+	return limiter.Allow(clientKey)
+}
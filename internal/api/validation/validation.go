@@ -0,0 +1,211 @@
+package validation
+
+import (
+	"github.com/GeneralKenobi/mailman/internal/api"
+	"github.com/go-playground/locales"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/es"
+	"github.com/go-playground/locales/fr"
+	"github.com/go-playground/locales/pt_BR"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	entranslations "github.com/go-playground/validator/v10/translations/en"
+	estranslations "github.com/go-playground/validator/v10/translations/es"
+	frtranslations "github.com/go-playground/validator/v10/translations/fr"
+	ptbrtranslations "github.com/go-playground/validator/v10/translations/pt_BR"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxRejectedValueLength limits how many characters of a rejected string are returned in field errors. Longer values would only bloat
+// the response and the client already has them.
+const maxRejectedValueLength = 100
+
+// Validate validates a request model with the rules in its validate tags. If it's invalid it returns an api.StatusBadInput error
+// describing every invalid field (see api.FieldErrors) with messages in the language best matching acceptLanguage, a value of the
+// Accept-Language header. English is used if no supported language matches. subject names what was validated in the error message,
+// e.g. "request body". Rejected values of fields with the `sensitive:"true"` tag, e.g. secrets, aren't included in the error.
+func Validate(toValidate any, acceptLanguage, subject string) error {
+	err := validate.Struct(toValidate)
+	if err == nil {
+		return nil
+	}
+
+	validationErrs, ok := err.(validator.ValidationErrors)
+	if !ok {
+		return api.StatusBadInput.WithMessageAndCause(err, "invalid %s", subject)
+	}
+
+	translator := findTranslator(acceptLanguage)
+	validatedType := reflect.TypeOf(toValidate)
+	fields := make([]api.FieldError, len(validationErrs))
+	summaries := make([]string, len(validationErrs))
+	for i, validationErr := range validationErrs {
+		fields[i] = api.FieldError{
+			Path:    fieldPath(validationErr),
+			Rule:    validationErr.Tag(),
+			Message: message(validationErr, translator),
+		}
+		if !sensitive(validatedType, validationErr) {
+			fields[i].RejectedValue = rejectedValue(validationErr.Value())
+		}
+		summaries[i] = fields[i].Path + ": " + fields[i].Rule
+	}
+	return api.StatusBadInput.WithFields(err, fields, "invalid %s: %s", subject, strings.Join(summaries, ", "))
+}
+
+// fieldPath converts the namespace of the field, e.g. "MailingEntryBatch.entries[0].email", into its path in the request without the
+// name of the validated struct, e.g. "entries[0].email".
+func fieldPath(validationErr validator.FieldError) string {
+	namespace := validationErr.Namespace()
+	if i := strings.Index(namespace, "."); i >= 0 {
+		return namespace[i+1:]
+	}
+	return namespace
+}
+
+// sensitive checks if the invalid field has the `sensitive:"true"` tag. The field is found by following its struct namespace, e.g.
+// "MailingEntryBatch.Entries[0].Content", from the validated type.
+func sensitive(validatedType reflect.Type, validationErr validator.FieldError) bool {
+	fieldNames := strings.Split(validationErr.StructNamespace(), ".")[1:]
+	structType := validatedType
+	for i, fieldName := range fieldNames {
+		fieldName, _, _ = strings.Cut(fieldName, "[")
+		for structType.Kind() == reflect.Pointer || structType.Kind() == reflect.Slice || structType.Kind() == reflect.Array ||
+			structType.Kind() == reflect.Map {
+			structType = structType.Elem()
+		}
+		if structType.Kind() != reflect.Struct {
+			return false
+		}
+		field, found := structType.FieldByName(fieldName)
+		if !found {
+			return false
+		}
+		if i == len(fieldNames)-1 {
+			return field.Tag.Get("sensitive") == "true"
+		}
+		structType = field.Type
+	}
+	return false
+}
+
+// rejectedValue truncates strings longer than maxRejectedValueLength characters, other values are returned as they are.
+func rejectedValue(value any) any {
+	str, ok := value.(string)
+	if !ok || utf8.RuneCountInString(str) <= maxRejectedValueLength {
+		return value
+	}
+	return string([]rune(str)[:maxRejectedValueLength]) + "…"
+}
+
+func message(validationErr validator.FieldError, translator ut.Translator) string {
+	translated := validationErr.Translate(translator)
+	if translated == validationErr.Error() {
+		// There's no translation of the rule, the default message is meant for developers and mentions Go struct names.
+		return validationErr.Field() + " doesn't satisfy the " + validationErr.Tag() + " rule"
+	}
+	return translated
+}
+
+// findTranslator chooses the translator of the supported language preferred the most in the Accept-Language header value.
+func findTranslator(acceptLanguage string) ut.Translator {
+	translator, found := universalTranslator.FindTranslator(preferredLocales(acceptLanguage)...)
+	if !found {
+		return universalTranslator.GetFallback()
+	}
+	return translator
+}
+
+// preferredLocales parses language tags from an Accept-Language header value, e.g. "pt-BR, fr;q=0.8", ordered from the most preferred.
+// Tags are converted into locale names, e.g. "pt_BR", and followed by their primary language, e.g. "pt".
+func preferredLocales(acceptLanguage string) []string {
+	type weightedLocale struct {
+		locale string
+		weight float64
+	}
+
+	var weightedLocales []weightedLocale
+	for _, languageRange := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(languageRange), ";")
+		if tag == "" || tag == "*" {
+			continue
+		}
+		weight := 1.0
+		if params = strings.TrimSpace(params); strings.HasPrefix(params, "q=") {
+			parsed, err := strconv.ParseFloat(strings.TrimPrefix(params, "q="), 64)
+			if err != nil {
+				continue
+			}
+			weight = parsed
+		}
+
+		locale := strings.ReplaceAll(tag, "-", "_")
+		weightedLocales = append(weightedLocales, weightedLocale{locale: locale, weight: weight})
+		if language, _, found := strings.Cut(locale, "_"); found {
+			weightedLocales = append(weightedLocales, weightedLocale{locale: strings.ToLower(language), weight: weight})
+		}
+	}
+
+	sort.SliceStable(weightedLocales, func(i, j int) bool {
+		return weightedLocales[i].weight > weightedLocales[j].weight
+	})
+	localeNames := make([]string, 0, len(weightedLocales))
+	for _, weighted := range weightedLocales {
+		if weighted.weight > 0 {
+			localeNames = append(localeNames, weighted.locale)
+		}
+	}
+	return localeNames
+}
+
+// Use a single instance of Validate, it caches struct info. Field names in errors are taken from JSON tags, so that they match the
+// request.
+var validate = newValidate()
+
+func newValidate() *validator.Validate {
+	newValidate := validator.New()
+	newValidate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		switch name {
+		case "-":
+			return ""
+		case "":
+			return field.Name
+		default:
+			return name
+		}
+	})
+	return newValidate
+}
+
+// universalTranslator holds translators of validation messages into the supported languages, with English as the fallback.
+var universalTranslator = newUniversalTranslator()
+
+func newUniversalTranslator() *ut.UniversalTranslator {
+	type supportedLanguage struct {
+		locale                      locales.Translator
+		registerDefaultTranslations func(validate *validator.Validate, translator ut.Translator) error
+	}
+	supportedLanguages := []supportedLanguage{
+		{locale: en.New(), registerDefaultTranslations: entranslations.RegisterDefaultTranslations},
+		{locale: es.New(), registerDefaultTranslations: estranslations.RegisterDefaultTranslations},
+		{locale: fr.New(), registerDefaultTranslations: frtranslations.RegisterDefaultTranslations},
+		{locale: pt_BR.New(), registerDefaultTranslations: ptbrtranslations.RegisterDefaultTranslations},
+	}
+
+	universalTranslator := ut.New(supportedLanguages[0].locale)
+	for _, language := range supportedLanguages {
+		if err := universalTranslator.AddTranslator(language.locale, true); err != nil {
+			panic("error adding translator " + language.locale.Locale() + ": " + err.Error())
+		}
+		translator, _ := universalTranslator.GetTranslator(language.locale.Locale())
+		if err := language.registerDefaultTranslations(validate, translator); err != nil {
+			panic("error registering validation messages in " + language.locale.Locale() + ": " + err.Error())
+		}
+	}
+	return universalTranslator
+}
@@ -0,0 +1,118 @@
+package validation
+
+import (
+	"errors"
+	"github.com/GeneralKenobi/mailman/internal/api"
+	"github.com/GeneralKenobi/mailman/pkg/api/apimodel"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidate(t *testing.T) {
+	validEntry := apimodel.MailingEntry{MailingId: 1, Email: "jan.kowalski@example.com", Title: "title", Content: "content", InsertTime: time.Now()}
+	invalidEntry := validEntry
+	invalidEntry.Email = "not an email"
+	longEmail := strings.Repeat("a", 150)
+	longEmailEntry := validEntry
+	longEmailEntry.Email = longEmail
+	entryWithoutContent := validEntry
+	entryWithoutContent.Content = ""
+	longTitle := strings.Repeat("a", 256)
+	emptyTitle := ""
+
+	tests := map[string]struct {
+		toValidate     any
+		acceptLanguage string
+		expectedFields []api.FieldError
+	}{
+		"Should accept a valid struct": {
+			toValidate: validEntry,
+		},
+		"Should describe the invalid field in English by default": {
+			toValidate: invalidEntry,
+			expectedFields: []api.FieldError{
+				{Path: "email", Rule: "email", Message: "email must be a valid email address", RejectedValue: "not an email"},
+			},
+		},
+		"Should use JSON paths of nested fields": {
+			toValidate: apimodel.MailingEntryBatch{Entries: []apimodel.MailingEntry{validEntry, invalidEntry}},
+			expectedFields: []api.FieldError{
+				{Path: "entries[1].email", Rule: "email", Message: "email must be a valid email address", RejectedValue: "not an email"},
+			},
+		},
+		"Should translate messages into the preferred supported language": {
+			toValidate:     invalidEntry,
+			acceptLanguage: "pl-PL, fr;q=0.9, en;q=0.8",
+			expectedFields: []api.FieldError{
+				{Path: "email", Rule: "email", Message: "email doit être une adresse email valide", RejectedValue: "not an email"},
+			},
+		},
+		"Should match a regional language tag": {
+			toValidate:     invalidEntry,
+			acceptLanguage: "es-MX",
+			expectedFields: []api.FieldError{
+				{Path: "email", Rule: "email", Message: "email debe ser una dirección de correo electrónico válida", RejectedValue: "not an email"},
+			},
+		},
+		"Should truncate a long rejected value": {
+			toValidate: longEmailEntry,
+			expectedFields: []api.FieldError{
+				{Path: "email", Rule: "email", Message: "email must be a valid email address", RejectedValue: longEmail[:100] + "…"},
+			},
+		},
+		"Should leave out the rejected value of a sensitive field": {
+			toValidate: apimodel.WebhookSubscription{Url: "https://example.com/hook", EventTypes: []string{"mailing.completed"}, Secret: "short"},
+			expectedFields: []api.FieldError{
+				{Path: "secret", Rule: "min", Message: "secret must be at least 16 characters in length"},
+			},
+		},
+		"Should leave out the rejected value of a sensitive nested field": {
+			toValidate: &apimodel.MailingEntryBatch{Entries: []apimodel.MailingEntry{validEntry, entryWithoutContent}},
+			expectedFields: []api.FieldError{
+				{Path: "entries[1].content", Rule: "required", Message: "content is a required field"},
+			},
+		},
+		"Should reject a title of an update longer than 255 characters": {
+			toValidate: apimodel.MailingEntryUpdate{Title: &longTitle},
+			expectedFields: []api.FieldError{
+				{Path: "title", Rule: "max", Message: "title must be a maximum of 255 characters in length", RejectedValue: longTitle[:100] + "…"},
+			},
+		},
+		"Should reject an empty title of an update": {
+			toValidate: apimodel.MailingEntryUpdate{Title: &emptyTitle},
+			expectedFields: []api.FieldError{
+				{Path: "title", Rule: "min", Message: "title must be at least 1 character in length", RejectedValue: ""},
+			},
+		},
+		"Should describe a rule without translation": {
+			toValidate: struct {
+				Prefix string `json:"prefix" validate:"startswith=mailman"`
+			}{Prefix: "test"},
+			expectedFields: []api.FieldError{
+				{Path: "prefix", Rule: "startswith", Message: "prefix doesn't satisfy the startswith rule", RejectedValue: "test"},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := Validate(test.toValidate, test.acceptLanguage, "request body")
+
+			if test.expectedFields == nil {
+				if err != nil {
+					t.Fatalf("Expected no error but got %v", err)
+				}
+				return
+			}
+			var statusErr api.StatusError
+			if !errors.As(err, &statusErr) || statusErr.Status() != api.StatusBadInput {
+				t.Fatalf("Expected bad input error but got %v", err)
+			}
+			if fields := api.FieldErrors(err); !reflect.DeepEqual(fields, test.expectedFields) {
+				t.Errorf("Expected fields %+v but got %+v", test.expectedFields, fields)
+			}
+		})
+	}
+}
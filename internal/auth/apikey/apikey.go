@@ -0,0 +1,78 @@
+package apikey
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"github.com/GeneralKenobi/mailman/internal/api"
+	"github.com/GeneralKenobi/mailman/internal/auth"
+	"github.com/GeneralKenobi/mailman/internal/config"
+	"net/http"
+)
+
+// Header is the request header carrying the API key.
+const Header = "X-API-Key"
+
+// New creates an authenticator accepting the given static API keys.
+func New(apiKeys []config.ApiKey) (*Authenticator, error) {
+	keys := make([]key, len(apiKeys))
+	for i, apiKey := range apiKeys {
+		if apiKey.Key == "" {
+			return nil, fmt.Errorf("API key %q has an empty value", apiKey.Name)
+		}
+		scopes, err := parseScopes(apiKey.Scopes)
+		if err != nil {
+			return nil, fmt.Errorf("API key %q: %w", apiKey.Name, err)
+		}
+		tenantId := apiKey.Tenant
+		if tenantId == "" {
+			tenantId = auth.DefaultTenantId
+		}
+		keys[i] = key{
+			value: []byte(apiKey.Key),
+			principal: auth.Principal{
+				Subject:  apiKey.Name,
+				TenantId: tenantId,
+				Scopes:   scopes,
+			},
+		}
+	}
+	return &Authenticator{keys: keys}, nil
+}
+
+type Authenticator struct {
+	keys []key
+}
+
+type key struct {
+	value     []byte
+	principal auth.Principal
+}
+
+var _ auth.Authenticator = (*Authenticator)(nil) // Interface guard
+
+func (authenticator *Authenticator) Authenticate(_ context.Context, request *http.Request) (auth.Principal, error) {
+	value := request.Header.Get(Header)
+	if value == "" {
+		return auth.Principal{}, auth.NoCredentialsError("missing %s header", Header)
+	}
+
+	for _, key := range authenticator.keys {
+		if subtle.ConstantTimeCompare(key.value, []byte(value)) == 1 {
+			return key.principal, nil
+		}
+	}
+	return auth.Principal{}, api.StatusUnauthorized.WithMessage("invalid API key")
+}
+
+func parseScopes(values []string) ([]auth.Scope, error) {
+	scopes := make([]auth.Scope, len(values))
+	for i, value := range values {
+		scope, ok := auth.ParseScope(value)
+		if !ok {
+			return nil, fmt.Errorf("unknown scope %q", value)
+		}
+		scopes[i] = scope
+	}
+	return scopes, nil
+}
@@ -0,0 +1,89 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+)
+
+// Authenticator resolves the principal making a request from the credentials carried by it.
+type Authenticator interface {
+	// Authenticate returns the principal identified by the request's credentials. It returns api.StatusUnauthorized error if the
+	// credentials are missing or invalid.
+	Authenticate(ctx context.Context, request *http.Request) (Principal, error)
+}
+
+// Scope is a permission required by API operations.
+type Scope string
+
+const (
+	ScopeMessagesRead   Scope = "messages:read"   // Listing mailing entries and mailings
+	ScopeMessagesWrite  Scope = "messages:write"  // Creating and deleting mailing entries
+	ScopeMessagesSend   Scope = "messages:send"   // Sending mailing entries
+	ScopeCustomersRead  Scope = "customers:read"  // Getting customers
+	ScopeCustomersWrite Scope = "customers:write" // Creating and deleting customers
+	ScopeWebhooksRead   Scope = "webhooks:read"   // Listing webhook subscriptions and their deliveries
+	ScopeWebhooksWrite  Scope = "webhooks:write"  // Creating and deleting webhook subscriptions
+	ScopeArchiveRead    Scope = "archive:read"    // Listing archived mailing entries
+)
+
+// AllScopes lists every scope known to mailman.
+var AllScopes = []Scope{
+	ScopeMessagesRead,
+	ScopeMessagesWrite,
+	ScopeMessagesSend,
+	ScopeCustomersRead,
+	ScopeCustomersWrite,
+	ScopeWebhooksRead,
+	ScopeWebhooksWrite,
+	ScopeArchiveRead,
+}
+
+// Principal is an authenticated client.
+type Principal struct {
+	Subject   string  // Identifies the client in logs, e.g. API key name or JWT subject
+	TenantId  string  // Tenant the client belongs to, the client can only access this tenant's data
+	Scopes    []Scope // Scopes granted to the client
+	Anonymous bool    // Set if authentication is disabled and the client wasn't identified
+}
+
+// DefaultTenantId is the tenant of clients whose credentials don't specify one.
+const DefaultTenantId = "default"
+
+// HasScope checks if the principal has been granted the scope.
+func (principal Principal) HasScope(scope Scope) bool {
+	for _, grantedScope := range principal.Scopes {
+		if grantedScope == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientKey identifies the client in rate limits and idempotency keys - authenticated clients by their tenant and subject, anonymous
+// clients and clients that weren't authenticated yet (with an empty principal) by IP address.
+func (principal Principal) ClientKey(clientIp string) string {
+	if principal.Anonymous || principal.Subject == "" {
+		return "ip:" + clientIp
+	}
+	return "subject:" + principal.TenantId + "/" + principal.Subject
+}
+
+// Anonymous returns a principal of the default tenant with every scope. It's used when authentication is disabled.
+func Anonymous() Principal {
+	return Principal{
+		Subject:   "anonymous",
+		TenantId:  DefaultTenantId,
+		Scopes:    AllScopes,
+		Anonymous: true,
+	}
+}
+
+// ParseScope converts the value into a known Scope.
+func ParseScope(value string) (scope Scope, ok bool) {
+	for _, knownScope := range AllScopes {
+		if string(knownScope) == value {
+			return knownScope, true
+		}
+	}
+	return "", false
+}
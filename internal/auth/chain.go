@@ -0,0 +1,46 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"github.com/GeneralKenobi/mailman/internal/api"
+	"net/http"
+)
+
+// ErrNoCredentials is returned (wrapped in api.StatusUnauthorized error) by authenticators when the request doesn't carry the type of
+// credentials they handle. It allows Chain to try the next authenticator.
+var ErrNoCredentials = errors.New("no credentials")
+
+// NoCredentialsError creates an api.StatusUnauthorized error wrapping ErrNoCredentials.
+func NoCredentialsError(messageFormat string, args ...any) error {
+	return api.StatusUnauthorized.WithMessageAndCause(ErrNoCredentials, messageFormat, args...)
+}
+
+// NewChain creates an authenticator that delegates to the first of the given authenticators that finds its type of credentials in the
+// request. If there are no authenticators then every request is authenticated as Anonymous.
+func NewChain(authenticators ...Authenticator) *Chain {
+	return &Chain{authenticators: authenticators}
+}
+
+type Chain struct {
+	authenticators []Authenticator
+}
+
+var _ Authenticator = (*Chain)(nil) // Interface guard
+
+func (chain *Chain) Authenticate(ctx context.Context, request *http.Request) (Principal, error) {
+	if len(chain.authenticators) == 0 {
+		return Anonymous(), nil
+	}
+
+	for _, authenticator := range chain.authenticators {
+		principal, err := authenticator.Authenticate(ctx, request)
+		if err == nil {
+			return principal, nil
+		}
+		if !errors.Is(err, ErrNoCredentials) {
+			return Principal{}, err
+		}
+	}
+	return Principal{}, NoCredentialsError("missing credentials")
+}
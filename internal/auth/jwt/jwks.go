@@ -0,0 +1,308 @@
+package jwt
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/GeneralKenobi/mailman/pkg/mdctx"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// KeySet holds public keys from a JSON Web Key Set (RFC 7517). The keys are loaded lazily and reloaded every refresh period, or sooner
+// if a token references an unknown key ID, but not more often than every minReloadInterval. Keys are loaded without holding the
+// key set's mutex, concurrent callers wait for the same load instead of starting their own.
+type KeySet struct {
+	source        string // Used in logs and error messages
+	load          func(ctx context.Context) ([]byte, error)
+	refreshPeriod time.Duration
+
+	mutex        sync.Mutex
+	keys         map[string]any // Key ID to *rsa.PublicKey or *ecdsa.PublicKey
+	loadedAt     time.Time
+	lastReloadAt time.Time  // Start of the last load, successful or not
+	reloading    *keyReload // Load in progress, nil if none
+}
+
+// keyReload is a load of keys shared by the callers that need it. done is closed when it completes.
+type keyReload struct {
+	done chan struct{}
+	keys map[string]any
+	err  error
+}
+
+// NewFileKeySet creates a key set loaded from a local file. It's meant for deployments without network access to the identity
+// provider and for tests.
+func NewFileKeySet(path string, refreshPeriod time.Duration) *KeySet {
+	return &KeySet{
+		source: path,
+		load: func(_ context.Context) ([]byte, error) {
+			return os.ReadFile(path)
+		},
+		refreshPeriod: refreshPeriod,
+	}
+}
+
+// NewUrlKeySet creates a key set downloaded from the URL, typically the jwks_uri of an OIDC provider.
+func NewUrlKeySet(url string, refreshPeriod time.Duration) *KeySet {
+	return &KeySet{
+		source: url,
+		load: func(ctx context.Context) ([]byte, error) {
+			return download(ctx, url)
+		},
+		refreshPeriod: refreshPeriod,
+	}
+}
+
+// Key returns the public key with the given key ID. If keyId is empty and the set has exactly one key then that key is returned. If
+// refreshing stale keys fails, the keys loaded before are used until a refresh succeeds.
+func (keySet *KeySet) Key(ctx context.Context, keyId string) (any, error) {
+	keySet.mutex.Lock()
+	keys := keySet.keys
+	stale := currentTime().Sub(keySet.loadedAt) > keySet.refreshPeriod
+	keySet.mutex.Unlock()
+
+	switch {
+	case keys == nil:
+		if keySet.loadFailedRecently() {
+			return nil, fmt.Errorf("%w: loading key set from %s failed less than %v ago", errKeySetUnavailable, keySet.source,
+				minReloadInterval)
+		}
+		var err error
+		if keys, err = keySet.reload(ctx); err != nil {
+			return nil, err
+		}
+	case stale && keySet.mayReload():
+		reloadedKeys, err := keySet.reload(ctx)
+		if err != nil {
+			mdctx.Warnf(ctx, "Error refreshing key set from %s, using the keys loaded before: %v", keySet.source, err)
+		} else {
+			keys = reloadedKeys
+		}
+	}
+
+	key, found := find(keys, keyId)
+	if !found && keySet.mayReload() {
+		mdctx.Debugf(ctx, "Key %q not found in key set from %s - reloading", keyId, keySet.source)
+		var err error
+		if keys, err = keySet.reload(ctx); err != nil {
+			return nil, err
+		}
+		key, found = find(keys, keyId)
+	}
+	if !found {
+		return nil, fmt.Errorf("key %q not found in key set from %s", keyId, keySet.source)
+	}
+	return key, nil
+}
+
+// mayReload returns whether the last load started at least minReloadInterval ago, so that tokens with unknown key IDs or an unavailable
+// key set can't make the key set reload on every request. It also prevents a second load right after the first one on a cold cache.
+func (keySet *KeySet) mayReload() bool {
+	keySet.mutex.Lock()
+	defer keySet.mutex.Unlock()
+	return keySet.reloading == nil && currentTime().Sub(keySet.lastReloadAt) > minReloadInterval
+}
+
+// loadFailedRecently returns whether no keys were loaded yet because the last load, which started less than minReloadInterval ago,
+// failed. Callers on a cold cache wait for a load in progress instead.
+func (keySet *KeySet) loadFailedRecently() bool {
+	keySet.mutex.Lock()
+	defer keySet.mutex.Unlock()
+	return keySet.keys == nil && keySet.reloading == nil && !keySet.lastReloadAt.IsZero() &&
+		currentTime().Sub(keySet.lastReloadAt) <= minReloadInterval
+}
+
+func find(keys map[string]any, keyId string) (any, bool) {
+	if keyId == "" && len(keys) == 1 {
+		for _, key := range keys {
+			return key, true
+		}
+	}
+	key, found := keys[keyId]
+	return key, found
+}
+
+// reload loads the keys, or waits for the load in progress if there is one. The keys are loaded without the caller's context, so that
+// a canceled request doesn't fail the load for the others waiting for it.
+func (keySet *KeySet) reload(ctx context.Context) (map[string]any, error) {
+	keySet.mutex.Lock()
+	reload := keySet.reloading
+	if reload != nil {
+		keySet.mutex.Unlock()
+		select {
+		case <-reload.done:
+			return reload.keys, reload.err
+		case <-ctx.Done():
+			return nil, fmt.Errorf("%w: waiting for key set from %s: %v", errKeySetUnavailable, keySet.source, ctx.Err())
+		}
+	}
+	reload = &keyReload{done: make(chan struct{})}
+	keySet.reloading = reload
+	keySet.lastReloadAt = currentTime()
+	keySet.mutex.Unlock()
+
+	keys, err := keySet.loadKeys(ctx)
+
+	keySet.mutex.Lock()
+	defer keySet.mutex.Unlock()
+	if err == nil {
+		mdctx.Infof(ctx, "Loaded %d keys from %s", len(keys), keySet.source)
+		keySet.keys = keys
+		keySet.loadedAt = currentTime()
+	}
+	reload.keys, reload.err = keys, err
+	keySet.reloading = nil
+	close(reload.done)
+	return keys, err
+}
+
+func (keySet *KeySet) loadKeys(ctx context.Context) (map[string]any, error) {
+	jwksBytes, err := keySet.load(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("%w: error loading key set from %s: %v", errKeySetUnavailable, keySet.source, err)
+	}
+	keys, err := parseJwks(ctx, jwksBytes)
+	if err != nil {
+		return nil, fmt.Errorf("%w: error parsing key set from %s: %v", errKeySetUnavailable, keySet.source, err)
+	}
+	return keys, nil
+}
+
+// errKeySetUnavailable signals a server-side problem with obtaining keys as opposed to an invalid token.
+var errKeySetUnavailable = fmt.Errorf("key set unavailable")
+
+const minReloadInterval = 10 * time.Second
+
+type jwks struct {
+	Keys []jwk `json:"keys"`
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`   // RSA modulus
+	E   string `json:"e"`   // RSA exponent
+	Crv string `json:"crv"` // EC curve
+	X   string `json:"x"`   // EC x coordinate
+	Y   string `json:"y"`   // EC y coordinate
+}
+
+// parseJwks converts signature verification keys from the JSON Web Key Set into public keys. Keys of unsupported types are skipped.
+func parseJwks(ctx context.Context, jwksBytes []byte) (map[string]any, error) {
+	var keySet jwks
+	if err := json.Unmarshal(jwksBytes, &keySet); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]any, len(keySet.Keys))
+	for _, key := range keySet.Keys {
+		if key.Use != "" && key.Use != "sig" {
+			continue
+		}
+
+		var publicKey any
+		var err error
+		switch key.Kty {
+		case "RSA":
+			publicKey, err = key.rsaPublicKey()
+		case "EC":
+			publicKey, err = key.ecdsaPublicKey()
+		default:
+			mdctx.Debugf(ctx, "Skipping key %q of unsupported type %q", key.Kid, key.Kty)
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid key %q: %w", key.Kid, err)
+		}
+		keys[key.Kid] = publicKey
+	}
+	return keys, nil
+}
+
+func (key jwk) rsaPublicKey() (*rsa.PublicKey, error) {
+	modulus, err := decodeBigInt(key.N)
+	if err != nil {
+		return nil, fmt.Errorf("invalid modulus: %w", err)
+	}
+	exponent, err := decodeBigInt(key.E)
+	if err != nil {
+		return nil, fmt.Errorf("invalid exponent: %w", err)
+	}
+	if !exponent.IsInt64() {
+		return nil, fmt.Errorf("exponent is too large")
+	}
+	return &rsa.PublicKey{N: modulus, E: int(exponent.Int64())}, nil
+}
+
+func (key jwk) ecdsaPublicKey() (*ecdsa.PublicKey, error) {
+	var curve elliptic.Curve
+	switch key.Crv {
+	case "P-256":
+		curve = elliptic.P256()
+	case "P-384":
+		curve = elliptic.P384()
+	case "P-521":
+		curve = elliptic.P521()
+	default:
+		return nil, fmt.Errorf("unsupported curve %q", key.Crv)
+	}
+
+	x, err := decodeBigInt(key.X)
+	if err != nil {
+		return nil, fmt.Errorf("invalid x coordinate: %w", err)
+	}
+	y, err := decodeBigInt(key.Y)
+	if err != nil {
+		return nil, fmt.Errorf("invalid y coordinate: %w", err)
+	}
+	if !curve.IsOnCurve(x, y) {
+		return nil, fmt.Errorf("point is not on curve %s", key.Crv)
+	}
+	return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	bytes, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	if len(bytes) == 0 {
+		return nil, fmt.Errorf("empty value")
+	}
+	return new(big.Int).SetBytes(bytes), nil
+}
+
+func download(ctx context.Context, url string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, downloadTimeout)
+	defer cancel()
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected response status %s", response.Status)
+	}
+	return io.ReadAll(response.Body)
+}
+
+const downloadTimeout = 10 * time.Second
+
+// Hook for mocking in unit tests.
+var currentTime = time.Now
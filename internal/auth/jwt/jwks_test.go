@@ -0,0 +1,195 @@
+package jwt
+
+import (
+	"context"
+	"errors"
+	"github.com/GeneralKenobi/mailman/internal/auth/jwt/jwttest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestKeySetShouldShareConcurrentLoads(t *testing.T) {
+	issuer := jwttest.NewIssuer(t, "https://idp.example.com", "mailman")
+	release := make(chan struct{})
+	var loads int32
+	keySet := &KeySet{
+		source: "test",
+		load: func(context.Context) ([]byte, error) {
+			atomic.AddInt32(&loads, 1)
+			<-release
+			return issuer.Jwks(), nil
+		},
+		refreshPeriod: time.Minute,
+	}
+
+	var waitGroup sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			if _, err := keySet.Key(context.TODO(), issuer.KeyId); err != nil {
+				t.Errorf("Expected no error but got %v", err)
+			}
+		}()
+	}
+	// The mutex isn't held during the load
+	for !keySet.loading() {
+		time.Sleep(time.Millisecond)
+	}
+	keySet.mutex.Lock()
+	keySet.mutex.Unlock()
+	close(release)
+	waitGroup.Wait()
+
+	if loads != 1 {
+		t.Errorf("Expected 1 load but got %d", loads)
+	}
+}
+
+func TestKeySetShouldRateLimitReloadsForUnknownKeys(t *testing.T) {
+	originalCurrentTimeHook := currentTime
+	defer func() {
+		currentTime = originalCurrentTimeHook
+	}()
+	now := time.Date(2022, 3, 30, 10, 0, 0, 0, time.UTC)
+	currentTime = func() time.Time { return now }
+
+	issuer := jwttest.NewIssuer(t, "https://idp.example.com", "mailman")
+	loads := 0
+	keySet := &KeySet{
+		source: "test",
+		load: func(context.Context) ([]byte, error) {
+			loads++
+			return issuer.Jwks(), nil
+		},
+		refreshPeriod: time.Hour,
+	}
+
+	// A cold cache is loaded once, the unknown key doesn't trigger another load right away
+	if _, err := keySet.Key(context.TODO(), "unknown"); err == nil {
+		t.Errorf("Expected an error for an unknown key")
+	}
+	if loads != 1 {
+		t.Errorf("Expected 1 load on a cold cache but got %d", loads)
+	}
+
+	now = now.Add(minReloadInterval / 2)
+	_, _ = keySet.Key(context.TODO(), "unknown")
+	if loads != 1 {
+		t.Errorf("Expected no load within the minimum reload interval but got %d loads", loads)
+	}
+
+	now = now.Add(minReloadInterval)
+	_, _ = keySet.Key(context.TODO(), "unknown")
+	if loads != 2 {
+		t.Errorf("Expected a load after the minimum reload interval but got %d loads", loads)
+	}
+
+	if _, err := keySet.Key(context.TODO(), issuer.KeyId); err != nil {
+		t.Errorf("Expected no error for a known key but got %v", err)
+	}
+	if loads != 2 {
+		t.Errorf("Expected no load for a known key but got %d loads", loads)
+	}
+}
+
+func TestKeySetShouldUseStaleKeysIfRefreshFails(t *testing.T) {
+	originalCurrentTimeHook := currentTime
+	defer func() {
+		currentTime = originalCurrentTimeHook
+	}()
+	now := time.Date(2022, 3, 30, 10, 0, 0, 0, time.UTC)
+	currentTime = func() time.Time { return now }
+
+	issuer := jwttest.NewIssuer(t, "https://idp.example.com", "mailman")
+	loads := 0
+	var loadErr error
+	keySet := &KeySet{
+		source: "test",
+		load: func(context.Context) ([]byte, error) {
+			loads++
+			return issuer.Jwks(), loadErr
+		},
+		refreshPeriod: time.Hour,
+	}
+	if _, err := keySet.Key(context.TODO(), issuer.KeyId); err != nil {
+		t.Fatalf("Expected no error on the first load but got %v", err)
+	}
+
+	loadErr = errors.New("key set unavailable")
+	now = now.Add(2 * time.Hour)
+	if _, err := keySet.Key(context.TODO(), issuer.KeyId); err != nil {
+		t.Errorf("Expected the stale key after a failed refresh but got %v", err)
+	}
+	if loads != 2 {
+		t.Errorf("Expected a refresh of stale keys but got %d loads", loads)
+	}
+
+	// Failed refreshes are throttled like reloads for unknown keys
+	now = now.Add(minReloadInterval / 2)
+	if _, err := keySet.Key(context.TODO(), issuer.KeyId); err != nil {
+		t.Errorf("Expected the stale key within the minimum reload interval but got %v", err)
+	}
+	if loads != 2 {
+		t.Errorf("Expected no refresh within the minimum reload interval but got %d loads", loads)
+	}
+
+	loadErr = nil
+	now = now.Add(minReloadInterval)
+	if _, err := keySet.Key(context.TODO(), issuer.KeyId); err != nil {
+		t.Errorf("Expected no error after a successful refresh but got %v", err)
+	}
+	if loads != 3 {
+		t.Errorf("Expected a refresh after the minimum reload interval but got %d loads", loads)
+	}
+	if _, err := keySet.Key(context.TODO(), issuer.KeyId); err != nil || loads != 3 {
+		t.Errorf("Expected refreshed keys not to be stale but got %d loads, error: %v", loads, err)
+	}
+}
+
+func TestKeySetShouldThrottleLoadsOfColdCacheAfterFailure(t *testing.T) {
+	originalCurrentTimeHook := currentTime
+	defer func() {
+		currentTime = originalCurrentTimeHook
+	}()
+	now := time.Date(2022, 3, 30, 10, 0, 0, 0, time.UTC)
+	currentTime = func() time.Time { return now }
+
+	issuer := jwttest.NewIssuer(t, "https://idp.example.com", "mailman")
+	loads := 0
+	loadErr := errors.New("key set unavailable")
+	keySet := &KeySet{
+		source: "test",
+		load: func(context.Context) ([]byte, error) {
+			loads++
+			return issuer.Jwks(), loadErr
+		},
+		refreshPeriod: time.Hour,
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, err := keySet.Key(context.TODO(), issuer.KeyId); !errors.Is(err, errKeySetUnavailable) {
+			t.Errorf("Expected error %v but got %v", errKeySetUnavailable, err)
+		}
+	}
+	if loads != 1 {
+		t.Errorf("Expected 1 load within the minimum reload interval but got %d", loads)
+	}
+
+	loadErr = nil
+	now = now.Add(2 * minReloadInterval)
+	if _, err := keySet.Key(context.TODO(), issuer.KeyId); err != nil {
+		t.Errorf("Expected no error after the minimum reload interval but got %v", err)
+	}
+	if loads != 2 {
+		t.Errorf("Expected a load after the minimum reload interval but got %d loads", loads)
+	}
+}
+
+func (keySet *KeySet) loading() bool {
+	keySet.mutex.Lock()
+	defer keySet.mutex.Unlock()
+	return keySet.reloading != nil
+}
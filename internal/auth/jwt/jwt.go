@@ -0,0 +1,163 @@
+package jwt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/GeneralKenobi/mailman/internal/api"
+	"github.com/GeneralKenobi/mailman/internal/auth"
+	"github.com/GeneralKenobi/mailman/internal/config"
+	"github.com/GeneralKenobi/mailman/pkg/mdctx"
+	jwtlib "github.com/golang-jwt/jwt/v5"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// New creates an authenticator validating bearer tokens signed with keys from the configured JSON Web Key Set.
+func New(cfg config.Jwt) (*Authenticator, error) {
+	if cfg.Issuer == "" {
+		return nil, fmt.Errorf("issuer is required")
+	}
+	if cfg.Audience == "" {
+		return nil, fmt.Errorf("audience is required")
+	}
+
+	refreshPeriod := time.Duration(cfg.JwksRefreshSeconds) * time.Second
+	var keySet *KeySet
+	switch {
+	case cfg.JwksFile != "":
+		keySet = NewFileKeySet(cfg.JwksFile, refreshPeriod)
+	case cfg.JwksUrl != "":
+		keySet = NewUrlKeySet(cfg.JwksUrl, refreshPeriod)
+	default:
+		return nil, fmt.Errorf("either JWKS file or JWKS URL is required")
+	}
+
+	scopeMapping := make(map[string][]auth.Scope, len(cfg.ScopeMapping))
+	for claimValue, scopeValues := range cfg.ScopeMapping {
+		for _, scopeValue := range scopeValues {
+			scope, ok := auth.ParseScope(scopeValue)
+			if !ok {
+				return nil, fmt.Errorf("scope mapping for %q: unknown scope %q", claimValue, scopeValue)
+			}
+			scopeMapping[claimValue] = append(scopeMapping[claimValue], scope)
+		}
+	}
+
+	parser := jwtlib.NewParser(
+		jwtlib.WithValidMethods(validMethods),
+		jwtlib.WithIssuer(cfg.Issuer),
+		jwtlib.WithAudience(cfg.Audience),
+		jwtlib.WithExpirationRequired(),
+		jwtlib.WithLeeway(time.Duration(cfg.LeewaySeconds)*time.Second),
+		jwtlib.WithTimeFunc(func() time.Time { return currentTime() }),
+	)
+
+	return &Authenticator{
+		keySet:       keySet,
+		parser:       parser,
+		scopesClaim:  cfg.ScopesClaim,
+		tenantClaim:  cfg.TenantClaim,
+		scopeMapping: scopeMapping,
+	}, nil
+}
+
+type Authenticator struct {
+	keySet       *KeySet
+	parser       *jwtlib.Parser
+	scopesClaim  string
+	tenantClaim  string
+	scopeMapping map[string][]auth.Scope
+}
+
+var _ auth.Authenticator = (*Authenticator)(nil) // Interface guard
+
+// Only asymmetric algorithms are accepted - the key set contains public keys.
+var validMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}
+
+// Authenticate validates the bearer token from the Authorization header and converts its claims into a principal.
+func (authenticator *Authenticator) Authenticate(ctx context.Context, request *http.Request) (auth.Principal, error) {
+	tokenString, ok := bearerToken(request)
+	if !ok {
+		return auth.Principal{}, auth.NoCredentialsError("missing bearer token")
+	}
+
+	claims := jwtlib.MapClaims{}
+	_, err := authenticator.parser.ParseWithClaims(tokenString, claims, func(token *jwtlib.Token) (any, error) {
+		keyId, _ := token.Header["kid"].(string)
+		return authenticator.keySet.Key(ctx, keyId)
+	})
+	if errors.Is(err, errKeySetUnavailable) {
+		return auth.Principal{}, fmt.Errorf("error verifying bearer token: %w", err)
+	}
+	if err != nil {
+		return auth.Principal{}, api.StatusUnauthorized.WithMessageAndCause(err, "invalid bearer token")
+	}
+
+	subject, err := claims.GetSubject()
+	if err != nil || subject == "" {
+		return auth.Principal{}, api.StatusUnauthorized.WithMessage("bearer token has no subject")
+	}
+
+	tenantId, err := authenticator.tenantId(claims)
+	if err != nil {
+		return auth.Principal{}, err
+	}
+
+	principal := auth.Principal{
+		Subject:  subject,
+		TenantId: tenantId,
+		Scopes:   authenticator.scopes(ctx, claims),
+	}
+	return principal, nil
+}
+
+// tenantId reads the tenant from the configured claim. If no tenant claim is configured then the default tenant is used.
+func (authenticator *Authenticator) tenantId(claims jwtlib.MapClaims) (string, error) {
+	if authenticator.tenantClaim == "" {
+		return auth.DefaultTenantId, nil
+	}
+	tenantId, _ := claims[authenticator.tenantClaim].(string)
+	if tenantId == "" {
+		return "", api.StatusUnauthorized.WithMessage("bearer token has no %s claim", authenticator.tenantClaim)
+	}
+	return tenantId, nil
+}
+
+// scopes maps values of the scopes claim to mailman scopes. Values that are neither mapped nor known scopes are ignored since tokens may
+// carry scopes for other services.
+func (authenticator *Authenticator) scopes(ctx context.Context, claims jwtlib.MapClaims) []auth.Scope {
+	var claimValues []string
+	switch value := claims[authenticator.scopesClaim].(type) {
+	case string:
+		claimValues = strings.Fields(value)
+	case []any:
+		for _, element := range value {
+			if elementString, ok := element.(string); ok {
+				claimValues = append(claimValues, elementString)
+			}
+		}
+	}
+
+	var scopes []auth.Scope
+	for _, claimValue := range claimValues {
+		if mappedScopes, found := authenticator.scopeMapping[claimValue]; found {
+			scopes = append(scopes, mappedScopes...)
+		} else if scope, ok := auth.ParseScope(claimValue); ok {
+			scopes = append(scopes, scope)
+		} else {
+			mdctx.Debugf(ctx, "Ignoring unknown scope %q", claimValue)
+		}
+	}
+	return scopes
+}
+
+func bearerToken(request *http.Request) (string, bool) {
+	const prefix = "bearer "
+	header := request.Header.Get("Authorization")
+	if len(header) <= len(prefix) || !strings.EqualFold(header[:len(prefix)], prefix) {
+		return "", false
+	}
+	return strings.TrimSpace(header[len(prefix):]), true
+}
@@ -0,0 +1,196 @@
+package jwt
+
+import (
+	"context"
+	"errors"
+	"github.com/GeneralKenobi/mailman/internal/api"
+	"github.com/GeneralKenobi/mailman/internal/auth"
+	"github.com/GeneralKenobi/mailman/internal/auth/jwt/jwttest"
+	"github.com/GeneralKenobi/mailman/internal/config"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestAuthenticate(t *testing.T) {
+	issuer := jwttest.NewIssuer(t, "https://idp.example.com", "mailman")
+	otherIssuer := jwttest.NewIssuer(t, issuer.Name, issuer.Audience)
+	cfg := config.Jwt{
+		JwksFile:           issuer.JwksFile(t),
+		JwksRefreshSeconds: 60,
+		Issuer:             issuer.Name,
+		Audience:           issuer.Audience,
+		ScopesClaim:        "scope",
+		ScopeMapping:       map[string][]string{"mailman-admin": {"messages:write", "messages:send"}},
+		LeewaySeconds:      30,
+	}
+
+	tests := map[string]struct {
+		authorizationHeader string
+		expected            auth.Principal
+		expectedStatus      api.Status
+		expectNoCredentials bool
+	}{
+		"Should authenticate a valid token and keep known scopes": {
+			authorizationHeader: "Bearer " + issuer.Token(t, "service-a", "messages:send other:scope", nil),
+			expected:            auth.Principal{Subject: "service-a", TenantId: auth.DefaultTenantId, Scopes: []auth.Scope{auth.ScopeMessagesSend}},
+		},
+		"Should map scopes according to the configuration": {
+			authorizationHeader: "bearer " + issuer.Token(t, "admin", "mailman-admin", nil),
+			expected:            auth.Principal{Subject: "admin", TenantId: auth.DefaultTenantId, Scopes: []auth.Scope{auth.ScopeMessagesWrite, auth.ScopeMessagesSend}},
+		},
+		"Should accept scopes claim as an array": {
+			authorizationHeader: "Bearer " + issuer.Token(t, "service-b", "", map[string]any{"scope": []string{"messages:write"}}),
+			expected:            auth.Principal{Subject: "service-b", TenantId: auth.DefaultTenantId, Scopes: []auth.Scope{auth.ScopeMessagesWrite}},
+		},
+		"Should accept a token expired within the leeway": {
+			authorizationHeader: "Bearer " + issuer.Token(t, "service-c", "", map[string]any{"exp": time.Now().Add(-10 * time.Second).Unix()}),
+			expected:            auth.Principal{Subject: "service-c", TenantId: auth.DefaultTenantId},
+		},
+		"Should reject an expired token": {
+			authorizationHeader: "Bearer " + issuer.Token(t, "service-a", "", map[string]any{"exp": time.Now().Add(-time.Hour).Unix()}),
+			expectedStatus:      api.StatusUnauthorized,
+		},
+		"Should reject a token without expiry": {
+			authorizationHeader: "Bearer " + issuer.Token(t, "service-a", "", map[string]any{"exp": nil}),
+			expectedStatus:      api.StatusUnauthorized,
+		},
+		"Should reject a token from another issuer": {
+			authorizationHeader: "Bearer " + issuer.Token(t, "service-a", "", map[string]any{"iss": "https://evil.example.com"}),
+			expectedStatus:      api.StatusUnauthorized,
+		},
+		"Should reject a token for another audience": {
+			authorizationHeader: "Bearer " + issuer.Token(t, "service-a", "", map[string]any{"aud": "other-service"}),
+			expectedStatus:      api.StatusUnauthorized,
+		},
+		"Should reject a token signed with an unknown key": {
+			authorizationHeader: "Bearer " + otherIssuer.Token(t, "service-a", "", nil),
+			expectedStatus:      api.StatusUnauthorized,
+		},
+		"Should reject a token without subject": {
+			authorizationHeader: "Bearer " + issuer.Token(t, "", "", nil),
+			expectedStatus:      api.StatusUnauthorized,
+		},
+		"Should reject a malformed token": {
+			authorizationHeader: "Bearer not-a-token",
+			expectedStatus:      api.StatusUnauthorized,
+		},
+		"Should report missing credentials if there's no bearer token": {
+			authorizationHeader: "Basic dXNlcjpwYXNz",
+			expectedStatus:      api.StatusUnauthorized,
+			expectNoCredentials: true,
+		},
+	}
+
+	testObj, err := New(cfg)
+	if err != nil {
+		t.Fatalf("Error creating authenticator: %v", err)
+	}
+
+	for title, test := range tests {
+		t.Run(title, func(t *testing.T) {
+			request, _ := http.NewRequest(http.MethodGet, "/api/messages", nil)
+			request.Header.Set("Authorization", test.authorizationHeader)
+
+			principal, err := testObj.Authenticate(context.TODO(), request)
+
+			if test.expectedStatus == "" {
+				if err != nil {
+					t.Fatalf("Expected no error but got %v", err)
+				}
+				if !reflect.DeepEqual(principal, test.expected) {
+					t.Errorf("Expected %#v\nGot %#v", test.expected, principal)
+				}
+				return
+			}
+
+			var statusErr api.StatusError
+			if !errors.As(err, &statusErr) || statusErr.Status() != test.expectedStatus {
+				t.Fatalf("Expected %v status error but got %v", test.expectedStatus, err)
+			}
+			if errors.Is(err, auth.ErrNoCredentials) != test.expectNoCredentials {
+				t.Errorf("Expected missing credentials: %v, got error %v", test.expectNoCredentials, err)
+			}
+		})
+	}
+}
+
+func TestAuthenticateWithTenantClaim(t *testing.T) {
+	issuer := jwttest.NewIssuer(t, "https://idp.example.com", "mailman")
+	cfg := config.Jwt{
+		JwksFile:    issuer.JwksFile(t),
+		Issuer:      issuer.Name,
+		Audience:    issuer.Audience,
+		ScopesClaim: "scope",
+		TenantClaim: "org",
+	}
+	testObj, err := New(cfg)
+	if err != nil {
+		t.Fatalf("Error creating authenticator: %v", err)
+	}
+
+	request, _ := http.NewRequest(http.MethodGet, "/api/messages", nil)
+	request.Header.Set("Authorization", "Bearer "+issuer.Token(t, "service-a", "", map[string]any{"org": "team-a"}))
+	principal, err := testObj.Authenticate(context.TODO(), request)
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if principal.TenantId != "team-a" {
+		t.Errorf("Expected tenant team-a but got %q", principal.TenantId)
+	}
+
+	request.Header.Set("Authorization", "Bearer "+issuer.Token(t, "service-a", "", nil))
+	_, err = testObj.Authenticate(context.TODO(), request)
+	var statusErr api.StatusError
+	if !errors.As(err, &statusErr) || statusErr.Status() != api.StatusUnauthorized {
+		t.Errorf("Expected unauthorized error for a token without tenant but got %v", err)
+	}
+}
+
+func TestAuthenticateWithJwksUrl(t *testing.T) {
+	issuer := jwttest.NewIssuer(t, "https://idp.example.com", "mailman")
+	cfg := config.Jwt{
+		JwksUrl:     issuer.JwksServer(t).URL,
+		Issuer:      issuer.Name,
+		Audience:    issuer.Audience,
+		ScopesClaim: "scope",
+	}
+	testObj, err := New(cfg)
+	if err != nil {
+		t.Fatalf("Error creating authenticator: %v", err)
+	}
+
+	request, _ := http.NewRequest(http.MethodGet, "/api/messages", nil)
+	request.Header.Set("Authorization", "Bearer "+issuer.Token(t, "service-a", "messages:write", nil))
+	principal, err := testObj.Authenticate(context.TODO(), request)
+
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if principal.Subject != "service-a" || !principal.HasScope(auth.ScopeMessagesWrite) {
+		t.Errorf("Unexpected principal %#v", principal)
+	}
+}
+
+func TestAuthenticateShouldReturnInternalErrorIfKeySetIsUnavailable(t *testing.T) {
+	issuer := jwttest.NewIssuer(t, "https://idp.example.com", "mailman")
+	cfg := config.Jwt{
+		JwksFile: "/does/not/exist.json",
+		Issuer:   issuer.Name,
+		Audience: issuer.Audience,
+	}
+	testObj, err := New(cfg)
+	if err != nil {
+		t.Fatalf("Error creating authenticator: %v", err)
+	}
+
+	request, _ := http.NewRequest(http.MethodGet, "/api/messages", nil)
+	request.Header.Set("Authorization", "Bearer "+issuer.Token(t, "service-a", "", nil))
+	_, err = testObj.Authenticate(context.TODO(), request)
+
+	var statusErr api.StatusError
+	if err == nil || errors.As(err, &statusErr) {
+		t.Errorf("Expected a non-status error but got %v", err)
+	}
+}
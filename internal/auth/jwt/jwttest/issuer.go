@@ -0,0 +1,100 @@
+// Package jwttest provides a local stand-in for an identity provider, for use in tests.
+package jwttest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	jwtlib "github.com/golang-jwt/jwt/v5"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// Issuer signs tokens with a freshly generated RSA key and publishes the public key as a JSON Web Key Set.
+type Issuer struct {
+	Name     string // Value of the iss claim
+	Audience string // Value of the aud claim
+	KeyId    string
+
+	privateKey *rsa.PrivateKey
+}
+
+// NewIssuer creates an issuer with a new signing key.
+func NewIssuer(t *testing.T, name, audience string) *Issuer {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Error generating RSA key: %v", err)
+	}
+	return &Issuer{
+		Name:       name,
+		Audience:   audience,
+		KeyId:      "test-key",
+		privateKey: privateKey,
+	}
+}
+
+// Jwks returns the JSON Web Key Set with the issuer's public key.
+func (issuer *Issuer) Jwks() []byte {
+	publicKey := issuer.privateKey.PublicKey
+	jwks := map[string]any{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": issuer.KeyId,
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes()),
+		}},
+	}
+	jwksBytes, _ := json.Marshal(jwks)
+	return jwksBytes
+}
+
+// JwksFile writes the key set to a file in a temporary directory and returns its path.
+func (issuer *Issuer) JwksFile(t *testing.T) string {
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, issuer.Jwks(), 0o600); err != nil {
+		t.Fatalf("Error writing JWKS file: %v", err)
+	}
+	return path
+}
+
+// JwksServer starts an HTTP server publishing the key set. The server is closed when the test completes.
+func (issuer *Issuer) JwksServer(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, _ *http.Request) {
+		writer.Header().Set("Content-Type", "application/json")
+		_, _ = writer.Write(issuer.Jwks())
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// Token signs a token for the subject with the scope claim, valid for an hour. Claims in extraClaims override the defaults.
+func (issuer *Issuer) Token(t *testing.T, subject, scope string, extraClaims map[string]any) string {
+	now := time.Now()
+	claims := jwtlib.MapClaims{
+		"iss":   issuer.Name,
+		"aud":   issuer.Audience,
+		"sub":   subject,
+		"scope": scope,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	}
+	for name, value := range extraClaims {
+		claims[name] = value
+	}
+
+	token := jwtlib.NewWithClaims(jwtlib.SigningMethodRS256, claims)
+	token.Header["kid"] = issuer.KeyId
+	signed, err := token.SignedString(issuer.privateKey)
+	if err != nil {
+		t.Fatalf("Error signing token: %v", err)
+	}
+	return signed
+}
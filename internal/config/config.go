@@ -18,6 +18,13 @@ import (
 // All given configuration files have to be loaded successfully, otherwise an error.
 func Load(configFiles []string) error {
 	cfg := defaultConfig
+	// Files add to maps of the configuration, they mustn't add to maps of the default one.
+	if defaultConfig.RateLimit.Groups != nil {
+		cfg.RateLimit.Groups = make(map[string]RateLimitGroup, len(defaultConfig.RateLimit.Groups))
+		for group, groupCfg := range defaultConfig.RateLimit.Groups {
+			cfg.RateLimit.Groups[group] = groupCfg
+		}
+	}
 
 	for _, configFile := range configFiles {
 		configBytes, err := readFile(configFile)
@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"reflect"
 	"testing"
 )
 
@@ -74,7 +75,7 @@ func TestLoad(t *testing.T) {
 			if !test.expectError && err != nil {
 				t.Errorf("Expected no error but got %v", err)
 			}
-			if result != test.expected {
+			if !reflect.DeepEqual(result, test.expected) {
 				t.Errorf("Expected %#v\nGot %#v", test.expected, result)
 			}
 		})
@@ -5,17 +5,79 @@ var defaultConfig = Config{
 		ShutdownTimeoutSeconds: 30,
 	},
 	HttpServer: HttpServer{
-		Port:                   8080,
-		ShutdownTimeoutSeconds: 30,
+		Port:                      8080,
+		AdminPort:                 8081,
+		ShutdownTimeoutSeconds:    30,
+		IdempotencyKeyTtlSeconds:  24 * 60 * 60, // 24 hours
+		ProgressPeriodSeconds:     5,
+		HealthCheckTimeoutSeconds: 2,
+	},
+	GrpcServer: GrpcServer{
+		Port: 9090,
+	},
+	Auth: Auth{
+		Jwt: Jwt{
+			JwksRefreshSeconds: 5 * 60, // 5 minutes
+			ScopesClaim:        "scope",
+			LeewaySeconds:      30,
+		},
+	},
+	RateLimit: RateLimit{
+		Groups: map[string]RateLimitGroup{
+			"authentication": {RequestsPerSecond: 100, Burst: 200}, // Per IP address, limits guessing credentials
+		},
+	},
+	Database: Database{
+		Driver:                "postgres",
+		StartupTimeoutSeconds: 60,
+		TransactionRetries:    3,
 	},
 	Postgres: Postgres{
-		Port:                  5432,
-		DefaultTimeoutSeconds: 30,
+		Port:                         5432,
+		SslMode:                      "disable",
+		ApplicationName:              "mailman",
+		MaxOpenConnections:           20,
+		MaxIdleConnections:           5,
+		ConnectionMaxLifetimeSeconds: 30 * 60, // 30 minutes
+		ConnectionMaxIdleSeconds:     5 * 60,  // 5 minutes
+		ConnectTimeoutSeconds:        10,
+		DefaultTimeoutSeconds:        30,
+	},
+	Sqlite: Sqlite{
+		Path:              "mailman.db",
+		BusyTimeoutMillis: 5000,
+		MigrateOnStartup:  true,
 	},
 	StaleMailingEntryRemover: StaleMailingEntryRemover{
 		StalenessThresholdSeconds: 5 * 60, // 5 minutes
+		BatchSize:                 1000,
 	},
 	MailingEntryCleanupJob: MailingEntryCleanupJob{
-		PeriodSeconds: 60 * 60, // 1 hour
+		PeriodSeconds:     60 * 60, // 1 hour
+		TimeBudgetSeconds: 5 * 60,  // 5 minutes
+	},
+	MailingEntryArchive: MailingEntryArchive{
+		RetentionSeconds:     365 * 24 * 60 * 60, // 365 days
+		CleanupPeriodSeconds: 60 * 60,            // 1 hour
+		BatchSize:            1000,
+	},
+	MailingEntryPartitioning: MailingEntryPartitioning{
+		Interval:        "daily",
+		PrecreatedCount: 7,
+		PeriodSeconds:   60 * 60, // 1 hour
+	},
+	LeaderElection: LeaderElection{
+		Enabled:            true,
+		CheckPeriodSeconds: 10,
+	},
+	Webhooks: Webhooks{
+		DispatchPeriodSeconds: 5,
+		BatchSize:             50,
+		TimeoutSeconds:        10,
+		MaxAttempts:           8,
+		MinBackoffSeconds:     10,
+		MaxBackoffSeconds:     60 * 60,          // 1 hour
+		RetentionSeconds:      7 * 24 * 60 * 60, // 7 days
+		CleanupPeriodSeconds:  60 * 60,          // 1 hour
 	},
 }
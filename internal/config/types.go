@@ -4,9 +4,20 @@ package config
 type Config struct {
 	Global                   Global                   `json:"global"`
 	HttpServer               HttpServer               `json:"httpServer"`
+	GrpcServer               GrpcServer               `json:"grpcServer"`
+	Auth                     Auth                     `json:"auth"`
+	RateLimit                RateLimit                `json:"rateLimit"`
+	Database                 Database                 `json:"database"`
 	Postgres                 Postgres                 `json:"postgres"`
+	Sqlite                   Sqlite                   `json:"sqlite"`
 	StaleMailingEntryRemover StaleMailingEntryRemover `json:"staleMailingEntryRemover"`
 	MailingEntryCleanupJob   MailingEntryCleanupJob   `json:"mailingEntryCleanupJob"`
+	MailingEntrySender       MailingEntrySender       `json:"mailingEntrySender"`
+	MailingEntryArchive      MailingEntryArchive      `json:"mailingEntryArchive"`
+	MailingEntryPartitioning MailingEntryPartitioning `json:"mailingEntryPartitioning"`
+	LeaderElection           LeaderElection           `json:"leaderElection"`
+	Webhooks                 Webhooks                 `json:"webhooks"`
+	Tenants                  map[string]Tenant        `json:"tenants"` // Per-tenant settings keyed by tenant ID
 }
 
 // Global contains general configuration or configuration for the entire application.
@@ -15,23 +26,139 @@ type Global struct {
 }
 
 type HttpServer struct {
-	Port                   int `json:"port"`                   // Port to listen on
-	ShutdownTimeoutSeconds int `json:"shutdownTimeoutSeconds"` // Graceful shutdown time
+	Port                      int `json:"port"`                      // Port to listen on
+	AdminPort                 int `json:"adminPort"`                 // Port of the admin server publishing metrics, not meant to be exposed to clients
+	ShutdownTimeoutSeconds    int `json:"shutdownTimeoutSeconds"`    // Graceful shutdown time
+	IdempotencyKeyTtlSeconds  int `json:"idempotencyKeyTtlSeconds"`  // How long responses are replayed to requests with the same Idempotency-Key
+	ProgressPeriodSeconds     int `json:"progressPeriodSeconds"`     // How often mailing event streams report progress counters
+	HealthCheckTimeoutSeconds int `json:"healthCheckTimeoutSeconds"` // Maximum time readiness probes wait for dependency checks
+}
+
+type GrpcServer struct {
+	Port int `json:"port"` // Port to listen on
+}
+
+// Auth configures authentication of API requests.
+type Auth struct {
+	Modes   []string `json:"modes"`   // Enabled authentication modes (apiKey, jwt), authentication is disabled if empty
+	ApiKeys []ApiKey `json:"apiKeys"` // Static API keys accepted in apiKey mode
+	Jwt     Jwt      `json:"jwt"`     // Bearer token validation for jwt mode
+}
+
+type ApiKey struct {
+	Name   string   `json:"name"`   // Name of the key's owner, used as the subject in logs
+	Key    string   `json:"key"`    // Secret value sent in the X-API-Key header
+	Tenant string   `json:"tenant"` // Tenant of the key's owner, the default tenant is used if empty
+	Scopes []string `json:"scopes"` // Scopes granted to the key's owner
+}
+
+type Jwt struct {
+	JwksFile           string              `json:"jwksFile"`           // Path to a JSON Web Key Set file used to verify tokens
+	JwksUrl            string              `json:"jwksUrl"`            // URL of a JSON Web Key Set used to verify tokens if JwksFile is empty
+	JwksRefreshSeconds int                 `json:"jwksRefreshSeconds"` // How often the key set is reloaded
+	Issuer             string              `json:"issuer"`             // Required value of the iss claim
+	Audience           string              `json:"audience"`           // Required value in the aud claim
+	ScopesClaim        string              `json:"scopesClaim"`        // Claim holding granted scopes (space-separated string or array)
+	TenantClaim        string              `json:"tenantClaim"`        // Claim holding the tenant ID, the default tenant is used if empty
+	ScopeMapping       map[string][]string `json:"scopeMapping"`       // Maps claim values to mailman scopes, unmapped values are used as is
+	LeewaySeconds      int                 `json:"leewaySeconds"`      // Tolerated clock skew when validating exp and nbf claims
+}
+
+// RateLimit configures limits of API requests per client. Clients are identified by their credentials or, if authentication is disabled,
+// by IP address.
+type RateLimit struct {
+	Groups map[string]RateLimitGroup `json:"groups"` // Limits keyed by route group (authentication, messages, send), groups without limits aren't limited
+}
+
+type RateLimitGroup struct {
+	RequestsPerSecond float64 `json:"requestsPerSecond"` // Rate at which a client's allowance is replenished
+	Burst             int     `json:"burst"`             // Maximum number of requests a client can make at once
+}
+
+type Database struct {
+	Driver                string `json:"driver"`                // DB to use: postgres, sqlite or memory (in-memory DB for local development, data is lost on exit)
+	StartupTimeoutSeconds int    `json:"startupTimeoutSeconds"` // Maximum time to wait for the DB to become reachable on startup
+	TransactionRetries    int    `json:"transactionRetries"`    // Maximum retries of transactions conflicting with concurrent ones
 }
 
 type Postgres struct {
-	Host                  string `json:"host"`                  // DB server host, e.g. my-postgres.com or 10.101.146.170
-	Port                  int    `json:"port"`                  // Port the DB is listening on
-	User                  string `json:"user"`                  // User to use for connecting to the DB
-	Password              string `json:"password"`              // Password authenticating User
-	Database              string `json:"database"`              // Database to use
-	DefaultTimeoutSeconds int    `json:"defaultTimeoutSeconds"` // Default timeout to use for DB operations if none is specified
+	Host                         string `json:"host"`                         // DB server host, e.g. my-postgres.com or 10.101.146.170
+	Port                         int    `json:"port"`                         // Port the DB is listening on
+	User                         string `json:"user"`                         // User to use for connecting to the DB
+	Password                     string `json:"password"`                     // Password authenticating User
+	Database                     string `json:"database"`                     // Database to use
+	SslMode                      string `json:"sslMode"`                      // disable, require, verify-ca or verify-full
+	SslRootCertFile              string `json:"sslRootCertFile"`              // CA certificate verifying the server in verify-ca and verify-full modes
+	SslCertFile                  string `json:"sslCertFile"`                  // Client certificate, for authenticating with a certificate
+	SslKeyFile                   string `json:"sslKeyFile"`                   // Private key of the client certificate
+	ApplicationName              string `json:"applicationName"`              // Name of the connections visible in pg_stat_activity
+	MaxOpenConnections           int    `json:"maxOpenConnections"`           // Maximum number of connections in the pool, 0 means no limit
+	MaxIdleConnections           int    `json:"maxIdleConnections"`           // Maximum number of idle connections kept in the pool
+	ConnectionMaxLifetimeSeconds int    `json:"connectionMaxLifetimeSeconds"` // Time after which connections are replaced, 0 means never
+	ConnectionMaxIdleSeconds     int    `json:"connectionMaxIdleSeconds"`     // Time after which idle connections are closed, 0 means never
+	ConnectTimeoutSeconds        int    `json:"connectTimeoutSeconds"`        // Maximum time to wait for a connection, 0 means no limit
+	StatementTimeoutMillis       int    `json:"statementTimeoutMillis"`       // Server-side limit of statement duration, 0 means no limit
+	DefaultTimeoutSeconds        int    `json:"defaultTimeoutSeconds"`        // Default timeout to use for DB operations if none is specified
+	MigrateOnStartup             bool   `json:"migrateOnStartup"`             // Whether to apply pending schema migrations before starting servers
+}
+
+// Sqlite configures the SQLite DB for single-node installations.
+type Sqlite struct {
+	Path              string `json:"path"`              // Path to the DB file, it's created if it doesn't exist
+	BusyTimeoutMillis int    `json:"busyTimeoutMillis"` // How long to wait for a lock held by another connection before failing
+	MigrateOnStartup  bool   `json:"migrateOnStartup"`  // Whether to apply pending schema migrations before starting servers
 }
 
 type StaleMailingEntryRemover struct {
 	StalenessThresholdSeconds int `json:"stalenessThresholdSeconds"` // Time after which a mailing entry is removed due to old age
+	BatchSize                 int `json:"batchSize"`                 // Maximum number of entries removed in one transaction
 }
 
 type MailingEntryCleanupJob struct {
-	PeriodSeconds int `json:"periodSeconds"` // Period for scheduled cleanup of mailing entries
+	PeriodSeconds     int `json:"periodSeconds"`     // Period for scheduled cleanup of mailing entries
+	TimeBudgetSeconds int `json:"timeBudgetSeconds"` // Time after which a cleanup run stops, remaining entries are removed by the next run
+}
+
+type MailingEntrySender struct {
+	DailySendQuota int `json:"dailySendQuota"` // Maximum number of entries a tenant can send per day (UTC), 0 means no limit
+}
+
+// MailingEntryArchive configures retention of sent, bounced and stale mailing entries moved to the archive.
+type MailingEntryArchive struct {
+	RetentionSeconds     int `json:"retentionSeconds"`     // Time after which archived entries are removed, 0 means they're kept forever
+	CleanupPeriodSeconds int `json:"cleanupPeriodSeconds"` // Period for scheduled removal of expired archived entries
+	BatchSize            int `json:"batchSize"`            // Maximum number of archived entries removed in one transaction
+}
+
+// MailingEntryPartitioning configures partitioning of mailing entries by insert time, which is only supported by Postgres.
+type MailingEntryPartitioning struct {
+	Interval        string `json:"interval"`        // Range of insert times of a partition: daily or weekly (starting on Monday), in UTC
+	PrecreatedCount int    `json:"precreatedCount"` // Number of partitions created ahead, including the current one
+	PeriodSeconds   int    `json:"periodSeconds"`   // Period for scheduled creation and dropping of partitions
+}
+
+// LeaderElection configures running every scheduled job on one replica at a time, which is only supported by Postgres.
+type LeaderElection struct {
+	Enabled            bool `json:"enabled"`            // Whether scheduled jobs only run on the replica holding their lock
+	CheckPeriodSeconds int  `json:"checkPeriodSeconds"` // How often the replica holding a lock checks that it still holds it
+}
+
+// Webhooks configures delivering events to webhook subscriptions.
+type Webhooks struct {
+	DispatchPeriodSeconds int      `json:"dispatchPeriodSeconds"` // How often due deliveries are attempted
+	BatchSize             int      `json:"batchSize"`             // Maximum number of deliveries of a tenant attempted in one dispatch
+	TimeoutSeconds        int      `json:"timeoutSeconds"`        // Timeout of a single delivery attempt
+	MaxAttempts           int      `json:"maxAttempts"`           // Number of attempts after which a delivery is given up
+	MinBackoffSeconds     int      `json:"minBackoffSeconds"`     // Delay before the first retry, doubled with every following one
+	MaxBackoffSeconds     int      `json:"maxBackoffSeconds"`     // Maximum delay between retries
+	RetentionSeconds      int      `json:"retentionSeconds"`      // Time after which events whose deliveries are finished are removed
+	CleanupPeriodSeconds  int      `json:"cleanupPeriodSeconds"`  // Period for scheduled removal of old events
+	AllowedNetworks       []string `json:"allowedNetworks"`       // CIDRs of loopback, private or link-local addresses webhooks can be delivered to
+}
+
+// Tenant contains settings overriding the global ones for a single tenant. Zero values mean the global setting applies.
+type Tenant struct {
+	StalenessThresholdSeconds int `json:"stalenessThresholdSeconds"` // Overrides StaleMailingEntryRemover.StalenessThresholdSeconds
+	DailySendQuota            int `json:"dailySendQuota"`            // Overrides MailingEntrySender.DailySendQuota
+	ArchiveRetentionSeconds   int `json:"archiveRetentionSeconds"`   // Overrides MailingEntryArchive.RetentionSeconds
 }
@@ -0,0 +1,781 @@
+// Package dbtest contains the contract tests every db.Context implementation has to pass.
+package dbtest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/GeneralKenobi/mailman/internal/db"
+	"github.com/GeneralKenobi/mailman/internal/db/model"
+	"sort"
+	"testing"
+	"time"
+)
+
+// RunContractTests runs the contract tests of db.Context and db.Repository. newContext has to return a context of an empty DB, it's
+// called once per test.
+//
+// Operations expected to fail are run last in their transactions because a failed statement aborts a postgres transaction.
+func RunContractTests(t *testing.T, newContext func(t *testing.T) db.Context) {
+	tests := map[string]func(t *testing.T, ctx context.Context, dbCtx db.Context){
+		"Customers":                        testCustomers,
+		"Mailing entries":                  testMailingEntries,
+		"Mailing entry updates":            testMailingEntryUpdates,
+		"Mailing entry deletion":           testMailingEntryDeletion,
+		"Mailing entry archive":            testMailingEntryArchive,
+		"Send quota":                       testSendQuota,
+		"Webhook subscriptions":            testWebhookSubscriptions,
+		"Webhook deliveries":               testWebhookDeliveries,
+		"Webhook event cleanup":            testWebhookEventCleanup,
+		"Transactions":                     testTransactions,
+		"Repository without a transaction": testRepositoryWithoutTransaction,
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			test(t, context.Background(), newContext(t))
+		})
+	}
+}
+
+func testCustomers(t *testing.T, ctx context.Context, dbCtx db.Context) {
+	customer := model.Customer{TenantId: "tenant-1", Email: "jan.kowalski@example.com"}
+	inTransaction(t, ctx, dbCtx, func(repository db.Repository) {
+		inserted, err := repository.InsertCustomer(ctx, customer)
+		expectNoError(t, "insert customer", err)
+		if inserted.Id == 0 {
+			t.Errorf("Expected inserted customer to have an ID")
+		}
+		customer.Id = inserted.Id
+		expectEqual(t, "inserted customer", customer, inserted)
+
+		found, err := repository.FindCustomerById(ctx, customer.TenantId, customer.Id)
+		expectNoError(t, "find customer by ID", err)
+		expectEqual(t, "customer found by ID", customer, found)
+		found, err = repository.FindCustomerByEmail(ctx, customer.TenantId, customer.Email)
+		expectNoError(t, "find customer by email", err)
+		expectEqual(t, "customer found by email", customer, found)
+
+		_, err = repository.FindCustomerById(ctx, "tenant-2", customer.Id)
+		expectError(t, "find customer of another tenant by ID", db.ErrNoRows, err)
+		_, err = repository.FindCustomerByEmail(ctx, "tenant-2", customer.Email)
+		expectError(t, "find customer of another tenant by email", db.ErrNoRows, err)
+
+		_, err = repository.InsertCustomer(ctx, model.Customer{TenantId: "tenant-2", Email: customer.Email})
+		expectNoError(t, "insert customer with the same email in another tenant", err)
+	})
+
+	expectFailureWithError(t, ctx, dbCtx, "insert customer with a duplicate email", db.ErrDuplicate, func(repository db.Repository) error {
+		_, err := repository.InsertCustomer(ctx, customer)
+		return err
+	})
+
+	inTransaction(t, ctx, dbCtx, func(repository db.Repository) {
+		found, err := repository.FindOrInsertCustomer(ctx, model.Customer{TenantId: customer.TenantId, Email: customer.Email})
+		expectNoError(t, "find or insert existing customer", err)
+		expectEqual(t, "existing customer found or inserted", customer, found)
+
+		inserted, err := repository.FindOrInsertCustomer(ctx, model.Customer{TenantId: "tenant-1", Email: "anna.nowak@example.com"})
+		expectNoError(t, "find or insert new customer", err)
+		found, err = repository.FindCustomerByEmail(ctx, "tenant-1", "anna.nowak@example.com")
+		expectNoError(t, "find customer found or inserted", err)
+		expectEqual(t, "new customer found or inserted", found, inserted)
+	})
+	expectFailure(t, ctx, dbCtx, "insert customer without email", func(repository db.Repository) error {
+		_, err := repository.InsertCustomer(ctx, model.Customer{TenantId: "tenant-1"})
+		return err
+	})
+
+	inTransaction(t, ctx, dbCtx, func(repository db.Repository) {
+		expectError(t, "delete customer of another tenant", db.ErrNoRows, repository.DeleteCustomerById(ctx, "tenant-3", customer.Id))
+		expectNoError(t, "delete customer", repository.DeleteCustomerById(ctx, customer.TenantId, customer.Id))
+		_, err := repository.FindCustomerById(ctx, customer.TenantId, customer.Id)
+		expectError(t, "find deleted customer", db.ErrNoRows, err)
+		expectError(t, "delete deleted customer", db.ErrNoRows, repository.DeleteCustomerById(ctx, customer.TenantId, customer.Id))
+	})
+}
+
+func testMailingEntries(t *testing.T, ctx context.Context, dbCtx db.Context) {
+	now := time.Now().UTC().Truncate(time.Second)
+	twoHoursAgo := now.Add(-2 * time.Hour)
+	minuteAgo := now.Add(-time.Minute)
+	customers := insertCustomers(t, ctx, dbCtx, "tenant-1", "tenant-1", "tenant-2")
+	entries := insertMailingEntries(t, ctx, dbCtx,
+		model.MailingEntry{TenantId: "tenant-1", CustomerId: customers[0].Id, MailingId: 1, Title: "title 1", Content: "content", InsertTime: twoHoursAgo},
+		model.MailingEntry{TenantId: "tenant-1", CustomerId: customers[1].Id, MailingId: 1, Title: "title 2", Content: "content", InsertTime: minuteAgo},
+		model.MailingEntry{TenantId: "tenant-1", CustomerId: customers[0].Id, MailingId: 2, Title: "title 3", Content: "content", InsertTime: twoHoursAgo},
+		model.MailingEntry{TenantId: "tenant-2", CustomerId: customers[2].Id, MailingId: 1, Title: "title 4", Content: "content", InsertTime: twoHoursAgo},
+	)
+	for _, entry := range entries {
+		if entry.Id == 0 || entry.Version != 1 {
+			t.Errorf("Expected inserted mailing entry to have an ID and version 1 but got %+v", entry)
+		}
+	}
+
+	inTransaction(t, ctx, dbCtx, func(repository db.Repository) {
+		found, err := repository.FindMailingEntryById(ctx, "tenant-1", entries[0].Id)
+		expectNoError(t, "find mailing entry by ID", err)
+		expectEqual(t, "mailing entry found by ID", entries[0], found)
+		_, err = repository.FindMailingEntryById(ctx, "tenant-2", entries[0].Id)
+		expectError(t, "find mailing entry of another tenant by ID", db.ErrNoRows, err)
+
+		foundEntries, err := repository.FindMailingEntriesByMailingId(ctx, "tenant-1", 1)
+		expectNoError(t, "find mailing entries by mailing ID", err)
+		expectEqual(t, "mailing entries found by mailing ID", entries[:2], sortedMailingEntries(foundEntries))
+
+		foundEntries, err = repository.FindMailingEntriesOlderThan(ctx, "tenant-1", time.Hour)
+		expectNoError(t, "find mailing entries older than", err)
+		expectEqual(t, "mailing entries older than", []model.MailingEntry{entries[0], entries[2]}, sortedMailingEntries(foundEntries))
+
+		foundEntries, err = repository.FindMailingEntriesByMailingIdOlderThan(ctx, "tenant-1", 1, time.Hour)
+		expectNoError(t, "find mailing entries by mailing ID older than", err)
+		expectEqual(t, "mailing entries by mailing ID older than", entries[:1], foundEntries)
+
+		foundEntries, err = repository.FindMailingEntriesByCustomerId(ctx, "tenant-1", customers[0].Id)
+		expectNoError(t, "find mailing entries by customer ID", err)
+		expectEqual(t, "mailing entries found by customer ID", []model.MailingEntry{entries[0], entries[2]}, sortedMailingEntries(foundEntries))
+
+		foundEntries, err = repository.FindMailingEntriesByMailingId(ctx, "tenant-3", 1)
+		expectNoError(t, "find mailing entries of a tenant without entries", err)
+		if len(foundEntries) != 0 {
+			t.Errorf("Expected no mailing entries of a tenant without entries but got %+v", foundEntries)
+		}
+
+		tenantIds, err := repository.FindTenantIdsWithMailingEntries(ctx)
+		expectNoError(t, "find tenant IDs with mailing entries", err)
+		sort.Strings(tenantIds)
+		expectEqual(t, "tenant IDs with mailing entries", []string{"tenant-1", "tenant-2"}, tenantIds)
+
+		mailingId := 1
+		customerId := customers[0].Id
+		hourAgo := now.Add(-time.Hour)
+		counts := map[string]struct {
+			filter   model.MailingEntryFilter
+			expected int
+		}{
+			"mailing ID":                    {filter: model.MailingEntryFilter{MailingId: &mailingId}, expected: 2},
+			"customer ID":                   {filter: model.MailingEntryFilter{CustomerId: &customerId}, expected: 2},
+			"inserted before":               {filter: model.MailingEntryFilter{InsertedBefore: &hourAgo}, expected: 2},
+			"inserted after (inclusive)":    {filter: model.MailingEntryFilter{InsertedAfter: &minuteAgo}, expected: 1},
+			"inserted before (exclusive)":   {filter: model.MailingEntryFilter{InsertedBefore: &twoHoursAgo}, expected: 0},
+			"mailing ID and inserted after": {filter: model.MailingEntryFilter{MailingId: &mailingId, InsertedAfter: &hourAgo}, expected: 1},
+		}
+		for name, count := range counts {
+			actual, err := repository.CountMailingEntries(ctx, "tenant-1", count.filter)
+			expectNoError(t, "count mailing entries by "+name, err)
+			if actual != count.expected {
+				t.Errorf("Expected %d mailing entries by %s but counted %d", count.expected, name, actual)
+			}
+		}
+	})
+
+	expectFailure(t, ctx, dbCtx, "insert mailing entry of a customer of another tenant", func(repository db.Repository) error {
+		_, err := repository.InsertMailingEntry(ctx, model.MailingEntry{
+			TenantId: "tenant-1", CustomerId: customers[2].Id, MailingId: 1, Title: "title", InsertTime: now})
+		return err
+	})
+	expectFailure(t, ctx, dbCtx, "insert mailing entry without title", func(repository db.Repository) error {
+		_, err := repository.InsertMailingEntry(ctx, model.MailingEntry{
+			TenantId: "tenant-1", CustomerId: customers[0].Id, MailingId: 1, InsertTime: now})
+		return err
+	})
+	expectFailure(t, ctx, dbCtx, "delete customer with mailing entries", func(repository db.Repository) error {
+		return repository.DeleteCustomerById(ctx, "tenant-1", customers[0].Id)
+	})
+}
+
+func testMailingEntryUpdates(t *testing.T, ctx context.Context, dbCtx db.Context) {
+	insertTime := time.Date(2022, 3, 30, 15, 42, 38, 0, time.UTC)
+	customers := insertCustomers(t, ctx, dbCtx, "tenant-1", "tenant-1", "tenant-2")
+	entry := insertMailingEntries(t, ctx, dbCtx,
+		model.MailingEntry{TenantId: "tenant-1", CustomerId: customers[0].Id, MailingId: 1, Title: "title", Content: "content", InsertTime: insertTime},
+	)[0]
+
+	inTransaction(t, ctx, dbCtx, func(repository db.Repository) {
+		update := entry
+		update.CustomerId = customers[1].Id
+		update.MailingId = 2
+		update.Title = "new title"
+		update.Content = "new content"
+		updated, err := repository.UpdateMailingEntry(ctx, update)
+		expectNoError(t, "update mailing entry", err)
+		update.Version = 2
+		expectEqual(t, "updated mailing entry", update, updated)
+
+		found, err := repository.FindMailingEntryById(ctx, entry.TenantId, entry.Id)
+		expectNoError(t, "find updated mailing entry", err)
+		expectEqual(t, "updated mailing entry found by ID", update, found)
+
+		_, err = repository.UpdateMailingEntry(ctx, entry)
+		expectError(t, "update outdated version of mailing entry", db.ErrNoRows, err)
+		missing := updated
+		missing.Id = updated.Id + 1000
+		_, err = repository.UpdateMailingEntry(ctx, missing)
+		expectError(t, "update nonexistent mailing entry", db.ErrNoRows, err)
+		otherTenant := updated
+		otherTenant.TenantId = "tenant-2"
+		_, err = repository.UpdateMailingEntry(ctx, otherTenant)
+		expectError(t, "update mailing entry of another tenant", db.ErrNoRows, err)
+	})
+
+	duplicate := model.MailingEntry{TenantId: "tenant-1", CustomerId: customers[1].Id, MailingId: 2, Title: "new title", Content: "new content",
+		InsertTime: insertTime}
+	expectFailureWithError(t, ctx, dbCtx, "insert duplicate mailing entry", db.ErrDuplicate, func(repository db.Repository) error {
+		_, err := repository.InsertMailingEntry(ctx, duplicate)
+		return err
+	})
+	inTransaction(t, ctx, dbCtx, func(repository db.Repository) {
+		// Entries are duplicates only if all of customer, mailing ID, title, content and insert time are equal.
+		variants := []model.MailingEntry{duplicate, duplicate, duplicate, duplicate}
+		variants[0].Title = "title 2"
+		variants[1].Content = "content 2"
+		variants[2].InsertTime = insertTime.Add(time.Second)
+		variants[3].Title, variants[3].Content = "new titlenew", " content" // Same concatenation of title and content
+		for _, variant := range variants {
+			_, err := repository.InsertMailingEntry(ctx, variant)
+			expectNoError(t, "insert mailing entry differing from an existing one", err)
+		}
+	})
+	expectFailureWithError(t, ctx, dbCtx, "update mailing entry to a duplicate of another one", db.ErrDuplicate, func(repository db.Repository) error {
+		other := duplicate
+		other.Title = "other title"
+		other, err := repository.InsertMailingEntry(ctx, other)
+		if err != nil {
+			return err
+		}
+		other.Title = duplicate.Title
+		_, err = repository.UpdateMailingEntry(ctx, other)
+		return err
+	})
+
+	expectFailure(t, ctx, dbCtx, "update mailing entry to a customer of another tenant", func(repository db.Repository) error {
+		update := entry
+		update.Version = 2
+		update.CustomerId = customers[2].Id
+		_, err := repository.UpdateMailingEntry(ctx, update)
+		return err
+	})
+}
+
+func testMailingEntryDeletion(t *testing.T, ctx context.Context, dbCtx db.Context) {
+	insertTime := time.Date(2022, 3, 30, 15, 42, 38, 0, time.UTC)
+	customers := insertCustomers(t, ctx, dbCtx, "tenant-1", "tenant-2")
+	entries := insertMailingEntries(t, ctx, dbCtx,
+		model.MailingEntry{TenantId: "tenant-1", CustomerId: customers[0].Id, MailingId: 1, Title: "title 1", InsertTime: insertTime},
+		model.MailingEntry{TenantId: "tenant-1", CustomerId: customers[0].Id, MailingId: 1, Title: "title 2", InsertTime: insertTime},
+		model.MailingEntry{TenantId: "tenant-1", CustomerId: customers[0].Id, MailingId: 2, Title: "title 3", InsertTime: insertTime},
+		model.MailingEntry{TenantId: "tenant-2", CustomerId: customers[1].Id, MailingId: 1, Title: "title 4", InsertTime: insertTime},
+	)
+
+	inTransaction(t, ctx, dbCtx, func(repository db.Repository) {
+		err := repository.DeleteMailingEntryById(ctx, "tenant-2", entries[2].Id)
+		expectError(t, "delete mailing entry of another tenant", db.ErrNoRows, err)
+		expectNoError(t, "delete mailing entry", repository.DeleteMailingEntryById(ctx, "tenant-1", entries[2].Id))
+		_, err = repository.FindMailingEntryById(ctx, "tenant-1", entries[2].Id)
+		expectError(t, "find deleted mailing entry", db.ErrNoRows, err)
+		err = repository.DeleteMailingEntryById(ctx, "tenant-1", entries[2].Id)
+		expectError(t, "delete deleted mailing entry", db.ErrNoRows, err)
+
+		mailingId := 1
+		deletedCount, err := repository.DeleteMailingEntries(ctx, "tenant-1", model.MailingEntryFilter{MailingId: &mailingId})
+		expectNoError(t, "delete mailing entries", err)
+		if deletedCount != 2 {
+			t.Errorf("Expected 2 deleted mailing entries but got %d", deletedCount)
+		}
+		remaining, err := repository.FindMailingEntriesByMailingId(ctx, "tenant-2", 1)
+		expectNoError(t, "find mailing entries of another tenant", err)
+		expectEqual(t, "mailing entries of another tenant", entries[3:], remaining)
+	})
+}
+
+func testMailingEntryArchive(t *testing.T, ctx context.Context, dbCtx db.Context) {
+	insertTime := time.Date(2022, 3, 30, 15, 42, 38, 0, time.UTC)
+	archiveTime := insertTime.Add(time.Hour)
+	customers := insertCustomers(t, ctx, dbCtx, "tenant-1", "tenant-1", "tenant-2")
+	entries := insertMailingEntries(t, ctx, dbCtx,
+		model.MailingEntry{TenantId: "tenant-1", CustomerId: customers[0].Id, MailingId: 1, Title: "title 1", Content: "content", InsertTime: insertTime},
+		model.MailingEntry{TenantId: "tenant-1", CustomerId: customers[0].Id, MailingId: 1, Title: "title 2", Content: "content", InsertTime: insertTime},
+		model.MailingEntry{TenantId: "tenant-1", CustomerId: customers[1].Id, MailingId: 1, Title: "title 3", Content: "content", InsertTime: insertTime},
+		model.MailingEntry{TenantId: "tenant-1", CustomerId: customers[1].Id, MailingId: 2, Title: "title 4", Content: "content", InsertTime: insertTime},
+		model.MailingEntry{TenantId: "tenant-2", CustomerId: customers[2].Id, MailingId: 1, Title: "title 5", Content: "content", InsertTime: insertTime},
+	)
+	archived := func(entry model.MailingEntry, customer model.Customer, reason model.ArchiveReason, archiveTime time.Time) model.ArchivedMailingEntry {
+		return model.ArchivedMailingEntry{Id: entry.Id, TenantId: entry.TenantId, CustomerId: customer.Id, CustomerEmail: customer.Email,
+			MailingId: entry.MailingId, Title: entry.Title, Content: entry.Content, InsertTime: entry.InsertTime, ArchiveTime: archiveTime, Reason: reason}
+	}
+	archivedEntries := []model.ArchivedMailingEntry{
+		archived(entries[2], customers[1], model.ArchiveReasonStale, archiveTime.Add(2*time.Hour)),
+		archived(entries[1], customers[0], model.ArchiveReasonStale, archiveTime.Add(time.Hour)),
+		archived(entries[0], customers[0], model.ArchiveReasonSent, archiveTime),
+	}
+
+	inTransaction(t, ctx, dbCtx, func(repository db.Repository) {
+		err := repository.ArchiveMailingEntryById(ctx, "tenant-2", entries[0].Id, model.ArchiveReasonSent, archiveTime)
+		expectError(t, "archive mailing entry of another tenant", db.ErrNoRows, err)
+		err = repository.ArchiveMailingEntryById(ctx, "tenant-1", entries[0].Id, model.ArchiveReasonSent, archiveTime)
+		expectNoError(t, "archive mailing entry", err)
+		_, err = repository.FindMailingEntryById(ctx, "tenant-1", entries[0].Id)
+		expectError(t, "find archived mailing entry", db.ErrNoRows, err)
+		err = repository.ArchiveMailingEntryById(ctx, "tenant-1", entries[0].Id, model.ArchiveReasonSent, archiveTime)
+		expectError(t, "archive archived mailing entry", db.ErrNoRows, err)
+
+		mailingId := 1
+		for i, limit := range []int{1, 10} {
+			archivedCount, err := repository.ArchiveMailingEntryBatch(ctx, "tenant-1", model.MailingEntryFilter{MailingId: &mailingId}, limit,
+				model.ArchiveReasonStale, archiveTime.Add(time.Duration(i+1)*time.Hour))
+			expectNoError(t, "archive mailing entry batch", err)
+			if archivedCount != 1 {
+				t.Errorf("Expected 1 archived mailing entry in batch %d but got %d", i, archivedCount)
+			}
+		}
+		remaining, err := repository.FindMailingEntriesByMailingId(ctx, "tenant-1", 1)
+		expectNoError(t, "find mailing entries remaining after archiving", err)
+		expectEqual(t, "mailing entries remaining after archiving", []model.MailingEntry(nil), remaining)
+
+		tenantIds, err := repository.FindTenantIdsWithArchivedMailingEntries(ctx)
+		expectNoError(t, "find tenant IDs with archived mailing entries", err)
+		expectEqual(t, "tenant IDs with archived mailing entries", []string{"tenant-1"}, tenantIds)
+	})
+
+	// Archived entries outlive their customers.
+	inTransaction(t, ctx, dbCtx, func(repository db.Repository) {
+		expectNoError(t, "delete customer of archived mailing entries", repository.DeleteCustomerById(ctx, "tenant-1", customers[0].Id))
+	})
+
+	inTransaction(t, ctx, dbCtx, func(repository db.Repository) {
+		email := customers[0].Email
+		otherMailingId := 2
+		archivedAfter, archivedBefore := archiveTime.Add(time.Hour), archiveTime.Add(2*time.Hour)
+		queries := []struct {
+			name     string
+			filter   model.ArchivedMailingEntryFilter
+			limit    int
+			expected []model.ArchivedMailingEntry
+		}{
+			{name: "all archived mailing entries", limit: 10, expected: archivedEntries},
+			{name: "most recently archived mailing entry", limit: 1, expected: archivedEntries[:1]},
+			{name: "archived mailing entries by email", filter: model.ArchivedMailingEntryFilter{CustomerEmail: &email}, limit: 10,
+				expected: archivedEntries[1:]},
+			{name: "archived mailing entries by archive time", limit: 10,
+				filter: model.ArchivedMailingEntryFilter{ArchivedAfter: &archivedAfter, ArchivedBefore: &archivedBefore}, expected: archivedEntries[1:2]},
+			{name: "archived mailing entries by mailing ID", filter: model.ArchivedMailingEntryFilter{MailingId: &otherMailingId}, limit: 10},
+		}
+		for _, query := range queries {
+			found, err := repository.FindArchivedMailingEntries(ctx, "tenant-1", query.filter, query.limit)
+			expectNoError(t, "find "+query.name, err)
+			expectEqual(t, query.name, query.expected, found)
+		}
+		found, err := repository.FindArchivedMailingEntries(ctx, "tenant-2", model.ArchivedMailingEntryFilter{}, 10)
+		expectNoError(t, "find archived mailing entries of another tenant", err)
+		expectEqual(t, "archived mailing entries of another tenant", []model.ArchivedMailingEntry(nil), found)
+	})
+
+	inTransaction(t, ctx, dbCtx, func(repository db.Repository) {
+		for i, limit := range []int{1, 10} {
+			deletedCount, err := repository.DeleteArchivedMailingEntryBatch(ctx, "tenant-1", archiveTime.Add(2*time.Hour), limit)
+			expectNoError(t, "delete archived mailing entry batch", err)
+			if deletedCount != 1 {
+				t.Errorf("Expected 1 deleted archived mailing entry in batch %d but got %d", i, deletedCount)
+			}
+		}
+		found, err := repository.FindArchivedMailingEntries(ctx, "tenant-1", model.ArchivedMailingEntryFilter{}, 10)
+		expectNoError(t, "find archived mailing entries remaining after deletion", err)
+		expectEqual(t, "archived mailing entries remaining after deletion", archivedEntries[:1], found)
+	})
+}
+
+func testSendQuota(t *testing.T, ctx context.Context, dbCtx db.Context) {
+	day := time.Date(2022, 3, 30, 8, 0, 0, 0, time.UTC)
+	increments := []struct {
+		tenantId string
+		day      time.Time
+		count    int
+		expected int
+	}{
+		{tenantId: "tenant-1", day: day, count: 2, expected: 2},
+		{tenantId: "tenant-1", day: day.Add(10 * time.Hour), count: 3, expected: 5},
+		{tenantId: "tenant-1", day: day.AddDate(0, 0, 1), count: 1, expected: 1},
+		{tenantId: "tenant-2", day: day, count: 4, expected: 4},
+	}
+
+	inTransaction(t, ctx, dbCtx, func(repository db.Repository) {
+		for _, increment := range increments {
+			sentCount, err := repository.IncrementDailySendCount(ctx, increment.tenantId, increment.day, increment.count)
+			expectNoError(t, "increment daily send count", err)
+			if sentCount != increment.expected {
+				t.Errorf("Expected %d sent by %s on %v but got %d", increment.expected, increment.tenantId, increment.day, sentCount)
+			}
+		}
+	})
+}
+
+func testWebhookSubscriptions(t *testing.T, ctx context.Context, dbCtx db.Context) {
+	createTime := time.Date(2022, 3, 30, 15, 42, 38, 123456000, time.UTC)
+	subscriptions := []model.WebhookSubscription{
+		{TenantId: "tenant-1", Url: "https://example.com/1", EventTypes: []string{"mailing.completed"}, Secret: "secret", CreateTime: createTime},
+		{TenantId: "tenant-1", Url: "https://example.com/2", EventTypes: []string{"mailing.completed", "mailing_entry.sent"}, Secret: "secret", CreateTime: createTime},
+		{TenantId: "tenant-2", Url: "https://example.com/3", EventTypes: []string{"mailing.completed"}, Secret: "secret", CreateTime: createTime},
+	}
+
+	inTransaction(t, ctx, dbCtx, func(repository db.Repository) {
+		for i, subscription := range subscriptions {
+			inserted, err := repository.InsertWebhookSubscription(ctx, subscription)
+			expectNoError(t, "insert webhook subscription", err)
+			subscriptions[i].Id = inserted.Id
+			expectEqual(t, "inserted webhook subscription", subscriptions[i], inserted)
+		}
+
+		found, err := repository.FindWebhookSubscriptionById(ctx, "tenant-1", subscriptions[1].Id)
+		expectNoError(t, "find webhook subscription by ID", err)
+		expectEqual(t, "webhook subscription found by ID", subscriptions[1], found)
+		_, err = repository.FindWebhookSubscriptionById(ctx, "tenant-2", subscriptions[1].Id)
+		expectError(t, "find webhook subscription of another tenant by ID", db.ErrNoRows, err)
+
+		foundSubscriptions, err := repository.FindWebhookSubscriptions(ctx, "tenant-1")
+		expectNoError(t, "find webhook subscriptions", err)
+		expectEqual(t, "webhook subscriptions", subscriptions[:2], foundSubscriptions)
+
+		err = repository.DeleteWebhookSubscriptionById(ctx, "tenant-2", subscriptions[0].Id)
+		expectError(t, "delete webhook subscription of another tenant", db.ErrNoRows, err)
+		expectNoError(t, "delete webhook subscription", repository.DeleteWebhookSubscriptionById(ctx, "tenant-1", subscriptions[0].Id))
+		err = repository.DeleteWebhookSubscriptionById(ctx, "tenant-1", subscriptions[0].Id)
+		expectError(t, "delete deleted webhook subscription", db.ErrNoRows, err)
+	})
+
+	expectFailure(t, ctx, dbCtx, "insert webhook subscription without URL", func(repository db.Repository) error {
+		_, err := repository.InsertWebhookSubscription(ctx, model.WebhookSubscription{
+			TenantId: "tenant-1", EventTypes: []string{"mailing.completed"}, Secret: "secret", CreateTime: createTime})
+		return err
+	})
+}
+
+func testWebhookDeliveries(t *testing.T, ctx context.Context, dbCtx db.Context) {
+	now := time.Date(2022, 3, 30, 15, 42, 38, 0, time.UTC)
+	subscription, event := insertWebhookSubscriptionAndEvent(t, ctx, dbCtx, "tenant-1", now)
+	lastAttemptTime := now.Add(-time.Minute)
+	responseCode := 500
+	lastError := "server error"
+	deliveries := []model.WebhookDelivery{
+		{Status: model.WebhookDeliveryStatusPending, AttemptCount: 0, NextAttemptTime: now.Add(-time.Minute)},
+		{Status: model.WebhookDeliveryStatusPending, AttemptCount: 1, NextAttemptTime: now.Add(-2 * time.Minute),
+			LastAttemptTime: &lastAttemptTime, LastResponseCode: &responseCode, LastError: &lastError},
+		{Status: model.WebhookDeliveryStatusPending, AttemptCount: 0, NextAttemptTime: now.Add(time.Hour)},
+		{Status: model.WebhookDeliveryStatusFailed, AttemptCount: 8, NextAttemptTime: now.Add(-time.Hour),
+			LastAttemptTime: &lastAttemptTime, LastError: &lastError},
+	}
+
+	inTransaction(t, ctx, dbCtx, func(repository db.Repository) {
+		for i, delivery := range deliveries {
+			delivery.TenantId = "tenant-1"
+			delivery.SubscriptionId = subscription.Id
+			delivery.EventId = event.Id
+			inserted, err := repository.InsertWebhookDelivery(ctx, delivery)
+			expectNoError(t, "insert webhook delivery", err)
+			delivery.Id = inserted.Id
+			expectEqual(t, "inserted webhook delivery", delivery, inserted)
+			deliveries[i] = delivery
+		}
+
+		due, err := repository.FindDueWebhookDeliveriesForUpdate(ctx, "tenant-1", now, 10)
+		expectNoError(t, "find due webhook deliveries", err)
+		expectEqual(t, "due webhook deliveries, oldest first", []model.WebhookDelivery{deliveries[1], deliveries[0]}, due)
+		due, err = repository.FindDueWebhookDeliveriesForUpdate(ctx, "tenant-1", now, 1)
+		expectNoError(t, "find limited due webhook deliveries", err)
+		expectEqual(t, "limited due webhook deliveries", deliveries[1:2], due)
+		due, err = repository.FindDueWebhookDeliveriesForUpdate(ctx, "tenant-2", now, 10)
+		expectNoError(t, "find due webhook deliveries of another tenant", err)
+		if len(due) != 0 {
+			t.Errorf("Expected no due webhook deliveries of another tenant but got %+v", due)
+		}
+
+		tenantIds, err := repository.FindTenantIdsWithDueWebhookDeliveries(ctx, now)
+		expectNoError(t, "find tenant IDs with due webhook deliveries", err)
+		expectEqual(t, "tenant IDs with due webhook deliveries", []string{"tenant-1"}, tenantIds)
+		tenantIds, err = repository.FindTenantIdsWithDueWebhookDeliveries(ctx, now.Add(-3*time.Minute))
+		expectNoError(t, "find tenant IDs with webhook deliveries due earlier", err)
+		if len(tenantIds) != 0 {
+			t.Errorf("Expected no tenants with webhook deliveries due earlier but got %v", tenantIds)
+		}
+
+		recent, err := repository.FindWebhookDeliveriesBySubscriptionId(ctx, "tenant-1", subscription.Id, 3)
+		expectNoError(t, "find webhook deliveries by subscription ID", err)
+		expectEqual(t, "webhook deliveries by subscription ID, newest first",
+			[]model.WebhookDelivery{deliveries[3], deliveries[2], deliveries[1]}, recent)
+
+		succeeded := deliveries[0]
+		succeeded.Status = model.WebhookDeliveryStatusSucceeded
+		succeeded.AttemptCount = 1
+		succeeded.LastAttemptTime = &now
+		expectNoError(t, "update webhook delivery", repository.UpdateWebhookDelivery(ctx, succeeded))
+		due, err = repository.FindDueWebhookDeliveriesForUpdate(ctx, "tenant-1", now, 10)
+		expectNoError(t, "find due webhook deliveries after update", err)
+		expectEqual(t, "due webhook deliveries after update", deliveries[1:2], due)
+		recent, err = repository.FindWebhookDeliveriesBySubscriptionId(ctx, "tenant-1", subscription.Id, 10)
+		expectNoError(t, "find updated webhook delivery", err)
+		expectEqual(t, "updated webhook delivery", succeeded, recent[len(recent)-1])
+
+		missing := succeeded
+		missing.Id = succeeded.Id + 1000
+		expectError(t, "update nonexistent webhook delivery", db.ErrNoRows, repository.UpdateWebhookDelivery(ctx, missing))
+
+		expectNoError(t, "delete webhook subscription", repository.DeleteWebhookSubscriptionById(ctx, "tenant-1", subscription.Id))
+		recent, err = repository.FindWebhookDeliveriesBySubscriptionId(ctx, "tenant-1", subscription.Id, 10)
+		expectNoError(t, "find webhook deliveries of deleted subscription", err)
+		if len(recent) != 0 {
+			t.Errorf("Expected deliveries to be deleted with their subscription but got %+v", recent)
+		}
+	})
+
+	expectFailure(t, ctx, dbCtx, "insert webhook delivery of a nonexistent subscription", func(repository db.Repository) error {
+		_, err := repository.InsertWebhookDelivery(ctx, model.WebhookDelivery{TenantId: "tenant-1", SubscriptionId: subscription.Id,
+			EventId: event.Id, Status: model.WebhookDeliveryStatusPending, NextAttemptTime: now})
+		return err
+	})
+}
+
+func testWebhookEventCleanup(t *testing.T, ctx context.Context, dbCtx db.Context) {
+	now := time.Date(2022, 3, 30, 15, 42, 38, 0, time.UTC)
+	subscription, pendingEvent := insertWebhookSubscriptionAndEvent(t, ctx, dbCtx, "tenant-1", now.Add(-2*time.Hour))
+	_, otherTenantEvent := insertWebhookSubscriptionAndEvent(t, ctx, dbCtx, "tenant-2", now.Add(-2*time.Hour))
+
+	inTransaction(t, ctx, dbCtx, func(repository db.Repository) {
+		found, err := repository.FindWebhookEventById(ctx, "tenant-1", pendingEvent.Id)
+		expectNoError(t, "find webhook event by ID", err)
+		expectEqual(t, "webhook event found by ID", pendingEvent, found)
+		_, err = repository.FindWebhookEventById(ctx, "tenant-2", pendingEvent.Id)
+		expectError(t, "find webhook event of another tenant by ID", db.ErrNoRows, err)
+
+		var events []model.WebhookEvent
+		for _, createTime := range []time.Time{now.Add(-2 * time.Hour), now} {
+			event, err := repository.InsertWebhookEvent(ctx,
+				model.WebhookEvent{TenantId: "tenant-1", EventType: "mailing.completed", Payload: "{}", CreateTime: createTime})
+			expectNoError(t, "insert webhook event", err)
+			events = append(events, event)
+		}
+		_, err = repository.InsertWebhookDelivery(ctx, model.WebhookDelivery{TenantId: "tenant-1", SubscriptionId: subscription.Id,
+			EventId: pendingEvent.Id, Status: model.WebhookDeliveryStatusPending, NextAttemptTime: now})
+		expectNoError(t, "insert pending webhook delivery", err)
+		finishedDelivery, err := repository.InsertWebhookDelivery(ctx, model.WebhookDelivery{TenantId: "tenant-1",
+			SubscriptionId: subscription.Id, EventId: events[0].Id, Status: model.WebhookDeliveryStatusSucceeded, NextAttemptTime: now})
+		expectNoError(t, "insert finished webhook delivery", err)
+
+		tenantIds, err := repository.FindTenantIdsWithWebhookEvents(ctx)
+		expectNoError(t, "find tenant IDs with webhook events", err)
+		sort.Strings(tenantIds)
+		expectEqual(t, "tenant IDs with webhook events", []string{"tenant-1", "tenant-2"}, tenantIds)
+
+		deletedCount, err := repository.DeleteFinishedWebhookEventsCreatedBefore(ctx, "tenant-1", now.Add(-time.Hour))
+		expectNoError(t, "delete finished webhook events", err)
+		if deletedCount != 1 {
+			t.Errorf("Expected 1 deleted webhook event but got %d", deletedCount)
+		}
+		_, err = repository.FindWebhookEventById(ctx, "tenant-1", events[0].Id)
+		expectError(t, "find deleted finished webhook event", db.ErrNoRows, err)
+		deliveries, err := repository.FindWebhookDeliveriesBySubscriptionId(ctx, "tenant-1", subscription.Id, 10)
+		expectNoError(t, "find webhook deliveries after cleanup", err)
+		for _, delivery := range deliveries {
+			if delivery.Id == finishedDelivery.Id {
+				t.Errorf("Expected delivery %d to be deleted with its event", delivery.Id)
+			}
+		}
+		for _, kept := range []model.WebhookEvent{pendingEvent, events[1]} {
+			_, err = repository.FindWebhookEventById(ctx, "tenant-1", kept.Id)
+			expectNoError(t, "find kept webhook event", err)
+		}
+		_, err = repository.FindWebhookEventById(ctx, "tenant-2", otherTenantEvent.Id)
+		expectNoError(t, "find webhook event of another tenant", err)
+	})
+}
+
+func testTransactions(t *testing.T, ctx context.Context, dbCtx db.Context) {
+	insert := func(repository db.Repository, email string) {
+		_, err := repository.InsertCustomer(ctx, model.Customer{TenantId: "tenant-1", Email: email})
+		expectNoError(t, "insert customer", err)
+	}
+
+	inserted, err := db.InTransactionRetV(ctx, dbCtx, func(repository db.Repository) (model.Customer, error) {
+		return repository.InsertCustomer(ctx, model.Customer{TenantId: "tenant-1", Email: "committed@example.com"})
+	})
+	expectNoError(t, "commit transaction", err)
+	if inserted.Email != "committed@example.com" {
+		t.Errorf("Expected the transaction's result but got %+v", inserted)
+	}
+
+	rollbackErr := errors.New("rollback")
+	err = db.InTransaction(ctx, dbCtx, func(repository db.Repository) error {
+		insert(repository, "rolled.back@example.com")
+		return rollbackErr
+	})
+	expectError(t, "roll back transaction", rollbackErr, err)
+
+	panicValue := func() (recovered any) {
+		defer func() {
+			recovered = recover()
+		}()
+		_ = db.InTransaction(ctx, dbCtx, func(repository db.Repository) error {
+			insert(repository, "panicked@example.com")
+			panic("panic in transaction")
+		})
+		return nil
+	}()
+	if panicValue != "panic in transaction" {
+		t.Errorf("Expected the panic to be propagated but got %v", panicValue)
+	}
+
+	inTransaction(t, ctx, dbCtx, func(repository db.Repository) {
+		_, err := repository.FindCustomerByEmail(ctx, "tenant-1", "committed@example.com")
+		expectNoError(t, "find customer inserted in committed transaction", err)
+		_, err = repository.FindCustomerByEmail(ctx, "tenant-1", "rolled.back@example.com")
+		expectError(t, "find customer inserted in rolled back transaction", db.ErrNoRows, err)
+		_, err = repository.FindCustomerByEmail(ctx, "tenant-1", "panicked@example.com")
+		expectError(t, "find customer inserted in panicked transaction", db.ErrNoRows, err)
+	})
+
+	err = db.InTransaction(ctx, dbCtx, func(repository db.Repository) error {
+		insert(repository, "outer@example.com")
+		err := db.InTransaction(ctx, db.Nested(repository), func(nestedRepository db.Repository) error {
+			insert(nestedRepository, "nested.committed@example.com")
+			return nil
+		})
+		expectNoError(t, "commit nested transaction", err)
+		err = db.InTransaction(ctx, db.Nested(repository), func(nestedRepository db.Repository) error {
+			insert(nestedRepository, "nested.rolled.back@example.com")
+			return rollbackErr
+		})
+		expectError(t, "roll back nested transaction", rollbackErr, err)
+		return nil
+	})
+	expectNoError(t, "commit transaction with nested transactions", err)
+
+	inTransaction(t, ctx, dbCtx, func(repository db.Repository) {
+		for _, email := range []string{"outer@example.com", "nested.committed@example.com"} {
+			_, err := repository.FindCustomerByEmail(ctx, "tenant-1", email)
+			expectNoError(t, "find customer inserted in committed transaction", err)
+		}
+		_, err := repository.FindCustomerByEmail(ctx, "tenant-1", "nested.rolled.back@example.com")
+		expectError(t, "find customer inserted in rolled back nested transaction", db.ErrNoRows, err)
+	})
+}
+
+func testRepositoryWithoutTransaction(t *testing.T, ctx context.Context, dbCtx db.Context) {
+	repository, err := dbCtx.Repository(ctx)
+	expectNoError(t, "create repository", err)
+	customer, err := repository.InsertCustomer(ctx, model.Customer{TenantId: "tenant-1", Email: "jan.kowalski@example.com"})
+	expectNoError(t, "insert customer without a transaction", err)
+
+	inTransaction(t, ctx, dbCtx, func(transactionalRepository db.Repository) {
+		found, err := transactionalRepository.FindCustomerById(ctx, customer.TenantId, customer.Id)
+		expectNoError(t, "find customer inserted without a transaction", err)
+		expectEqual(t, "customer inserted without a transaction", customer, found)
+	})
+}
+
+func insertCustomers(t *testing.T, ctx context.Context, dbCtx db.Context, tenantIds ...string) []model.Customer {
+	t.Helper()
+	var customers []model.Customer
+	inTransaction(t, ctx, dbCtx, func(repository db.Repository) {
+		for i, tenantId := range tenantIds {
+			customer, err := repository.InsertCustomer(ctx, model.Customer{TenantId: tenantId, Email: "customer" + string(rune('a'+i)) + "@example.com"})
+			expectNoError(t, "insert customer", err)
+			customers = append(customers, customer)
+		}
+	})
+	return customers
+}
+
+func insertMailingEntries(t *testing.T, ctx context.Context, dbCtx db.Context, entries ...model.MailingEntry) []model.MailingEntry {
+	t.Helper()
+	var inserted []model.MailingEntry
+	inTransaction(t, ctx, dbCtx, func(repository db.Repository) {
+		for _, entry := range entries {
+			insertedEntry, err := repository.InsertMailingEntry(ctx, entry)
+			expectNoError(t, "insert mailing entry", err)
+			entry.Id = insertedEntry.Id
+			entry.Version = 1
+			expectEqual(t, "inserted mailing entry", entry, insertedEntry)
+			inserted = append(inserted, insertedEntry)
+		}
+	})
+	return inserted
+}
+
+func insertWebhookSubscriptionAndEvent(
+	t *testing.T, ctx context.Context, dbCtx db.Context, tenantId string, createTime time.Time) (model.WebhookSubscription, model.WebhookEvent) {
+	t.Helper()
+	var subscription model.WebhookSubscription
+	var event model.WebhookEvent
+	inTransaction(t, ctx, dbCtx, func(repository db.Repository) {
+		var err error
+		subscription, err = repository.InsertWebhookSubscription(ctx, model.WebhookSubscription{
+			TenantId: tenantId, Url: "https://example.com", EventTypes: []string{"mailing.completed"}, Secret: "secret", CreateTime: createTime})
+		expectNoError(t, "insert webhook subscription", err)
+		event = model.WebhookEvent{TenantId: tenantId, EventType: "mailing.completed", Payload: `{"mailing_id":1}`, CreateTime: createTime}
+		inserted, err := repository.InsertWebhookEvent(ctx, event)
+		expectNoError(t, "insert webhook event", err)
+		event.Id = inserted.Id
+		expectEqual(t, "inserted webhook event", event, inserted)
+	})
+	return subscription, event
+}
+
+// inTransaction runs todo in a transaction that's committed if the test didn't fail.
+func inTransaction(t *testing.T, ctx context.Context, dbCtx db.Context, todo func(repository db.Repository)) {
+	t.Helper()
+	err := db.InTransaction(ctx, dbCtx, func(repository db.Repository) error {
+		todo(repository)
+		if t.Failed() {
+			return errors.New("test failed")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Transaction failed: %v", err)
+	}
+}
+
+// expectFailure runs todo in a transaction and expects it to fail, e.g. because it violates a constraint.
+func expectFailure(t *testing.T, ctx context.Context, dbCtx db.Context, operation string, todo func(repository db.Repository) error) {
+	t.Helper()
+	if err := db.InTransaction(ctx, dbCtx, todo); err == nil {
+		t.Errorf("Expected %s to fail but it succeeded", operation)
+	}
+}
+
+func expectFailureWithError(t *testing.T, ctx context.Context, dbCtx db.Context, operation string, expected error,
+	todo func(repository db.Repository) error) {
+
+	t.Helper()
+	expectError(t, operation, expected, db.InTransaction(ctx, dbCtx, todo))
+}
+
+func expectNoError(t *testing.T, operation string, err error) {
+	t.Helper()
+	if err != nil {
+		t.Errorf("Expected %s to succeed but got %v", operation, err)
+	}
+}
+
+func expectError(t *testing.T, operation string, expected, err error) {
+	t.Helper()
+	if !errors.Is(err, expected) {
+		t.Errorf("Expected %s to fail with %q but got %v", operation, expected, err)
+	}
+}
+
+// expectEqual compares JSON of the values, so that equal times in different locations are equal.
+func expectEqual(t *testing.T, what string, expected, actual any) {
+	t.Helper()
+	expectedJson, _ := json.Marshal(expected)
+	actualJson, _ := json.Marshal(actual)
+	if string(expectedJson) != string(actualJson) {
+		t.Errorf("Unexpected %s\nExpected: %s\nGot:      %s", what, expectedJson, actualJson)
+	}
+}
+
+// sortedMailingEntries sorts mailing entries returned in an unspecified order by ID.
+func sortedMailingEntries(entries []model.MailingEntry) []model.MailingEntry {
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Id < entries[j].Id
+	})
+	return entries
+}
@@ -0,0 +1,117 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"github.com/GeneralKenobi/mailman/internal/db"
+	"github.com/GeneralKenobi/mailman/internal/db/model"
+	"sort"
+	"time"
+)
+
+func (repo *repository) FindArchivedMailingEntries(
+	_ context.Context, tenantId string, archiveFilter model.ArchivedMailingEntryFilter, limit int) ([]model.ArchivedMailingEntry, error) {
+
+	defer repo.lock()()
+	found := filter(repo.data.archive, func(archivedEntry model.ArchivedMailingEntry) bool {
+		return archivedEntry.TenantId == tenantId &&
+			(archiveFilter.MailingId == nil || archivedEntry.MailingId == *archiveFilter.MailingId) &&
+			(archiveFilter.CustomerEmail == nil || archivedEntry.CustomerEmail == *archiveFilter.CustomerEmail) &&
+			(archiveFilter.ArchivedAfter == nil || !archivedEntry.ArchiveTime.Before(*archiveFilter.ArchivedAfter)) &&
+			(archiveFilter.ArchivedBefore == nil || archivedEntry.ArchiveTime.Before(*archiveFilter.ArchivedBefore))
+	})
+	sort.SliceStable(found, func(i, j int) bool {
+		if !found[i].ArchiveTime.Equal(found[j].ArchiveTime) {
+			return found[i].ArchiveTime.After(found[j].ArchiveTime)
+		}
+		return found[i].Id > found[j].Id
+	})
+	if len(found) > limit {
+		found = found[:limit]
+	}
+	return found, nil
+}
+
+func (repo *repository) ArchiveMailingEntryById(_ context.Context, tenantId string, id int, reason model.ArchiveReason, archiveTime time.Time) error {
+	defer repo.lock()()
+	archivedCount := repo.archiveMailingEntries(func(entry model.MailingEntry) bool {
+		return entry.TenantId == tenantId && entry.Id == id
+	}, 1, reason, archiveTime)
+	if archivedCount == 0 {
+		return fmt.Errorf("archive mailing entry by ID: %w", db.ErrNoRows)
+	}
+	return nil
+}
+
+func (repo *repository) ArchiveMailingEntryBatch(_ context.Context, tenantId string, entryFilter model.MailingEntryFilter, limit int,
+	reason model.ArchiveReason, archiveTime time.Time) (int64, error) {
+
+	defer repo.lock()()
+	archivedCount := repo.archiveMailingEntries(func(entry model.MailingEntry) bool {
+		return entry.TenantId == tenantId && matchesFilter(entry, entryFilter)
+	}, limit, reason, archiveTime)
+	return int64(archivedCount), nil
+}
+
+// archiveMailingEntries moves up to limit mailing entries matching the function, lowest IDs first, to the archive and returns how many
+// were moved.
+func (repo *repository) archiveMailingEntries(matches func(entry model.MailingEntry) bool, limit int, reason model.ArchiveReason,
+	archiveTime time.Time) int {
+
+	var archivedCount int
+	repo.data.mailingEntries = filter(repo.data.mailingEntries, func(entry model.MailingEntry) bool {
+		// Entries are kept in the order of their IDs.
+		if archivedCount >= limit || !matches(entry) {
+			return true
+		}
+		var customerEmail string
+		for _, customer := range repo.data.customers {
+			if customer.Id == entry.CustomerId {
+				customerEmail = customer.Email
+			}
+		}
+		repo.data.archive = append(repo.data.archive, model.ArchivedMailingEntry{
+			Id:            entry.Id,
+			TenantId:      entry.TenantId,
+			CustomerId:    entry.CustomerId,
+			CustomerEmail: customerEmail,
+			MailingId:     entry.MailingId,
+			Title:         entry.Title,
+			Content:       entry.Content,
+			InsertTime:    entry.InsertTime,
+			ArchiveTime:   archiveTime,
+			Reason:        reason,
+		})
+		archivedCount++
+		return false
+	})
+	if archivedCount > 0 {
+		repo.modified()
+	}
+	return archivedCount
+}
+
+func (repo *repository) DeleteArchivedMailingEntryBatch(_ context.Context, tenantId string, archivedBefore time.Time, limit int) (int64, error) {
+	defer repo.lock()()
+	expired := filter(repo.data.archive, func(archivedEntry model.ArchivedMailingEntry) bool {
+		return archivedEntry.TenantId == tenantId && archivedEntry.ArchiveTime.Before(archivedBefore)
+	})
+	sort.SliceStable(expired, func(i, j int) bool {
+		return expired[i].ArchiveTime.Before(expired[j].ArchiveTime)
+	})
+	if len(expired) > limit {
+		expired = expired[:limit]
+	}
+
+	deletedIds := make(map[int]bool, len(expired))
+	for _, archivedEntry := range expired {
+		deletedIds[archivedEntry.Id] = true
+	}
+	repo.data.archive = filter(repo.data.archive, func(archivedEntry model.ArchivedMailingEntry) bool {
+		return !deletedIds[archivedEntry.Id]
+	})
+	if len(deletedIds) > 0 {
+		repo.modified()
+	}
+	return int64(len(deletedIds)), nil
+}
@@ -0,0 +1,150 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/GeneralKenobi/mailman/internal/db"
+	"github.com/GeneralKenobi/mailman/internal/db/model"
+	"sync"
+)
+
+// Context is an in-memory db.Context for tests and local development. Data is lost when the process exits.
+//
+// Each transaction works on a snapshot of the data taken when it begins, which replaces the data on commit. Transactions are
+// serializable: committing a transaction that changed data fails with db.ErrSerializationFailure if another transaction changing data
+// was committed after it began. Repositories without a transaction apply every operation to the data right away.
+type Context struct {
+	mutex   sync.Mutex
+	data    snapshot
+	version int // Incremented whenever data changes, for detecting conflicting transactions
+}
+
+var _ db.Context = (*Context)(nil) // Interface guard
+
+func New() *Context {
+	return &Context{data: snapshot{sendCounts: make(map[string]int)}}
+}
+
+// snapshot is the state of all tables.
+type snapshot struct {
+	customers      []model.Customer
+	mailingEntries []model.MailingEntry
+	archive        []model.ArchivedMailingEntry
+	sendCounts     map[string]int // Keyed by tenant ID and day
+	subscriptions  []model.WebhookSubscription
+	events         []model.WebhookEvent
+	deliveries     []model.WebhookDelivery
+
+	// Last IDs assigned to rows of each table, like postgres sequences.
+	lastCustomerId     int
+	lastMailingEntryId int
+	lastSubscriptionId int
+	lastEventId        int
+	lastDeliveryId     int
+}
+
+// clone copies the snapshot so that changes to the copy don't affect the original.
+func (data snapshot) clone() snapshot {
+	cloned := data
+	cloned.customers = append([]model.Customer(nil), data.customers...)
+	cloned.mailingEntries = append([]model.MailingEntry(nil), data.mailingEntries...)
+	cloned.archive = append([]model.ArchivedMailingEntry(nil), data.archive...)
+	cloned.subscriptions = append([]model.WebhookSubscription(nil), data.subscriptions...)
+	cloned.events = append([]model.WebhookEvent(nil), data.events...)
+	cloned.deliveries = append([]model.WebhookDelivery(nil), data.deliveries...)
+	cloned.sendCounts = make(map[string]int, len(data.sendCounts))
+	for key, count := range data.sendCounts {
+		cloned.sendCounts[key] = count
+	}
+	return cloned
+}
+
+func (memoryCtx *Context) Repository(_ context.Context) (db.Repository, error) {
+	return &repository{data: &memoryCtx.data, autocommit: memoryCtx}, nil
+}
+
+// TransactionalRepository begins a transaction. Transactions are always serializable, so the isolation level is ignored.
+func (memoryCtx *Context) TransactionalRepository(_ context.Context, _ sql.IsolationLevel) (db.Repository, db.Transaction, error) {
+	memoryCtx.mutex.Lock()
+	defer memoryCtx.mutex.Unlock()
+
+	data := memoryCtx.data.clone()
+	transactionalRepository := &repository{data: &data}
+	transactionCtx := &transaction{memoryCtx: memoryCtx, repository: transactionalRepository, startVersion: memoryCtx.version}
+	return transactionalRepository, transactionCtx, nil
+}
+
+// transaction implements the db.Transaction interface.
+type transaction struct {
+	memoryCtx    *Context
+	repository   *repository
+	startVersion int                 // Version of the data the transaction's snapshot was taken at
+	savepoints   map[string]snapshot // Copies of the data taken when savepoints were created, keyed by name
+	done         bool
+}
+
+var _ db.Transaction = (*transaction)(nil) // Interface guard
+
+func (transactionCtx *transaction) Commit() error {
+	if transactionCtx.done {
+		return sql.ErrTxDone
+	}
+	transactionCtx.done = true
+	if !transactionCtx.repository.changed {
+		return nil
+	}
+
+	memoryCtx := transactionCtx.memoryCtx
+	memoryCtx.mutex.Lock()
+	defer memoryCtx.mutex.Unlock()
+	if memoryCtx.version != transactionCtx.startVersion {
+		return db.ErrSerializationFailure
+	}
+	memoryCtx.data = *transactionCtx.repository.data
+	memoryCtx.version++
+	return nil
+}
+
+func (transactionCtx *transaction) Rollback() error {
+	if transactionCtx.done {
+		return sql.ErrTxDone
+	}
+	transactionCtx.done = true
+	return nil
+}
+
+func (transactionCtx *transaction) Savepoint(_ context.Context, name string) error {
+	if transactionCtx.done {
+		return sql.ErrTxDone
+	}
+	if transactionCtx.savepoints == nil {
+		transactionCtx.savepoints = make(map[string]snapshot)
+	}
+	transactionCtx.savepoints[name] = transactionCtx.repository.data.clone()
+	return nil
+}
+
+func (transactionCtx *transaction) RollbackToSavepoint(_ context.Context, name string) error {
+	if transactionCtx.done {
+		return sql.ErrTxDone
+	}
+	data, found := transactionCtx.savepoints[name]
+	if !found {
+		return fmt.Errorf("savepoint %s doesn't exist", name)
+	}
+	// The savepoint remains, so its copy can't be modified by the transaction.
+	*transactionCtx.repository.data = data.clone()
+	return nil
+}
+
+func (transactionCtx *transaction) ReleaseSavepoint(_ context.Context, name string) error {
+	if transactionCtx.done {
+		return sql.ErrTxDone
+	}
+	if _, found := transactionCtx.savepoints[name]; !found {
+		return fmt.Errorf("savepoint %s doesn't exist", name)
+	}
+	delete(transactionCtx.savepoints, name)
+	return nil
+}
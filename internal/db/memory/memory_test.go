@@ -0,0 +1,174 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/GeneralKenobi/mailman/internal/db"
+	"github.com/GeneralKenobi/mailman/internal/db/dbtest"
+	"github.com/GeneralKenobi/mailman/internal/db/model"
+	"testing"
+)
+
+func TestTransactions(t *testing.T) {
+	ctx := context.Background()
+	customer := model.Customer{TenantId: "tenant-1", Email: "jan.kowalski@example.com"}
+
+	tests := map[string]struct {
+		run              func(t *testing.T, memoryCtx *Context) error
+		expectedErr      error
+		expectedCustomer bool
+	}{
+		"Should make changes visible on commit": {
+			run: func(t *testing.T, memoryCtx *Context) error {
+				repository, transaction := begin(t, memoryCtx)
+				insertCustomer(t, repository, customer)
+				return transaction.Commit()
+			},
+			expectedCustomer: true,
+		},
+		"Should discard changes on rollback": {
+			run: func(t *testing.T, memoryCtx *Context) error {
+				repository, transaction := begin(t, memoryCtx)
+				insertCustomer(t, repository, customer)
+				return transaction.Rollback()
+			},
+		},
+		"Should apply changes without a transaction right away": {
+			run: func(t *testing.T, memoryCtx *Context) error {
+				repository, err := memoryCtx.Repository(ctx)
+				if err != nil {
+					t.Fatalf("Error creating repository: %v", err)
+				}
+				insertCustomer(t, repository, customer)
+				return nil
+			},
+			expectedCustomer: true,
+		},
+		"Should not show changes committed after the transaction began": {
+			run: func(t *testing.T, memoryCtx *Context) error {
+				repository, transaction := begin(t, memoryCtx)
+				otherRepository, otherTransaction := begin(t, memoryCtx)
+				insertCustomer(t, otherRepository, customer)
+				if err := otherTransaction.Commit(); err != nil {
+					t.Fatalf("Error committing the other transaction: %v", err)
+				}
+
+				_, err := repository.FindCustomerByEmail(ctx, customer.TenantId, customer.Email)
+				if !errors.Is(err, db.ErrNoRows) {
+					t.Errorf("Expected the customer to be invisible in the transaction but got %v", err)
+				}
+				return transaction.Commit()
+			},
+			expectedCustomer: true,
+		},
+		"Should fail committing changes conflicting with a concurrent transaction": {
+			run: func(t *testing.T, memoryCtx *Context) error {
+				repository, transaction := begin(t, memoryCtx)
+				otherRepository, otherTransaction := begin(t, memoryCtx)
+				insertCustomer(t, otherRepository, customer)
+				if err := otherTransaction.Commit(); err != nil {
+					t.Fatalf("Error committing the other transaction: %v", err)
+				}
+
+				insertCustomer(t, repository, model.Customer{TenantId: "tenant-1", Email: "anna.nowak@example.com"})
+				return transaction.Commit()
+			},
+			expectedErr:      db.ErrSerializationFailure,
+			expectedCustomer: true,
+		},
+		"Should fail committing a finished transaction": {
+			run: func(t *testing.T, memoryCtx *Context) error {
+				repository, transaction := begin(t, memoryCtx)
+				insertCustomer(t, repository, customer)
+				if err := transaction.Commit(); err != nil {
+					t.Fatalf("Error committing the transaction: %v", err)
+				}
+				return transaction.Commit()
+			},
+			expectedErr:      sql.ErrTxDone,
+			expectedCustomer: true,
+		},
+		"Should reject a mailing entry of a nonexistent customer": {
+			run: func(t *testing.T, memoryCtx *Context) error {
+				repository, transaction := begin(t, memoryCtx)
+				defer transaction.Rollback()
+				_, err := repository.InsertMailingEntry(ctx, model.MailingEntry{TenantId: "tenant-1", CustomerId: 1, MailingId: 1, Title: "title"})
+				if err == nil {
+					t.Errorf("Expected a foreign key violation but got none")
+				}
+				return nil
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			memoryCtx := New()
+
+			err := test.run(t, memoryCtx)
+
+			if !errors.Is(err, test.expectedErr) {
+				t.Errorf("Expected error %v but got %v", test.expectedErr, err)
+			}
+			repository, _ := memoryCtx.Repository(ctx)
+			_, err = repository.FindCustomerByEmail(ctx, customer.TenantId, customer.Email)
+			if found := err == nil; found != test.expectedCustomer {
+				t.Errorf("Expected customer to be committed: %v, but got error %v", test.expectedCustomer, err)
+			}
+		})
+	}
+}
+
+func TestFindOne(t *testing.T) {
+	items := []int{1, 2, 2}
+	tests := map[string]struct {
+		item        int
+		expectedErr error
+	}{
+		"Should find the only matching item": {item: 1},
+		"Should return ErrNoRows if no item matches": {
+			item:        3,
+			expectedErr: db.ErrNoRows,
+		},
+		"Should return ErrTooManyRows if more than 1 item matches": {
+			item:        2,
+			expectedErr: db.ErrTooManyRows,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			found, err := findOne("find item", items, func(item int) bool { return item == test.item })
+
+			if !errors.Is(err, test.expectedErr) {
+				t.Fatalf("Expected error %v but got %v", test.expectedErr, err)
+			}
+			if err == nil && found != test.item {
+				t.Errorf("Expected %d but got %d", test.item, found)
+			}
+		})
+	}
+}
+
+func begin(t *testing.T, memoryCtx *Context) (db.Repository, db.Transaction) {
+	t.Helper()
+	repository, transaction, err := memoryCtx.TransactionalRepository(context.Background(), sql.LevelDefault)
+	if err != nil {
+		t.Fatalf("Error beginning a transaction: %v", err)
+	}
+	return repository, transaction
+}
+
+func insertCustomer(t *testing.T, repository db.Repository, customer model.Customer) {
+	t.Helper()
+	if _, err := repository.InsertCustomer(context.Background(), customer); err != nil {
+		t.Fatalf("Error inserting customer: %v", err)
+	}
+}
+
+func TestRepositoryContract(t *testing.T) {
+	dbtest.RunContractTests(t, func(*testing.T) db.Context {
+		return New()
+	})
+}
@@ -0,0 +1,499 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"github.com/GeneralKenobi/mailman/internal/db"
+	"github.com/GeneralKenobi/mailman/internal/db/model"
+	"github.com/GeneralKenobi/mailman/pkg/util"
+	"sort"
+	"time"
+)
+
+// repository implements db.Repository on a snapshot of the data. It enforces the same constraints as the postgres schema.
+type repository struct {
+	data       *snapshot
+	autocommit *Context // Set if operations run without a transaction, they're applied to the committed data of the context
+	changed    bool     // Whether the data was changed in the transaction
+}
+
+var _ db.Repository = (*repository)(nil) // Interface guard
+
+// lock is called at the start of every operation. Operations without a transaction lock the committed data for their duration, the
+// returned function unlocks it.
+func (repo *repository) lock() (unlock func()) {
+	if repo.autocommit == nil {
+		return func() {}
+	}
+	repo.autocommit.mutex.Lock()
+	return repo.autocommit.mutex.Unlock
+}
+
+// modified records that the operation changed data.
+func (repo *repository) modified() {
+	if repo.autocommit != nil {
+		repo.autocommit.version++
+	} else {
+		repo.changed = true
+	}
+}
+
+func (repo *repository) FindTenantIdsWithMailingEntries(_ context.Context) ([]string, error) {
+	defer repo.lock()()
+	tenantIds := make(map[string]bool)
+	for _, entry := range repo.data.mailingEntries {
+		tenantIds[entry.TenantId] = true
+	}
+	return keys(tenantIds), nil
+}
+
+func (repo *repository) FindTenantIdsWithArchivedMailingEntries(_ context.Context) ([]string, error) {
+	defer repo.lock()()
+	tenantIds := make(map[string]bool)
+	for _, archivedEntry := range repo.data.archive {
+		tenantIds[archivedEntry.TenantId] = true
+	}
+	return keys(tenantIds), nil
+}
+
+func (repo *repository) FindTenantIdsWithDueWebhookDeliveries(_ context.Context, now time.Time) ([]string, error) {
+	defer repo.lock()()
+	tenantIds := make(map[string]bool)
+	for _, delivery := range repo.data.deliveries {
+		if delivery.Status == model.WebhookDeliveryStatusPending && !delivery.NextAttemptTime.After(now) {
+			tenantIds[delivery.TenantId] = true
+		}
+	}
+	return keys(tenantIds), nil
+}
+
+func (repo *repository) FindTenantIdsWithWebhookEvents(_ context.Context) ([]string, error) {
+	defer repo.lock()()
+	tenantIds := make(map[string]bool)
+	for _, event := range repo.data.events {
+		tenantIds[event.TenantId] = true
+	}
+	return keys(tenantIds), nil
+}
+
+func (repo *repository) FindCustomerById(_ context.Context, tenantId string, id int) (model.Customer, error) {
+	defer repo.lock()()
+	return findOne("find customer by ID", repo.data.customers, func(customer model.Customer) bool {
+		return customer.TenantId == tenantId && customer.Id == id
+	})
+}
+
+func (repo *repository) FindCustomerByEmail(_ context.Context, tenantId, email string) (model.Customer, error) {
+	defer repo.lock()()
+	return findOne("find customer by email", repo.data.customers, func(customer model.Customer) bool {
+		return customer.TenantId == tenantId && customer.Email == email
+	})
+}
+
+func (repo *repository) InsertCustomer(_ context.Context, customer model.Customer) (model.Customer, error) {
+	defer repo.lock()()
+	if customer.TenantId == "" || customer.Email == "" {
+		return model.Customer{}, constraintViolation("insert customer", "customer_check")
+	}
+	if repo.customerExists(func(existing model.Customer) bool {
+		return existing.TenantId == customer.TenantId && existing.Email == customer.Email
+	}) {
+		return model.Customer{}, fmt.Errorf("insert customer: %w", db.ErrDuplicate)
+	}
+	return repo.insertCustomer(customer), nil
+}
+
+func (repo *repository) FindOrInsertCustomer(_ context.Context, customer model.Customer) (model.Customer, error) {
+	defer repo.lock()()
+	if customer.TenantId == "" || customer.Email == "" {
+		return model.Customer{}, constraintViolation("find or insert customer", "customer_check")
+	}
+	for _, existing := range repo.data.customers {
+		if existing.TenantId == customer.TenantId && existing.Email == customer.Email {
+			return existing, nil
+		}
+	}
+	return repo.insertCustomer(customer), nil
+}
+
+func (repo *repository) insertCustomer(customer model.Customer) model.Customer {
+	repo.data.lastCustomerId++
+	customer.Id = repo.data.lastCustomerId
+	repo.data.customers = append(repo.data.customers, customer)
+	repo.modified()
+	return customer
+}
+
+func (repo *repository) DeleteCustomerById(_ context.Context, tenantId string, id int) error {
+	defer repo.lock()()
+	if !repo.customerExists(func(customer model.Customer) bool { return customer.TenantId == tenantId && customer.Id == id }) {
+		return fmt.Errorf("delete customer by ID: %w", db.ErrNoRows)
+	}
+	for _, entry := range repo.data.mailingEntries {
+		if entry.TenantId == tenantId && entry.CustomerId == id {
+			return constraintViolation("delete customer by ID", "fk_customer")
+		}
+	}
+
+	repo.data.customers = filter(repo.data.customers, func(customer model.Customer) bool {
+		return customer.TenantId != tenantId || customer.Id != id
+	})
+	repo.modified()
+	return nil
+}
+
+func (repo *repository) customerExists(matches func(customer model.Customer) bool) bool {
+	for _, customer := range repo.data.customers {
+		if matches(customer) {
+			return true
+		}
+	}
+	return false
+}
+
+func (repo *repository) FindMailingEntryById(_ context.Context, tenantId string, id int) (model.MailingEntry, error) {
+	defer repo.lock()()
+	return findOne("find mailing entry by ID", repo.data.mailingEntries, func(entry model.MailingEntry) bool {
+		return entry.TenantId == tenantId && entry.Id == id
+	})
+}
+
+func (repo *repository) FindMailingEntriesByMailingId(_ context.Context, tenantId string, mailingId int) ([]model.MailingEntry, error) {
+	defer repo.lock()()
+	return filter(repo.data.mailingEntries, func(entry model.MailingEntry) bool {
+		return entry.TenantId == tenantId && entry.MailingId == mailingId
+	}), nil
+}
+
+func (repo *repository) FindMailingEntriesOlderThan(_ context.Context, tenantId string, olderThan time.Duration) ([]model.MailingEntry, error) {
+	defer repo.lock()()
+	return filter(repo.data.mailingEntries, func(entry model.MailingEntry) bool {
+		return entry.TenantId == tenantId && time.Since(entry.InsertTime) > olderThan
+	}), nil
+}
+
+func (repo *repository) FindMailingEntriesByMailingIdOlderThan(
+	_ context.Context, tenantId string, mailingId int, olderThan time.Duration) ([]model.MailingEntry, error) {
+	defer repo.lock()()
+	return filter(repo.data.mailingEntries, func(entry model.MailingEntry) bool {
+		return entry.TenantId == tenantId && entry.MailingId == mailingId && time.Since(entry.InsertTime) > olderThan
+	}), nil
+}
+
+func (repo *repository) FindMailingEntriesByCustomerId(_ context.Context, tenantId string, id int) ([]model.MailingEntry, error) {
+	defer repo.lock()()
+	return filter(repo.data.mailingEntries, func(entry model.MailingEntry) bool {
+		return entry.TenantId == tenantId && entry.CustomerId == id
+	}), nil
+}
+
+func (repo *repository) CountMailingEntries(_ context.Context, tenantId string, entryFilter model.MailingEntryFilter) (int, error) {
+	defer repo.lock()()
+	return len(filter(repo.data.mailingEntries, func(entry model.MailingEntry) bool {
+		return entry.TenantId == tenantId && matchesFilter(entry, entryFilter)
+	})), nil
+}
+
+func matchesFilter(entry model.MailingEntry, filter model.MailingEntryFilter) bool {
+	return (filter.MailingId == nil || entry.MailingId == *filter.MailingId) &&
+		(filter.CustomerId == nil || entry.CustomerId == *filter.CustomerId) &&
+		(filter.InsertedAfter == nil || !entry.InsertTime.Before(*filter.InsertedAfter)) &&
+		(filter.InsertedBefore == nil || entry.InsertTime.Before(*filter.InsertedBefore))
+}
+
+func (repo *repository) InsertMailingEntry(_ context.Context, mailingEntry model.MailingEntry) (model.MailingEntry, error) {
+	defer repo.lock()()
+	if err := repo.checkMailingEntry("insert mailing entry", mailingEntry); err != nil {
+		return model.MailingEntry{}, err
+	}
+
+	repo.data.lastMailingEntryId++
+	mailingEntry.Id = repo.data.lastMailingEntryId
+	mailingEntry.Version = 1
+	repo.data.mailingEntries = append(repo.data.mailingEntries, mailingEntry)
+	repo.modified()
+	return mailingEntry, nil
+}
+
+func (repo *repository) UpdateMailingEntry(_ context.Context, mailingEntry model.MailingEntry) (model.MailingEntry, error) {
+	defer repo.lock()()
+	for i, entry := range repo.data.mailingEntries {
+		if entry.TenantId == mailingEntry.TenantId && entry.Id == mailingEntry.Id && entry.Version == mailingEntry.Version {
+			entry.CustomerId = mailingEntry.CustomerId
+			entry.MailingId = mailingEntry.MailingId
+			entry.Title = mailingEntry.Title
+			entry.Content = mailingEntry.Content
+			entry.Version++
+			if err := repo.checkMailingEntry("update mailing entry", entry); err != nil {
+				return model.MailingEntry{}, err
+			}
+			repo.data.mailingEntries[i] = entry
+			repo.modified()
+			return entry, nil
+		}
+	}
+	return model.MailingEntry{}, fmt.Errorf("update mailing entry: %w", db.ErrNoRows)
+}
+
+// checkMailingEntry checks the constraints of the mailing_entry table.
+func (repo *repository) checkMailingEntry(operationName string, mailingEntry model.MailingEntry) error {
+	if mailingEntry.TenantId == "" || mailingEntry.Title == "" {
+		return constraintViolation(operationName, "mailing_entry_check")
+	}
+	if !repo.customerExists(func(customer model.Customer) bool {
+		return customer.TenantId == mailingEntry.TenantId && customer.Id == mailingEntry.CustomerId
+	}) {
+		return constraintViolation(operationName, "fk_customer")
+	}
+	for _, entry := range repo.data.mailingEntries {
+		if entry.Id != mailingEntry.Id && entry.CustomerId == mailingEntry.CustomerId && entry.MailingId == mailingEntry.MailingId &&
+			entry.ContentHash() == mailingEntry.ContentHash() && entry.InsertTime.Equal(mailingEntry.InsertTime) {
+			return fmt.Errorf("%s: %w", operationName, db.ErrDuplicate)
+		}
+	}
+	return nil
+}
+
+func (repo *repository) DeleteMailingEntryById(_ context.Context, tenantId string, id int) error {
+	defer repo.lock()()
+	count := len(repo.data.mailingEntries)
+	repo.data.mailingEntries = filter(repo.data.mailingEntries, func(entry model.MailingEntry) bool {
+		return entry.TenantId != tenantId || entry.Id != id
+	})
+	if count == len(repo.data.mailingEntries) {
+		return fmt.Errorf("delete mailing entry by ID: %w", db.ErrNoRows)
+	}
+	repo.modified()
+	return nil
+}
+
+func (repo *repository) DeleteMailingEntries(_ context.Context, tenantId string, entryFilter model.MailingEntryFilter) (int64, error) {
+	defer repo.lock()()
+	count := len(repo.data.mailingEntries)
+	repo.data.mailingEntries = filter(repo.data.mailingEntries, func(entry model.MailingEntry) bool {
+		return entry.TenantId != tenantId || !matchesFilter(entry, entryFilter)
+	})
+	deletedCount := count - len(repo.data.mailingEntries)
+	if deletedCount > 0 {
+		repo.modified()
+	}
+	return int64(deletedCount), nil
+}
+
+func (repo *repository) IncrementDailySendCount(_ context.Context, tenantId string, day time.Time, count int) (int, error) {
+	defer repo.lock()()
+	key := tenantId + "/" + day.Format("2006-01-02")
+	repo.data.sendCounts[key] += count
+	repo.modified()
+	return repo.data.sendCounts[key], nil
+}
+
+func (repo *repository) FindWebhookSubscriptionById(_ context.Context, tenantId string, id int) (model.WebhookSubscription, error) {
+	defer repo.lock()()
+	return findOne("find webhook subscription by ID", repo.data.subscriptions, func(subscription model.WebhookSubscription) bool {
+		return subscription.TenantId == tenantId && subscription.Id == id
+	})
+}
+
+func (repo *repository) FindWebhookSubscriptions(_ context.Context, tenantId string) ([]model.WebhookSubscription, error) {
+	defer repo.lock()()
+	return filter(repo.data.subscriptions, func(subscription model.WebhookSubscription) bool {
+		return subscription.TenantId == tenantId
+	}), nil
+}
+
+func (repo *repository) FindWebhookEventById(_ context.Context, tenantId string, id int) (model.WebhookEvent, error) {
+	defer repo.lock()()
+	return findOne("find webhook event by ID", repo.data.events, func(event model.WebhookEvent) bool {
+		return event.TenantId == tenantId && event.Id == id
+	})
+}
+
+func (repo *repository) FindWebhookDeliveriesBySubscriptionId(
+	_ context.Context, tenantId string, subscriptionId, limit int) ([]model.WebhookDelivery, error) {
+	defer repo.lock()()
+
+	var result []model.WebhookDelivery
+	for i := len(repo.data.deliveries) - 1; i >= 0 && len(result) < limit; i-- {
+		delivery := repo.data.deliveries[i]
+		if delivery.TenantId == tenantId && delivery.SubscriptionId == subscriptionId {
+			result = append(result, delivery)
+		}
+	}
+	return result, nil
+}
+
+// FindDueWebhookDeliveriesForUpdate doesn't lock anything, transactions that update the same deliveries conflict on commit instead.
+func (repo *repository) FindDueWebhookDeliveriesForUpdate(
+	_ context.Context, tenantId string, now time.Time, limit int) ([]model.WebhookDelivery, error) {
+	defer repo.lock()()
+
+	result := filter(repo.data.deliveries, func(delivery model.WebhookDelivery) bool {
+		return delivery.TenantId == tenantId && delivery.Status == model.WebhookDeliveryStatusPending && !delivery.NextAttemptTime.After(now)
+	})
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].NextAttemptTime.Before(result[j].NextAttemptTime)
+	})
+	if len(result) > limit {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
+func (repo *repository) InsertWebhookSubscription(_ context.Context, subscription model.WebhookSubscription) (model.WebhookSubscription, error) {
+	defer repo.lock()()
+	if subscription.TenantId == "" || subscription.Url == "" || subscription.Secret == "" {
+		return model.WebhookSubscription{}, constraintViolation("insert webhook subscription", "webhook_subscription_check")
+	}
+
+	repo.data.lastSubscriptionId++
+	subscription.Id = repo.data.lastSubscriptionId
+	repo.data.subscriptions = append(repo.data.subscriptions, subscription)
+	repo.modified()
+	return subscription, nil
+}
+
+func (repo *repository) InsertWebhookEvent(_ context.Context, event model.WebhookEvent) (model.WebhookEvent, error) {
+	defer repo.lock()()
+	if event.TenantId == "" {
+		return model.WebhookEvent{}, constraintViolation("insert webhook event", "webhook_event_check")
+	}
+
+	repo.data.lastEventId++
+	event.Id = repo.data.lastEventId
+	repo.data.events = append(repo.data.events, event)
+	repo.modified()
+	return event, nil
+}
+
+func (repo *repository) InsertWebhookDelivery(_ context.Context, delivery model.WebhookDelivery) (model.WebhookDelivery, error) {
+	defer repo.lock()()
+	if err := repo.checkWebhookDelivery("insert webhook delivery", delivery); err != nil {
+		return model.WebhookDelivery{}, err
+	}
+
+	repo.data.lastDeliveryId++
+	delivery.Id = repo.data.lastDeliveryId
+	repo.data.deliveries = append(repo.data.deliveries, delivery)
+	repo.modified()
+	return delivery, nil
+}
+
+func (repo *repository) UpdateWebhookDelivery(_ context.Context, delivery model.WebhookDelivery) error {
+	defer repo.lock()()
+	for i, existing := range repo.data.deliveries {
+		if existing.TenantId == delivery.TenantId && existing.Id == delivery.Id {
+			// Like in postgres, the subscription and event of a delivery can't change.
+			delivery.SubscriptionId = existing.SubscriptionId
+			delivery.EventId = existing.EventId
+			repo.data.deliveries[i] = delivery
+			repo.modified()
+			return nil
+		}
+	}
+	return fmt.Errorf("update webhook delivery: %w", db.ErrNoRows)
+}
+
+// checkWebhookDelivery checks the constraints of the webhook_delivery table.
+func (repo *repository) checkWebhookDelivery(operationName string, delivery model.WebhookDelivery) error {
+	if delivery.TenantId == "" {
+		return constraintViolation(operationName, "webhook_delivery_check")
+	}
+	subscriptions := filter(repo.data.subscriptions, func(subscription model.WebhookSubscription) bool {
+		return subscription.TenantId == delivery.TenantId && subscription.Id == delivery.SubscriptionId
+	})
+	if len(subscriptions) == 0 {
+		return constraintViolation(operationName, "fk_subscription")
+	}
+	events := filter(repo.data.events, func(event model.WebhookEvent) bool {
+		return event.TenantId == delivery.TenantId && event.Id == delivery.EventId
+	})
+	if len(events) == 0 {
+		return constraintViolation(operationName, "fk_event")
+	}
+	return nil
+}
+
+func (repo *repository) DeleteWebhookSubscriptionById(_ context.Context, tenantId string, id int) error {
+	defer repo.lock()()
+	count := len(repo.data.subscriptions)
+	repo.data.subscriptions = filter(repo.data.subscriptions, func(subscription model.WebhookSubscription) bool {
+		return subscription.TenantId != tenantId || subscription.Id != id
+	})
+	if count == len(repo.data.subscriptions) {
+		return fmt.Errorf("delete webhook subscription by ID: %w", db.ErrNoRows)
+	}
+	repo.data.deliveries = filter(repo.data.deliveries, func(delivery model.WebhookDelivery) bool {
+		return delivery.TenantId != tenantId || delivery.SubscriptionId != id
+	})
+	repo.modified()
+	return nil
+}
+
+func (repo *repository) DeleteFinishedWebhookEventsCreatedBefore(_ context.Context, tenantId string, createdBefore time.Time) (int64, error) {
+	defer repo.lock()()
+	pendingEventIds := make(map[int]bool)
+	for _, delivery := range repo.data.deliveries {
+		if delivery.Status == model.WebhookDeliveryStatusPending {
+			pendingEventIds[delivery.EventId] = true
+		}
+	}
+
+	deletedEventIds := make(map[int]bool)
+	repo.data.events = filter(repo.data.events, func(event model.WebhookEvent) bool {
+		if event.TenantId == tenantId && event.CreateTime.Before(createdBefore) && !pendingEventIds[event.Id] {
+			deletedEventIds[event.Id] = true
+			return false
+		}
+		return true
+	})
+	if len(deletedEventIds) == 0 {
+		return 0, nil
+	}
+	repo.data.deliveries = filter(repo.data.deliveries, func(delivery model.WebhookDelivery) bool {
+		return !deletedEventIds[delivery.EventId]
+	})
+	repo.modified()
+	return int64(len(deletedEventIds)), nil
+}
+
+// findOne returns the only item for which matches returns true. It returns wrapped db.ErrNoRows if no item matches and
+// db.ErrTooManyRows if more than 1 item matches, like select queries expecting a single row.
+//
+// queryName is only used in error messages.
+func findOne[T any](queryName string, items []T, matches func(item T) bool) (T, error) {
+	found := filter(items, matches)
+	switch len(found) {
+	case 0:
+		return util.ZeroValue[T](), fmt.Errorf("%s: %w", queryName, db.ErrNoRows)
+	case 1:
+		return found[0], nil
+	default:
+		return util.ZeroValue[T](), fmt.Errorf("%s: %w", queryName, db.ErrTooManyRows)
+	}
+}
+
+// filter returns a new slice with items for which keep returns true.
+func filter[T any](items []T, keep func(item T) bool) []T {
+	var result []T
+	for _, item := range items {
+		if keep(item) {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+func keys(set map[string]bool) []string {
+	var result []string
+	for key := range set {
+		result = append(result, key)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// constraintViolation returns an error of an operation violating a constraint of the postgres schema.
+func constraintViolation(operationName, constraintName string) error {
+	return fmt.Errorf("%s: violates constraint %s", operationName, constraintName)
+}
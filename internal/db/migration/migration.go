@@ -0,0 +1,259 @@
+// Package migration applies versioned changes of the DB schema. Migrations are SQL files named <version>_<name>.up.sql and
+// <version>_<name>.down.sql. Every version needs both, versions are applied in ascending order. Each migration runs in a transaction
+// together with recording it in the bookkeeping table.
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/GeneralKenobi/mailman/pkg/mdctx"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Migration is a versioned change of the DB schema.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string // SQL applying the change
+	Down    string // SQL reverting the change
+}
+
+// Dialect contains the parts of migrating that depend on the DB.
+type Dialect struct {
+	// Table applied migrations are recorded in, it's created if it doesn't exist.
+	Table string
+	// Lock is acquired on the migrating connection, so that concurrent migrations wait for each other. unlock is called when migrating
+	// is done. No lock is acquired if it's nil.
+	Lock func(ctx context.Context, conn *sql.Conn) (unlock func(), err error)
+}
+
+// New creates a migrator of the DB with the migrations from the SQL files in the directory.
+func New(db *sql.DB, fileSystem fs.FS, dir string, dialect Dialect) (*Migrator, error) {
+	migrations, err := Load(fileSystem, dir)
+	if err != nil {
+		return nil, fmt.Errorf("error loading migrations: %w", err)
+	}
+	return &Migrator{db: db, dialect: dialect, migrations: migrations}, nil
+}
+
+type Migrator struct {
+	db         *sql.DB
+	dialect    Dialect
+	migrations []Migration // Sorted by version
+}
+
+// Up applies all migrations that weren't applied yet.
+func (migrator *Migrator) Up(ctx context.Context) error {
+	return migrator.whileLocked(ctx, func(conn *sql.Conn) error {
+		applied, err := migrator.appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if latest := migrator.latestVersion(); len(applied) > 0 && applied[len(applied)-1] > latest {
+			mdctx.Warnf(ctx, "DB schema version %d is newer than the latest known version %d", applied[len(applied)-1], latest)
+		}
+
+		isApplied := make(map[int]bool, len(applied))
+		for _, version := range applied {
+			isApplied[version] = true
+		}
+		pendingCount := 0
+		for _, migration := range migrator.migrations {
+			if isApplied[migration.Version] {
+				continue
+			}
+			mdctx.Infof(ctx, "Applying migration %d %s", migration.Version, migration.Name)
+			err = inTransaction(ctx, conn, migration.Up,
+				fmt.Sprintf("INSERT INTO %s(version, name, applied_time) VALUES ($1, $2, $3)", migrator.dialect.Table),
+				migration.Version, migration.Name, time.Now().UTC())
+			if err != nil {
+				return fmt.Errorf("error applying migration %d %s: %w", migration.Version, migration.Name, err)
+			}
+			pendingCount++
+		}
+		mdctx.Infof(ctx, "Applied %d migrations, DB schema is at version %d", pendingCount, migrator.latestVersion())
+		return nil
+	})
+}
+
+// Down reverts the given number of the latest applied migrations.
+func (migrator *Migrator) Down(ctx context.Context, steps int) error {
+	if steps < 1 {
+		return fmt.Errorf("number of migrations to revert has to be positive, got %d", steps)
+	}
+
+	return migrator.whileLocked(ctx, func(conn *sql.Conn) error {
+		applied, err := migrator.appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if steps > len(applied) {
+			return fmt.Errorf("can't revert %d migrations, only %d are applied", steps, len(applied))
+		}
+
+		for i := len(applied) - 1; i >= len(applied)-steps; i-- {
+			migration, found := migrator.find(applied[i])
+			if !found {
+				return fmt.Errorf("migration %d is applied but unknown, it can't be reverted", applied[i])
+			}
+			mdctx.Infof(ctx, "Reverting migration %d %s", migration.Version, migration.Name)
+			err = inTransaction(ctx, conn, migration.Down,
+				fmt.Sprintf("DELETE FROM %s WHERE version = $1", migrator.dialect.Table), migration.Version)
+			if err != nil {
+				return fmt.Errorf("error reverting migration %d %s: %w", migration.Version, migration.Name, err)
+			}
+		}
+		return nil
+	})
+}
+
+// Version returns the latest applied migration version, 0 if none is applied.
+func (migrator *Migrator) Version(ctx context.Context) (int, error) {
+	var version int
+	err := migrator.whileLocked(ctx, func(conn *sql.Conn) error {
+		applied, err := migrator.appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if len(applied) > 0 {
+			version = applied[len(applied)-1]
+		}
+		return nil
+	})
+	return version, err
+}
+
+func (migrator *Migrator) latestVersion() int {
+	if len(migrator.migrations) == 0 {
+		return 0
+	}
+	return migrator.migrations[len(migrator.migrations)-1].Version
+}
+
+func (migrator *Migrator) find(version int) (Migration, bool) {
+	for _, migration := range migrator.migrations {
+		if migration.Version == version {
+			return migration, true
+		}
+	}
+	return Migration{}, false
+}
+
+// whileLocked runs todo on a single connection holding the migration lock. The bookkeeping table is created first if it doesn't exist.
+func (migrator *Migrator) whileLocked(ctx context.Context, todo func(conn *sql.Conn) error) error {
+	conn, err := migrator.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("error getting a DB connection: %w", err)
+	}
+	defer conn.Close()
+
+	if migrator.dialect.Lock != nil {
+		mdctx.Debugf(ctx, "Acquiring migration lock")
+		unlock, err := migrator.dialect.Lock(ctx, conn)
+		if err != nil {
+			return fmt.Errorf("error acquiring migration lock: %w", err)
+		}
+		defer unlock()
+	}
+
+	_, err = conn.ExecContext(ctx, fmt.Sprintf(
+		"CREATE TABLE IF NOT EXISTS %s (version INT PRIMARY KEY, name VARCHAR(255) NOT NULL, applied_time TIMESTAMP NOT NULL)",
+		migrator.dialect.Table))
+	if err != nil {
+		return fmt.Errorf("error creating %s table: %w", migrator.dialect.Table, err)
+	}
+	return todo(conn)
+}
+
+// appliedVersions returns versions of the applied migrations in ascending order.
+func (migrator *Migrator) appliedVersions(ctx context.Context, conn *sql.Conn) ([]int, error) {
+	rows, err := conn.QueryContext(ctx, fmt.Sprintf("SELECT version FROM %s ORDER BY version", migrator.dialect.Table))
+	if err != nil {
+		return nil, fmt.Errorf("error querying applied migrations: %w", err)
+	}
+	defer rows.Close()
+
+	var versions []int
+	for rows.Next() {
+		var version int
+		if err = rows.Scan(&version); err != nil {
+			return nil, fmt.Errorf("error scanning applied migration: %w", err)
+		}
+		versions = append(versions, version)
+	}
+	return versions, rows.Err()
+}
+
+// inTransaction executes the migration script and the bookkeeping query in one transaction.
+func inTransaction(ctx context.Context, conn *sql.Conn, script, bookkeepingQuery string, bookkeepingArgs ...any) error {
+	transaction, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error beginning a transaction: %w", err)
+	}
+	// Rollback after commit is a no-op.
+	defer transaction.Rollback()
+
+	if _, err = transaction.ExecContext(ctx, script); err != nil {
+		return err
+	}
+	if _, err = transaction.ExecContext(ctx, bookkeepingQuery, bookkeepingArgs...); err != nil {
+		return fmt.Errorf("error recording the migration: %w", err)
+	}
+	return transaction.Commit()
+}
+
+var migrationFileNamePattern = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Load reads migrations from the SQL files in the directory and sorts them by version.
+func Load(fileSystem fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fileSystem, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	migrationsByVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		match := migrationFileNamePattern.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			return nil, fmt.Errorf("%s isn't a migration file named <version>_<name>.up.sql or <version>_<name>.down.sql", entry.Name())
+		}
+		version, err := strconv.Atoi(match[1])
+		if err != nil || version < 1 {
+			return nil, fmt.Errorf("%s has invalid version %s", entry.Name(), match[1])
+		}
+		content, err := fs.ReadFile(fileSystem, dir+"/"+entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		migration, found := migrationsByVersion[version]
+		if !found {
+			migration = &Migration{Version: version, Name: match[2]}
+			migrationsByVersion[version] = migration
+		} else if migration.Name != match[2] {
+			return nil, fmt.Errorf("migrations %s and %s have the same version %d", migration.Name, match[2], version)
+		}
+		if match[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(migrationsByVersion))
+	for _, migration := range migrationsByVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %d %s needs both up and down SQL files", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
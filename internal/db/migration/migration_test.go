@@ -0,0 +1,236 @@
+package migration
+
+import (
+	"context"
+	"database/sql"
+	_ "modernc.org/sqlite" // SQLite driver registration by import, the migrator is tested against SQLite DBs.
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	tests := map[string]struct {
+		files       fstest.MapFS
+		expected    []Migration
+		expectError bool
+	}{
+		"Should pair up and down files and sort migrations by version": {
+			files: fstest.MapFS{
+				"migrations/0010_add_index.up.sql":     {Data: []byte("CREATE INDEX")},
+				"migrations/0010_add_index.down.sql":   {Data: []byte("DROP INDEX")},
+				"migrations/0002_add_table.down.sql":   {Data: []byte("DROP TABLE")},
+				"migrations/0002_add_table.up.sql":     {Data: []byte("CREATE TABLE")},
+				"migrations/0001_init_schema.up.sql":   {Data: []byte("CREATE SCHEMA")},
+				"migrations/0001_init_schema.down.sql": {Data: []byte("DROP SCHEMA")},
+			},
+			expected: []Migration{
+				{Version: 1, Name: "init_schema", Up: "CREATE SCHEMA", Down: "DROP SCHEMA"},
+				{Version: 2, Name: "add_table", Up: "CREATE TABLE", Down: "DROP TABLE"},
+				{Version: 10, Name: "add_index", Up: "CREATE INDEX", Down: "DROP INDEX"},
+			},
+		},
+		"Should reject a migration without down SQL": {
+			files: fstest.MapFS{
+				"migrations/0001_init_schema.up.sql": {Data: []byte("CREATE SCHEMA")},
+			},
+			expectError: true,
+		},
+		"Should reject migrations with the same version": {
+			files: fstest.MapFS{
+				"migrations/0001_init_schema.up.sql": {Data: []byte("CREATE SCHEMA")},
+				"migrations/0001_add_table.down.sql": {Data: []byte("DROP TABLE")},
+			},
+			expectError: true,
+		},
+		"Should reject a file with an unexpected name": {
+			files: fstest.MapFS{
+				"migrations/init_schema.sql": {Data: []byte("CREATE SCHEMA")},
+			},
+			expectError: true,
+		},
+		"Should reject version 0": {
+			files: fstest.MapFS{
+				"migrations/0000_init_schema.up.sql":   {Data: []byte("CREATE SCHEMA")},
+				"migrations/0000_init_schema.down.sql": {Data: []byte("DROP SCHEMA")},
+			},
+			expectError: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			migrations, err := Load(test.files, "migrations")
+
+			if test.expectError {
+				if err == nil {
+					t.Errorf("Expected an error but got migrations %+v", migrations)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error but got %v", err)
+			}
+			if !reflect.DeepEqual(migrations, test.expected) {
+				t.Errorf("Expected %+v but got %+v", test.expected, migrations)
+			}
+		})
+	}
+}
+
+// testMigrations create a table each, so that applying one twice fails.
+var testMigrations = fstest.MapFS{
+	"migrations/0001_customer.up.sql":        {Data: []byte("CREATE TABLE customer (id INTEGER PRIMARY KEY)")},
+	"migrations/0001_customer.down.sql":      {Data: []byte("DROP TABLE customer")},
+	"migrations/0002_mailing_entry.up.sql":   {Data: []byte("CREATE TABLE mailing_entry (id INTEGER PRIMARY KEY)")},
+	"migrations/0002_mailing_entry.down.sql": {Data: []byte("DROP TABLE mailing_entry")},
+	"migrations/0003_archive.up.sql":         {Data: []byte("CREATE TABLE archive (id INTEGER PRIMARY KEY)")},
+	"migrations/0003_archive.down.sql":       {Data: []byte("DROP TABLE archive")},
+}
+
+func TestMigratorUpAndDown(t *testing.T) {
+	ctx := context.Background()
+	sqlDb := openTestDb(t)
+	migrator, err := New(sqlDb, testMigrations, "migrations", Dialect{Table: "schema_migrations"})
+	if err != nil {
+		t.Fatalf("Error creating migrator: %v", err)
+	}
+
+	if err = migrator.Up(ctx); err != nil {
+		t.Fatalf("Error applying migrations: %v", err)
+	}
+	assertVersion(t, ctx, migrator, 3)
+	assertTables(t, sqlDb, "archive", "customer", "mailing_entry", "schema_migrations")
+	if err = migrator.Up(ctx); err != nil {
+		t.Errorf("Expected applying migrations again to do nothing but got %v", err)
+	}
+
+	if err = migrator.Down(ctx, 2); err != nil {
+		t.Fatalf("Error reverting migrations: %v", err)
+	}
+	assertVersion(t, ctx, migrator, 1)
+	assertTables(t, sqlDb, "customer", "schema_migrations")
+
+	if err = migrator.Down(ctx, 2); err == nil {
+		t.Errorf("Expected an error reverting more migrations than are applied")
+	}
+	if err = migrator.Down(ctx, 0); err == nil {
+		t.Errorf("Expected an error reverting 0 migrations")
+	}
+	assertVersion(t, ctx, migrator, 1)
+
+	if err = migrator.Up(ctx); err != nil {
+		t.Fatalf("Error applying reverted migrations again: %v", err)
+	}
+	assertVersion(t, ctx, migrator, 3)
+}
+
+func TestMigratorShouldRollBackFailedMigration(t *testing.T) {
+	ctx := context.Background()
+	sqlDb := openTestDb(t)
+	migrations := fstest.MapFS{
+		"migrations/0001_customer.up.sql":   testMigrations["migrations/0001_customer.up.sql"],
+		"migrations/0001_customer.down.sql": testMigrations["migrations/0001_customer.down.sql"],
+		"migrations/0002_invalid.up.sql": {
+			Data: []byte("CREATE TABLE mailing_entry (id INTEGER PRIMARY KEY); INSERT INTO unknown_table VALUES (1);"),
+		},
+		"migrations/0002_invalid.down.sql": {Data: []byte("DROP TABLE mailing_entry")},
+	}
+	migrator, err := New(sqlDb, migrations, "migrations", Dialect{Table: "schema_migrations"})
+	if err != nil {
+		t.Fatalf("Error creating migrator: %v", err)
+	}
+
+	if err = migrator.Up(ctx); err == nil {
+		t.Fatalf("Expected an error applying an invalid migration")
+	}
+
+	assertVersion(t, ctx, migrator, 1)
+	assertTables(t, sqlDb, "customer", "schema_migrations")
+}
+
+func TestMigratorShouldHoldLockWhileMigrating(t *testing.T) {
+	ctx := context.Background()
+	sqlDb := openTestDb(t)
+	locked := make(chan struct{}, 1)
+	lockCount := 0
+	dialect := Dialect{
+		Table: "schema_migrations",
+		Lock: func(ctx context.Context, conn *sql.Conn) (func(), error) {
+			select {
+			case locked <- struct{}{}:
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+			lockCount++
+			return func() { <-locked }, nil
+		},
+	}
+
+	// Migrators running concurrently wait for each other, applying a migration twice would fail.
+	const concurrentMigrators = 5
+	var waitGroup sync.WaitGroup
+	for i := 0; i < concurrentMigrators; i++ {
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			migrator, err := New(sqlDb, testMigrations, "migrations", dialect)
+			if err != nil {
+				t.Errorf("Error creating migrator: %v", err)
+				return
+			}
+			if err = migrator.Up(ctx); err != nil {
+				t.Errorf("Error applying migrations: %v", err)
+			}
+		}()
+	}
+	waitGroup.Wait()
+
+	if lockCount != concurrentMigrators {
+		t.Errorf("Expected the lock to be acquired %d times but it was acquired %d times", concurrentMigrators, lockCount)
+	}
+	if len(locked) != 0 {
+		t.Errorf("Expected the lock to be released")
+	}
+	migrator, _ := New(sqlDb, testMigrations, "migrations", Dialect{Table: "schema_migrations"})
+	assertVersion(t, ctx, migrator, 3)
+}
+
+func openTestDb(t *testing.T) *sql.DB {
+	sqlDb, err := sql.Open("sqlite", "file:"+filepath.Join(t.TempDir(), "test.db")+"?_pragma=busy_timeout(5000)")
+	if err != nil {
+		t.Fatalf("Error opening the DB: %v", err)
+	}
+	t.Cleanup(func() { _ = sqlDb.Close() })
+	return sqlDb
+}
+
+func assertVersion(t *testing.T, ctx context.Context, migrator *Migrator, expected int) {
+	t.Helper()
+	if version, err := migrator.Version(ctx); err != nil || version != expected {
+		t.Errorf("Expected version %d but got %d, error: %v", expected, version, err)
+	}
+}
+
+// assertTables checks that the DB has exactly the expected tables, given in alphabetical order.
+func assertTables(t *testing.T, sqlDb *sql.DB, expected ...string) {
+	t.Helper()
+	rows, err := sqlDb.Query("SELECT name FROM sqlite_master WHERE type = 'table' ORDER BY name")
+	if err != nil {
+		t.Fatalf("Error querying tables: %v", err)
+	}
+	defer rows.Close()
+	var tables []string
+	for rows.Next() {
+		var table string
+		if err = rows.Scan(&table); err != nil {
+			t.Fatalf("Error scanning table: %v", err)
+		}
+		tables = append(tables, table)
+	}
+	if !reflect.DeepEqual(tables, expected) {
+		t.Errorf("Expected tables %v but got %v", expected, tables)
+	}
+}
@@ -0,0 +1,34 @@
+package model
+
+import "time"
+
+// ArchivedMailingEntry is a mailing entry that was moved to the archive after it was sent or removed by the cleanup job.
+type ArchivedMailingEntry struct {
+	Id            int // Primary key, the ID the entry had before it was archived
+	TenantId      string
+	CustomerId    int    // ID of the customer the entry was addressed to, the customer may have been deleted since
+	CustomerEmail string // Email of the customer when the entry was archived
+	MailingId     int
+	Title         string
+	Content       string
+	InsertTime    time.Time
+	ArchiveTime   time.Time
+	Reason        ArchiveReason
+}
+
+// ArchiveReason is why a mailing entry was archived.
+type ArchiveReason string
+
+const (
+	ArchiveReasonSent    ArchiveReason = "sent"    // The entry was sent
+	ArchiveReasonBounced ArchiveReason = "bounced" // Sending the entry bounced, it won't be sent again
+	ArchiveReasonStale   ArchiveReason = "stale"   // The entry wasn't sent before it became stale
+)
+
+// ArchivedMailingEntryFilter selects archived mailing entries of a tenant. Fields that aren't set don't restrict the selection.
+type ArchivedMailingEntryFilter struct {
+	MailingId      *int
+	CustomerEmail  *string
+	ArchivedAfter  *time.Time // Inclusive lower bound of ArchiveTime
+	ArchivedBefore *time.Time // Exclusive upper bound of ArchiveTime
+}
@@ -1,6 +1,7 @@
 package model
 
 type Customer struct {
-	Id    int // Primary key
-	Email string
+	Id       int // Primary key
+	TenantId string
+	Email    string // Unique within tenant
 }
@@ -1,14 +1,37 @@
 package model
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
 	"time"
 )
 
 type MailingEntry struct {
 	Id         int // Primary key
-	CustomerId int // Maps many-to-one relationship to Customer.Id
+	TenantId   string
+	CustomerId int // Maps many-to-one relationship to Customer.Id, the customer belongs to the same tenant
 	MailingId  int
 	Title      string
 	Content    string
 	InsertTime time.Time
+	Version    int // Incremented on every update, for optimistic concurrency control
+}
+
+// ContentHash identifies the title and content of the entry. Entries of a customer are unique by mailing ID, content hash and insert
+// time.
+//
+// It's the hex-encoded SHA-256 of the title's length in bytes, a colon, the title and the content. The length keeps the boundary between
+// title and content from being ambiguous. Migrations computing hashes of existing entries in SQL depend on this format.
+func (mailingEntry MailingEntry) ContentHash() string {
+	hash := sha256.Sum256([]byte(strconv.Itoa(len(mailingEntry.Title)) + ":" + mailingEntry.Title + mailingEntry.Content))
+	return hex.EncodeToString(hash[:])
+}
+
+// MailingEntryFilter selects mailing entries of a tenant for bulk operations. Fields that aren't set don't restrict the selection.
+type MailingEntryFilter struct {
+	MailingId      *int
+	CustomerId     *int
+	InsertedAfter  *time.Time // Inclusive lower bound of InsertTime
+	InsertedBefore *time.Time // Exclusive upper bound of InsertTime
 }
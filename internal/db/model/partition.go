@@ -0,0 +1,10 @@
+package model
+
+import "time"
+
+// MailingEntryPartition is a partition storing mailing entries inserted in [Start, End).
+type MailingEntryPartition struct {
+	Name  string
+	Start time.Time
+	End   time.Time
+}
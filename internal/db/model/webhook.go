@@ -0,0 +1,43 @@
+package model
+
+import (
+	"time"
+)
+
+type WebhookSubscription struct {
+	Id         int // Primary key
+	TenantId   string
+	Url        string
+	EventTypes []string // Types of events delivered to Url
+	Secret     string   // Key for signing payloads delivered to Url
+	CreateTime time.Time
+}
+
+// WebhookEvent is an event recorded in the outbox in the same transaction as the change it describes.
+type WebhookEvent struct {
+	Id         int // Primary key
+	TenantId   string
+	EventType  string
+	Payload    string // JSON sent to subscribers
+	CreateTime time.Time
+}
+
+// WebhookDelivery tracks delivering an event to a subscription.
+type WebhookDelivery struct {
+	Id               int // Primary key
+	TenantId         string
+	SubscriptionId   int // Maps many-to-one relationship to WebhookSubscription.Id, the subscription belongs to the same tenant
+	EventId          int // Maps many-to-one relationship to WebhookEvent.Id, the event belongs to the same tenant
+	Status           string
+	AttemptCount     int
+	NextAttemptTime  time.Time // When the next attempt is due, if Status is WebhookDeliveryStatusPending
+	LastAttemptTime  *time.Time
+	LastResponseCode *int    // HTTP status of the last attempt's response, nil if no response was received
+	LastError        *string // Reason of the last failed attempt
+}
+
+const (
+	WebhookDeliveryStatusPending   = "pending"   // Waiting for the first attempt or a retry
+	WebhookDeliveryStatusSucceeded = "succeeded" // Delivered
+	WebhookDeliveryStatusFailed    = "failed"    // All attempts failed, won't be retried
+)
@@ -0,0 +1,21 @@
+package db
+
+import (
+	"context"
+	"github.com/GeneralKenobi/mailman/internal/db/model"
+	"time"
+)
+
+// Partitioner is implemented by contexts of DBs that partition mailing entries by insert time. Entries outside of all partitions are
+// stored in a default partition. Every method opens its own transactions.
+type Partitioner interface {
+	// FindMailingEntryPartitions returns the partitions of mailing entries, except for the default one, oldest first.
+	FindMailingEntryPartitions(ctx context.Context) ([]model.MailingEntryPartition, error)
+	// CreateMailingEntryPartition creates a partition for entries inserted in [start, end) and moves the entries in the range from the
+	// default partition to it. The range mustn't overlap other partitions.
+	CreateMailingEntryPartition(ctx context.Context, start, end time.Time) error
+	// DropMailingEntryPartition moves all entries of the partition to the archive, up to batchSize entries per transaction, and drops the
+	// partition. Returns the number of archived entries, which are archived even if dropping the partition fails.
+	DropMailingEntryPartition(ctx context.Context, partition model.MailingEntryPartition, reason model.ArchiveReason,
+		archiveTime time.Time, batchSize int) (int64, error)
+}
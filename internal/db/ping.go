@@ -0,0 +1,41 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/GeneralKenobi/mailman/pkg/mdctx"
+	"time"
+)
+
+// Pinger is implemented by contexts of DBs accessed through a connection pool, which may be unreachable.
+type Pinger interface {
+	// Ping checks that a connection to the DB can be established.
+	Ping(ctx context.Context) error
+	// Stats returns statistics of the connection pool.
+	Stats() sql.DBStats
+}
+
+// WaitUntilReachable pings the DB until it responds or ctx is done. Failed pings are retried after a backoff that starts at minBackoff
+// and is doubled after every failure, up to maxBackoff. The last ping's error is returned if ctx is done before the DB responds.
+func WaitUntilReachable(ctx context.Context, pinger Pinger, minBackoff, maxBackoff time.Duration) error {
+	backoff := minBackoff
+	for attempt := 1; ; attempt++ {
+		err := pinger.Ping(ctx)
+		if err == nil {
+			mdctx.Infof(ctx, "DB is reachable")
+			return nil
+		}
+		mdctx.Warnf(ctx, "DB is unreachable (attempt %d), retrying in %v: %v", attempt, backoff, err)
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("DB is unreachable after %d attempts: %w", attempt, err)
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
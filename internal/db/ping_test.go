@@ -0,0 +1,77 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWaitUntilReachable(t *testing.T) {
+	errUnreachable := errors.New("connection refused")
+	tests := map[string]struct {
+		failedPings   int
+		timeout       time.Duration
+		expectedPings int
+		expectError   bool
+	}{
+		"Should return after the first successful ping": {
+			failedPings:   0,
+			timeout:       time.Second,
+			expectedPings: 1,
+		},
+		"Should retry failed pings": {
+			failedPings:   3,
+			timeout:       time.Second,
+			expectedPings: 4,
+		},
+		"Should fail with the last ping's error when the context is done": {
+			failedPings: 1000,
+			timeout:     50 * time.Millisecond,
+			expectError: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), test.timeout)
+			defer cancel()
+			pinger := &pingerMock{failedPings: test.failedPings, err: errUnreachable}
+
+			err := WaitUntilReachable(ctx, pinger, time.Millisecond, 4*time.Millisecond)
+
+			if test.expectError {
+				if !errors.Is(err, errUnreachable) {
+					t.Errorf("Expected the ping error but got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error but got %v", err)
+			}
+			if pinger.pings != test.expectedPings {
+				t.Errorf("Expected %d pings but got %d", test.expectedPings, pinger.pings)
+			}
+		})
+	}
+}
+
+// pingerMock fails the given number of pings before succeeding.
+type pingerMock struct {
+	failedPings int
+	err         error
+	pings       int
+}
+
+func (mock *pingerMock) Ping(_ context.Context) error {
+	mock.pings++
+	if mock.pings <= mock.failedPings {
+		return mock.err
+	}
+	return nil
+}
+
+func (mock *pingerMock) Stats() sql.DBStats {
+	return sql.DBStats{}
+}
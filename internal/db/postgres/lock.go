@@ -0,0 +1,143 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"github.com/GeneralKenobi/mailman/internal/config"
+	"github.com/GeneralKenobi/mailman/pkg/mdctx"
+	"github.com/GeneralKenobi/mailman/pkg/scheduler"
+	"hash/fnv"
+	"sync"
+	"time"
+)
+
+// schedulerLockClassId is the first key of advisory locks of schedulers, the second one is a hash of the lock's name. Two-key advisory locks
+// don't conflict with single-key ones like migrationLockKey.
+const schedulerLockClassId = 0x6d61696c // "mail" in ASCII
+
+var _ scheduler.Locker = (*Context)(nil) // Interface guard
+
+// TryLock acquires a session-level advisory lock on a connection dedicated to the lock, which is held until it's released or the
+// connection breaks. The connection is checked periodically and the lock is reported as lost if it's no longer held.
+func (postgresCtx *Context) TryLock(ctx context.Context, name string) (scheduler.Lock, bool, error) {
+	conn, err := postgresCtx.db.Conn(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("error obtaining a connection: %w", err)
+	}
+
+	lock := &schedulerLock{
+		conn:     conn,
+		name:     name,
+		objectId: lockObjectId(name),
+		lost:     make(chan struct{}),
+		released: make(chan struct{}),
+	}
+	var acquired bool
+	err = conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1, $2)", schedulerLockClassId, lock.objectId).Scan(&acquired)
+	if err != nil {
+		lock.discardConn(ctx)
+		return nil, false, fmt.Errorf("error acquiring advisory lock %q: %w", name, err)
+	}
+	if !acquired {
+		if err = conn.Close(); err != nil {
+			mdctx.Errorf(ctx, "Error returning connection to the pool: %v", err)
+		}
+		return nil, false, nil
+	}
+
+	go lock.watch(time.Duration(config.Get().LeaderElection.CheckPeriodSeconds) * time.Second)
+	return lock, true, nil
+}
+
+// lockObjectId hashes the name of the lock into the second key of its advisory lock.
+func lockObjectId(name string) int32 {
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(name))
+	return int32(hash.Sum32())
+}
+
+// schedulerLock is a scheduler.Lock held by a dedicated connection.
+type schedulerLock struct {
+	conn     *sql.Conn
+	name     string
+	objectId int32
+	lost     chan struct{} // Closed when the lock is lost
+	released chan struct{} // Closed when the lock is released
+	once     sync.Once     // Ends the lock, by losing or releasing it
+}
+
+var _ scheduler.Lock = (*schedulerLock)(nil) // Interface guard
+
+func (lock *schedulerLock) Lost() <-chan struct{} {
+	return lock.lost
+}
+
+// Release unlocks the lock and returns the connection to the pool. The connection is closed instead if unlocking fails, which releases
+// the lock too.
+func (lock *schedulerLock) Release(ctx context.Context) error {
+	var err error
+	lock.once.Do(func() {
+		close(lock.released)
+		_, err = lock.conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1, $2)", schedulerLockClassId, lock.objectId)
+		if err != nil {
+			lock.discardConn(ctx)
+			err = fmt.Errorf("error releasing advisory lock %q: %w", lock.name, err)
+			return
+		}
+		err = lock.conn.Close()
+	})
+	return err
+}
+
+// watch checks that the lock is still held every period until it's released. The lock is lost if the check fails.
+func (lock *schedulerLock) watch(period time.Duration) {
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-lock.released:
+			return
+		case <-ticker.C:
+			ctx := mdctx.New()
+			if err := lock.check(ctx, period); err != nil {
+				lock.once.Do(func() {
+					mdctx.Warnf(ctx, "Lost advisory lock %q: %v", lock.name, err)
+					close(lock.lost)
+					lock.discardConn(ctx)
+				})
+				return
+			}
+		}
+	}
+}
+
+// check returns an error if the connection broke or the lock isn't held by its session anymore.
+func (lock *schedulerLock) check(ctx context.Context, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	var held bool
+	err := lock.conn.QueryRowContext(ctx,
+		"SELECT EXISTS (SELECT 1 FROM pg_locks WHERE locktype = 'advisory' AND pid = pg_backend_pid() AND classid = $1 AND objid = $2 AND objsubid = 2 AND granted)",
+		uint32(schedulerLockClassId), uint32(lock.objectId)).Scan(&held)
+	if err != nil {
+		return fmt.Errorf("error checking the lock: %w", err)
+	}
+	if !held {
+		return fmt.Errorf("the lock isn't held by its connection")
+	}
+	return nil
+}
+
+// discardConn closes the connection instead of returning it to the pool, so that a lock it may still hold can't leak to other users of
+// the pool.
+func (lock *schedulerLock) discardConn(ctx context.Context) {
+	_ = lock.conn.Raw(func(any) error {
+		return driver.ErrBadConn
+	})
+	if err := lock.conn.Close(); err != nil {
+		mdctx.Debugf(ctx, "Error closing discarded connection: %v", err)
+	}
+}
@@ -0,0 +1,46 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"github.com/GeneralKenobi/mailman/internal/db/migration"
+	"github.com/GeneralKenobi/mailman/pkg/mdctx"
+)
+
+//go:embed migrations/*.sql
+var embeddedMigrations embed.FS
+
+// migrationLockKey identifies the advisory lock held while migrating, so that replicas starting at the same time don't migrate
+// concurrently.
+const migrationLockKey = 0x6d61696c6d616e // "mailman" in ASCII
+
+// NewMigrator creates a migrator of the postgres DB with the migrations embedded in the binary. Applied migrations are recorded in
+// public.schema_migrations, so that reverting the first migration can drop the mailmandb schema.
+func NewMigrator(db *sql.DB) (*migration.Migrator, error) {
+	return migration.New(db, embeddedMigrations, "migrations", migration.Dialect{
+		Table: "public.schema_migrations",
+		Lock:  advisoryLock,
+	})
+}
+
+// Migrate applies the schema migrations that weren't applied yet.
+func (postgresCtx *Context) Migrate(ctx context.Context) error {
+	migrator, err := NewMigrator(postgresCtx.db)
+	if err != nil {
+		return err
+	}
+	return migrator.Up(ctx)
+}
+
+func advisoryLock(ctx context.Context, conn *sql.Conn) (func(), error) {
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", migrationLockKey); err != nil {
+		return nil, err
+	}
+	return func() {
+		// The lock is released with the session anyway, the context may be canceled already.
+		if _, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", migrationLockKey); err != nil {
+			mdctx.Errorf(ctx, "Error releasing migration lock: %v", err)
+		}
+	}, nil
+}
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS mailmandb.mailing_entry;
+DROP TABLE IF EXISTS mailmandb.customer;
+DROP SCHEMA IF EXISTS mailmandb;
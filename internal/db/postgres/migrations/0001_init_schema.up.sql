@@ -0,0 +1,45 @@
+-- The schema created by the former Postgres init scripts. Tables are only created if they don't exist, so that databases created by the
+-- init scripts are adopted. Later changes of the schema are separate migrations, so a schema that doesn't match this one isn't adopted.
+DO
+$$
+    BEGIN
+        IF EXISTS(SELECT FROM information_schema.tables WHERE table_schema = 'mailmandb') AND EXISTS(
+                WITH baseline_columns(table_name, column_name) AS (
+                    VALUES ('customer', 'id'), ('customer', 'email'),
+                           ('mailing_entry', 'id'), ('mailing_entry', 'customer_id'), ('mailing_entry', 'mailing_id'),
+                           ('mailing_entry', 'title'), ('mailing_entry', 'content'), ('mailing_entry', 'insert_time')),
+                     existing_columns AS (
+                         SELECT table_name::TEXT, column_name::TEXT FROM information_schema.columns WHERE table_schema = 'mailmandb')
+                (SELECT * FROM existing_columns EXCEPT SELECT * FROM baseline_columns)
+                UNION ALL
+                (SELECT * FROM baseline_columns EXCEPT SELECT * FROM existing_columns))
+        THEN
+            RAISE EXCEPTION 'existing schema mailmandb doesn''t match the schema created by the former init scripts, it can''t be adopted';
+        END IF;
+    END
+$$;
+
+CREATE SCHEMA IF NOT EXISTS mailmandb;
+SET LOCAL search_path TO mailmandb;
+
+CREATE TABLE IF NOT EXISTS customer
+(
+    id    SERIAL PRIMARY KEY,
+    email VARCHAR(255) NOT NULL CHECK (email <> ''),
+
+    CONSTRAINT unique_email UNIQUE (email)
+);
+CREATE INDEX IF NOT EXISTS customer_email ON customer (email);
+
+CREATE TABLE IF NOT EXISTS mailing_entry
+(
+    id          SERIAL PRIMARY KEY,
+    customer_id INT          NOT NULL,
+    mailing_id  INT          NOT NULL,
+    title       VARCHAR(255) NOT NULL CHECK (title <> ''),
+    content     TEXT,
+    insert_time TIMESTAMP    NOT NULL,
+
+    CONSTRAINT fk_customer FOREIGN KEY (customer_id) REFERENCES customer (id)
+);
+CREATE INDEX IF NOT EXISTS mailing_entry_insert_time ON mailing_entry (insert_time);
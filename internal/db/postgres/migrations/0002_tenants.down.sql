@@ -0,0 +1,11 @@
+-- Fails if customers of different tenants have the same email.
+DROP INDEX IF EXISTS mailmandb.mailing_entry_tenant_mailing_id;
+ALTER TABLE mailmandb.mailing_entry DROP CONSTRAINT fk_customer;
+ALTER TABLE mailmandb.mailing_entry DROP COLUMN tenant_id;
+ALTER TABLE mailmandb.mailing_entry ADD CONSTRAINT fk_customer FOREIGN KEY (customer_id) REFERENCES mailmandb.customer (id);
+
+ALTER TABLE mailmandb.customer DROP CONSTRAINT unique_id_tenant;
+ALTER TABLE mailmandb.customer DROP CONSTRAINT unique_email;
+ALTER TABLE mailmandb.customer DROP COLUMN tenant_id;
+ALTER TABLE mailmandb.customer ADD CONSTRAINT unique_email UNIQUE (email);
+CREATE INDEX customer_email ON mailmandb.customer (email);
@@ -0,0 +1,17 @@
+-- Customers and mailing entries belong to tenants. Existing ones are assigned to the default tenant (see auth.DefaultTenantId), which
+-- clients whose credentials don't specify a tenant belong to.
+ALTER TABLE mailmandb.customer ADD COLUMN tenant_id VARCHAR(64) NOT NULL DEFAULT 'default' CHECK (tenant_id <> '');
+ALTER TABLE mailmandb.customer ALTER COLUMN tenant_id DROP DEFAULT;
+-- Emails are unique per tenant. The unique constraint's index makes the separate email index redundant.
+ALTER TABLE mailmandb.customer DROP CONSTRAINT unique_email;
+DROP INDEX mailmandb.customer_email;
+ALTER TABLE mailmandb.customer ADD CONSTRAINT unique_email UNIQUE (tenant_id, email);
+ALTER TABLE mailmandb.customer ADD CONSTRAINT unique_id_tenant UNIQUE (id, tenant_id);
+
+ALTER TABLE mailmandb.mailing_entry ADD COLUMN tenant_id VARCHAR(64) NOT NULL DEFAULT 'default' CHECK (tenant_id <> '');
+ALTER TABLE mailmandb.mailing_entry ALTER COLUMN tenant_id DROP DEFAULT;
+-- Including tenant ID guarantees that the entry and its customer belong to the same tenant
+ALTER TABLE mailmandb.mailing_entry DROP CONSTRAINT fk_customer;
+ALTER TABLE mailmandb.mailing_entry
+    ADD CONSTRAINT fk_customer FOREIGN KEY (customer_id, tenant_id) REFERENCES mailmandb.customer (id, tenant_id);
+CREATE INDEX mailing_entry_tenant_mailing_id ON mailmandb.mailing_entry (tenant_id, mailing_id);
@@ -0,0 +1 @@
+ALTER TABLE mailmandb.mailing_entry DROP COLUMN IF EXISTS version;
@@ -0,0 +1,2 @@
+-- Incremented on every update for optimistic concurrency control
+ALTER TABLE mailmandb.mailing_entry ADD COLUMN version INT NOT NULL DEFAULT 1;
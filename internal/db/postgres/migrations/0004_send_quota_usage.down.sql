@@ -0,0 +1 @@
+DROP TABLE IF EXISTS mailmandb.send_quota_usage;
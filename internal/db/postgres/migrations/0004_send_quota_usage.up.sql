@@ -0,0 +1,9 @@
+-- Number of mailing entries sent by a tenant per day, for enforcing daily send quotas
+CREATE TABLE mailmandb.send_quota_usage
+(
+    tenant_id  VARCHAR(64) NOT NULL,
+    day        DATE        NOT NULL,
+    sent_count INT         NOT NULL,
+
+    PRIMARY KEY (tenant_id, day)
+);
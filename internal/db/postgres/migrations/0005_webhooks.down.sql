@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS mailmandb.webhook_delivery;
+DROP TABLE IF EXISTS mailmandb.webhook_event;
+DROP TABLE IF EXISTS mailmandb.webhook_subscription;
@@ -0,0 +1,47 @@
+-- Endpoints notified about events of a tenant
+CREATE TABLE mailmandb.webhook_subscription
+(
+    id          SERIAL PRIMARY KEY,
+    tenant_id   VARCHAR(64)   NOT NULL CHECK (tenant_id <> ''),
+    url         VARCHAR(2048) NOT NULL CHECK (url <> ''),
+    event_types VARCHAR(64)[] NOT NULL,
+    secret      VARCHAR(255)  NOT NULL CHECK (secret <> ''),
+    create_time TIMESTAMP     NOT NULL,
+
+    CONSTRAINT unique_webhook_subscription_id_tenant UNIQUE (id, tenant_id)
+);
+CREATE INDEX webhook_subscription_tenant_id ON mailmandb.webhook_subscription (tenant_id);
+
+-- Outbox of events, written in the same transaction as the change they describe
+CREATE TABLE mailmandb.webhook_event
+(
+    id          SERIAL PRIMARY KEY,
+    tenant_id   VARCHAR(64) NOT NULL CHECK (tenant_id <> ''),
+    event_type  VARCHAR(64) NOT NULL,
+    payload     TEXT        NOT NULL,
+    create_time TIMESTAMP   NOT NULL,
+
+    CONSTRAINT unique_webhook_event_id_tenant UNIQUE (id, tenant_id)
+);
+CREATE INDEX webhook_event_create_time ON mailmandb.webhook_event (create_time);
+
+-- Delivery of an event to a subscription, doubles as the delivery log
+CREATE TABLE mailmandb.webhook_delivery
+(
+    id                 SERIAL PRIMARY KEY,
+    tenant_id          VARCHAR(64) NOT NULL CHECK (tenant_id <> ''),
+    subscription_id    INT         NOT NULL,
+    event_id           INT         NOT NULL,
+    status             VARCHAR(16) NOT NULL,
+    attempt_count      INT         NOT NULL,
+    next_attempt_time  TIMESTAMP   NOT NULL,
+    last_attempt_time  TIMESTAMP,
+    last_response_code INT,
+    last_error         TEXT,
+
+    CONSTRAINT fk_subscription FOREIGN KEY (subscription_id, tenant_id)
+        REFERENCES mailmandb.webhook_subscription (id, tenant_id) ON DELETE CASCADE,
+    CONSTRAINT fk_event FOREIGN KEY (event_id, tenant_id) REFERENCES mailmandb.webhook_event (id, tenant_id) ON DELETE CASCADE
+);
+CREATE INDEX webhook_delivery_status_next_attempt_time ON mailmandb.webhook_delivery (status, next_attempt_time);
+CREATE INDEX webhook_delivery_tenant_subscription_id ON mailmandb.webhook_delivery (tenant_id, subscription_id);
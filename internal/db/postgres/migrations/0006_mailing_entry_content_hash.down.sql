@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS mailmandb.mailing_entry_unique_content;
+ALTER TABLE mailmandb.mailing_entry DROP COLUMN IF EXISTS content_hash;
@@ -0,0 +1,18 @@
+-- Duplicate mailing entries were rejected by the application, which couldn't prevent concurrent requests from both creating the same
+-- entry. The unique index rejects them in the DB. It uses a hash of the title and content (see model.MailingEntry ContentHash), so that
+-- long contents don't make the index large.
+ALTER TABLE mailmandb.mailing_entry ADD COLUMN content_hash CHAR(64);
+UPDATE mailmandb.mailing_entry
+SET content_hash = encode(sha256(convert_to(octet_length(title) || ':' || title || COALESCE(content, ''), 'UTF8')), 'hex');
+ALTER TABLE mailmandb.mailing_entry ALTER COLUMN content_hash SET NOT NULL;
+
+-- Duplicates created by concurrent requests are equal, the first one is kept.
+DELETE FROM mailmandb.mailing_entry AS duplicate
+    USING mailmandb.mailing_entry AS kept
+WHERE duplicate.customer_id = kept.customer_id
+  AND duplicate.mailing_id = kept.mailing_id
+  AND duplicate.content_hash = kept.content_hash
+  AND duplicate.insert_time = kept.insert_time
+  AND duplicate.id > kept.id;
+
+CREATE UNIQUE INDEX mailing_entry_unique_content ON mailmandb.mailing_entry (customer_id, mailing_id, content_hash, insert_time);
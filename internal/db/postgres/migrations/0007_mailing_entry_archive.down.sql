@@ -0,0 +1 @@
+DROP TABLE IF EXISTS mailmandb.mailing_entry_archive;
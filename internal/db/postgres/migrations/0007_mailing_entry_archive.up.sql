@@ -0,0 +1,18 @@
+-- Sent, bounced and stale mailing entries are moved here instead of being deleted, and removed after the archive's retention period.
+-- There's no foreign key to the customer, archived entries outlive deleted customers. Their email is copied for the same reason.
+CREATE TABLE mailmandb.mailing_entry_archive
+(
+    id             INT PRIMARY KEY, -- ID of the archived mailing entry
+    tenant_id      VARCHAR(64)  NOT NULL,
+    customer_id    INT          NOT NULL,
+    customer_email VARCHAR(255) NOT NULL,
+    mailing_id     INT          NOT NULL,
+    title          VARCHAR(255) NOT NULL,
+    content        TEXT,
+    insert_time    TIMESTAMP    NOT NULL,
+    archive_time   TIMESTAMP    NOT NULL,
+    reason         VARCHAR(16)  NOT NULL
+);
+CREATE INDEX mailing_entry_archive_tenant_archive_time ON mailmandb.mailing_entry_archive (tenant_id, archive_time);
+CREATE INDEX mailing_entry_archive_tenant_customer_email ON mailmandb.mailing_entry_archive (tenant_id, customer_email);
+CREATE INDEX mailing_entry_archive_tenant_mailing_id ON mailmandb.mailing_entry_archive (tenant_id, mailing_id);
@@ -0,0 +1,13 @@
+-- Entries of all partitions are moved back to a regular table.
+CREATE TABLE mailmandb.mailing_entry_unpartitioned (LIKE mailmandb.mailing_entry INCLUDING DEFAULTS INCLUDING CONSTRAINTS);
+INSERT INTO mailmandb.mailing_entry_unpartitioned SELECT * FROM mailmandb.mailing_entry;
+ALTER SEQUENCE mailmandb.mailing_entry_id_seq OWNED BY mailmandb.mailing_entry_unpartitioned.id;
+DROP TABLE mailmandb.mailing_entry;
+
+ALTER TABLE mailmandb.mailing_entry_unpartitioned RENAME TO mailing_entry;
+ALTER TABLE mailmandb.mailing_entry ADD CONSTRAINT mailing_entry_pkey PRIMARY KEY (id);
+ALTER TABLE mailmandb.mailing_entry
+    ADD CONSTRAINT fk_customer FOREIGN KEY (customer_id, tenant_id) REFERENCES mailmandb.customer (id, tenant_id);
+CREATE INDEX mailing_entry_insert_time ON mailmandb.mailing_entry (insert_time);
+CREATE INDEX mailing_entry_tenant_mailing_id ON mailmandb.mailing_entry (tenant_id, mailing_id);
+CREATE UNIQUE INDEX mailing_entry_unique_content ON mailmandb.mailing_entry (customer_id, mailing_id, content_hash, insert_time);
@@ -0,0 +1,34 @@
+-- Mailing entries are partitioned by insert time, so that expired entries can be archived and dropped a partition at a time instead of
+-- row by row. Partitions are created ahead of time and dropped by the partition maintenance job. The existing table becomes the default
+-- partition, which stores entries inserted before partitioning and entries outside of all partitions.
+ALTER TABLE mailmandb.mailing_entry RENAME TO mailing_entry_default;
+-- A table can only have one primary key, the partitioned table's one includes the insert time.
+ALTER TABLE mailmandb.mailing_entry_default DROP CONSTRAINT mailing_entry_pkey;
+ALTER INDEX mailmandb.mailing_entry_insert_time RENAME TO mailing_entry_default_insert_time;
+ALTER INDEX mailmandb.mailing_entry_tenant_mailing_id RENAME TO mailing_entry_default_tenant_mailing_id;
+ALTER INDEX mailmandb.mailing_entry_unique_content RENAME TO mailing_entry_default_unique_content;
+
+CREATE TABLE mailmandb.mailing_entry
+(
+    id           INT          NOT NULL DEFAULT nextval('mailmandb.mailing_entry_id_seq'),
+    tenant_id    VARCHAR(64)  NOT NULL CHECK (tenant_id <> ''),
+    customer_id  INT          NOT NULL,
+    mailing_id   INT          NOT NULL,
+    title        VARCHAR(255) NOT NULL CHECK (title <> ''),
+    content      TEXT,
+    insert_time  TIMESTAMP    NOT NULL,
+    version      INT          NOT NULL DEFAULT 1, -- Incremented on every update for optimistic concurrency control
+    content_hash CHAR(64)     NOT NULL,
+
+    -- Unique keys of a partitioned table have to include the partition key
+    PRIMARY KEY (id, insert_time),
+    CONSTRAINT fk_customer FOREIGN KEY (customer_id, tenant_id) REFERENCES mailmandb.customer (id, tenant_id)
+) PARTITION BY RANGE (insert_time);
+ALTER SEQUENCE mailmandb.mailing_entry_id_seq OWNED BY mailmandb.mailing_entry.id;
+
+-- Equal indexes of the default partition are attached to these instead of being created again.
+CREATE INDEX mailing_entry_insert_time ON mailmandb.mailing_entry (insert_time);
+CREATE INDEX mailing_entry_tenant_mailing_id ON mailmandb.mailing_entry (tenant_id, mailing_id);
+CREATE UNIQUE INDEX mailing_entry_unique_content ON mailmandb.mailing_entry (customer_id, mailing_id, content_hash, insert_time);
+
+ALTER TABLE mailmandb.mailing_entry ATTACH PARTITION mailmandb.mailing_entry_default DEFAULT;
@@ -0,0 +1,64 @@
+package postgres
+
+import (
+	"context"
+	"github.com/GeneralKenobi/mailman/internal/db"
+	"github.com/GeneralKenobi/mailman/internal/db/model"
+	"github.com/GeneralKenobi/mailman/internal/db/postgres/repository"
+	"time"
+)
+
+var _ db.Partitioner = (*Context)(nil) // Interface guard
+
+func (postgresCtx *Context) FindMailingEntryPartitions(ctx context.Context) ([]model.MailingEntryPartition, error) {
+	return repository.New(postgresCtx.db, postgresCtx.defaultTimeout).FindMailingEntryPartitions(ctx)
+}
+
+func (postgresCtx *Context) CreateMailingEntryPartition(ctx context.Context, start, end time.Time) error {
+	return postgresCtx.inTransaction(ctx, func(transactionalRepository *repository.Repository) error {
+		return transactionalRepository.CreateMailingEntryPartition(ctx, start, end)
+	})
+}
+
+// DropMailingEntryPartition archives the entries of the partition batch by batch, locking only the archived entries, and then detaches and
+// drops the partition, archiving entries inserted meanwhile. Detaching locks all mailing entries until the partition is dropped, the
+// batches keep that short.
+func (postgresCtx *Context) DropMailingEntryPartition(ctx context.Context, partition model.MailingEntryPartition,
+	reason model.ArchiveReason, archiveTime time.Time, batchSize int) (int64, error) {
+
+	var archivedCount int64
+	for {
+		var batchCount int64
+		err := postgresCtx.inTransaction(ctx, func(transactionalRepository *repository.Repository) error {
+			var err error
+			batchCount, err = transactionalRepository.ArchiveMailingEntryPartitionBatch(ctx, partition, reason, archiveTime, batchSize)
+			return err
+		})
+		if err != nil {
+			return archivedCount, err
+		}
+		archivedCount += batchCount
+		if batchCount == 0 || batchCount < int64(batchSize) {
+			break
+		}
+	}
+
+	var remainingCount int64
+	err := postgresCtx.inTransaction(ctx, func(transactionalRepository *repository.Repository) error {
+		var err error
+		remainingCount, err = transactionalRepository.DropMailingEntryPartition(ctx, partition, reason, archiveTime)
+		return err
+	})
+	if err != nil {
+		return archivedCount, err
+	}
+	return archivedCount + remainingCount, nil
+}
+
+// inTransaction runs the function with a repository of the postgres-specific queries, which aren't part of db.Repository, in a
+// transaction opened by db.InTransaction.
+func (postgresCtx *Context) inTransaction(ctx context.Context, todo func(transactionalRepository *repository.Repository) error) error {
+	return db.InTransaction(ctx, postgresCtx, func(transactionalRepository db.Repository) error {
+		return todo(db.Unwrap(transactionalRepository).(*repository.Repository))
+	})
+}
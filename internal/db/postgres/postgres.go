@@ -10,28 +10,91 @@ import (
 	"github.com/GeneralKenobi/mailman/pkg/mdctx"
 	"github.com/GeneralKenobi/mailman/pkg/shutdown"
 	_ "github.com/lib/pq" // Postgres driver registration by import.
+	"net"
+	"net/url"
+	"strconv"
+	"time"
 )
 
 // Context implements DB integration for postgres.
 type Context struct {
-	db *sql.DB
+	db             *sql.DB
+	defaultTimeout time.Duration // Applied to repository operations whose context has no deadline, none if 0
 }
 
 var _ db.Context = (*Context)(nil) // Interface guard
+var _ db.Pinger = (*Context)(nil)  // Interface guard
 
 // NewContext creates a postgres DB context. The DB client is closed when context is canceled.
 func NewContext(ctx shutdown.Context) (*Context, error) {
+	db, err := Open()
+	if err != nil {
+		return nil, err
+	}
+
+	dbCtx := Context{db: db, defaultTimeout: time.Duration(config.Get().Postgres.DefaultTimeoutSeconds) * time.Second}
+	go shutdownDbOnContextCancellation(ctx, db)
+	return &dbCtx, nil
+}
+
+// Open creates a DB client for the configured postgres DB. The caller is responsible for closing it.
+func Open() (*sql.DB, error) {
 	cfg := config.Get().Postgres
-	// TODO: Add configuration for ssl
-	connectionString := fmt.Sprintf("postgres://%s:%s@%s:%d/%s?sslmode=disable", cfg.User, cfg.Password, cfg.Host, cfg.Port, cfg.Database)
+	connectionString, err := connectionString(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("connection configuration is invalid: %w", err)
+	}
 	db, err := sql.Open("postgres", connectionString)
 	if err != nil {
 		return nil, fmt.Errorf("connection configuration is invalid: %w", err)
 	}
 
-	dbCtx := Context{db: db}
-	go shutdownDbOnContextCancellation(ctx, db)
-	return &dbCtx, nil
+	db.SetMaxOpenConns(cfg.MaxOpenConnections)
+	db.SetMaxIdleConns(cfg.MaxIdleConnections)
+	db.SetConnMaxLifetime(time.Duration(cfg.ConnectionMaxLifetimeSeconds) * time.Second)
+	db.SetConnMaxIdleTime(time.Duration(cfg.ConnectionMaxIdleSeconds) * time.Second)
+	return db, nil
+}
+
+var sslModes = map[string]bool{"disable": true, "require": true, "verify-ca": true, "verify-full": true}
+
+// connectionString creates a postgres URL with the connection parameters supported by lib/pq. Parameters lib/pq doesn't know, like
+// statement_timeout, are set as run-time parameters of the connections.
+func connectionString(cfg config.Postgres) (string, error) {
+	if !sslModes[cfg.SslMode] {
+		return "", fmt.Errorf("unsupported sslMode %q", cfg.SslMode)
+	}
+	if (cfg.SslCertFile == "") != (cfg.SslKeyFile == "") {
+		return "", fmt.Errorf("sslCertFile and sslKeyFile have to be set together")
+	}
+
+	params := url.Values{"sslmode": {cfg.SslMode}}
+	optionalParams := map[string]string{
+		"sslrootcert":      cfg.SslRootCertFile,
+		"sslcert":          cfg.SslCertFile,
+		"sslkey":           cfg.SslKeyFile,
+		"application_name": cfg.ApplicationName,
+	}
+	if cfg.ConnectTimeoutSeconds > 0 {
+		optionalParams["connect_timeout"] = strconv.Itoa(cfg.ConnectTimeoutSeconds)
+	}
+	if cfg.StatementTimeoutMillis > 0 {
+		optionalParams["statement_timeout"] = strconv.Itoa(cfg.StatementTimeoutMillis)
+	}
+	for name, value := range optionalParams {
+		if value != "" {
+			params.Set(name, value)
+		}
+	}
+
+	connectionUrl := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(cfg.User, cfg.Password),
+		Host:     net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port)),
+		Path:     "/" + cfg.Database,
+		RawQuery: params.Encode(),
+	}
+	return connectionUrl.String(), nil
 }
 
 func shutdownDbOnContextCancellation(ctx shutdown.Context, db *sql.DB) {
@@ -51,17 +114,25 @@ func shutdownDb(db *sql.DB) {
 	mdctx.Infof(nil, "DB connection closed")
 }
 
+func (postgresCtx *Context) Ping(ctx context.Context) error {
+	return postgresCtx.db.PingContext(ctx)
+}
+
+func (postgresCtx *Context) Stats() sql.DBStats {
+	return postgresCtx.db.Stats()
+}
+
 func (postgresCtx *Context) Repository(ctx context.Context) (db.Repository, error) {
-	return repository.New(postgresCtx.db), nil
+	return repository.New(postgresCtx.db, postgresCtx.defaultTimeout), nil
 }
 
-func (postgresCtx *Context) TransactionalRepository(ctx context.Context) (db.Repository, db.Transaction, error) {
-	transaction, err := postgresCtx.db.BeginTx(ctx, nil)
+func (postgresCtx *Context) TransactionalRepository(ctx context.Context, isolation sql.IsolationLevel) (db.Repository, db.Transaction, error) {
+	transaction, err := postgresCtx.db.BeginTx(ctx, &sql.TxOptions{Isolation: isolation})
 	if err != nil {
 		return nil, nil, fmt.Errorf("error beginning a transaction: %w", err)
 	}
 
-	transactionalRepository := repository.New(transaction)
+	transactionalRepository := repository.New(transaction, postgresCtx.defaultTimeout)
 	transactionCtx := &transactionContext{transaction: transaction}
 	return transactionalRepository, transactionCtx, nil
 }
@@ -74,9 +145,24 @@ type transactionContext struct {
 var _ db.Transaction = (*transactionContext)(nil) // Interface guard
 
 func (transactionCtx *transactionContext) Commit() error {
-	return transactionCtx.transaction.Commit()
+	return repository.TranslateError(transactionCtx.transaction.Commit())
 }
 
 func (transactionCtx *transactionContext) Rollback() error {
 	return transactionCtx.transaction.Rollback()
 }
+
+func (transactionCtx *transactionContext) Savepoint(ctx context.Context, name string) error {
+	_, err := transactionCtx.transaction.ExecContext(ctx, "SAVEPOINT "+name)
+	return repository.TranslateError(err)
+}
+
+func (transactionCtx *transactionContext) RollbackToSavepoint(ctx context.Context, name string) error {
+	_, err := transactionCtx.transaction.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+name)
+	return repository.TranslateError(err)
+}
+
+func (transactionCtx *transactionContext) ReleaseSavepoint(ctx context.Context, name string) error {
+	_, err := transactionCtx.transaction.ExecContext(ctx, "RELEASE SAVEPOINT "+name)
+	return repository.TranslateError(err)
+}
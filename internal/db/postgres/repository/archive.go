@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"github.com/GeneralKenobi/mailman/internal/db/model"
+	"time"
+)
+
+func (repository *Repository) FindArchivedMailingEntries(
+	ctx context.Context, tenantId string, filter model.ArchivedMailingEntryFilter, limit int) ([]model.ArchivedMailingEntry, error) {
+
+	return selectingAll(ctx, "find archived mailing entries", repository.sql, archivedMailingEntryRowScanSupplier,
+		"SELECT id, tenant_id, customer_id, customer_email, mailing_id, title, content, insert_time, archive_time, reason FROM mailmandb.mailing_entry_archive WHERE tenant_id = $1 AND ($2::INT IS NULL OR mailing_id = $2) AND ($3::VARCHAR IS NULL OR customer_email = $3) AND ($4::TIMESTAMP IS NULL OR archive_time >= $4) AND ($5::TIMESTAMP IS NULL OR archive_time < $5) ORDER BY archive_time DESC, id DESC LIMIT $6",
+		tenantId, filter.MailingId, filter.CustomerEmail, filter.ArchivedAfter, filter.ArchivedBefore, limit)
+}
+
+func (repository *Repository) ArchiveMailingEntryById(
+	ctx context.Context, tenantId string, id int, reason model.ArchiveReason, archiveTime time.Time) error {
+
+	return affectingOne(ctx, "archive mailing entry by ID", repository.sql,
+		"WITH archived AS (DELETE FROM mailmandb.mailing_entry WHERE tenant_id = $1 AND id = $2 RETURNING *) "+archiveInsert("$3", "$4"),
+		tenantId, id, archiveTime, reason)
+}
+
+// ArchiveMailingEntryBatch skips entries locked by concurrent transactions, they're archived by a following batch.
+func (repository *Repository) ArchiveMailingEntryBatch(ctx context.Context, tenantId string, filter model.MailingEntryFilter, limit int,
+	reason model.ArchiveReason, archiveTime time.Time) (int64, error) {
+
+	return affectingMany(ctx, "archive mailing entry batch", repository.sql,
+		"WITH archived AS (DELETE FROM mailmandb.mailing_entry WHERE id IN (SELECT id FROM mailmandb.mailing_entry WHERE "+mailingEntryFilterCondition+" ORDER BY id LIMIT $6 FOR UPDATE SKIP LOCKED) RETURNING *) "+archiveInsert("$7", "$8"),
+		append(mailingEntryFilterArgs(tenantId, filter), limit, archiveTime, reason)...)
+}
+
+// DeleteArchivedMailingEntryBatch skips entries locked by concurrent transactions, they're deleted by a following batch.
+func (repository *Repository) DeleteArchivedMailingEntryBatch(ctx context.Context, tenantId string, archivedBefore time.Time, limit int) (int64, error) {
+	return affectingMany(ctx, "delete archived mailing entry batch", repository.sql,
+		"DELETE FROM mailmandb.mailing_entry_archive WHERE id IN (SELECT id FROM mailmandb.mailing_entry_archive WHERE tenant_id = $1 AND archive_time < $2 ORDER BY archive_time LIMIT $3 FOR UPDATE SKIP LOCKED)",
+		tenantId, archivedBefore, limit)
+}
+
+// archiveInsert inserts the mailing entries deleted by the "archived" CTE into the archive, with the archive time and reason given by the
+// query parameters.
+func archiveInsert(archiveTimeParam, reasonParam string) string {
+	return "INSERT INTO mailmandb.mailing_entry_archive(id, tenant_id, customer_id, customer_email, mailing_id, title, content, insert_time, archive_time, reason) " +
+		"SELECT a.id, a.tenant_id, a.customer_id, c.email, a.mailing_id, a.title, a.content, a.insert_time, " + archiveTimeParam + ", " + reasonParam +
+		" FROM archived a JOIN mailmandb.customer c ON c.id = a.customer_id"
+}
+
+func archivedMailingEntryRowScanSupplier() (*model.ArchivedMailingEntry, []any) {
+	var archivedEntry model.ArchivedMailingEntry
+	return &archivedEntry, []any{
+		&archivedEntry.Id,
+		&archivedEntry.TenantId,
+		&archivedEntry.CustomerId,
+		&archivedEntry.CustomerEmail,
+		&archivedEntry.MailingId,
+		&archivedEntry.Title,
+		&archivedEntry.Content,
+		&archivedEntry.InsertTime,
+		&archivedEntry.ArchiveTime,
+		&archivedEntry.Reason,
+	}
+}
@@ -2,33 +2,49 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"github.com/GeneralKenobi/mailman/internal/db"
 	"github.com/GeneralKenobi/mailman/internal/db/model"
 )
 
-func (repository *Repository) FindCustomerById(ctx context.Context, id int) (model.Customer, error) {
+func (repository *Repository) FindCustomerById(ctx context.Context, tenantId string, id int) (model.Customer, error) {
 	return selectingOne(ctx, "find customer by ID", repository.sql, customerRowScanSupplier,
-		"SELECT id, email FROM mailmandb.customer WHERE id = $1", id)
+		"SELECT id, tenant_id, email FROM mailmandb.customer WHERE tenant_id = $1 AND id = $2", tenantId, id)
 }
 
-func (repository *Repository) FindCustomerByEmail(ctx context.Context, email string) (model.Customer, error) {
+func (repository *Repository) FindCustomerByEmail(ctx context.Context, tenantId, email string) (model.Customer, error) {
 	return selectingOne(ctx, "find customer by email", repository.sql, customerRowScanSupplier,
-		"SELECT id, email FROM mailmandb.customer WHERE email = $1", email)
+		"SELECT id, tenant_id, email FROM mailmandb.customer WHERE tenant_id = $1 AND email = $2", tenantId, email)
 }
 
 func (repository *Repository) InsertCustomer(ctx context.Context, customer model.Customer) (model.Customer, error) {
 	return selectingOne(ctx, "insert customer", repository.sql, customerRowScanSupplier,
-		"INSERT INTO mailmandb.customer(email) VALUES($1) RETURNING id, email", customer.Email)
+		"INSERT INTO mailmandb.customer(tenant_id, email) VALUES($1, $2) RETURNING id, tenant_id, email", customer.TenantId, customer.Email)
 }
 
-func (repository *Repository) DeleteCustomerById(ctx context.Context, id int) error {
+func (repository *Repository) FindOrInsertCustomer(ctx context.Context, customer model.Customer) (model.Customer, error) {
+	// Nothing is returned if the customer exists. Inserting waits for concurrent transactions inserting the same customer, so the existing
+	// one is visible to the select then. It's locked against deletion, like inserting mailing entries of the customer does.
+	insertedCustomer, err := selectingOne(ctx, "insert customer if it doesn't exist", repository.sql, customerRowScanSupplier,
+		"INSERT INTO mailmandb.customer(tenant_id, email) VALUES($1, $2) ON CONFLICT ON CONSTRAINT unique_email DO NOTHING RETURNING id, tenant_id, email",
+		customer.TenantId, customer.Email)
+	if !errors.Is(err, db.ErrNoRows) {
+		return insertedCustomer, err
+	}
+	return selectingOne(ctx, "find existing customer", repository.sql, customerRowScanSupplier,
+		"SELECT id, tenant_id, email FROM mailmandb.customer WHERE tenant_id = $1 AND email = $2 FOR KEY SHARE", customer.TenantId, customer.Email)
+}
+
+func (repository *Repository) DeleteCustomerById(ctx context.Context, tenantId string, id int) error {
 	return affectingOne(ctx, "delete customer by ID", repository.sql,
-		"DELETE FROM mailmandb.customer WHERE id = $1", id)
+		"DELETE FROM mailmandb.customer WHERE tenant_id = $1 AND id = $2", tenantId, id)
 }
 
 func customerRowScanSupplier() (*model.Customer, []any) {
 	var customer model.Customer
 	return &customer, []any{
 		&customer.Id,
+		&customer.TenantId,
 		&customer.Email,
 	}
 }
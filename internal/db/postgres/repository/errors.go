@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"github.com/GeneralKenobi/mailman/internal/db"
+	"github.com/lib/pq"
+)
+
+// SQLSTATE codes of errors translated into db package errors, see https://www.postgresql.org/docs/current/errcodes-appendix.html.
+const (
+	stringDataRightTruncationCode = "22001"
+	uniqueViolationCode           = "23505"
+	serializationFailureCode      = "40001"
+	deadlockDetectedCode          = "40P01"
+)
+
+// TranslateError converts postgres errors into their db package equivalents, so that callers don't depend on the driver: unique
+// violations into db.ErrDuplicate, serialization failures and deadlocks into db.ErrSerializationFailure, values too long for their
+// VARCHAR columns into db.ErrValueTooLong. Other errors are returned as they are.
+func TranslateError(err error) error {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return err
+	}
+	switch pqErr.Code {
+	case uniqueViolationCode:
+		return fmt.Errorf("%w: %v", db.ErrDuplicate, err)
+	case serializationFailureCode, deadlockDetectedCode:
+		return fmt.Errorf("%w: %v", db.ErrSerializationFailure, err)
+	case stringDataRightTruncationCode:
+		return fmt.Errorf("%w: %v", db.ErrValueTooLong, err)
+	default:
+		return err
+	}
+}
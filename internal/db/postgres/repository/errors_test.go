@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"errors"
+	"github.com/GeneralKenobi/mailman/internal/db"
+	"github.com/lib/pq"
+	"testing"
+)
+
+func TestTranslateError(t *testing.T) {
+	tests := map[string]struct {
+		err                          error
+		expectedSerializationFailure bool
+		expectedDuplicate            bool
+		expectedValueTooLong         bool
+	}{
+		"Should translate a serialization failure": {
+			err:                          &pq.Error{Code: "40001"},
+			expectedSerializationFailure: true,
+		},
+		"Should translate a deadlock": {
+			err:                          &pq.Error{Code: "40P01"},
+			expectedSerializationFailure: true,
+		},
+		"Should translate a unique violation": {
+			err:               &pq.Error{Code: "23505"},
+			expectedDuplicate: true,
+		},
+		"Should translate a string data right truncation": {
+			err:                  &pq.Error{Code: "22001"},
+			expectedValueTooLong: true,
+		},
+		"Should not translate a foreign key violation": {
+			err: &pq.Error{Code: "23503"},
+		},
+		"Should not translate errors of other packages": {
+			err: errors.New("connection refused"),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			translated := TranslateError(test.err)
+
+			if errors.Is(translated, db.ErrSerializationFailure) != test.expectedSerializationFailure {
+				t.Errorf("Expected serialization failure %v but got %v", test.expectedSerializationFailure, translated)
+			}
+			if errors.Is(translated, db.ErrDuplicate) != test.expectedDuplicate {
+				t.Errorf("Expected duplicate %v but got %v", test.expectedDuplicate, translated)
+			}
+			if errors.Is(translated, db.ErrValueTooLong) != test.expectedValueTooLong {
+				t.Errorf("Expected value too long %v but got %v", test.expectedValueTooLong, translated)
+			}
+			if !test.expectedSerializationFailure && !test.expectedDuplicate && !test.expectedValueTooLong && translated != test.err {
+				t.Errorf("Expected the error to be returned as it is but got %v", translated)
+			}
+		})
+	}
+}
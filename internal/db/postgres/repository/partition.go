@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"github.com/GeneralKenobi/mailman/internal/db/model"
+	"github.com/lib/pq"
+	"regexp"
+	"sort"
+	"time"
+)
+
+// Partitions of mailing entries are named after the start of their range, e.g. mailing_entry_p20220330.
+const (
+	partitionNamePrefix = "mailing_entry_p"
+	partitionNameLayout = "20060102"
+	// partitionBoundLayout is the format of timestamps in partition bounds, insert times don't have a time zone.
+	partitionBoundLayout = "2006-01-02 15:04:05"
+)
+
+var (
+	partitionNamePattern  = regexp.MustCompile(`^` + partitionNamePrefix + `\d{8}$`)
+	partitionBoundPattern = regexp.MustCompile(`^FOR VALUES FROM \('([^']+)'\) TO \('([^']+)'\)$`)
+)
+
+// mailingEntryColumns are the columns of mailing entries, in the order of the table definition.
+const mailingEntryColumns = "id, tenant_id, customer_id, mailing_id, title, content, insert_time, version, content_hash"
+
+// FindMailingEntryPartitions skips partitions that weren't created by CreateMailingEntryPartition.
+func (repository *Repository) FindMailingEntryPartitions(ctx context.Context) ([]model.MailingEntryPartition, error) {
+	type partitionRow struct {
+		name  string
+		bound string
+	}
+	rows, err := selectingAll(ctx, "find mailing entry partitions", repository.sql, func() (*partitionRow, []any) {
+		var row partitionRow
+		return &row, []any{&row.name, &row.bound}
+	}, "SELECT c.relname, pg_get_expr(c.relpartbound, c.oid) FROM pg_inherits i JOIN pg_class c ON c.oid = i.inhrelid WHERE i.inhparent = 'mailmandb.mailing_entry'::regclass")
+	if err != nil {
+		return nil, err
+	}
+
+	var partitions []model.MailingEntryPartition
+	for _, row := range rows {
+		bounds := partitionBoundPattern.FindStringSubmatch(row.bound)
+		if !partitionNamePattern.MatchString(row.name) || bounds == nil {
+			continue
+		}
+		start, err := time.Parse(partitionBoundLayout, bounds[1])
+		if err != nil {
+			return nil, fmt.Errorf("find mailing entry partitions: invalid start of partition %s: %w", row.name, err)
+		}
+		end, err := time.Parse(partitionBoundLayout, bounds[2])
+		if err != nil {
+			return nil, fmt.Errorf("find mailing entry partitions: invalid end of partition %s: %w", row.name, err)
+		}
+		partitions = append(partitions, model.MailingEntryPartition{Name: row.name, Start: start, End: end})
+	}
+	sort.Slice(partitions, func(i, j int) bool {
+		return partitions[i].Start.Before(partitions[j].Start)
+	})
+	return partitions, nil
+}
+
+// CreateMailingEntryPartition creates the partition as a regular table and attaches it after moving the entries in its range from the
+// default partition, which can't contain entries of an attached partition. Has to run in a transaction.
+func (repository *Repository) CreateMailingEntryPartition(ctx context.Context, start, end time.Time) error {
+	start, end = start.UTC(), end.UTC()
+	table := pq.QuoteIdentifier(partitionNamePrefix + start.Format(partitionNameLayout))
+
+	_, err := affectingMany(ctx, "create mailing entry partition", repository.sql,
+		"CREATE TABLE mailmandb."+table+" (LIKE mailmandb.mailing_entry INCLUDING DEFAULTS INCLUDING CONSTRAINTS)")
+	if err != nil {
+		return err
+	}
+	_, err = affectingMany(ctx, "move mailing entries to partition", repository.sql,
+		"WITH moved AS (DELETE FROM mailmandb.mailing_entry_default WHERE insert_time >= $1 AND insert_time < $2 RETURNING "+mailingEntryColumns+") "+
+			"INSERT INTO mailmandb."+table+"("+mailingEntryColumns+") SELECT "+mailingEntryColumns+" FROM moved",
+		start, end)
+	if err != nil {
+		return err
+	}
+	// Bounds of partitions can't be query parameters.
+	_, err = affectingMany(ctx, "attach mailing entry partition", repository.sql,
+		fmt.Sprintf("ALTER TABLE mailmandb.mailing_entry ATTACH PARTITION mailmandb.%s FOR VALUES FROM ('%s') TO ('%s')",
+			table, start.Format(partitionBoundLayout), end.Format(partitionBoundLayout)))
+	return err
+}
+
+// ArchiveMailingEntryPartitionBatch moves up to batchSize entries of the partition to the archive. Entries locked by other transactions
+// are skipped. Returns the number of archived entries.
+func (repository *Repository) ArchiveMailingEntryPartitionBatch(ctx context.Context, partition model.MailingEntryPartition,
+	reason model.ArchiveReason, archiveTime time.Time, batchSize int) (int64, error) {
+
+	table, err := partitionTable(partition)
+	if err != nil {
+		return 0, fmt.Errorf("archive mailing entry partition batch: %w", err)
+	}
+	return affectingMany(ctx, "archive mailing entry partition batch", repository.sql,
+		"WITH archived AS (DELETE FROM mailmandb."+table+" WHERE id IN "+
+			"(SELECT id FROM mailmandb."+table+" ORDER BY id LIMIT $3 FOR UPDATE SKIP LOCKED) RETURNING *) "+
+			archiveInsert("$1", "$2"),
+		archiveTime, reason, batchSize)
+}
+
+// DropMailingEntryPartition detaches the partition first, which locks the mailing entries until the end of the transaction, so that no
+// entry is inserted to the partition after it's archived. Entries left in the partition are archived before it's dropped, archiving them
+// with ArchiveMailingEntryPartitionBatch first keeps the lock short. Has to run in a transaction.
+func (repository *Repository) DropMailingEntryPartition(ctx context.Context, partition model.MailingEntryPartition,
+	reason model.ArchiveReason, archiveTime time.Time) (int64, error) {
+
+	table, err := partitionTable(partition)
+	if err != nil {
+		return 0, fmt.Errorf("drop mailing entry partition: %w", err)
+	}
+
+	_, err = affectingMany(ctx, "detach mailing entry partition", repository.sql,
+		"ALTER TABLE mailmandb.mailing_entry DETACH PARTITION mailmandb."+table)
+	if err != nil {
+		return 0, err
+	}
+	archivedCount, err := affectingMany(ctx, "archive mailing entry partition", repository.sql,
+		"WITH archived AS (SELECT * FROM mailmandb."+table+") "+archiveInsert("$1", "$2"),
+		archiveTime, reason)
+	if err != nil {
+		return 0, err
+	}
+	_, err = affectingMany(ctx, "drop mailing entry partition", repository.sql, "DROP TABLE mailmandb."+table)
+	if err != nil {
+		return 0, err
+	}
+	return archivedCount, nil
+}
+
+// partitionTable returns the quoted name of the partition's table. Names of tables can't be query parameters, so only names of partitions
+// created by CreateMailingEntryPartition are accepted.
+func partitionTable(partition model.MailingEntryPartition) (string, error) {
+	if !partitionNamePattern.MatchString(partition.Name) {
+		return "", fmt.Errorf("%q isn't a mailing entry partition", partition.Name)
+	}
+	return pq.QuoteIdentifier(partition.Name), nil
+}
@@ -16,12 +16,14 @@ type rowScanSupplier[T any] func() (scanTarget *T, scanTargetProperties []any)
 // selectingAll executes the query and converts obtained rows into a slice of T. It's not considered an error if the query returned no rows.
 //
 // queryName is only used in error messages.
-func selectingAll[T any](ctx context.Context, queryName string, sql SqlExecutor, rowScanSupplier rowScanSupplier[T],
+func selectingAll[T any](ctx context.Context, queryName string, sql executor, rowScanSupplier rowScanSupplier[T],
 	query string, args ...any) ([]T, error) {
 
+	ctx, cancel := sql.withDefaultTimeout(ctx)
+	defer cancel()
 	rows, err := sql.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("%s: error running query: %w", queryName, err)
+		return nil, fmt.Errorf("%s: error running query: %w", queryName, TranslateError(err))
 	}
 	defer closeRows(ctx, rows)
 
@@ -30,7 +32,7 @@ func selectingAll[T any](ctx context.Context, queryName string, sql SqlExecutor,
 		item, props := rowScanSupplier()
 		err = rows.Scan(props...)
 		if err != nil {
-			return nil, fmt.Errorf("%v: error reading customer row: %w", queryName, err)
+			return nil, fmt.Errorf("%v: error reading customer row: %w", queryName, TranslateError(err))
 		}
 		items = append(items, *item)
 	}
@@ -41,12 +43,14 @@ func selectingAll[T any](ctx context.Context, queryName string, sql SqlExecutor,
 // query returned no rows and db.ErrTooManyRows if the query returned more than 1 row.
 //
 // queryName is only used in error messages.
-func selectingOne[T any](ctx context.Context, queryName string, sql SqlExecutor, rowScanSupplier rowScanSupplier[T],
+func selectingOne[T any](ctx context.Context, queryName string, sql executor, rowScanSupplier rowScanSupplier[T],
 	query string, args ...any) (T, error) {
 
+	ctx, cancel := sql.withDefaultTimeout(ctx)
+	defer cancel()
 	rows, err := sql.QueryContext(ctx, query, args...)
 	if err != nil {
-		return util.ZeroValue[T](), fmt.Errorf("%v: error running query: %w", queryName, err)
+		return util.ZeroValue[T](), fmt.Errorf("%v: error running query: %w", queryName, TranslateError(err))
 	}
 	defer closeRows(ctx, rows)
 
@@ -67,10 +71,12 @@ func selectingOne[T any](ctx context.Context, queryName string, sql SqlExecutor,
 // affectingMany executes the query and returns the number of affected rows. It's not considered an error if the query affected no rows.
 //
 // queryName is only used in error messages.
-func affectingMany(ctx context.Context, queryName string, sql SqlExecutor, query string, args ...any) (affectedRowsCount int64, err error) {
+func affectingMany(ctx context.Context, queryName string, sql executor, query string, args ...any) (affectedRowsCount int64, err error) {
+	ctx, cancel := sql.withDefaultTimeout(ctx)
+	defer cancel()
 	result, err := sql.ExecContext(ctx, query, args...)
 	if err != nil {
-		return 0, fmt.Errorf("%v: error running query: %w", queryName, err)
+		return 0, fmt.Errorf("%v: error running query: %w", queryName, TranslateError(err))
 	}
 
 	affectedRowsCount, err = result.RowsAffected()
@@ -85,7 +91,7 @@ func affectingMany(ctx context.Context, queryName string, sql SqlExecutor, query
 // if the query affected more than 1 row.
 //
 // queryName is only used in error messages.
-func affectingOne(ctx context.Context, queryName string, sql SqlExecutor, query string, args ...any) error {
+func affectingOne(ctx context.Context, queryName string, sql executor, query string, args ...any) error {
 	affectedRowsCount, err := affectingMany(ctx, queryName, sql, query, args...)
 	if err != nil {
 		return err
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"github.com/GeneralKenobi/mailman/internal/db"
+	"time"
 )
 
 // SqlExecutor is an interface for common query execution functions from sql.DB and sql.Tx.
@@ -12,12 +13,29 @@ type SqlExecutor interface {
 	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
 }
 
-func New(sql SqlExecutor) *Repository {
-	return &Repository{sql: sql}
+// New creates a repository running queries with sql. defaultTimeout limits queries whose context has no deadline, they aren't limited if
+// it's 0.
+func New(sql SqlExecutor, defaultTimeout time.Duration) *Repository {
+	return &Repository{sql: executor{SqlExecutor: sql, defaultTimeout: defaultTimeout}}
 }
 
 type Repository struct {
-	sql SqlExecutor
+	sql executor
 }
 
 var _ db.Repository = (*Repository)(nil) // Interface guard
+
+// executor runs queries with the default timeout.
+type executor struct {
+	SqlExecutor
+	defaultTimeout time.Duration
+}
+
+// withDefaultTimeout returns a context with the default timeout if ctx has no deadline. cancel has to be called once the query's rows
+// are read.
+func (executor executor) withDefaultTimeout(ctx context.Context) (timeoutCtx context.Context, cancel context.CancelFunc) {
+	if _, hasDeadline := ctx.Deadline(); hasDeadline || executor.defaultTimeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, executor.defaultTimeout)
+}
@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+	"time"
+)
+
+func TestDefaultTimeout(t *testing.T) {
+	callerDeadline := time.Now().Add(time.Hour)
+	tests := map[string]struct {
+		ctx              func() (context.Context, context.CancelFunc)
+		defaultTimeout   time.Duration
+		expectDeadline   bool
+		expectedDeadline time.Time // Checked with a tolerance of a second
+		expectCanceled   bool      // Whether the context of the query is canceled when the operation returns
+	}{
+		"Should apply the default timeout if the caller didn't set a deadline": {
+			ctx:              func() (context.Context, context.CancelFunc) { return context.Background(), func() {} },
+			defaultTimeout:   30 * time.Second,
+			expectDeadline:   true,
+			expectedDeadline: time.Now().Add(30 * time.Second),
+			expectCanceled:   true,
+		},
+		"Should keep the caller's deadline": {
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithDeadline(context.Background(), callerDeadline)
+			},
+			defaultTimeout:   30 * time.Second,
+			expectDeadline:   true,
+			expectedDeadline: callerDeadline,
+		},
+		"Should not set a deadline if there's no default timeout": {
+			ctx: func() (context.Context, context.CancelFunc) { return context.Background(), func() {} },
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := test.ctx()
+			defer cancel()
+			sqlExecutor := &sqlExecutorMock{}
+			repository := New(sqlExecutor, test.defaultTimeout)
+
+			err := repository.DeleteCustomerById(ctx, "tenant-1", 1)
+
+			if err != nil {
+				t.Fatalf("Expected no error but got %v", err)
+			}
+			deadline, hasDeadline := sqlExecutor.ctx.Deadline()
+			if hasDeadline != test.expectDeadline {
+				t.Fatalf("Expected deadline: %v, but got deadline %v", test.expectDeadline, deadline)
+			}
+			if difference := deadline.Sub(test.expectedDeadline); hasDeadline && (difference > time.Second || difference < -time.Second) {
+				t.Errorf("Expected deadline %v but got %v", test.expectedDeadline, deadline)
+			}
+			if canceled := sqlExecutor.ctx.Err() != nil; canceled != test.expectCanceled {
+				t.Errorf("Expected the query's context to be canceled after the operation returned: %v, but got %v", test.expectCanceled, canceled)
+			}
+		})
+	}
+}
+
+// sqlExecutorMock records the context of the query and reports 1 affected row.
+type sqlExecutorMock struct {
+	ctx context.Context
+}
+
+func (mock *sqlExecutorMock) QueryContext(ctx context.Context, _ string, _ ...any) (*sql.Rows, error) {
+	mock.ctx = ctx
+	return nil, driver.ErrSkip
+}
+
+func (mock *sqlExecutorMock) ExecContext(ctx context.Context, _ string, _ ...any) (sql.Result, error) {
+	mock.ctx = ctx
+	return driver.RowsAffected(1), nil
+}
@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+func (repository *Repository) IncrementDailySendCount(ctx context.Context, tenantId string, day time.Time, count int) (int, error) {
+	return selectingOne(ctx, "increment daily send count", repository.sql, countRowScanSupplier,
+		"INSERT INTO mailmandb.send_quota_usage(tenant_id, day, sent_count) VALUES ($1, $2, $3) ON CONFLICT (tenant_id, day) DO UPDATE SET sent_count = send_quota_usage.sent_count + EXCLUDED.sent_count RETURNING sent_count",
+		tenantId, day.Format("2006-01-02"), count)
+}
+
+func countRowScanSupplier() (*int, []any) {
+	var count int
+	return &count, []any{&count}
+}
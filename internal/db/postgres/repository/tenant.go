@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"context"
+	"github.com/GeneralKenobi/mailman/internal/db/model"
+	"time"
+)
+
+func (repository *Repository) FindTenantIdsWithMailingEntries(ctx context.Context) ([]string, error) {
+	return selectingAll(ctx, "find tenant IDs with mailing entries", repository.sql, tenantIdRowScanSupplier,
+		"SELECT DISTINCT tenant_id FROM mailmandb.mailing_entry")
+}
+
+func (repository *Repository) FindTenantIdsWithArchivedMailingEntries(ctx context.Context) ([]string, error) {
+	return selectingAll(ctx, "find tenant IDs with archived mailing entries", repository.sql, tenantIdRowScanSupplier,
+		"SELECT DISTINCT tenant_id FROM mailmandb.mailing_entry_archive")
+}
+
+func (repository *Repository) FindTenantIdsWithDueWebhookDeliveries(ctx context.Context, now time.Time) ([]string, error) {
+	return selectingAll(ctx, "find tenant IDs with due webhook deliveries", repository.sql, tenantIdRowScanSupplier,
+		"SELECT DISTINCT tenant_id FROM mailmandb.webhook_delivery WHERE status = $1 AND next_attempt_time <= $2",
+		model.WebhookDeliveryStatusPending, now)
+}
+
+func (repository *Repository) FindTenantIdsWithWebhookEvents(ctx context.Context) ([]string, error) {
+	return selectingAll(ctx, "find tenant IDs with webhook events", repository.sql, tenantIdRowScanSupplier,
+		"SELECT DISTINCT tenant_id FROM mailmandb.webhook_event")
+}
+
+func tenantIdRowScanSupplier() (*string, []any) {
+	var tenantId string
+	return &tenantId, []any{&tenantId}
+}
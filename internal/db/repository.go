@@ -2,6 +2,7 @@ package db
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"github.com/GeneralKenobi/mailman/internal/db/model"
 	"time"
@@ -25,41 +26,130 @@ type Querent interface {
 // Transactioner is a db manager that creates transaction-scoped repositories.
 type Transactioner interface {
 	// TransactionalRepository creates a Repository that runs all queries within the returned Transaction. It's not allowed to use the
-	// repository after committing or rolling back the transaction.
-	TransactionalRepository(ctx context.Context) (Repository, Transaction, error)
+	// repository after committing or rolling back the transaction. The transaction runs at the given isolation level, or a stricter one
+	// if the DB doesn't support it. sql.LevelDefault is the DB's default level.
+	TransactionalRepository(ctx context.Context, isolation sql.IsolationLevel) (Repository, Transaction, error)
 }
 
 type Transaction interface {
 	Commit() error
 	Rollback() error
+	// Savepoint creates a savepoint with the given name. Changes made after it can be rolled back with RollbackToSavepoint without
+	// rolling back the entire transaction.
+	Savepoint(ctx context.Context, name string) error
+	// RollbackToSavepoint rolls back changes made after the savepoint was created. The savepoint remains.
+	RollbackToSavepoint(ctx context.Context, name string) error
+	// ReleaseSavepoint destroys the savepoint, keeping changes made after it was created.
+	ReleaseSavepoint(ctx context.Context, name string) error
 }
 
 // Repository aggregates all queries implemented by db providers.
+//
+// Every query is scoped to a single tenant - it only finds, modifies or deletes data belonging to the given tenant ID.
 type Repository interface {
+	TenantRepository
 	CustomerRepository
 	MailingEntryRepository
+	MailingEntryArchiveRepository
+	SendQuotaRepository
+	WebhookRepository
+}
+
+type TenantRepository interface {
+	// FindTenantIdsWithMailingEntries returns IDs of tenants that have at least one mailing entry.
+	FindTenantIdsWithMailingEntries(ctx context.Context) ([]string, error)
+	// FindTenantIdsWithArchivedMailingEntries returns IDs of tenants that have at least one archived mailing entry.
+	FindTenantIdsWithArchivedMailingEntries(ctx context.Context) ([]string, error)
+	// FindTenantIdsWithDueWebhookDeliveries returns IDs of tenants that have at least one pending webhook delivery due at the given time.
+	FindTenantIdsWithDueWebhookDeliveries(ctx context.Context, now time.Time) ([]string, error)
+	// FindTenantIdsWithWebhookEvents returns IDs of tenants that have at least one webhook event.
+	FindTenantIdsWithWebhookEvents(ctx context.Context) ([]string, error)
 }
 
 type CustomerRepository interface {
-	FindCustomerById(ctx context.Context, id int) (model.Customer, error)
-	FindCustomerByEmail(ctx context.Context, email string) (model.Customer, error)
+	FindCustomerById(ctx context.Context, tenantId string, id int) (model.Customer, error)
+	FindCustomerByEmail(ctx context.Context, tenantId, email string) (model.Customer, error)
 
+	// InsertCustomer returns wrapped db.ErrDuplicate if the tenant already has a customer with the email.
 	InsertCustomer(ctx context.Context, customer model.Customer) (model.Customer, error)
+	// FindOrInsertCustomer returns the tenant's customer with the email, inserting it if it doesn't exist. Concurrent calls return the
+	// same customer.
+	FindOrInsertCustomer(ctx context.Context, customer model.Customer) (model.Customer, error)
 
-	DeleteCustomerById(ctx context.Context, id int) error
+	DeleteCustomerById(ctx context.Context, tenantId string, id int) error
 }
 
 type MailingEntryRepository interface {
-	FindMailingEntriesByMailingId(ctx context.Context, mailingId int) ([]model.MailingEntry, error)
-	FindMailingEntriesOlderThan(ctx context.Context, olderThan time.Duration) ([]model.MailingEntry, error)
-	FindMailingEntriesByMailingIdOlderThan(ctx context.Context, mailingId int, olderThan time.Duration) ([]model.MailingEntry, error)
-	FindMailingEntriesByCustomerId(ctx context.Context, id int) ([]model.MailingEntry, error)
-	FindMailingEntriesByCustomerIdMailingIdTitleContentInsertTime(
-		ctx context.Context, customerId, mailingId int, title, content string, insertTime time.Time) ([]model.MailingEntry, error)
+	FindMailingEntryById(ctx context.Context, tenantId string, id int) (model.MailingEntry, error)
+	FindMailingEntriesByMailingId(ctx context.Context, tenantId string, mailingId int) ([]model.MailingEntry, error)
+	FindMailingEntriesOlderThan(ctx context.Context, tenantId string, olderThan time.Duration) ([]model.MailingEntry, error)
+	FindMailingEntriesByMailingIdOlderThan(ctx context.Context, tenantId string, mailingId int, olderThan time.Duration) ([]model.MailingEntry, error)
+	FindMailingEntriesByCustomerId(ctx context.Context, tenantId string, id int) ([]model.MailingEntry, error)
 
+	// CountMailingEntries returns the number of the tenant's mailing entries matching the filter.
+	CountMailingEntries(ctx context.Context, tenantId string, filter model.MailingEntryFilter) (int, error)
+
+	// InsertMailingEntry returns wrapped db.ErrDuplicate if an entry of the customer with the same mailing ID, content hash (see
+	// model.MailingEntry ContentHash) and insert time already exists.
 	InsertMailingEntry(ctx context.Context, mailingEntry model.MailingEntry) (model.MailingEntry, error)
 
-	DeleteMailingEntryById(ctx context.Context, id int) error
+	// UpdateMailingEntry updates the customer ID, mailing ID, title and content of the mailing entry and increments its version. The
+	// entry is only updated if its version in the database is still mailingEntry.Version, otherwise db.ErrNoRows is returned. Wrapped
+	// db.ErrDuplicate is returned if the update would make the entry a duplicate of another one, like in InsertMailingEntry.
+	UpdateMailingEntry(ctx context.Context, mailingEntry model.MailingEntry) (model.MailingEntry, error)
+
+	DeleteMailingEntryById(ctx context.Context, tenantId string, id int) error
+	// DeleteMailingEntries deletes the tenant's mailing entries matching the filter and returns how many were deleted.
+	DeleteMailingEntries(ctx context.Context, tenantId string, filter model.MailingEntryFilter) (int64, error)
+}
+
+// MailingEntryArchiveRepository keeps history of mailing entries after they're sent or removed by the cleanup job.
+type MailingEntryArchiveRepository interface {
+	// FindArchivedMailingEntries returns up to limit of the tenant's archived mailing entries matching the filter, most recently archived
+	// first.
+	FindArchivedMailingEntries(ctx context.Context, tenantId string, filter model.ArchivedMailingEntryFilter, limit int) ([]model.ArchivedMailingEntry, error)
+
+	// ArchiveMailingEntryById moves the tenant's mailing entry to the archive, together with the current email of its customer. Returns
+	// wrapped db.ErrNoRows if the entry doesn't exist.
+	ArchiveMailingEntryById(ctx context.Context, tenantId string, id int, reason model.ArchiveReason, archiveTime time.Time) error
+	// ArchiveMailingEntryBatch moves up to limit of the tenant's mailing entries matching the filter, lowest IDs first, to the archive like
+	// ArchiveMailingEntryById and returns how many were moved. Moving many entries batch by batch, each in a separate transaction, keeps
+	// the transactions short.
+	ArchiveMailingEntryBatch(ctx context.Context, tenantId string, filter model.MailingEntryFilter, limit int, reason model.ArchiveReason,
+		archiveTime time.Time) (int64, error)
+
+	// DeleteArchivedMailingEntryBatch deletes up to limit of the tenant's mailing entries archived before the given time, oldest first,
+	// and returns how many were deleted.
+	DeleteArchivedMailingEntryBatch(ctx context.Context, tenantId string, archivedBefore time.Time, limit int) (int64, error)
+}
+
+type SendQuotaRepository interface {
+	// IncrementDailySendCount adds count to the number of mailing entries the tenant sent on the given day (only the date part is used)
+	// and returns the updated number.
+	IncrementDailySendCount(ctx context.Context, tenantId string, day time.Time, count int) (int, error)
+}
+
+type WebhookRepository interface {
+	FindWebhookSubscriptionById(ctx context.Context, tenantId string, id int) (model.WebhookSubscription, error)
+	FindWebhookSubscriptions(ctx context.Context, tenantId string) ([]model.WebhookSubscription, error)
+	FindWebhookEventById(ctx context.Context, tenantId string, id int) (model.WebhookEvent, error)
+	// FindWebhookDeliveriesBySubscriptionId returns up to limit most recent deliveries to the subscription, newest first.
+	FindWebhookDeliveriesBySubscriptionId(ctx context.Context, tenantId string, subscriptionId, limit int) ([]model.WebhookDelivery, error)
+	// FindDueWebhookDeliveriesForUpdate returns up to limit pending deliveries due at the given time, oldest first. The deliveries are
+	// locked until the end of the transaction and deliveries locked by other transactions are skipped.
+	FindDueWebhookDeliveriesForUpdate(ctx context.Context, tenantId string, now time.Time, limit int) ([]model.WebhookDelivery, error)
+
+	InsertWebhookSubscription(ctx context.Context, subscription model.WebhookSubscription) (model.WebhookSubscription, error)
+	InsertWebhookEvent(ctx context.Context, event model.WebhookEvent) (model.WebhookEvent, error)
+	InsertWebhookDelivery(ctx context.Context, delivery model.WebhookDelivery) (model.WebhookDelivery, error)
+
+	UpdateWebhookDelivery(ctx context.Context, delivery model.WebhookDelivery) error
+
+	// DeleteWebhookSubscriptionById deletes the subscription together with its deliveries.
+	DeleteWebhookSubscriptionById(ctx context.Context, tenantId string, id int) error
+	// DeleteFinishedWebhookEventsCreatedBefore deletes events created before the given time that have no pending deliveries, together with
+	// their deliveries. Returns the number of deleted events.
+	DeleteFinishedWebhookEventsCreatedBefore(ctx context.Context, tenantId string, createdBefore time.Time) (int64, error)
 }
 
 var (
@@ -67,4 +157,13 @@ var (
 	ErrNoRows = fmt.Errorf("no row matched the query")
 	// ErrTooManyRows is returned from queries that returned/affected more rows than expected (e.g. select one found 2 rows).
 	ErrTooManyRows = fmt.Errorf("more rows than expected matched the query")
+	// ErrDuplicate is returned from queries that would insert a row with the same unique key as an existing row.
+	ErrDuplicate = fmt.Errorf("a row with the same unique key already exists")
+	// ErrValueTooLong is returned from queries that would save a value longer than its column allows, e.g. a title over 255 characters.
+	ErrValueTooLong = fmt.Errorf("a value is too long for its column")
+	// ErrNoTransaction is returned from opening a nested transaction of a repository that doesn't belong to a transaction.
+	ErrNoTransaction = fmt.Errorf("repository doesn't belong to a transaction")
+	// ErrSerializationFailure is returned from transactions that conflict with a concurrent one, i.e. a serialization failure or a
+	// deadlock. The transaction can be retried, see WithRetries.
+	ErrSerializationFailure = fmt.Errorf("transaction conflicts with a concurrent transaction")
 )
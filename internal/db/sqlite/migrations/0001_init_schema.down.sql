@@ -0,0 +1,6 @@
+DROP TABLE webhook_delivery;
+DROP TABLE webhook_event;
+DROP TABLE webhook_subscription;
+DROP TABLE send_quota_usage;
+DROP TABLE mailing_entry;
+DROP TABLE customer;
@@ -0,0 +1,85 @@
+-- Same tables as in postgres. Timestamps are stored as UTC text (YYYY-MM-DD HH:MM:SS.SSSSSS), which sorts chronologically, and arrays
+-- as JSON text. AUTOINCREMENT keeps IDs of deleted rows from being reused, like postgres sequences.
+CREATE TABLE customer
+(
+    id        INTEGER PRIMARY KEY AUTOINCREMENT,
+    tenant_id VARCHAR(64)  NOT NULL CHECK (tenant_id <> ''),
+    email     VARCHAR(255) NOT NULL CHECK (email <> ''),
+
+    CONSTRAINT unique_email UNIQUE (tenant_id, email),
+    CONSTRAINT unique_id_tenant UNIQUE (id, tenant_id)
+);
+
+CREATE TABLE mailing_entry
+(
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    tenant_id   VARCHAR(64)  NOT NULL CHECK (tenant_id <> ''),
+    customer_id INT          NOT NULL,
+    mailing_id  INT          NOT NULL,
+    title       VARCHAR(255) NOT NULL CHECK (title <> ''),
+    content     TEXT,
+    insert_time TIMESTAMP    NOT NULL,
+    version     INT          NOT NULL DEFAULT 1, -- Incremented on every update for optimistic concurrency control
+
+    -- Including tenant ID guarantees that the entry and its customer belong to the same tenant
+    CONSTRAINT fk_customer FOREIGN KEY (customer_id, tenant_id) REFERENCES customer (id, tenant_id)
+);
+CREATE INDEX mailing_entry_insert_time ON mailing_entry (insert_time);
+CREATE INDEX mailing_entry_tenant_mailing_id ON mailing_entry (tenant_id, mailing_id);
+
+-- Number of mailing entries sent by a tenant per day, for enforcing daily send quotas
+CREATE TABLE send_quota_usage
+(
+    tenant_id  VARCHAR(64) NOT NULL,
+    day        DATE        NOT NULL,
+    sent_count INT         NOT NULL,
+
+    PRIMARY KEY (tenant_id, day)
+);
+
+-- Endpoints notified about events of a tenant
+CREATE TABLE webhook_subscription
+(
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    tenant_id   VARCHAR(64)   NOT NULL CHECK (tenant_id <> ''),
+    url         VARCHAR(2048) NOT NULL CHECK (url <> ''),
+    event_types TEXT          NOT NULL, -- JSON array of strings
+    secret      VARCHAR(255)  NOT NULL CHECK (secret <> ''),
+    create_time TIMESTAMP     NOT NULL,
+
+    CONSTRAINT unique_webhook_subscription_id_tenant UNIQUE (id, tenant_id)
+);
+CREATE INDEX webhook_subscription_tenant_id ON webhook_subscription (tenant_id);
+
+-- Outbox of events, written in the same transaction as the change they describe
+CREATE TABLE webhook_event
+(
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    tenant_id   VARCHAR(64) NOT NULL CHECK (tenant_id <> ''),
+    event_type  VARCHAR(64) NOT NULL,
+    payload     TEXT        NOT NULL,
+    create_time TIMESTAMP   NOT NULL,
+
+    CONSTRAINT unique_webhook_event_id_tenant UNIQUE (id, tenant_id)
+);
+CREATE INDEX webhook_event_create_time ON webhook_event (create_time);
+
+-- Delivery of an event to a subscription, doubles as the delivery log
+CREATE TABLE webhook_delivery
+(
+    id                 INTEGER PRIMARY KEY AUTOINCREMENT,
+    tenant_id          VARCHAR(64) NOT NULL CHECK (tenant_id <> ''),
+    subscription_id    INT         NOT NULL,
+    event_id           INT         NOT NULL,
+    status             VARCHAR(16) NOT NULL,
+    attempt_count      INT         NOT NULL,
+    next_attempt_time  TIMESTAMP   NOT NULL,
+    last_attempt_time  TIMESTAMP,
+    last_response_code INT,
+    last_error         TEXT,
+
+    CONSTRAINT fk_subscription FOREIGN KEY (subscription_id, tenant_id) REFERENCES webhook_subscription (id, tenant_id) ON DELETE CASCADE,
+    CONSTRAINT fk_event FOREIGN KEY (event_id, tenant_id) REFERENCES webhook_event (id, tenant_id) ON DELETE CASCADE
+);
+CREATE INDEX webhook_delivery_status_next_attempt_time ON webhook_delivery (status, next_attempt_time);
+CREATE INDEX webhook_delivery_tenant_subscription_id ON webhook_delivery (tenant_id, subscription_id);
@@ -0,0 +1,2 @@
+DROP INDEX mailing_entry_unique_content;
+ALTER TABLE mailing_entry DROP COLUMN content_hash;
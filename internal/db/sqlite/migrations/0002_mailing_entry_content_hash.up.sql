@@ -0,0 +1,17 @@
+-- Same as in postgres. SQLite has no hash functions, sha256_hex is registered by the application. Columns added with NOT NULL need a
+-- default, inserts always set the hash.
+ALTER TABLE mailing_entry ADD COLUMN content_hash CHAR(64) NOT NULL DEFAULT '';
+UPDATE mailing_entry
+SET content_hash = sha256_hex(length(CAST(title AS BLOB)) || ':' || title || COALESCE(content, ''));
+
+-- Duplicates created by concurrent requests are equal, the first one is kept.
+DELETE FROM mailing_entry AS duplicate
+WHERE EXISTS (SELECT 1
+              FROM mailing_entry AS kept
+              WHERE kept.customer_id = duplicate.customer_id
+                AND kept.mailing_id = duplicate.mailing_id
+                AND kept.content_hash = duplicate.content_hash
+                AND kept.insert_time = duplicate.insert_time
+                AND kept.id < duplicate.id);
+
+CREATE UNIQUE INDEX mailing_entry_unique_content ON mailing_entry (customer_id, mailing_id, content_hash, insert_time);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS mailing_entry_archive;
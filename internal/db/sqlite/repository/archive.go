@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"github.com/GeneralKenobi/mailman/internal/db/model"
+	"time"
+)
+
+func (repository *Repository) FindArchivedMailingEntries(
+	ctx context.Context, tenantId string, filter model.ArchivedMailingEntryFilter, limit int) ([]model.ArchivedMailingEntry, error) {
+
+	return selectingAll(ctx, "find archived mailing entries", repository.sql, archivedMailingEntryRowScanSupplier,
+		"SELECT id, tenant_id, customer_id, customer_email, mailing_id, title, content, insert_time, archive_time, reason FROM mailing_entry_archive WHERE tenant_id = $1 AND ($2 IS NULL OR mailing_id = $2) AND ($3 IS NULL OR customer_email = $3) AND ($4 IS NULL OR archive_time >= $4) AND ($5 IS NULL OR archive_time < $5) ORDER BY archive_time DESC, id DESC LIMIT $6",
+		tenantId, filter.MailingId, filter.CustomerEmail, nullableTimestamp(filter.ArchivedAfter), nullableTimestamp(filter.ArchivedBefore), limit)
+}
+
+// ArchiveMailingEntryById copies the entry to the archive and deletes it. SQLite can't insert rows returned by a DELETE, so it's 2
+// statements - the repository has to be transactional for them to be atomic.
+func (repository *Repository) ArchiveMailingEntryById(
+	ctx context.Context, tenantId string, id int, reason model.ArchiveReason, archiveTime time.Time) error {
+
+	_, err := affectingMany(ctx, "archive mailing entry by ID", repository.sql,
+		archiveInsert("e.tenant_id = $1 AND e.id = $2", "$3", "$4"),
+		tenantId, id, timestamp(archiveTime), reason)
+	if err != nil {
+		return err
+	}
+	return affectingOne(ctx, "archive mailing entry by ID", repository.sql,
+		"DELETE FROM mailing_entry WHERE tenant_id = $1 AND id = $2", tenantId, id)
+}
+
+// ArchiveMailingEntryBatch copies the entries to the archive and deletes them, like ArchiveMailingEntryById.
+func (repository *Repository) ArchiveMailingEntryBatch(ctx context.Context, tenantId string, filter model.MailingEntryFilter, limit int,
+	reason model.ArchiveReason, archiveTime time.Time) (int64, error) {
+
+	batch := "SELECT id FROM mailing_entry WHERE " + mailingEntryFilterCondition + " ORDER BY id LIMIT $6"
+	args := append(mailingEntryFilterArgs(tenantId, filter), limit, timestamp(archiveTime), reason)
+	archivedCount, err := affectingMany(ctx, "archive mailing entry batch", repository.sql,
+		archiveInsert("e.id IN ("+batch+")", "$7", "$8"), args...)
+	if err != nil {
+		return 0, err
+	}
+	deletedCount, err := affectingMany(ctx, "archive mailing entry batch", repository.sql,
+		"DELETE FROM mailing_entry WHERE id IN ("+batch+")", args[:6]...)
+	if err != nil {
+		return 0, err
+	}
+	if deletedCount != archivedCount {
+		return 0, fmt.Errorf("archive mailing entry batch: archived %d entries but deleted %d", archivedCount, deletedCount)
+	}
+	return deletedCount, nil
+}
+
+func (repository *Repository) DeleteArchivedMailingEntryBatch(ctx context.Context, tenantId string, archivedBefore time.Time, limit int) (int64, error) {
+	return affectingMany(ctx, "delete archived mailing entry batch", repository.sql,
+		"DELETE FROM mailing_entry_archive WHERE id IN (SELECT id FROM mailing_entry_archive WHERE tenant_id = $1 AND archive_time < $2 ORDER BY archive_time LIMIT $3)",
+		tenantId, timestamp(archivedBefore), limit)
+}
+
+// archiveInsert inserts the mailing entries matching the condition on "e" into the archive, with the archive time and reason given by the
+// query parameters.
+func archiveInsert(condition, archiveTimeParam, reasonParam string) string {
+	return "INSERT INTO mailing_entry_archive(id, tenant_id, customer_id, customer_email, mailing_id, title, content, insert_time, archive_time, reason) " +
+		"SELECT e.id, e.tenant_id, e.customer_id, c.email, e.mailing_id, e.title, e.content, e.insert_time, " + archiveTimeParam + ", " + reasonParam +
+		" FROM mailing_entry e JOIN customer c ON c.id = e.customer_id WHERE " + condition
+}
+
+func archivedMailingEntryRowScanSupplier() (*model.ArchivedMailingEntry, []any) {
+	var archivedEntry model.ArchivedMailingEntry
+	return &archivedEntry, []any{
+		&archivedEntry.Id,
+		&archivedEntry.TenantId,
+		&archivedEntry.CustomerId,
+		&archivedEntry.CustomerEmail,
+		&archivedEntry.MailingId,
+		&archivedEntry.Title,
+		&archivedEntry.Content,
+		&archivedEntry.InsertTime,
+		&archivedEntry.ArchiveTime,
+		&archivedEntry.Reason,
+	}
+}
@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"github.com/GeneralKenobi/mailman/internal/db"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// TranslateError converts SQLite errors into their db package equivalents, so that callers don't depend on the driver: unique constraint
+// violations into db.ErrDuplicate. Other errors are returned as they are.
+func TranslateError(err error) error {
+	var sqliteErr *sqlite.Error
+	if !errors.As(err, &sqliteErr) {
+		return err
+	}
+	switch sqliteErr.Code() {
+	case sqlite3.SQLITE_CONSTRAINT_UNIQUE, sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY:
+		return fmt.Errorf("%w: %v", db.ErrDuplicate, err)
+	default:
+		return err
+	}
+}
@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/GeneralKenobi/mailman/internal/db"
+	"github.com/GeneralKenobi/mailman/internal/db/model"
+	"time"
+)
+
+func (repository *Repository) FindMailingEntryById(ctx context.Context, tenantId string, id int) (model.MailingEntry, error) {
+	return selectingOne(ctx, "find mailing entry by ID", repository.sql, mailingEntryRowScanSupplier,
+		"SELECT id, tenant_id, customer_id, mailing_id, title, content, insert_time, version FROM mailing_entry WHERE tenant_id = $1 AND id = $2",
+		tenantId, id)
+}
+
+func (repository *Repository) FindMailingEntriesByMailingId(ctx context.Context, tenantId string, mailingId int) ([]model.MailingEntry, error) {
+	return selectingAll(ctx, "find mailing entries by mailing ID", repository.sql, mailingEntryRowScanSupplier,
+		"SELECT id, tenant_id, customer_id, mailing_id, title, content, insert_time, version FROM mailing_entry WHERE tenant_id = $1 AND mailing_id = $2",
+		tenantId, mailingId)
+}
+
+func (repository *Repository) FindMailingEntriesOlderThan(ctx context.Context, tenantId string, olderThan time.Duration) ([]model.MailingEntry, error) {
+	return selectingAll(ctx, "find mailing entries older than", repository.sql, mailingEntryRowScanSupplier,
+		"SELECT id, tenant_id, customer_id, mailing_id, title, content, insert_time, version FROM mailing_entry WHERE tenant_id = $1 AND insert_time < $2",
+		tenantId, timestamp(time.Now().Add(-olderThan)))
+}
+
+func (repository *Repository) FindMailingEntriesByMailingIdOlderThan(ctx context.Context, tenantId string, mailingId int, olderThan time.Duration) ([]model.MailingEntry, error) {
+	return selectingAll(ctx, "find mailing entries by mailing ID older than", repository.sql, mailingEntryRowScanSupplier,
+		"SELECT id, tenant_id, customer_id, mailing_id, title, content, insert_time, version FROM mailing_entry WHERE tenant_id = $1 AND mailing_id = $2 AND insert_time < $3",
+		tenantId, mailingId, timestamp(time.Now().Add(-olderThan)))
+}
+
+func (repository *Repository) FindMailingEntriesByCustomerId(ctx context.Context, tenantId string, customerId int) ([]model.MailingEntry, error) {
+	return selectingAll(ctx, "find mailing by customer ID", repository.sql, mailingEntryRowScanSupplier,
+		"SELECT id, tenant_id, customer_id, mailing_id, title, content, insert_time, version FROM mailing_entry WHERE tenant_id = $1 AND customer_id = $2",
+		tenantId, customerId)
+}
+
+func (repository *Repository) CountMailingEntries(ctx context.Context, tenantId string, filter model.MailingEntryFilter) (int, error) {
+	return selectingOne(ctx, "count mailing entries", repository.sql, countRowScanSupplier,
+		"SELECT COUNT(*) FROM mailing_entry WHERE "+mailingEntryFilterCondition,
+		mailingEntryFilterArgs(tenantId, filter)...)
+}
+
+func (repository *Repository) InsertMailingEntry(ctx context.Context, mailingEntry model.MailingEntry) (model.MailingEntry, error) {
+	// Nothing is returned if the entry is a duplicate.
+	insertedEntry, err := selectingOne(ctx, "insert mailing entry", repository.sql, mailingEntryRowScanSupplier,
+		"INSERT INTO mailing_entry(tenant_id, customer_id, mailing_id, title, content, content_hash, insert_time) VALUES ($1, $2, $3, $4, $5, $6, $7) ON CONFLICT (customer_id, mailing_id, content_hash, insert_time) DO NOTHING RETURNING id, tenant_id, customer_id, mailing_id, title, content, insert_time, version",
+		mailingEntry.TenantId, mailingEntry.CustomerId, mailingEntry.MailingId, mailingEntry.Title, mailingEntry.Content, mailingEntry.ContentHash(),
+		timestamp(mailingEntry.InsertTime))
+	if errors.Is(err, db.ErrNoRows) {
+		return model.MailingEntry{}, fmt.Errorf("insert mailing entry: %w", db.ErrDuplicate)
+	}
+	return insertedEntry, err
+}
+
+func (repository *Repository) UpdateMailingEntry(ctx context.Context, mailingEntry model.MailingEntry) (model.MailingEntry, error) {
+	return selectingOne(ctx, "update mailing entry", repository.sql, mailingEntryRowScanSupplier,
+		"UPDATE mailing_entry SET customer_id = $3, mailing_id = $4, title = $5, content = $6, content_hash = $7, version = version + 1 WHERE tenant_id = $1 AND id = $2 AND version = $8 RETURNING id, tenant_id, customer_id, mailing_id, title, content, insert_time, version",
+		mailingEntry.TenantId, mailingEntry.Id, mailingEntry.CustomerId, mailingEntry.MailingId, mailingEntry.Title, mailingEntry.Content,
+		mailingEntry.ContentHash(), mailingEntry.Version)
+}
+
+func (repository *Repository) DeleteMailingEntryById(ctx context.Context, tenantId string, id int) error {
+	return affectingOne(ctx, "delete mailing entry by ID", repository.sql,
+		"DELETE FROM mailing_entry WHERE tenant_id = $1 AND id = $2", tenantId, id)
+}
+
+func (repository *Repository) DeleteMailingEntries(ctx context.Context, tenantId string, filter model.MailingEntryFilter) (int64, error) {
+	return affectingMany(ctx, "delete mailing entries", repository.sql,
+		"DELETE FROM mailing_entry WHERE "+mailingEntryFilterCondition,
+		mailingEntryFilterArgs(tenantId, filter)...)
+}
+
+// mailingEntryFilterCondition matches mailing entries selected by model.MailingEntryFilter, with arguments from mailingEntryFilterArgs.
+// NULL arguments don't restrict the selection.
+const mailingEntryFilterCondition = "tenant_id = $1 AND ($2 IS NULL OR mailing_id = $2) AND ($3 IS NULL OR customer_id = $3) AND ($4 IS NULL OR insert_time >= $4) AND ($5 IS NULL OR insert_time < $5)"
+
+func mailingEntryFilterArgs(tenantId string, filter model.MailingEntryFilter) []any {
+	return []any{tenantId, filter.MailingId, filter.CustomerId, nullableTimestamp(filter.InsertedAfter), nullableTimestamp(filter.InsertedBefore)}
+}
+
+func mailingEntryRowScanSupplier() (*model.MailingEntry, []any) {
+	var mailingEntry model.MailingEntry
+	return &mailingEntry, []any{
+		&mailingEntry.Id,
+		&mailingEntry.TenantId,
+		&mailingEntry.CustomerId,
+		&mailingEntry.MailingId,
+		&mailingEntry.Title,
+		&mailingEntry.Content,
+		&mailingEntry.InsertTime,
+		&mailingEntry.Version,
+	}
+}
@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"github.com/GeneralKenobi/mailman/internal/db"
+	"github.com/GeneralKenobi/mailman/pkg/mdctx"
+	"github.com/GeneralKenobi/mailman/pkg/util"
+	"time"
+)
+
+// rowScanSupplier defines a function that returns an object and its properties that are then used in sql.Rows Scan method to convert
+// columns of a single row into an object.
+type rowScanSupplier[T any] func() (scanTarget *T, scanTargetProperties []any)
+
+// selectingAll executes the query and converts obtained rows into a slice of T. It's not considered an error if the query returned no rows.
+//
+// queryName is only used in error messages.
+func selectingAll[T any](ctx context.Context, queryName string, sql SqlExecutor, rowScanSupplier rowScanSupplier[T],
+	query string, args ...any) ([]T, error) {
+
+	rows, err := sql.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: error running query: %w", queryName, TranslateError(err))
+	}
+	defer closeRows(ctx, rows)
+
+	var items []T
+	for rows.Next() {
+		item, props := rowScanSupplier()
+		err = rows.Scan(props...)
+		if err != nil {
+			return nil, fmt.Errorf("%s: error reading row: %w", queryName, err)
+		}
+		items = append(items, *item)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: error reading rows: %w", queryName, TranslateError(err))
+	}
+	return items, nil
+}
+
+// selectingOne executes the query and converts the obtained row into an instance of T. It returns wrapped db.ErrNoRows if the
+// query returned no rows and db.ErrTooManyRows if the query returned more than 1 row.
+//
+// Statements with RETURNING are executed while rows are read, so errors like constraint violations may only be reported by rows.Err.
+// All rows are read so that the statement runs to completion.
+//
+// queryName is only used in error messages.
+func selectingOne[T any](ctx context.Context, queryName string, sql SqlExecutor, rowScanSupplier rowScanSupplier[T],
+	query string, args ...any) (T, error) {
+
+	items, err := selectingAll(ctx, queryName, sql, rowScanSupplier, query, args...)
+	if err != nil {
+		return util.ZeroValue[T](), err
+	}
+	if len(items) == 0 {
+		return util.ZeroValue[T](), fmt.Errorf("%s: %w", queryName, db.ErrNoRows)
+	}
+	if len(items) > 1 {
+		return util.ZeroValue[T](), fmt.Errorf("%s: %w", queryName, db.ErrTooManyRows)
+	}
+	return items[0], nil
+}
+
+// affectingMany executes the query and returns the number of affected rows. It's not considered an error if the query affected no rows.
+//
+// queryName is only used in error messages.
+func affectingMany(ctx context.Context, queryName string, sql SqlExecutor, query string, args ...any) (affectedRowsCount int64, err error) {
+	result, err := sql.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("%s: error running query: %w", queryName, TranslateError(err))
+	}
+
+	affectedRowsCount, err = result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: error obtaining the number of affected rows: %w", queryName, err)
+	}
+
+	return affectedRowsCount, nil
+}
+
+// affectingOne executes the query. It returns wrapped db.ErrNoRows if the query affected no rows and db.ErrTooManyRows
+// if the query affected more than 1 row.
+//
+// queryName is only used in error messages.
+func affectingOne(ctx context.Context, queryName string, sql SqlExecutor, query string, args ...any) error {
+	affectedRowsCount, err := affectingMany(ctx, queryName, sql, query, args...)
+	if err != nil {
+		return err
+	}
+
+	if affectedRowsCount == 0 {
+		return fmt.Errorf("%s: %w", queryName, db.ErrNoRows)
+	}
+	if affectedRowsCount > 1 {
+		return fmt.Errorf("%s: %w", queryName, db.ErrTooManyRows)
+	}
+	return nil
+}
+
+func closeRows(ctx context.Context, rows *sql.Rows) {
+	err := rows.Close()
+	if err != nil {
+		mdctx.Errorf(ctx, "Error closing rows: %v", err)
+	}
+}
+
+// timestampFormat has fixed width, so that timestamps compare as text in chronological order. Precision is the same as in postgres.
+const timestampFormat = "2006-01-02 15:04:05.000000"
+
+// timestamp converts the time to a query argument stored in a TIMESTAMP column.
+func timestamp(t time.Time) string {
+	return t.UTC().Format(timestampFormat)
+}
+
+// nullableTimestamp converts the time to a query argument stored in a nullable TIMESTAMP column.
+func nullableTimestamp(t *time.Time) any {
+	if t == nil {
+		return nil
+	}
+	return timestamp(*t)
+}
+
+// jsonArray stores a string slice as a JSON array because SQLite has no array type.
+type jsonArray []string
+
+func (array jsonArray) Value() (driver.Value, error) {
+	if array == nil {
+		return nil, nil
+	}
+	bytes, err := json.Marshal([]string(array))
+	return string(bytes), err
+}
+
+func (array *jsonArray) Scan(value any) error {
+	switch value := value.(type) {
+	case nil:
+		*array = nil
+		return nil
+	case string:
+		return json.Unmarshal([]byte(value), (*[]string)(array))
+	case []byte:
+		return json.Unmarshal(value, (*[]string)(array))
+	default:
+		return fmt.Errorf("can't scan %T into a JSON array", value)
+	}
+}
@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"github.com/GeneralKenobi/mailman/internal/db"
+)
+
+// SqlExecutor is an interface for common query execution functions from sql.DB and sql.Tx.
+type SqlExecutor interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+func New(sql SqlExecutor) *Repository {
+	return &Repository{sql: sql}
+}
+
+// Repository implements db.Repository with the same queries as the postgres one, adjusted to SQLite:
+//   - timestamps are bound as UTC text with microsecond precision (see timestamp), the driver parses TIMESTAMP columns when scanning
+//   - arrays are stored as JSON text (see jsonArray)
+//   - rows aren't locked for update, transactions are serialized instead
+type Repository struct {
+	sql SqlExecutor
+}
+
+var _ db.Repository = (*Repository)(nil) // Interface guard
@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"github.com/GeneralKenobi/mailman/internal/db/model"
+	"time"
+)
+
+func (repository *Repository) FindWebhookSubscriptionById(ctx context.Context, tenantId string, id int) (model.WebhookSubscription, error) {
+	return selectingOne(ctx, "find webhook subscription by ID", repository.sql, webhookSubscriptionRowScanSupplier,
+		"SELECT id, tenant_id, url, event_types, secret, create_time FROM webhook_subscription WHERE tenant_id = $1 AND id = $2",
+		tenantId, id)
+}
+
+func (repository *Repository) FindWebhookSubscriptions(ctx context.Context, tenantId string) ([]model.WebhookSubscription, error) {
+	return selectingAll(ctx, "find webhook subscriptions", repository.sql, webhookSubscriptionRowScanSupplier,
+		"SELECT id, tenant_id, url, event_types, secret, create_time FROM webhook_subscription WHERE tenant_id = $1 ORDER BY id",
+		tenantId)
+}
+
+func (repository *Repository) FindWebhookEventById(ctx context.Context, tenantId string, id int) (model.WebhookEvent, error) {
+	return selectingOne(ctx, "find webhook event by ID", repository.sql, webhookEventRowScanSupplier,
+		"SELECT id, tenant_id, event_type, payload, create_time FROM webhook_event WHERE tenant_id = $1 AND id = $2",
+		tenantId, id)
+}
+
+func (repository *Repository) FindWebhookDeliveriesBySubscriptionId(
+	ctx context.Context, tenantId string, subscriptionId, limit int) ([]model.WebhookDelivery, error) {
+
+	return selectingAll(ctx, "find webhook deliveries by subscription ID", repository.sql, webhookDeliveryRowScanSupplier,
+		"SELECT id, tenant_id, subscription_id, event_id, status, attempt_count, next_attempt_time, last_attempt_time, last_response_code, last_error FROM webhook_delivery WHERE tenant_id = $1 AND subscription_id = $2 ORDER BY id DESC LIMIT $3",
+		tenantId, subscriptionId, limit)
+}
+
+// FindDueWebhookDeliveriesForUpdate doesn't lock the rows, SQLite transactions are serialized.
+func (repository *Repository) FindDueWebhookDeliveriesForUpdate(
+	ctx context.Context, tenantId string, now time.Time, limit int) ([]model.WebhookDelivery, error) {
+
+	return selectingAll(ctx, "find due webhook deliveries for update", repository.sql, webhookDeliveryRowScanSupplier,
+		"SELECT id, tenant_id, subscription_id, event_id, status, attempt_count, next_attempt_time, last_attempt_time, last_response_code, last_error FROM webhook_delivery WHERE tenant_id = $1 AND status = $2 AND next_attempt_time <= $3 ORDER BY next_attempt_time LIMIT $4",
+		tenantId, model.WebhookDeliveryStatusPending, timestamp(now), limit)
+}
+
+func (repository *Repository) InsertWebhookSubscription(ctx context.Context, subscription model.WebhookSubscription) (model.WebhookSubscription, error) {
+	return selectingOne(ctx, "insert webhook subscription", repository.sql, webhookSubscriptionRowScanSupplier,
+		"INSERT INTO webhook_subscription(tenant_id, url, event_types, secret, create_time) VALUES ($1, $2, $3, $4, $5) RETURNING id, tenant_id, url, event_types, secret, create_time",
+		subscription.TenantId, subscription.Url, jsonArray(subscription.EventTypes), subscription.Secret, timestamp(subscription.CreateTime))
+}
+
+func (repository *Repository) InsertWebhookEvent(ctx context.Context, event model.WebhookEvent) (model.WebhookEvent, error) {
+	return selectingOne(ctx, "insert webhook event", repository.sql, webhookEventRowScanSupplier,
+		"INSERT INTO webhook_event(tenant_id, event_type, payload, create_time) VALUES ($1, $2, $3, $4) RETURNING id, tenant_id, event_type, payload, create_time",
+		event.TenantId, event.EventType, event.Payload, timestamp(event.CreateTime))
+}
+
+func (repository *Repository) InsertWebhookDelivery(ctx context.Context, delivery model.WebhookDelivery) (model.WebhookDelivery, error) {
+	return selectingOne(ctx, "insert webhook delivery", repository.sql, webhookDeliveryRowScanSupplier,
+		"INSERT INTO webhook_delivery(tenant_id, subscription_id, event_id, status, attempt_count, next_attempt_time, last_attempt_time, last_response_code, last_error) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id, tenant_id, subscription_id, event_id, status, attempt_count, next_attempt_time, last_attempt_time, last_response_code, last_error",
+		delivery.TenantId, delivery.SubscriptionId, delivery.EventId, delivery.Status, delivery.AttemptCount, timestamp(delivery.NextAttemptTime),
+		nullableTimestamp(delivery.LastAttemptTime), delivery.LastResponseCode, delivery.LastError)
+}
+
+func (repository *Repository) UpdateWebhookDelivery(ctx context.Context, delivery model.WebhookDelivery) error {
+	return affectingOne(ctx, "update webhook delivery", repository.sql,
+		"UPDATE webhook_delivery SET status = $3, attempt_count = $4, next_attempt_time = $5, last_attempt_time = $6, last_response_code = $7, last_error = $8 WHERE tenant_id = $1 AND id = $2",
+		delivery.TenantId, delivery.Id, delivery.Status, delivery.AttemptCount, timestamp(delivery.NextAttemptTime),
+		nullableTimestamp(delivery.LastAttemptTime), delivery.LastResponseCode, delivery.LastError)
+}
+
+func (repository *Repository) DeleteWebhookSubscriptionById(ctx context.Context, tenantId string, id int) error {
+	return affectingOne(ctx, "delete webhook subscription by ID", repository.sql,
+		"DELETE FROM webhook_subscription WHERE tenant_id = $1 AND id = $2", tenantId, id)
+}
+
+func (repository *Repository) DeleteFinishedWebhookEventsCreatedBefore(ctx context.Context, tenantId string, createdBefore time.Time) (int64, error) {
+	return affectingMany(ctx, "delete finished webhook events created before", repository.sql,
+		"DELETE FROM webhook_event AS e WHERE e.tenant_id = $1 AND e.create_time < $2 AND NOT EXISTS (SELECT 1 FROM webhook_delivery d WHERE d.event_id = e.id AND d.status = $3)",
+		tenantId, timestamp(createdBefore), model.WebhookDeliveryStatusPending)
+}
+
+func webhookSubscriptionRowScanSupplier() (*model.WebhookSubscription, []any) {
+	var subscription model.WebhookSubscription
+	return &subscription, []any{
+		&subscription.Id,
+		&subscription.TenantId,
+		&subscription.Url,
+		(*jsonArray)(&subscription.EventTypes),
+		&subscription.Secret,
+		&subscription.CreateTime,
+	}
+}
+
+func webhookEventRowScanSupplier() (*model.WebhookEvent, []any) {
+	var event model.WebhookEvent
+	return &event, []any{
+		&event.Id,
+		&event.TenantId,
+		&event.EventType,
+		&event.Payload,
+		&event.CreateTime,
+	}
+}
+
+func webhookDeliveryRowScanSupplier() (*model.WebhookDelivery, []any) {
+	var delivery model.WebhookDelivery
+	return &delivery, []any{
+		&delivery.Id,
+		&delivery.TenantId,
+		&delivery.SubscriptionId,
+		&delivery.EventId,
+		&delivery.Status,
+		&delivery.AttemptCount,
+		&delivery.NextAttemptTime,
+		&delivery.LastAttemptTime,
+		&delivery.LastResponseCode,
+		&delivery.LastError,
+	}
+}
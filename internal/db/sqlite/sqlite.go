@@ -0,0 +1,174 @@
+package sqlite
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"database/sql/driver"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"github.com/GeneralKenobi/mailman/internal/config"
+	"github.com/GeneralKenobi/mailman/internal/db"
+	"github.com/GeneralKenobi/mailman/internal/db/migration"
+	"github.com/GeneralKenobi/mailman/internal/db/sqlite/repository"
+	"github.com/GeneralKenobi/mailman/pkg/mdctx"
+	"github.com/GeneralKenobi/mailman/pkg/shutdown"
+	sqlitedriver "modernc.org/sqlite" // SQLite driver registration by import, pure Go so that CGO isn't needed.
+	"net/url"
+)
+
+//go:embed migrations/*.sql
+var embeddedMigrations embed.FS
+
+func init() {
+	// SQLite has no hash functions. Migrations use it for computing hashes of existing rows.
+	sqlitedriver.MustRegisterDeterministicScalarFunction("sha256_hex", 1, sha256Hex)
+}
+
+// sha256Hex returns the hex-encoded SHA-256 of the argument's text or bytes.
+func sha256Hex(_ *sqlitedriver.FunctionContext, args []driver.Value) (driver.Value, error) {
+	var data []byte
+	switch arg := args[0].(type) {
+	case string:
+		data = []byte(arg)
+	case []byte:
+		data = arg
+	default:
+		return nil, fmt.Errorf("sha256_hex: unsupported argument type %T", arg)
+	}
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// Context implements DB integration for SQLite, for single-node installations without a DB server.
+//
+// Transactions take the write lock when they begin (BEGIN IMMEDIATE), so they're serialized. That's what SELECT ... FOR UPDATE achieves
+// in postgres, and it avoids failures of transactions that read and then write data concurrently, because SQLite can't upgrade a read
+// lock if another connection wrote in the meantime. Connections wait for locks for up to the configured busy timeout. The DB uses
+// write-ahead logging, so that reads don't wait for writes.
+type Context struct {
+	db *sql.DB
+}
+
+var _ db.Context = (*Context)(nil) // Interface guard
+var _ db.Pinger = (*Context)(nil)  // Interface guard
+
+// NewContext creates a SQLite DB context. The DB client is closed when context is canceled.
+func NewContext(ctx shutdown.Context) (*Context, error) {
+	db, err := Open()
+	if err != nil {
+		return nil, err
+	}
+
+	dbCtx := Context{db: db}
+	go shutdownDbOnContextCancellation(ctx, db)
+	return &dbCtx, nil
+}
+
+// Open creates a DB client for the configured SQLite DB file. The caller is responsible for closing it.
+func Open() (*sql.DB, error) {
+	cfg := config.Get().Sqlite
+	return open(cfg.Path, cfg.BusyTimeoutMillis)
+}
+
+func open(path string, busyTimeoutMillis int) (*sql.DB, error) {
+	// Pragmas are set on every connection of the pool.
+	params := url.Values{
+		"_pragma": {
+			fmt.Sprintf("busy_timeout(%d)", busyTimeoutMillis),
+			"journal_mode(WAL)",
+			"synchronous(NORMAL)", // Durable enough with WAL, a power loss can only lose the latest transactions
+			"foreign_keys(ON)",
+		},
+		"_txlock":      {"immediate"},
+		"_time_format": {"sqlite"},
+	}
+	db, err := sql.Open("sqlite", "file:"+path+"?"+params.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("connection configuration is invalid: %w", err)
+	}
+	return db, nil
+}
+
+// NewMigrator creates a migrator of the SQLite DB with the migrations embedded in the binary. SQLite is only used by a single instance, so
+// migrations aren't locked.
+func NewMigrator(db *sql.DB) (*migration.Migrator, error) {
+	return migration.New(db, embeddedMigrations, "migrations", migration.Dialect{Table: "schema_migrations"})
+}
+
+// Migrate applies the schema migrations that weren't applied yet.
+func (sqliteCtx *Context) Migrate(ctx context.Context) error {
+	migrator, err := NewMigrator(sqliteCtx.db)
+	if err != nil {
+		return err
+	}
+	return migrator.Up(ctx)
+}
+
+func shutdownDbOnContextCancellation(ctx shutdown.Context, db *sql.DB) {
+	defer ctx.Notify()
+
+	<-ctx.Done()
+	mdctx.Infof(nil, "DB context canceled")
+	mdctx.Infof(nil, "Shutting down DB connection")
+	err := db.Close()
+	if err != nil {
+		mdctx.Errorf(nil, "Error closing DB connection: %v", err)
+	}
+	mdctx.Infof(nil, "DB connection closed")
+}
+
+func (sqliteCtx *Context) Ping(ctx context.Context) error {
+	return sqliteCtx.db.PingContext(ctx)
+}
+
+func (sqliteCtx *Context) Stats() sql.DBStats {
+	return sqliteCtx.db.Stats()
+}
+
+func (sqliteCtx *Context) Repository(ctx context.Context) (db.Repository, error) {
+	return repository.New(sqliteCtx.db), nil
+}
+
+// TransactionalRepository begins a transaction. SQLite transactions are always serializable, so the isolation level is ignored.
+func (sqliteCtx *Context) TransactionalRepository(ctx context.Context, _ sql.IsolationLevel) (db.Repository, db.Transaction, error) {
+	transaction, err := sqliteCtx.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error beginning a transaction: %w", err)
+	}
+
+	transactionalRepository := repository.New(transaction)
+	transactionCtx := &transactionContext{transaction: transaction}
+	return transactionalRepository, transactionCtx, nil
+}
+
+// transactionContext implements the db.Transaction interface.
+type transactionContext struct {
+	transaction *sql.Tx
+}
+
+var _ db.Transaction = (*transactionContext)(nil) // Interface guard
+
+func (transactionCtx *transactionContext) Commit() error {
+	return transactionCtx.transaction.Commit()
+}
+
+func (transactionCtx *transactionContext) Rollback() error {
+	return transactionCtx.transaction.Rollback()
+}
+
+func (transactionCtx *transactionContext) Savepoint(ctx context.Context, name string) error {
+	_, err := transactionCtx.transaction.ExecContext(ctx, "SAVEPOINT "+name)
+	return err
+}
+
+func (transactionCtx *transactionContext) RollbackToSavepoint(ctx context.Context, name string) error {
+	_, err := transactionCtx.transaction.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+name)
+	return err
+}
+
+func (transactionCtx *transactionContext) ReleaseSavepoint(ctx context.Context, name string) error {
+	_, err := transactionCtx.transaction.ExecContext(ctx, "RELEASE SAVEPOINT "+name)
+	return err
+}
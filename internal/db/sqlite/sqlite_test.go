@@ -0,0 +1,60 @@
+package sqlite
+
+import (
+	"context"
+	"github.com/GeneralKenobi/mailman/internal/db"
+	"github.com/GeneralKenobi/mailman/internal/db/dbtest"
+	"github.com/GeneralKenobi/mailman/internal/db/migration"
+	"path/filepath"
+	"testing"
+)
+
+func TestRepositoryContract(t *testing.T) {
+	dbtest.RunContractTests(t, func(t *testing.T) db.Context {
+		sqlDb, err := open(filepath.Join(t.TempDir(), "mailman.db"), 5000)
+		if err != nil {
+			t.Fatalf("Error opening the DB: %v", err)
+		}
+		t.Cleanup(func() {
+			sqlDb.Close()
+		})
+		sqliteCtx := &Context{db: sqlDb}
+		if err = sqliteCtx.Migrate(context.Background()); err != nil {
+			t.Fatalf("Error migrating the DB: %v", err)
+		}
+		return sqliteCtx
+	})
+}
+
+func TestMigrations(t *testing.T) {
+	ctx := context.Background()
+	sqlDb, err := open(filepath.Join(t.TempDir(), "mailman.db"), 5000)
+	if err != nil {
+		t.Fatalf("Error opening the DB: %v", err)
+	}
+	defer sqlDb.Close()
+	migrator, err := NewMigrator(sqlDb)
+	if err != nil {
+		t.Fatalf("Error loading migrations: %v", err)
+	}
+	migrations, err := migration.Load(embeddedMigrations, "migrations")
+	if err != nil {
+		t.Fatalf("Error loading embedded migrations: %v", err)
+	}
+
+	if err = migrator.Up(ctx); err != nil {
+		t.Fatalf("Error applying migrations: %v", err)
+	}
+	if version, err := migrator.Version(ctx); err != nil || version != len(migrations) {
+		t.Errorf("Expected version %d after applying migrations but got %d, error: %v", len(migrations), version, err)
+	}
+	if err = migrator.Down(ctx, len(migrations)); err != nil {
+		t.Fatalf("Error reverting migrations: %v", err)
+	}
+	if version, err := migrator.Version(ctx); err != nil || version != 0 {
+		t.Errorf("Expected version 0 after reverting migrations but got %d, error: %v", version, err)
+	}
+	if err = migrator.Up(ctx); err != nil {
+		t.Errorf("Error applying reverted migrations again: %v", err)
+	}
+}
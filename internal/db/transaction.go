@@ -2,20 +2,63 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"expvar"
 	"fmt"
 	"github.com/GeneralKenobi/mailman/pkg/mdctx"
 	"github.com/GeneralKenobi/mailman/pkg/util"
+	"math/rand"
+	"time"
 )
 
+const (
+	minRetryBackoff = 10 * time.Millisecond
+	maxRetryBackoff = time.Second
+)
+
+// transactionMetrics count retries of transactions that failed with ErrSerializationFailure. They're published with expvar.
+var transactionMetrics = expvar.NewMap("dbTransactions")
+
+// TransactionOption configures transactions opened by InTransaction and InTransactionRetV.
+type TransactionOption func(options *transactionOptions)
+
+type transactionOptions struct {
+	isolation  sql.IsolationLevel
+	maxRetries int
+}
+
+// WithIsolation runs the transaction at the given isolation level instead of the DB's default one, e.g. sql.LevelSerializable for
+// transactions that check that data doesn't exist before inserting it. DBs that don't support the level use a stricter one.
+func WithIsolation(isolation sql.IsolationLevel) TransactionOption {
+	return func(options *transactionOptions) {
+		options.isolation = isolation
+	}
+}
+
+// WithRetries runs the transaction again, up to maxRetries times, if it fails with ErrSerializationFailure (a serialization failure or a
+// deadlock). Retries are delayed with exponential backoff with full jitter. The function run in the transaction has to be safe to run
+// again, e.g. it mustn't have side effects outside of the DB.
+func WithRetries(maxRetries int) TransactionOption {
+	return func(options *transactionOptions) {
+		options.maxRetries = maxRetries
+	}
+}
+
 // InTransaction opens a transaction and runs the given function with transaction-scoped repository.
 // If the function returns no errors then transaction is committed.
 // If the function returns an error the transaction is rolled back and the error is returned.
 // If the function panics the transaction is rolled back and this function re-panics with the original value.
+// If the context is done when the function returns the transaction is rolled back and the context's error is returned.
 // Error is also returned if it wasn't possible to open or commit a transaction.
-func InTransaction(ctx context.Context, transactioner Transactioner, todo func(transactionalRepository Repository) error) error {
+//
+// Passing the repository to Nested opens nested transactions.
+func InTransaction(ctx context.Context, transactioner Transactioner, todo func(transactionalRepository Repository) error,
+	options ...TransactionOption) error {
+
 	_, err := InTransactionRetV(ctx, transactioner, func(transactionalRepository Repository) (any, error) {
 		return nil, todo(transactionalRepository)
-	})
+	}, options...)
 	return err
 }
 
@@ -23,30 +66,86 @@ func InTransaction(ctx context.Context, transactioner Transactioner, todo func(t
 // If the function returns no errors then transaction is committed and the function's result is returned.
 // If the function returns an error the transaction is rolled back and the error is returned.
 // If the function panics the transaction is rolled back and this function re-panics with the original value.
+// If the context is done when the function returns the transaction is rolled back and the context's error is returned.
 // Error is also returned if it wasn't possible to open or commit a transaction.
-func InTransactionRetV[V any](ctx context.Context, transactioner Transactioner, todo func(transactionalRepository Repository) (V, error)) (V, error) {
+//
+// Passing the repository to Nested opens nested transactions.
+func InTransactionRetV[V any](ctx context.Context, transactioner Transactioner, todo func(transactionalRepository Repository) (V, error),
+	options ...TransactionOption) (V, error) {
+
+	var opts transactionOptions
+	for _, option := range options {
+		option(&opts)
+	}
+
+	if _, nested := transactioner.(*transactionalRepository); nested {
+		opts.maxRetries = 0
+	}
+	for retry := 0; ; retry++ {
+		value, err := inTransaction(ctx, transactioner, opts.isolation, todo)
+		if !errors.Is(err, ErrSerializationFailure) {
+			if retry > 0 && err == nil {
+				mdctx.Infof(ctx, "Transaction succeeded after %d retries", retry)
+				transactionMetrics.Add("retriedSuccesses", 1)
+			}
+			return value, err
+		}
+		if retry >= opts.maxRetries {
+			if opts.maxRetries > 0 {
+				mdctx.Warnf(ctx, "Transaction failed after %d retries: %v", retry, err)
+				transactionMetrics.Add("retriesExhausted", 1)
+			}
+			return value, err
+		}
+
+		backoff := retryBackoff(retry)
+		mdctx.Infof(ctx, "Retrying transaction in %v (retry %d of %d): %v", backoff, retry+1, opts.maxRetries, err)
+		transactionMetrics.Add("retries", 1)
+		select {
+		case <-ctx.Done():
+			return util.ZeroValue[V](), fmt.Errorf("context done before retrying transaction: %w", err)
+		case <-time.After(backoff):
+		}
+	}
+}
+
+func inTransaction[V any](ctx context.Context, transactioner Transactioner, isolation sql.IsolationLevel,
+	todo func(transactionalRepository Repository) (V, error)) (value V, err error) {
 
 	mdctx.Debugf(ctx, "Opening transaction")
-	transactionalRepository, transaction, err := transactioner.TransactionalRepository(ctx)
+	repository, transaction, err := transactioner.TransactionalRepository(ctx, isolation)
 	if err != nil {
 		return util.ZeroValue[V](), fmt.Errorf("error creating a transaction: %w", err)
 	}
 
-	value, err := todo(transactionalRepository)
-
-	if panicErr := recover(); panicErr != nil {
-		mdctx.Debugf(ctx, "Rolling back transaction after panic: %v", panicErr)
-		rollbackErr := transaction.Rollback()
-		if rollbackErr != nil {
-			mdctx.Errorf(ctx, "Error rolling back transaction after panic: %v", rollbackErr)
+	defer func() {
+		if panicErr := recover(); panicErr != nil {
+			mdctx.Debugf(ctx, "Rolling back transaction after panic: %v", panicErr)
+			rollbackErr := transaction.Rollback()
+			if rollbackErr != nil {
+				mdctx.Errorf(ctx, "Error rolling back transaction after panic: %v", rollbackErr)
+			}
+			panic(panicErr)
 		}
-		panic(panicErr)
+	}()
+
+	depth := 0
+	if outer, nested := transactioner.(*transactionalRepository); nested {
+		depth = outer.depth + 1
+	}
+	value, err = todo(&transactionalRepository{Repository: repository, transaction: transaction, depth: depth})
+
+	// The function may not notice that the context is done, e.g. if it doesn't query the DB after that. Its changes are rolled back
+	// anyway, the caller can't wait for the result.
+	if err == nil && ctx.Err() != nil {
+		err = fmt.Errorf("transaction canceled: %w", ctx.Err())
 	}
 
 	if err != nil {
 		mdctx.Debugf(ctx, "Rolling back transaction after error: %v", err)
 		rollbackErr := transaction.Rollback()
-		if rollbackErr != nil {
+		// database/sql rolls back transactions by itself when their context is done, rolling back again fails with sql.ErrTxDone.
+		if rollbackErr != nil && !errors.Is(rollbackErr, sql.ErrTxDone) {
 			mdctx.Errorf(ctx, "Error rolling back transaction after error: %v", rollbackErr)
 		}
 		return util.ZeroValue[V](), err
@@ -60,3 +159,94 @@ func InTransactionRetV[V any](ctx context.Context, transactioner Transactioner,
 
 	return value, nil
 }
+
+// Nested returns a Transactioner opening nested transactions of the transaction of the repository, which has to be passed to a function
+// run by InTransaction or InTransactionRetV. Nested transactions are savepoints: rolling back a nested transaction only rolls back its
+// changes, committing it keeps them in the outer transaction, which can still be rolled back. Nested transactions aren't retried, the
+// outer transaction has to be retried instead.
+func Nested(repository Repository) Transactioner {
+	if transactional, ok := repository.(*transactionalRepository); ok {
+		return transactional
+	}
+	return noTransaction{}
+}
+
+// Unwrap returns the repository created by the DB's context that's wrapped by the repository passed to functions run by InTransaction or
+// InTransactionRetV, so that DB-specific queries, which aren't part of Repository, can run in the transaction.
+func Unwrap(repository Repository) Repository {
+	if transactional, ok := repository.(*transactionalRepository); ok {
+		return transactional.Repository
+	}
+	return repository
+}
+
+// transactionalRepository is the repository passed to functions run in transactions. It opens nested transactions as savepoints.
+type transactionalRepository struct {
+	Repository
+	transaction Transaction
+	depth       int // 0 for top-level transactions
+}
+
+var _ Transactioner = (*transactionalRepository)(nil) // Interface guard
+
+// TransactionalRepository creates a savepoint. Nested transactions share the isolation level of the outer one.
+func (repository *transactionalRepository) TransactionalRepository(ctx context.Context, _ sql.IsolationLevel) (Repository, Transaction, error) {
+	savepoint := savepoint{
+		ctx:         ctx,
+		transaction: repository.transaction,
+		name:        fmt.Sprintf("nested_%d", repository.depth+1),
+	}
+	if err := repository.transaction.Savepoint(ctx, savepoint.name); err != nil {
+		return nil, nil, fmt.Errorf("error creating savepoint: %w", err)
+	}
+	return repository.Repository, &savepoint, nil
+}
+
+// savepoint is a Transaction of a nested transaction.
+type savepoint struct {
+	ctx         context.Context
+	transaction Transaction
+	name        string
+}
+
+var _ Transaction = (*savepoint)(nil) // Interface guard
+
+func (savepoint *savepoint) Commit() error {
+	return savepoint.transaction.ReleaseSavepoint(savepoint.ctx, savepoint.name)
+}
+
+func (savepoint *savepoint) Rollback() error {
+	// The savepoint remains after rolling back to it, it's destroyed anyway when the outer transaction ends.
+	return savepoint.transaction.RollbackToSavepoint(savepoint.ctx, savepoint.name)
+}
+
+func (savepoint *savepoint) Savepoint(ctx context.Context, name string) error {
+	return savepoint.transaction.Savepoint(ctx, name)
+}
+
+func (savepoint *savepoint) RollbackToSavepoint(ctx context.Context, name string) error {
+	return savepoint.transaction.RollbackToSavepoint(ctx, name)
+}
+
+func (savepoint *savepoint) ReleaseSavepoint(ctx context.Context, name string) error {
+	return savepoint.transaction.ReleaseSavepoint(ctx, name)
+}
+
+// noTransaction is returned by Nested for repositories that don't belong to a transaction.
+type noTransaction struct{}
+
+func (noTransaction) TransactionalRepository(context.Context, sql.IsolationLevel) (Repository, Transaction, error) {
+	return nil, nil, ErrNoTransaction
+}
+
+// retryBackoff returns exponential backoff with full jitter for the retry (starting at 0).
+func retryBackoff(retry int) time.Duration {
+	backoff := minRetryBackoff << retry
+	if backoff > maxRetryBackoff || backoff <= 0 {
+		backoff = maxRetryBackoff
+	}
+	return time.Duration(jitter(int64(backoff)) + 1)
+}
+
+// Hook for mocking in unit tests.
+var jitter = rand.Int63n
@@ -0,0 +1,284 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"expvar"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestTransactionRetries(t *testing.T) {
+	conflictErr := fmt.Errorf("commit: %w", ErrSerializationFailure)
+	otherErr := errors.New("connection reset")
+	tests := map[string]struct {
+		options             []TransactionOption
+		commitErrs          []error // Returned by consecutive commits, commits after the last one succeed
+		expectedErr         error
+		expectedAttempts    int
+		expectedIsolation   sql.IsolationLevel
+		expectedRetryMetric int64
+	}{
+		"Should not retry without the retries option": {
+			commitErrs:       []error{conflictErr},
+			expectedErr:      ErrSerializationFailure,
+			expectedAttempts: 1,
+		},
+		"Should retry a serialization failure": {
+			options:             []TransactionOption{WithRetries(3)},
+			commitErrs:          []error{conflictErr, conflictErr},
+			expectedAttempts:    3,
+			expectedRetryMetric: 2,
+		},
+		"Should give up after the maximum number of retries": {
+			options:             []TransactionOption{WithRetries(2)},
+			commitErrs:          []error{conflictErr, conflictErr, conflictErr, conflictErr},
+			expectedErr:         ErrSerializationFailure,
+			expectedAttempts:    3,
+			expectedRetryMetric: 2,
+		},
+		"Should not retry other errors": {
+			options:          []TransactionOption{WithRetries(3)},
+			commitErrs:       []error{otherErr},
+			expectedErr:      otherErr,
+			expectedAttempts: 1,
+		},
+		"Should open transactions at the chosen isolation level": {
+			options:           []TransactionOption{WithIsolation(sql.LevelSerializable)},
+			expectedAttempts:  1,
+			expectedIsolation: sql.LevelSerializable,
+		},
+	}
+
+	jitter = func(n int64) int64 { return 0 }
+	defer func() { jitter = defaultJitter }()
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			transactioner := &transactionerMock{commitErrs: test.commitErrs}
+			retriesBefore := metricValue("retries")
+			attempts := 0
+
+			err := InTransaction(context.Background(), transactioner, func(Repository) error {
+				attempts++
+				return nil
+			}, test.options...)
+
+			if !errors.Is(err, test.expectedErr) || (test.expectedErr == nil && err != nil) {
+				t.Errorf("Expected error %v but got %v", test.expectedErr, err)
+			}
+			if attempts != test.expectedAttempts {
+				t.Errorf("Expected %d attempts but got %d", test.expectedAttempts, attempts)
+			}
+			for _, isolation := range transactioner.isolations {
+				if isolation != test.expectedIsolation {
+					t.Errorf("Expected isolation level %v but got %v", test.expectedIsolation, isolation)
+				}
+			}
+			if retries := metricValue("retries") - retriesBefore; retries != test.expectedRetryMetric {
+				t.Errorf("Expected %d retries in metrics but got %d", test.expectedRetryMetric, retries)
+			}
+		})
+	}
+}
+
+func TestTransactionEnd(t *testing.T) {
+	todoErr := errors.New("todo failed")
+	tests := map[string]struct {
+		todo          func(ctx context.Context, cancel context.CancelFunc, repository Repository) error
+		expectedErr   error
+		expectedPanic any
+		expectedCalls []string
+	}{
+		"Should commit when the function succeeds": {
+			todo: func(context.Context, context.CancelFunc, Repository) error {
+				return nil
+			},
+			expectedCalls: []string{"begin", "commit"},
+		},
+		"Should roll back when the function fails": {
+			todo: func(context.Context, context.CancelFunc, Repository) error {
+				return todoErr
+			},
+			expectedErr:   todoErr,
+			expectedCalls: []string{"begin", "rollback"},
+		},
+		"Should roll back and re-panic when the function panics": {
+			todo: func(context.Context, context.CancelFunc, Repository) error {
+				panic("panic in transaction")
+			},
+			expectedPanic: "panic in transaction",
+			expectedCalls: []string{"begin", "rollback"},
+		},
+		"Should roll back when the context is canceled during the transaction": {
+			todo: func(_ context.Context, cancel context.CancelFunc, _ Repository) error {
+				cancel()
+				return nil
+			},
+			expectedErr:   context.Canceled,
+			expectedCalls: []string{"begin", "rollback"},
+		},
+		"Should release the savepoint of a successful nested transaction": {
+			todo: func(ctx context.Context, _ context.CancelFunc, repository Repository) error {
+				return InTransaction(ctx, Nested(repository), func(Repository) error {
+					return nil
+				})
+			},
+			expectedCalls: []string{"begin", "savepoint nested_1", "release savepoint nested_1", "commit"},
+		},
+		"Should only roll back a failed nested transaction": {
+			todo: func(ctx context.Context, _ context.CancelFunc, repository Repository) error {
+				err := InTransaction(ctx, Nested(repository), func(Repository) error {
+					return todoErr
+				})
+				if !errors.Is(err, todoErr) {
+					return fmt.Errorf("expected the nested transaction's error but got %v", err)
+				}
+				return nil
+			},
+			expectedCalls: []string{"begin", "savepoint nested_1", "rollback to savepoint nested_1", "commit"},
+		},
+		"Should name savepoints of nested transactions after their depth": {
+			todo: func(ctx context.Context, _ context.CancelFunc, repository Repository) error {
+				return InTransaction(ctx, Nested(repository), func(nestedRepository Repository) error {
+					return InTransaction(ctx, Nested(nestedRepository), func(Repository) error {
+						return nil
+					})
+				})
+			},
+			expectedCalls: []string{"begin", "savepoint nested_1", "savepoint nested_2", "release savepoint nested_2",
+				"release savepoint nested_1", "commit"},
+		},
+		"Should roll back nested and outer transactions when a nested transaction panics": {
+			todo: func(ctx context.Context, _ context.CancelFunc, repository Repository) error {
+				return InTransaction(ctx, Nested(repository), func(Repository) error {
+					panic("panic in nested transaction")
+				})
+			},
+			expectedPanic: "panic in nested transaction",
+			expectedCalls: []string{"begin", "savepoint nested_1", "rollback to savepoint nested_1", "rollback"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			transactioner := &transactionerMock{}
+
+			var err error
+			panicValue := func() (recovered any) {
+				defer func() {
+					recovered = recover()
+				}()
+				err = InTransaction(ctx, transactioner, func(repository Repository) error {
+					return test.todo(ctx, cancel, repository)
+				})
+				return nil
+			}()
+
+			if panicValue != test.expectedPanic {
+				t.Errorf("Expected panic %v but got %v", test.expectedPanic, panicValue)
+			}
+			if !errors.Is(err, test.expectedErr) || (test.expectedErr == nil && err != nil) {
+				t.Errorf("Expected error %v but got %v", test.expectedErr, err)
+			}
+			if strings.Join(transactioner.calls, ", ") != strings.Join(test.expectedCalls, ", ") {
+				t.Errorf("Expected calls %v but got %v", test.expectedCalls, transactioner.calls)
+			}
+		})
+	}
+}
+
+func TestNestedTransactionWithoutTransaction(t *testing.T) {
+	err := InTransaction(context.Background(), Nested(nil), func(Repository) error {
+		return nil
+	})
+
+	if !errors.Is(err, ErrNoTransaction) {
+		t.Errorf("Expected error %v but got %v", ErrNoTransaction, err)
+	}
+}
+
+func TestUnwrap(t *testing.T) {
+	err := InTransaction(context.Background(), &transactionerMock{}, func(repository Repository) error {
+		if repository == nil {
+			return errors.New("expected the repository to be wrapped")
+		}
+		// The mock creates nil repositories.
+		if unwrapped := Unwrap(repository); unwrapped != nil {
+			return fmt.Errorf("expected the repository created by the transactioner but got %v", unwrapped)
+		}
+		return nil
+	})
+
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	for retry := 0; retry < 100; retry++ {
+		backoff := retryBackoff(retry)
+		if backoff <= 0 || backoff > maxRetryBackoff {
+			t.Errorf("Expected backoff of retry %d in (0, %v] but got %v", retry, maxRetryBackoff, backoff)
+		}
+	}
+}
+
+var defaultJitter = jitter
+
+func metricValue(key string) int64 {
+	transactionMetrics.Add(key, 0) // Creates the counter if nothing was counted yet
+	return transactionMetrics.Get(key).(*expvar.Int).Value()
+}
+
+// transactionerMock opens transactionMock transactions and records their isolation levels and calls of their methods.
+type transactionerMock struct {
+	commitErrs []error
+	isolations []sql.IsolationLevel
+	commits    int
+	calls      []string
+}
+
+func (transactioner *transactionerMock) TransactionalRepository(_ context.Context, isolation sql.IsolationLevel) (Repository, Transaction, error) {
+	transactioner.isolations = append(transactioner.isolations, isolation)
+	transactioner.calls = append(transactioner.calls, "begin")
+	return nil, &transactionMock{transactioner: transactioner}, nil
+}
+
+type transactionMock struct {
+	transactioner *transactionerMock
+}
+
+func (transaction *transactionMock) Commit() error {
+	transactioner := transaction.transactioner
+	transactioner.calls = append(transactioner.calls, "commit")
+	transactioner.commits++
+	if transactioner.commits <= len(transactioner.commitErrs) {
+		return transactioner.commitErrs[transactioner.commits-1]
+	}
+	return nil
+}
+
+func (transaction *transactionMock) Rollback() error {
+	transaction.transactioner.calls = append(transaction.transactioner.calls, "rollback")
+	return nil
+}
+
+func (transaction *transactionMock) Savepoint(_ context.Context, name string) error {
+	transaction.transactioner.calls = append(transaction.transactioner.calls, "savepoint "+name)
+	return nil
+}
+
+func (transaction *transactionMock) RollbackToSavepoint(_ context.Context, name string) error {
+	transaction.transactioner.calls = append(transaction.transactioner.calls, "rollback to savepoint "+name)
+	return nil
+}
+
+func (transaction *transactionMock) ReleaseSavepoint(_ context.Context, name string) error {
+	transaction.transactioner.calls = append(transaction.transactioner.calls, "release savepoint "+name)
+	return nil
+}
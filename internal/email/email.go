@@ -1,7 +1,22 @@
 package email
 
-import "context"
+import (
+	"context"
+	"errors"
+)
 
 type Service interface {
+	// Send sends an email. Errors wrapping ErrBounced mean the message will never be delivered to the address, other errors may be
+	// temporary.
 	Send(ctx context.Context, emailAddress, title, content string) error
 }
+
+// Pinger is implemented by email services that send through a transport which may be unreachable, e.g. an SMTP server.
+type Pinger interface {
+	// Ping checks that the transport is reachable.
+	Ping(ctx context.Context) error
+}
+
+// ErrBounced is returned (wrapped) by Service.Send if the recipient's server permanently rejected the message, e.g. because the address
+// doesn't exist.
+var ErrBounced = errors.New("email bounced")
@@ -9,6 +9,7 @@ import (
 type Emailer struct{}
 
 var _ email.Service = (*Emailer)(nil)
+var _ email.Pinger = (*Emailer)(nil)
 
 func NewEmailer() *Emailer {
 	return &Emailer{}
@@ -18,3 +19,8 @@ func (emailer *Emailer) Send(ctx context.Context, emailAddress, title, content s
 	mdctx.Infof(ctx, "Mock email service: Sending email titled %q to %q with content %q", title, emailAddress, content)
 	return nil
 }
+
+// Ping always succeeds, the mock doesn't use a transport.
+func (emailer *Emailer) Ping(_ context.Context) error {
+	return nil
+}
@@ -0,0 +1,54 @@
+package mailingentry
+
+import (
+	"context"
+	"fmt"
+	"github.com/GeneralKenobi/mailman/internal/config"
+	"github.com/GeneralKenobi/mailman/internal/db"
+	"github.com/GeneralKenobi/mailman/internal/service/mailingentry/archiveremover"
+	"github.com/GeneralKenobi/mailman/pkg/mdctx"
+	"github.com/GeneralKenobi/mailman/pkg/scheduler"
+	"github.com/GeneralKenobi/mailman/pkg/shutdown"
+	"time"
+)
+
+func NewArchiveCleanupJob(transactioner db.Transactioner) *ArchiveCleanupJob {
+	return &ArchiveCleanupJob{transactioner: transactioner}
+}
+
+// ArchiveCleanupJob removes archived mailing entries after the archive retention period.
+type ArchiveCleanupJob struct {
+	transactioner db.Transactioner
+}
+
+// RunScheduled runs archive cleanup periodically until the context is canceled, only on the replica holding its lock
+// if locker isn't nil.
+func (cleanupJob *ArchiveCleanupJob) RunScheduled(ctx shutdown.Context, locker scheduler.Locker) {
+	jobScheduler := scheduler.New("mailing entry archive cleanup", cleanupJob.RunCleanup, scheduler.WithLocker(locker))
+	jobScheduler.RunPeriodically(ctx, time.Duration(config.Get().MailingEntryArchive.CleanupPeriodSeconds)*time.Second)
+}
+
+// RunCleanup removes expired archived entries of every tenant, applying each tenant's retention settings.
+func (cleanupJob *ArchiveCleanupJob) RunCleanup(ctx context.Context) error {
+	tenantIds, err := db.InTransactionRetV(ctx, cleanupJob.transactioner, func(repository db.Repository) ([]string, error) {
+		return repository.FindTenantIdsWithArchivedMailingEntries(ctx)
+	})
+	if err != nil {
+		return fmt.Errorf("error listing tenants: %w", err)
+	}
+
+	expiredEntryRemover := archiveremover.New(cleanupJob.transactioner)
+	var failedTenantIds []string
+	for _, tenantId := range tenantIds {
+		tenantCtx := mdctx.WithTenantId(ctx, tenantId)
+		if err = expiredEntryRemover.Remove(tenantCtx, tenantId); err != nil {
+			mdctx.Errorf(tenantCtx, "Error cleaning up archived mailing entries: %v", err)
+			failedTenantIds = append(failedTenantIds, tenantId)
+		}
+	}
+
+	if len(failedTenantIds) > 0 {
+		return fmt.Errorf("cleanup failed for tenants %v", failedTenantIds)
+	}
+	return nil
+}
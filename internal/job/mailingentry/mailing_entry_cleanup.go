@@ -2,36 +2,82 @@ package mailingentry
 
 import (
 	"context"
+	"fmt"
 	"github.com/GeneralKenobi/mailman/internal/config"
 	"github.com/GeneralKenobi/mailman/internal/db"
+	"github.com/GeneralKenobi/mailman/internal/service/mailingentry/partitionmanager"
 	"github.com/GeneralKenobi/mailman/internal/service/mailingentry/staleremover"
+	"github.com/GeneralKenobi/mailman/pkg/mdctx"
 	"github.com/GeneralKenobi/mailman/pkg/scheduler"
 	"github.com/GeneralKenobi/mailman/pkg/shutdown"
 	"time"
 )
 
-func NewCleanupJob(transactioner db.Transactioner) *CleanupJob {
-	return &CleanupJob{transactioner: transactioner}
+// NewCleanupJob creates a stale entry cleanup job. partitioner is nil if the DB doesn't partition mailing entries.
+func NewCleanupJob(transactioner db.Transactioner, partitioner db.Partitioner) *CleanupJob {
+	return &CleanupJob{transactioner: transactioner, partitioner: partitioner}
 }
 
 type CleanupJob struct {
 	transactioner db.Transactioner
+	partitioner   db.Partitioner
 }
 
-// RunScheduled runs stale entry cleanup periodically until the context is canceled.
-func (cleanupJob *CleanupJob) RunScheduled(ctx shutdown.Context) {
-	jobScheduler := scheduler.New("stale mailing entry cleanup", cleanupJob.RunCleanup)
+// RunScheduled runs stale entry cleanup periodically until the context is canceled, only on the replica holding its lock
+// if locker isn't nil.
+func (cleanupJob *CleanupJob) RunScheduled(ctx shutdown.Context, locker scheduler.Locker) {
+	jobScheduler := scheduler.New("stale mailing entry cleanup", cleanupJob.RunCleanup, scheduler.WithLocker(locker))
 	jobScheduler.RunPeriodically(ctx, schedulingPeriod())
 }
 
+// RunCleanup removes stale entries of every tenant, applying each tenant's retention settings. Partitions of mailing entries that only
+// contain stale entries are dropped at once, the remaining entries are removed in batches, each in a separate transaction, so a failure
+// for one tenant doesn't prevent cleaning up the others. The run stops when its time budget is exhausted, tenants that weren't cleaned up
+// completely are cleaned up by the next run.
 func (cleanupJob *CleanupJob) RunCleanup(ctx context.Context) error {
-	return db.InTransaction(ctx, cleanupJob.transactioner, func(repository db.Repository) error {
-		staleMailingEntryRemover := staleremover.New(repository)
-		return staleMailingEntryRemover.Remove(ctx)
+	deadline := time.Now().Add(timeBudget())
+
+	if cleanupJob.partitioner != nil {
+		if _, err := partitionmanager.New(cleanupJob.partitioner).DropExpired(ctx); err != nil {
+			// Entries of partitions that weren't dropped are removed in batches.
+			mdctx.Errorf(ctx, "Error dropping expired mailing entry partitions: %v", err)
+		}
+	}
+
+	tenantIds, err := db.InTransactionRetV(ctx, cleanupJob.transactioner, func(repository db.Repository) ([]string, error) {
+		return repository.FindTenantIdsWithMailingEntries(ctx)
 	})
+	if err != nil {
+		return fmt.Errorf("error listing tenants: %w", err)
+	}
+
+	staleMailingEntryRemover := staleremover.New(cleanupJob.transactioner)
+	var failedTenantIds []string
+	for i, tenantId := range tenantIds {
+		tenantCtx := mdctx.WithTenantId(ctx, tenantId)
+		done, err := staleMailingEntryRemover.Remove(tenantCtx, tenantId, deadline)
+		if err == nil && !done {
+			mdctx.Infof(ctx, "Cleanup time budget exhausted, %d tenants are left for the next run", len(tenantIds)-i)
+			break
+		}
+		if err != nil {
+			mdctx.Errorf(tenantCtx, "Error cleaning up stale mailing entries: %v", err)
+			failedTenantIds = append(failedTenantIds, tenantId)
+		}
+	}
+
+	if len(failedTenantIds) > 0 {
+		return fmt.Errorf("cleanup failed for tenants %v", failedTenantIds)
+	}
+	return nil
 }
 
 // Hook for mocking in unit tests.
 var schedulingPeriod = func() time.Duration {
 	return time.Duration(config.Get().MailingEntryCleanupJob.PeriodSeconds) * time.Second
 }
+
+// Hook for mocking in unit tests.
+var timeBudget = func() time.Duration {
+	return time.Duration(config.Get().MailingEntryCleanupJob.TimeBudgetSeconds) * time.Second
+}
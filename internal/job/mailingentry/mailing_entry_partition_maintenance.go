@@ -0,0 +1,41 @@
+package mailingentry
+
+import (
+	"context"
+	"fmt"
+	"github.com/GeneralKenobi/mailman/internal/config"
+	"github.com/GeneralKenobi/mailman/internal/db"
+	"github.com/GeneralKenobi/mailman/internal/service/mailingentry/partitionmanager"
+	"github.com/GeneralKenobi/mailman/pkg/scheduler"
+	"github.com/GeneralKenobi/mailman/pkg/shutdown"
+	"time"
+)
+
+func NewPartitionMaintenanceJob(partitioner db.Partitioner) *PartitionMaintenanceJob {
+	return &PartitionMaintenanceJob{partitioner: partitioner}
+}
+
+// PartitionMaintenanceJob creates partitions of mailing entries ahead of time and drops expired ones.
+type PartitionMaintenanceJob struct {
+	partitioner db.Partitioner
+}
+
+// RunScheduled runs partition maintenance on start and periodically until the context is canceled, only on the replica holding its
+// lock if locker isn't nil. Running it on start creates the partitions of a fresh deployment without waiting for the first period.
+func (maintenanceJob *PartitionMaintenanceJob) RunScheduled(ctx shutdown.Context, locker scheduler.Locker) {
+	jobScheduler := scheduler.New("mailing entry partition maintenance", maintenanceJob.RunMaintenance,
+		scheduler.WithLocker(locker), scheduler.WithRunOnStart())
+	jobScheduler.RunPeriodically(ctx, time.Duration(config.Get().MailingEntryPartitioning.PeriodSeconds)*time.Second)
+}
+
+// RunMaintenance creates upcoming partitions and drops expired ones. Expired partitions are dropped even if creating partitions fails.
+func (maintenanceJob *PartitionMaintenanceJob) RunMaintenance(ctx context.Context) error {
+	partitionManager := partitionmanager.New(maintenanceJob.partitioner)
+	createErr := partitionManager.CreateUpcoming(ctx)
+	_, dropErr := partitionManager.DropExpired(ctx)
+
+	if createErr != nil || dropErr != nil {
+		return fmt.Errorf("partition maintenance failed - creating partitions: %v, dropping partitions: %v", createErr, dropErr)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"github.com/GeneralKenobi/mailman/internal/config"
+	"github.com/GeneralKenobi/mailman/internal/db"
+	"github.com/GeneralKenobi/mailman/internal/service/webhook/dispatcher"
+	"github.com/GeneralKenobi/mailman/pkg/mdctx"
+	"github.com/GeneralKenobi/mailman/pkg/scheduler"
+	"github.com/GeneralKenobi/mailman/pkg/shutdown"
+	"time"
+)
+
+func NewDispatchJob(transactioner db.Transactioner, httpClient dispatcher.HttpClient) *DispatchJob {
+	return &DispatchJob{
+		transactioner: transactioner,
+		httpClient:    httpClient,
+	}
+}
+
+// DispatchJob delivers webhook events recorded in the outbox.
+type DispatchJob struct {
+	transactioner db.Transactioner
+	httpClient    dispatcher.HttpClient
+}
+
+// RunScheduled runs webhook dispatch periodically until the context is canceled, only on the replica holding its lock
+// if locker isn't nil.
+func (dispatchJob *DispatchJob) RunScheduled(ctx shutdown.Context, locker scheduler.Locker) {
+	jobScheduler := scheduler.New("webhook dispatch", dispatchJob.RunDispatch, scheduler.WithLocker(locker))
+	jobScheduler.RunPeriodically(ctx, time.Duration(config.Get().Webhooks.DispatchPeriodSeconds)*time.Second)
+}
+
+// RunDispatch attempts a batch of due deliveries of every tenant. A failure for one tenant doesn't prevent delivering events of the
+// others.
+func (dispatchJob *DispatchJob) RunDispatch(ctx context.Context) error {
+	tenantIds, err := db.InTransactionRetV(ctx, dispatchJob.transactioner, func(repository db.Repository) ([]string, error) {
+		return repository.FindTenantIdsWithDueWebhookDeliveries(ctx, currentTime().UTC())
+	})
+	if err != nil {
+		return fmt.Errorf("error listing tenants: %w", err)
+	}
+
+	var failedTenantIds []string
+	for _, tenantId := range tenantIds {
+		tenantCtx := mdctx.WithTenantId(ctx, tenantId)
+		webhookDispatcher := dispatcher.New(dispatchJob.transactioner, dispatchJob.httpClient)
+		_, err = webhookDispatcher.Dispatch(tenantCtx, tenantId)
+		if err != nil {
+			mdctx.Errorf(tenantCtx, "Error dispatching webhook events: %v", err)
+			failedTenantIds = append(failedTenantIds, tenantId)
+		}
+	}
+
+	if len(failedTenantIds) > 0 {
+		return fmt.Errorf("dispatch failed for tenants %v", failedTenantIds)
+	}
+	return nil
+}
+
+// Hook for mocking in unit tests.
+var currentTime = time.Now
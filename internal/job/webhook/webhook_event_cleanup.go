@@ -0,0 +1,57 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"github.com/GeneralKenobi/mailman/internal/config"
+	"github.com/GeneralKenobi/mailman/internal/db"
+	"github.com/GeneralKenobi/mailman/internal/service/webhook/eventremover"
+	"github.com/GeneralKenobi/mailman/pkg/mdctx"
+	"github.com/GeneralKenobi/mailman/pkg/scheduler"
+	"github.com/GeneralKenobi/mailman/pkg/shutdown"
+	"time"
+)
+
+func NewCleanupJob(transactioner db.Transactioner) *CleanupJob {
+	return &CleanupJob{transactioner: transactioner}
+}
+
+// CleanupJob removes webhook events and their delivery log after the retention period.
+type CleanupJob struct {
+	transactioner db.Transactioner
+}
+
+// RunScheduled runs webhook event cleanup periodically until the context is canceled, only on the replica holding its lock
+// if locker isn't nil.
+func (cleanupJob *CleanupJob) RunScheduled(ctx shutdown.Context, locker scheduler.Locker) {
+	jobScheduler := scheduler.New("webhook event cleanup", cleanupJob.RunCleanup, scheduler.WithLocker(locker))
+	jobScheduler.RunPeriodically(ctx, time.Duration(config.Get().Webhooks.CleanupPeriodSeconds)*time.Second)
+}
+
+// RunCleanup removes old webhook events of every tenant. Each tenant is cleaned up in a separate transaction.
+func (cleanupJob *CleanupJob) RunCleanup(ctx context.Context) error {
+	tenantIds, err := db.InTransactionRetV(ctx, cleanupJob.transactioner, func(repository db.Repository) ([]string, error) {
+		return repository.FindTenantIdsWithWebhookEvents(ctx)
+	})
+	if err != nil {
+		return fmt.Errorf("error listing tenants: %w", err)
+	}
+
+	var failedTenantIds []string
+	for _, tenantId := range tenantIds {
+		tenantCtx := mdctx.WithTenantId(ctx, tenantId)
+		err = db.InTransaction(tenantCtx, cleanupJob.transactioner, func(repository db.Repository) error {
+			oldEventRemover := eventremover.New(repository)
+			return oldEventRemover.Remove(tenantCtx, tenantId)
+		})
+		if err != nil {
+			mdctx.Errorf(tenantCtx, "Error cleaning up webhook events: %v", err)
+			failedTenantIds = append(failedTenantIds, tenantId)
+		}
+	}
+
+	if len(failedTenantIds) > 0 {
+		return fmt.Errorf("cleanup failed for tenants %v", failedTenantIds)
+	}
+	return nil
+}
@@ -11,8 +11,8 @@ import (
 )
 
 type Repository interface {
-	FindCustomerByEmail(ctx context.Context, email string) (model.Customer, error)
 	InsertCustomer(ctx context.Context, customer model.Customer) (model.Customer, error)
+	FindOrInsertCustomer(ctx context.Context, customer model.Customer) (model.Customer, error)
 }
 
 func New(repository Repository) *Creator {
@@ -23,22 +23,25 @@ type Creator struct {
 	repository Repository
 }
 
-// CreateFromEmail creates a customer with the given email.
-// Returns api.StatusBadInput error if the email is already assigned to a customer.
-func (creator *Creator) CreateFromEmail(ctx context.Context, email string) (model.Customer, error) {
-	customer := model.Customer{Email: email}
+// CreateFromEmail creates a customer of the tenant with the given email.
+// Returns api.StatusBadInput error if the email is already assigned to a customer of the tenant.
+func (creator *Creator) CreateFromEmail(ctx context.Context, tenantId, email string) (model.Customer, error) {
+	customer := model.Customer{
+		TenantId: tenantId,
+		Email:    email,
+	}
 	return creator.Create(ctx, customer)
 }
 
 // Create saves the given customer.
-// Returns api.StatusBadInput error if the customer's email is already assigned to a customer.
+// Returns api.StatusBadInput error if the customer's email is already assigned to a customer of the same tenant.
 func (creator *Creator) Create(ctx context.Context, customer model.Customer) (model.Customer, error) {
-	if err := creator.assertEmailIsNotUsed(ctx, customer.Email); err != nil {
-		return model.Customer{}, err
-	}
-
 	mdctx.Debugf(ctx, "Creating a new customer")
 	customer, err := creator.repository.InsertCustomer(ctx, customer)
+	if errors.Is(err, db.ErrDuplicate) {
+		mdctx.Debugf(ctx, "Email is already used by another customer")
+		return model.Customer{}, api.StatusBadInput.WithMessageAndCause(err, "customer with this email already exists")
+	}
 	if err != nil {
 		return model.Customer{}, fmt.Errorf("error creating customer: %w", err)
 	}
@@ -46,15 +49,13 @@ func (creator *Creator) Create(ctx context.Context, customer model.Customer) (mo
 	return customer, nil
 }
 
-func (creator *Creator) assertEmailIsNotUsed(ctx context.Context, email string) error {
-	customer, err := creator.repository.FindCustomerByEmail(ctx, email)
-	if err == nil {
-		mdctx.Debugf(ctx, "Email is already used by customer %d", customer.Id)
-		return api.StatusBadInput.WithMessage("customer with this email already exists")
-	}
-	if errors.Is(err, db.ErrNoRows) {
-		mdctx.Debugf(ctx, "No customer found - email is available")
-		return nil
+// GetOrCreateFromEmail finds the tenant's customer with the email or creates a new one if it doesn't exist. Concurrent calls with the
+// same email return the same customer.
+func (creator *Creator) GetOrCreateFromEmail(ctx context.Context, tenantId, email string) (model.Customer, error) {
+	customer, err := creator.repository.FindOrInsertCustomer(ctx, model.Customer{TenantId: tenantId, Email: email})
+	if err != nil {
+		return model.Customer{}, fmt.Errorf("error finding or creating customer: %w", err)
 	}
-	return fmt.Errorf("error checking if customer with an email already exists: %w", err)
+	mdctx.Debugf(ctx, "Resolved customer %d", customer.Id)
+	return customer, nil
 }
@@ -0,0 +1,34 @@
+package finder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/GeneralKenobi/mailman/internal/api"
+	"github.com/GeneralKenobi/mailman/internal/db"
+	"github.com/GeneralKenobi/mailman/internal/db/model"
+)
+
+type Repository interface {
+	FindCustomerById(ctx context.Context, tenantId string, id int) (model.Customer, error)
+}
+
+func New(repository Repository) *Finder {
+	return &Finder{repository: repository}
+}
+
+type Finder struct {
+	repository Repository
+}
+
+// FindById returns the tenant's customer. Returns api.StatusNotFound error if the tenant doesn't have a customer with the ID.
+func (finder *Finder) FindById(ctx context.Context, tenantId string, id int) (model.Customer, error) {
+	customer, err := finder.repository.FindCustomerById(ctx, tenantId, id)
+	if err != nil {
+		if errors.Is(err, db.ErrNoRows) {
+			return model.Customer{}, api.StatusNotFound.WithMessageAndCause(err, "customer with ID %d doesn't exist", id)
+		}
+		return model.Customer{}, fmt.Errorf("error finding customer %d: %w", id, err)
+	}
+	return customer, nil
+}
@@ -0,0 +1,43 @@
+package remover
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/GeneralKenobi/mailman/internal/api"
+	"github.com/GeneralKenobi/mailman/internal/db"
+	"github.com/GeneralKenobi/mailman/internal/db/model"
+	"github.com/GeneralKenobi/mailman/pkg/mdctx"
+)
+
+type Repository interface {
+	FindMailingEntriesByCustomerId(ctx context.Context, tenantId string, id int) ([]model.MailingEntry, error)
+	DeleteCustomerById(ctx context.Context, tenantId string, id int) error
+}
+
+func New(repository Repository) *Remover {
+	return &Remover{repository: repository}
+}
+
+type Remover struct {
+	repository Repository
+}
+
+// Remove deletes the tenant's customer. Returns api.StatusNotFound error if the tenant doesn't have a customer with the ID and
+// api.StatusBadInput error if the customer still has mailing entries.
+func (remover *Remover) Remove(ctx context.Context, tenantId string, id int) error {
+	mailingEntries, err := remover.repository.FindMailingEntriesByCustomerId(ctx, tenantId, id)
+	if err != nil {
+		return fmt.Errorf("error finding mailing entries of customer %d: %w", id, err)
+	}
+	if len(mailingEntries) > 0 {
+		return api.StatusBadInput.WithMessage("customer with ID %d has %d mailing entries, they have to be deleted first", id, len(mailingEntries))
+	}
+
+	mdctx.Infof(ctx, "Deleting customer %d", id)
+	err = remover.repository.DeleteCustomerById(ctx, tenantId, id)
+	if err != nil && errors.Is(err, db.ErrNoRows) {
+		return api.StatusNotFound.WithMessageAndCause(err, "customer with ID %d doesn't exist", id)
+	}
+	return err
+}
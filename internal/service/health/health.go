@@ -0,0 +1,116 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"github.com/GeneralKenobi/mailman/internal/db"
+	"github.com/GeneralKenobi/mailman/internal/email"
+	"github.com/GeneralKenobi/mailman/pkg/mdctx"
+	"sync"
+	"time"
+)
+
+// Statuses of the application and its dependencies.
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
+// Check checks whether a dependency is available. It returns details describing the dependency's state, and an error if it's
+// unavailable.
+type Check func(ctx context.Context) (details map[string]any, err error)
+
+// Report is the result of checking all dependencies.
+type Report struct {
+	Status       string // StatusUp if all dependencies are up
+	Dependencies map[string]DependencyReport
+}
+
+type DependencyReport struct {
+	Status  string
+	Error   string
+	Details map[string]any
+}
+
+// NewChecker creates a checker of the dependencies that can be unavailable: the DB and its connection pool if dbCtx implements db.Pinger,
+// and the email transport if emailer implements email.Pinger. Each check is limited to timeout.
+func NewChecker(dbCtx db.Context, emailer email.Service, timeout time.Duration) *Checker {
+	checks := make(map[string]Check)
+	if pinger, ok := dbCtx.(db.Pinger); ok {
+		checks["db"] = dbCheck(pinger)
+		checks["dbPool"] = dbPoolCheck(pinger)
+	}
+	if pinger, ok := emailer.(email.Pinger); ok {
+		checks["email"] = emailCheck(pinger)
+	}
+	return &Checker{checks: checks, timeout: timeout}
+}
+
+type Checker struct {
+	checks  map[string]Check // Keyed by dependency name
+	timeout time.Duration
+}
+
+// Check runs all checks concurrently.
+func (checker *Checker) Check(ctx context.Context) Report {
+	ctx, cancel := context.WithTimeout(ctx, checker.timeout)
+	defer cancel()
+
+	report := Report{Status: StatusUp, Dependencies: make(map[string]DependencyReport, len(checker.checks))}
+	var mutex sync.Mutex
+	var waitGroup sync.WaitGroup
+	for name, check := range checker.checks {
+		waitGroup.Add(1)
+		go func(name string, check Check) {
+			defer waitGroup.Done()
+			details, err := check(ctx)
+			dependency := DependencyReport{Status: StatusUp, Details: details}
+			if err != nil {
+				mdctx.Warnf(ctx, "Dependency %s is down: %v", name, err)
+				dependency.Status = StatusDown
+				dependency.Error = err.Error()
+			}
+
+			mutex.Lock()
+			defer mutex.Unlock()
+			report.Dependencies[name] = dependency
+			if err != nil {
+				report.Status = StatusDown
+			}
+		}(name, check)
+	}
+	waitGroup.Wait()
+	return report
+}
+
+func dbCheck(pinger db.Pinger) Check {
+	return func(ctx context.Context) (map[string]any, error) {
+		return nil, pinger.Ping(ctx)
+	}
+}
+
+// dbPoolCheck reports the pool as down when all connections are in use, so that requests aren't routed to an instance where they would
+// wait for a connection.
+func dbPoolCheck(pinger db.Pinger) Check {
+	return func(_ context.Context) (map[string]any, error) {
+		stats := pinger.Stats()
+		details := map[string]any{
+			"open":               stats.OpenConnections,
+			"inUse":              stats.InUse,
+			"idle":               stats.Idle,
+			"maxOpen":            stats.MaxOpenConnections,
+			"waitCount":          stats.WaitCount,
+			"waitDurationMillis": stats.WaitDuration.Milliseconds(),
+		}
+		if stats.MaxOpenConnections > 0 && stats.InUse >= stats.MaxOpenConnections {
+			return details, fmt.Errorf("all %d connections are in use", stats.MaxOpenConnections)
+		}
+		return details, nil
+	}
+}
+
+func emailCheck(pinger email.Pinger) Check {
+	return func(ctx context.Context) (map[string]any, error) {
+		return nil, pinger.Ping(ctx)
+	}
+}
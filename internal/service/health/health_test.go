@@ -0,0 +1,118 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/GeneralKenobi/mailman/internal/db/memory"
+	"testing"
+	"time"
+)
+
+func TestChecker(t *testing.T) {
+	tests := map[string]struct {
+		dbCtx          *dbContextMock
+		emailer        *emailerMock
+		expectedStatus string
+		expectedDown   []string
+		expectedUp     []string
+	}{
+		"Should report up if all dependencies are up": {
+			dbCtx:          &dbContextMock{stats: sql.DBStats{MaxOpenConnections: 10, InUse: 9}},
+			emailer:        &emailerMock{},
+			expectedStatus: StatusUp,
+			expectedUp:     []string{"db", "dbPool", "email"},
+		},
+		"Should report down if the DB is unreachable": {
+			dbCtx:          &dbContextMock{pingErr: errors.New("connection refused")},
+			emailer:        &emailerMock{},
+			expectedStatus: StatusDown,
+			expectedDown:   []string{"db"},
+			expectedUp:     []string{"dbPool", "email"},
+		},
+		"Should report down if all DB connections are in use": {
+			dbCtx:          &dbContextMock{stats: sql.DBStats{MaxOpenConnections: 10, InUse: 10}},
+			emailer:        &emailerMock{},
+			expectedStatus: StatusDown,
+			expectedDown:   []string{"dbPool"},
+			expectedUp:     []string{"db", "email"},
+		},
+		"Should report down if the email transport is unreachable": {
+			dbCtx:          &dbContextMock{},
+			emailer:        &emailerMock{pingErr: errors.New("connection refused")},
+			expectedStatus: StatusDown,
+			expectedDown:   []string{"email"},
+			expectedUp:     []string{"db", "dbPool"},
+		},
+		"Should report down if a check times out": {
+			dbCtx:          &dbContextMock{pingBlocks: true},
+			emailer:        &emailerMock{},
+			expectedStatus: StatusDown,
+			expectedDown:   []string{"db"},
+			expectedUp:     []string{"dbPool", "email"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			checker := NewChecker(test.dbCtx, test.emailer, 50*time.Millisecond)
+
+			report := checker.Check(context.Background())
+
+			if report.Status != test.expectedStatus {
+				t.Errorf("Expected status %s but got %s", test.expectedStatus, report.Status)
+			}
+			for status, dependencies := range map[string][]string{StatusDown: test.expectedDown, StatusUp: test.expectedUp} {
+				for _, dependency := range dependencies {
+					if actual := report.Dependencies[dependency]; actual.Status != status {
+						t.Errorf("Expected %s to be %s but got %+v", dependency, status, actual)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestCheckerWithoutPingers(t *testing.T) {
+	checker := NewChecker(memory.New(), emailerWithoutPing{}, time.Second)
+
+	report := checker.Check(context.Background())
+
+	if report.Status != StatusUp || len(report.Dependencies) != 0 {
+		t.Errorf("Expected status up without dependencies but got %+v", report)
+	}
+}
+
+type dbContextMock struct {
+	*memory.Context
+	pingErr    error
+	pingBlocks bool // Ping blocks until the context is done
+	stats      sql.DBStats
+}
+
+func (mock *dbContextMock) Ping(ctx context.Context) error {
+	if mock.pingBlocks {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	return mock.pingErr
+}
+
+func (mock *dbContextMock) Stats() sql.DBStats {
+	return mock.stats
+}
+
+type emailerMock struct {
+	emailerWithoutPing
+	pingErr error
+}
+
+func (mock *emailerMock) Ping(_ context.Context) error {
+	return mock.pingErr
+}
+
+type emailerWithoutPing struct{}
+
+func (emailerWithoutPing) Send(_ context.Context, _, _, _ string) error {
+	return nil
+}
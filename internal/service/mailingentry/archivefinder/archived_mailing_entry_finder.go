@@ -0,0 +1,57 @@
+package archivefinder
+
+import (
+	"context"
+	"fmt"
+	"github.com/GeneralKenobi/mailman/internal/api"
+	"github.com/GeneralKenobi/mailman/internal/db/model"
+	"github.com/GeneralKenobi/mailman/pkg/mdctx"
+)
+
+type Repository interface {
+	FindArchivedMailingEntries(ctx context.Context, tenantId string, filter model.ArchivedMailingEntryFilter, limit int) ([]model.ArchivedMailingEntry, error)
+}
+
+func New(repository Repository) *Finder {
+	return &Finder{repository: repository}
+}
+
+type Finder struct {
+	repository Repository
+}
+
+const (
+	// defaultLimit is the number of archived entries returned by Find if the query doesn't set a limit.
+	defaultLimit = 100
+	// maxLimit is the maximum number of archived entries returned by Find.
+	maxLimit = 1000
+)
+
+// Query selects archived mailing entries to find. Limit is the maximum number of returned entries, defaultLimit if nil.
+type Query struct {
+	Filter model.ArchivedMailingEntryFilter
+	Limit  *int
+}
+
+// Find returns the tenant's archived mailing entries matching the query, most recently archived first. Returns api.StatusBadInput error
+// if the limit isn't between 1 and maxLimit or the archive time range is empty.
+func (finder *Finder) Find(ctx context.Context, tenantId string, query Query) ([]model.ArchivedMailingEntry, error) {
+	limit := defaultLimit
+	if query.Limit != nil {
+		limit = *query.Limit
+	}
+	if limit < 1 || limit > maxLimit {
+		return nil, api.StatusBadInput.WithMessage("limit has to be between 1 and %d", maxLimit)
+	}
+	filter := query.Filter
+	if filter.ArchivedAfter != nil && filter.ArchivedBefore != nil && !filter.ArchivedAfter.Before(*filter.ArchivedBefore) {
+		return nil, api.StatusBadInput.WithMessage("archived_after has to be before archived_before")
+	}
+
+	mdctx.Debugf(ctx, "Finding up to %d archived mailing entries", limit)
+	archivedEntries, err := finder.repository.FindArchivedMailingEntries(ctx, tenantId, filter, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error finding archived mailing entries: %w", err)
+	}
+	return archivedEntries, nil
+}
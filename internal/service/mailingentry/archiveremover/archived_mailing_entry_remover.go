@@ -0,0 +1,72 @@
+package archiveremover
+
+import (
+	"context"
+	"expvar"
+	"fmt"
+	"github.com/GeneralKenobi/mailman/internal/config"
+	"github.com/GeneralKenobi/mailman/internal/db"
+	"github.com/GeneralKenobi/mailman/pkg/mdctx"
+	"time"
+)
+
+// metrics count removed archived entries and the batches they were removed in. They're published with expvar.
+var metrics = expvar.NewMap("archivedMailingEntryRemover")
+
+func New(transactioner db.Transactioner) *ExpiredEntryRemover {
+	return &ExpiredEntryRemover{transactioner: transactioner}
+}
+
+// ExpiredEntryRemover removes archived entries after the archive retention period in batches, each in a separate transaction.
+type ExpiredEntryRemover struct {
+	transactioner db.Transactioner
+}
+
+// Remove removes the tenant's entries archived before the tenant's retention period. Nothing is removed if the retention period is 0.
+// Batches removed before an error stay removed.
+func (remover *ExpiredEntryRemover) Remove(ctx context.Context, tenantId string) error {
+	tenantRetention := retention(tenantId)
+	if tenantRetention <= 0 {
+		mdctx.Debugf(ctx, "Archived mailing entries are kept forever")
+		return nil
+	}
+
+	archivedBefore := now().UTC().Add(-tenantRetention)
+	limit := batchSize()
+	var removedCount int64
+	for {
+		count, err := db.InTransactionRetV(ctx, remover.transactioner, func(repository db.Repository) (int64, error) {
+			return repository.DeleteArchivedMailingEntryBatch(ctx, tenantId, archivedBefore, limit)
+		}, db.WithRetries(config.Get().Database.TransactionRetries))
+		if err != nil {
+			return fmt.Errorf("error removing mailing entries archived before %v: %w", archivedBefore, err)
+		}
+		removedCount += count
+		metrics.Add("removed", count)
+		metrics.Add("batches", 1)
+
+		if count < int64(limit) {
+			mdctx.Infof(ctx, "Removed %d mailing entries archived before %v", removedCount, archivedBefore)
+			return nil
+		}
+	}
+}
+
+// Hook for mocking in unit tests.
+// The tenant's retention period is used if it's configured, otherwise the global one.
+var retention = func(tenantId string) time.Duration {
+	cfg := config.Get()
+	retentionSeconds := cfg.MailingEntryArchive.RetentionSeconds
+	if tenantRetentionSeconds := cfg.Tenants[tenantId].ArchiveRetentionSeconds; tenantRetentionSeconds > 0 {
+		retentionSeconds = tenantRetentionSeconds
+	}
+	return time.Duration(retentionSeconds) * time.Second
+}
+
+// Hook for mocking in unit tests.
+var batchSize = func() int {
+	return config.Get().MailingEntryArchive.BatchSize
+}
+
+// Hook for mocking in unit tests.
+var now = time.Now
@@ -0,0 +1,124 @@
+package archiveremover
+
+import (
+	"context"
+	"expvar"
+	"fmt"
+	"github.com/GeneralKenobi/mailman/internal/db"
+	"github.com/GeneralKenobi/mailman/internal/db/memory"
+	"github.com/GeneralKenobi/mailman/internal/db/model"
+	"testing"
+	"time"
+)
+
+func TestRemove(t *testing.T) {
+	currentTime := time.Date(2022, 3, 30, 12, 0, 0, 0, time.UTC)
+
+	tests := map[string]struct {
+		retention         time.Duration
+		expectedRemaining int
+		expectedBatches   int64
+	}{
+		"Should remove expired archived entries in batches": {
+			retention:         time.Hour,
+			expectedRemaining: 1,
+			expectedBatches:   3,
+		},
+		"Should keep archived entries that didn't expire": {
+			retention:         3 * time.Hour,
+			expectedRemaining: 5,
+			expectedBatches:   1,
+		},
+		"Should keep archived entries forever without retention": {
+			retention:         0,
+			expectedRemaining: 5,
+			expectedBatches:   0,
+		},
+	}
+
+	originalNowHook := now
+	originalBatchSizeHook := batchSize
+	originalRetentionHook := retention
+	defer func() {
+		now = originalNowHook
+		batchSize = originalBatchSizeHook
+		retention = originalRetentionHook
+	}()
+	now = func() time.Time { return currentTime }
+	batchSize = func() int { return 2 }
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			dbCtx := memory.New()
+			prepareArchivedMailingEntries(t, ctx, dbCtx, currentTime)
+			retention = func(string) time.Duration { return test.retention }
+			batchesBefore := metricValue("batches")
+
+			err := New(dbCtx).Remove(ctx, "tenant-1")
+
+			if err != nil {
+				t.Fatalf("Expected no error but got %v", err)
+			}
+			if batches := metricValue("batches") - batchesBefore; batches != test.expectedBatches {
+				t.Errorf("Expected %d batches but got %d", test.expectedBatches, batches)
+			}
+			remaining, err := db.InTransactionRetV(ctx, dbCtx, func(repository db.Repository) ([]model.ArchivedMailingEntry, error) {
+				return repository.FindArchivedMailingEntries(ctx, "tenant-1", model.ArchivedMailingEntryFilter{}, 100)
+			})
+			if err != nil {
+				t.Fatalf("Error finding remaining archived entries: %v", err)
+			}
+			if len(remaining) != test.expectedRemaining {
+				t.Errorf("Expected %d remaining archived entries but got %d", test.expectedRemaining, len(remaining))
+			}
+		})
+	}
+}
+
+// prepareArchivedMailingEntries archives 4 entries of tenant-1 two hours ago and 1 now, and an entry of tenant-2 two hours ago.
+func prepareArchivedMailingEntries(t *testing.T, ctx context.Context, dbCtx db.Transactioner, currentTime time.Time) {
+	err := db.InTransaction(ctx, dbCtx, func(repository db.Repository) error {
+		entries := []struct {
+			tenantId    string
+			archiveTime time.Time
+		}{
+			{"tenant-1", currentTime.Add(-2 * time.Hour)},
+			{"tenant-1", currentTime.Add(-2 * time.Hour)},
+			{"tenant-1", currentTime.Add(-2 * time.Hour)},
+			{"tenant-1", currentTime.Add(-2 * time.Hour)},
+			{"tenant-1", currentTime},
+			{"tenant-2", currentTime.Add(-2 * time.Hour)},
+		}
+		for i, entry := range entries {
+			customer, err := repository.FindOrInsertCustomer(ctx, model.Customer{TenantId: entry.tenantId, Email: "jan.kowalski@example.com"})
+			if err != nil {
+				return err
+			}
+			mailingEntry, err := repository.InsertMailingEntry(ctx, model.MailingEntry{
+				TenantId:   entry.tenantId,
+				CustomerId: customer.Id,
+				MailingId:  7,
+				Title:      fmt.Sprintf("title %d", i),
+				Content:    "content",
+				InsertTime: currentTime.Add(-3 * time.Hour),
+			})
+			if err != nil {
+				return err
+			}
+			err = repository.ArchiveMailingEntryById(ctx, entry.tenantId, mailingEntry.Id, model.ArchiveReasonSent, entry.archiveTime)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Error preparing archived mailing entries: %v", err)
+	}
+}
+
+func metricValue(key string) int64 {
+	metrics.Add(key, 0) // Creates the counter if nothing was counted yet
+	return metrics.Get(key).(*expvar.Int).Value()
+}
@@ -5,22 +5,25 @@ import (
 	"errors"
 	"fmt"
 	"github.com/GeneralKenobi/mailman/internal/api"
+	"github.com/GeneralKenobi/mailman/internal/config"
 	"github.com/GeneralKenobi/mailman/internal/db"
 	"github.com/GeneralKenobi/mailman/internal/db/model"
 	"github.com/GeneralKenobi/mailman/pkg/api/apimodel"
 	"github.com/GeneralKenobi/mailman/pkg/mdctx"
-	"time"
 )
 
 type Repository interface {
-	FindCustomerByEmail(ctx context.Context, email string) (model.Customer, error)
-	FindMailingEntriesByCustomerIdMailingIdTitleContentInsertTime(
-		ctx context.Context, customerId, mailingId int, title, content string, insertTime time.Time) ([]model.MailingEntry, error)
 	InsertMailingEntry(ctx context.Context, mailingEntry model.MailingEntry) (model.MailingEntry, error)
 }
 
 type CustomerCreator interface {
-	CreateFromEmail(ctx context.Context, email string) (model.Customer, error)
+	GetOrCreateFromEmail(ctx context.Context, tenantId, email string) (model.Customer, error)
+}
+
+// TransactionOptions are options of transactions creating mailing entries. Uniqueness of entries and customers is enforced by the DB, so
+// the default isolation level is enough, but concurrent batches inserting the same customers can deadlock and are retried.
+func TransactionOptions() []db.TransactionOption {
+	return []db.TransactionOption{db.WithRetries(config.Get().Database.TransactionRetries)}
 }
 
 func New(repository Repository, customerCreator CustomerCreator) *Creator {
@@ -35,15 +38,16 @@ type Creator struct {
 	customerCreator CustomerCreator
 }
 
-// CreateFromDto creates a new mailing entry. It finds or creates a new user based on the email in the DTO.
+// CreateFromDto creates a new mailing entry of the tenant. It finds or creates a new user of the tenant based on the email in the DTO.
 // This operation is idempotent - same mailing entry can't be created twice. In that case api.StatusBadInput is returned.
-func (creator *Creator) CreateFromDto(ctx context.Context, mailingEntryDto apimodel.MailingEntry) (model.MailingEntry, error) {
-	customer, err := creator.getOrCreateCustomer(ctx, mailingEntryDto.Email)
+func (creator *Creator) CreateFromDto(ctx context.Context, tenantId string, mailingEntryDto apimodel.MailingEntry) (model.MailingEntry, error) {
+	customer, err := creator.GetOrCreateCustomer(ctx, tenantId, mailingEntryDto.Email)
 	if err != nil {
 		return model.MailingEntry{}, fmt.Errorf("error resolving customer for new mailing entry: %w", err)
 	}
 
 	mailingEntry := model.MailingEntry{
+		TenantId:   tenantId,
 		CustomerId: customer.Id,
 		MailingId:  mailingEntryDto.MailingId,
 		Title:      mailingEntryDto.Title,
@@ -51,53 +55,45 @@ func (creator *Creator) CreateFromDto(ctx context.Context, mailingEntryDto apimo
 		InsertTime: mailingEntryDto.InsertTime,
 	}
 
-	if err = creator.assertMailingEntryDoesNotExist(ctx, mailingEntry); err != nil {
-		return model.MailingEntry{}, err
-	}
-
 	return creator.Create(ctx, mailingEntry)
 }
 
-func (creator *Creator) getOrCreateCustomer(ctx context.Context, email string) (customer model.Customer, err error) {
-	customer, err = creator.repository.FindCustomerByEmail(ctx, email)
-	if err == nil {
-		mdctx.Debugf(ctx, "Customer already exists (ID %d)", customer.Id)
-		return customer, nil
-	}
-	if !errors.Is(err, db.ErrNoRows) {
-		return model.Customer{}, fmt.Errorf("error finding customer by email: %w", err)
-	}
-
-	mdctx.Debugf(ctx, "Customer doesn't exist - creating")
-	customer, err = creator.customerCreator.CreateFromEmail(ctx, email)
-	if err != nil {
-		return model.Customer{}, fmt.Errorf("error creating customer for new mailing entry: %w", err)
+// CreateBatchFromDtos creates new mailing entries of the tenant like CreateFromDto. It stops at the first entry that can't be created and
+// returns its error, so it has to be called within a transaction that is rolled back on error.
+func (creator *Creator) CreateBatchFromDtos(ctx context.Context, tenantId string, mailingEntryDtos []apimodel.MailingEntry) ([]model.MailingEntry, error) {
+	mailingEntries := make([]model.MailingEntry, len(mailingEntryDtos))
+	for i, mailingEntryDto := range mailingEntryDtos {
+		mailingEntry, err := creator.CreateFromDto(ctx, tenantId, mailingEntryDto)
+		if err != nil {
+			var statusErr api.StatusError
+			if errors.As(err, &statusErr) {
+				return nil, statusErr.Status().WithMessageAndCause(err, "entries[%d]: %s", i, statusErr.Message())
+			}
+			return nil, fmt.Errorf("error creating mailing entry %d of the batch: %w", i, err)
+		}
+		mailingEntries[i] = mailingEntry
 	}
-	return customer, nil
+	return mailingEntries, nil
 }
 
-func (creator *Creator) assertMailingEntryDoesNotExist(ctx context.Context, mailingEntry model.MailingEntry) error {
-	foundEntries, err := creator.repository.FindMailingEntriesByCustomerIdMailingIdTitleContentInsertTime(
-		ctx, mailingEntry.CustomerId, mailingEntry.MailingId, mailingEntry.Title, mailingEntry.Content, mailingEntry.InsertTime)
-	if err != nil {
-		return fmt.Errorf("error checking if mailing entry already exists: %w", err)
-	}
-
-	if len(foundEntries) == 0 {
-		mdctx.Debugf(ctx, "Mailing entry doesn't exist")
-		return nil
-	}
-
-	// There shouldn't be more than 1 already existing mailing entry, so logging the first one's ID should be enough information.
-	mdctx.Debugf(ctx, "Found %d existing mailing entries, the first one is %d", len(foundEntries), foundEntries[0].Id)
-	return api.StatusBadInput.WithMessage("this mailing entry already exists")
+// GetOrCreateCustomer finds the tenant's customer with the email or creates a new one if it doesn't exist.
+func (creator *Creator) GetOrCreateCustomer(ctx context.Context, tenantId, email string) (model.Customer, error) {
+	return creator.customerCreator.GetOrCreateFromEmail(ctx, tenantId, email)
 }
 
+// Create saves the mailing entry. Returns api.StatusBadInput error if an equal entry already exists or a field is too long.
 func (creator *Creator) Create(ctx context.Context, mailingEntry model.MailingEntry) (model.MailingEntry, error) {
 	mdctx.Debugf(ctx, "Creating mailing entry with mailing ID %d and insert time %v for customer for customer %d",
 		mailingEntry.MailingId, mailingEntry.InsertTime, mailingEntry.CustomerId)
 
 	mailingEntry, err := creator.repository.InsertMailingEntry(ctx, mailingEntry)
+	if errors.Is(err, db.ErrDuplicate) {
+		mdctx.Debugf(ctx, "Mailing entry already exists")
+		return model.MailingEntry{}, api.StatusBadInput.WithMessageAndCause(err, "this mailing entry already exists")
+	}
+	if errors.Is(err, db.ErrValueTooLong) {
+		return model.MailingEntry{}, api.StatusBadInput.WithMessageAndCause(err, "a field of the mailing entry is too long")
+	}
 	if err != nil {
 		return model.MailingEntry{}, fmt.Errorf("error creating mailing entry: %w", err)
 	}
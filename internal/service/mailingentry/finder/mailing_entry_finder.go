@@ -0,0 +1,99 @@
+package finder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/GeneralKenobi/mailman/internal/api"
+	"github.com/GeneralKenobi/mailman/internal/db"
+	"github.com/GeneralKenobi/mailman/internal/db/model"
+	"github.com/GeneralKenobi/mailman/pkg/api/apimodel"
+	"github.com/GeneralKenobi/mailman/pkg/mdctx"
+)
+
+type Repository interface {
+	FindMailingEntryById(ctx context.Context, tenantId string, id int) (model.MailingEntry, error)
+	FindMailingEntriesByMailingId(ctx context.Context, tenantId string, mailingId int) ([]model.MailingEntry, error)
+	FindMailingEntriesByCustomerId(ctx context.Context, tenantId string, id int) ([]model.MailingEntry, error)
+	CountMailingEntries(ctx context.Context, tenantId string, filter model.MailingEntryFilter) (int, error)
+}
+
+func New(repository Repository) *Finder {
+	return &Finder{repository: repository}
+}
+
+type Finder struct {
+	repository Repository
+}
+
+// Query selects mailing entries to find. Exactly one of the IDs has to be set.
+type Query struct {
+	MailingId  *int
+	CustomerId *int
+}
+
+// FindById returns the tenant's mailing entry. Returns api.StatusNotFound error if the tenant doesn't have a mailing entry with the ID.
+func (finder *Finder) FindById(ctx context.Context, tenantId string, id int) (model.MailingEntry, error) {
+	mdctx.Debugf(ctx, "Finding mailing entry %d", id)
+	mailingEntry, err := finder.repository.FindMailingEntryById(ctx, tenantId, id)
+	if err != nil {
+		if errors.Is(err, db.ErrNoRows) {
+			return model.MailingEntry{}, api.StatusNotFound.WithMessageAndCause(err, "mailing entry with ID %d doesn't exist", id)
+		}
+		return model.MailingEntry{}, fmt.Errorf("error finding mailing entry %d: %w", id, err)
+	}
+	return mailingEntry, nil
+}
+
+// Find returns the tenant's mailing entries matching the query. Returns api.StatusBadInput error if the query doesn't set exactly one ID.
+func (finder *Finder) Find(ctx context.Context, tenantId string, query Query) ([]model.MailingEntry, error) {
+	switch {
+	case query.MailingId != nil && query.CustomerId == nil:
+		mdctx.Debugf(ctx, "Finding mailing entries with mailing ID %d", *query.MailingId)
+		mailingEntries, err := finder.repository.FindMailingEntriesByMailingId(ctx, tenantId, *query.MailingId)
+		if err != nil {
+			return nil, fmt.Errorf("error finding mailing entries with mailing ID %d: %w", *query.MailingId, err)
+		}
+		return mailingEntries, nil
+	case query.CustomerId != nil && query.MailingId == nil:
+		mdctx.Debugf(ctx, "Finding mailing entries of customer %d", *query.CustomerId)
+		mailingEntries, err := finder.repository.FindMailingEntriesByCustomerId(ctx, tenantId, *query.CustomerId)
+		if err != nil {
+			return nil, fmt.Errorf("error finding mailing entries of customer %d: %w", *query.CustomerId, err)
+		}
+		return mailingEntries, nil
+	default:
+		return nil, api.StatusBadInput.WithMessage("exactly one of mailing_id and customer_id has to be given")
+	}
+}
+
+// CountByMailingId returns the number of the tenant's mailing entries with the mailing ID.
+func (finder *Finder) CountByMailingId(ctx context.Context, tenantId string, mailingId int) (int, error) {
+	count, err := finder.repository.CountMailingEntries(ctx, tenantId, model.MailingEntryFilter{MailingId: &mailingId})
+	if err != nil {
+		return 0, fmt.Errorf("error counting mailing entries with mailing ID %d: %w", mailingId, err)
+	}
+	return count, nil
+}
+
+// SummarizeMailing counts the tenant's mailing entries with the mailing ID. Returns api.StatusNotFound error if there are none.
+func (finder *Finder) SummarizeMailing(ctx context.Context, tenantId string, mailingId int) (apimodel.Mailing, error) {
+	mailingEntries, err := finder.repository.FindMailingEntriesByMailingId(ctx, tenantId, mailingId)
+	if err != nil {
+		return apimodel.Mailing{}, fmt.Errorf("error finding mailing entries with mailing ID %d: %w", mailingId, err)
+	}
+	if len(mailingEntries) == 0 {
+		return apimodel.Mailing{}, api.StatusNotFound.WithMessage("mailing with ID %d doesn't have any entries", mailingId)
+	}
+
+	customerIds := make(map[int]bool)
+	for _, mailingEntry := range mailingEntries {
+		customerIds[mailingEntry.CustomerId] = true
+	}
+	mailing := apimodel.Mailing{
+		Id:            mailingId,
+		EntryCount:    len(mailingEntries),
+		CustomerCount: len(customerIds),
+	}
+	return mailing, nil
+}
@@ -0,0 +1,84 @@
+package mailing
+
+import (
+	"context"
+	"fmt"
+	"github.com/GeneralKenobi/mailman/internal/db"
+	"github.com/GeneralKenobi/mailman/internal/db/model"
+	customercreator "github.com/GeneralKenobi/mailman/internal/service/customer/creator"
+	mailingentrycreator "github.com/GeneralKenobi/mailman/internal/service/mailingentry/creator"
+	"github.com/GeneralKenobi/mailman/internal/service/mailingentry/sender"
+	"github.com/GeneralKenobi/mailman/internal/service/mailingentry/staleremover"
+	"github.com/GeneralKenobi/mailman/internal/service/quota"
+	"github.com/GeneralKenobi/mailman/internal/service/webhook/publisher"
+	"github.com/GeneralKenobi/mailman/pkg/api/apimodel"
+	"github.com/GeneralKenobi/mailman/pkg/mdctx"
+)
+
+// New creates a service of mailings. Progress of sending mailing entries is published with progressPublisher, e.g. a progress.Bus.
+func New(transactioner db.Transactioner, emailer sender.Emailer, progressPublisher sender.EventPublisher) *Service {
+	return &Service{
+		transactioner:     transactioner,
+		emailer:           emailer,
+		progressPublisher: progressPublisher,
+	}
+}
+
+// Service creates and sends mailing entries in transactions of its own. It's shared by the HTTP and gRPC APIs.
+type Service struct {
+	transactioner     db.Transactioner
+	emailer           sender.Emailer
+	progressPublisher sender.EventPublisher
+}
+
+// Create creates a mailing entry of the tenant, and its recipient if it doesn't exist yet.
+func (service *Service) Create(ctx context.Context, tenantId string, mailingEntryDto apimodel.MailingEntry) (model.MailingEntry, error) {
+	return db.InTransactionRetV(ctx, service.transactioner, func(repository db.Repository) (model.MailingEntry, error) {
+		customerCreator := customercreator.New(repository)
+		mailingEntryCreator := mailingentrycreator.New(repository, customerCreator)
+
+		mailingEntry, err := mailingEntryCreator.CreateFromDto(ctx, tenantId, mailingEntryDto)
+		if err != nil {
+			return model.MailingEntry{}, fmt.Errorf("error creating mailing entry: %w", err)
+		}
+		return mailingEntry, nil
+	}, mailingentrycreator.TransactionOptions()...)
+}
+
+// CreateBatch creates mailing entries of the tenant in one transaction, either all of them or none.
+func (service *Service) CreateBatch(ctx context.Context, tenantId string, mailingEntryDtos []apimodel.MailingEntry) ([]model.MailingEntry, error) {
+	return db.InTransactionRetV(ctx, service.transactioner, func(repository db.Repository) ([]model.MailingEntry, error) {
+		customerCreator := customercreator.New(repository)
+		mailingEntryCreator := mailingentrycreator.New(repository, customerCreator)
+
+		mailingEntries, err := mailingEntryCreator.CreateBatchFromDtos(ctx, tenantId, mailingEntryDtos)
+		if err != nil {
+			return nil, fmt.Errorf("error creating mailing entries: %w", err)
+		}
+		return mailingEntries, nil
+	}, mailingentrycreator.TransactionOptions()...)
+}
+
+// Send removes the tenant's stale entries with the request's mailing ID and sends the remaining ones. Returns an error if any entry
+// couldn't be sent, see sender.Summary.Err.
+func (service *Service) Send(ctx context.Context, tenantId string, mailingRequest apimodel.MailingRequest) error {
+	mdctx.Debugf(ctx, "Sending mailing entries with mailing ID %d", mailingRequest.MailingId)
+
+	// Stale entry cleanup commits its own transactions, removed entries stay removed even if sending fails later on.
+	staleEntryRemover := staleremover.New(service.transactioner)
+	err := staleEntryRemover.RemoveByMailingId(ctx, tenantId, mailingRequest.MailingId)
+	if err != nil {
+		return fmt.Errorf("can't proceed with sending mailing entries with ID %d - error cleaning up stale entries: %w",
+			mailingRequest.MailingId, err)
+	}
+
+	summary, err := db.InTransactionRetV(ctx, service.transactioner, func(repository db.Repository) (sender.Summary, error) {
+		eventPublishers := sender.EventPublishers{publisher.New(repository), service.progressPublisher}
+		mailer := sender.New(repository, service.emailer, quota.New(repository), eventPublishers)
+		return mailer.SendMailingRequest(ctx, tenantId, mailingRequest)
+	})
+	if err != nil {
+		return fmt.Errorf("error sending mailing entries with mailing ID %d: %w", mailingRequest.MailingId, err)
+	}
+	return summary.Err()
+}
@@ -0,0 +1,145 @@
+package partitionmanager
+
+import (
+	"context"
+	"expvar"
+	"fmt"
+	"github.com/GeneralKenobi/mailman/internal/config"
+	"github.com/GeneralKenobi/mailman/internal/db"
+	"github.com/GeneralKenobi/mailman/internal/db/model"
+	"github.com/GeneralKenobi/mailman/pkg/mdctx"
+	"time"
+)
+
+// metrics count created and dropped partitions and the entries archived from dropped partitions. They're published with expvar.
+var metrics = expvar.NewMap("mailingEntryPartitions")
+
+const (
+	intervalDaily  = "daily"
+	intervalWeekly = "weekly"
+)
+
+func New(partitioner db.Partitioner) *Manager {
+	return &Manager{partitioner: partitioner}
+}
+
+// Manager creates partitions of mailing entries ahead of time and drops partitions whose entries are all stale.
+type Manager struct {
+	partitioner db.Partitioner
+}
+
+// CreateUpcoming creates partitions for the current interval and the following ones, up to the configured number of partitions. Intervals
+// overlapping existing partitions, e.g. after changing the interval, are skipped, their entries are stored in the default partition.
+func (manager *Manager) CreateUpcoming(ctx context.Context) error {
+	partitions, err := manager.partitioner.FindMailingEntryPartitions(ctx)
+	if err != nil {
+		return fmt.Errorf("error finding mailing entry partitions: %w", err)
+	}
+
+	start, err := intervalStart(now().UTC(), interval())
+	if err != nil {
+		return err
+	}
+	for i := 0; i < precreatedCount(); i++ {
+		end := nextIntervalStart(start, interval())
+		if !overlapsAny(partitions, start, end) {
+			if err = manager.partitioner.CreateMailingEntryPartition(ctx, start, end); err != nil {
+				return fmt.Errorf("error creating mailing entry partition for %v - %v: %w", start, end, err)
+			}
+			mdctx.Infof(ctx, "Created mailing entry partition for %v - %v", start, end)
+			metrics.Add("created", 1)
+		}
+		start = end
+	}
+	return nil
+}
+
+// DropExpired archives and drops partitions whose entries are stale for every tenant, which is cheaper than archiving the entries with the
+// stale remover because the partition's table and indexes are dropped at once. Returns the number of archived entries.
+func (manager *Manager) DropExpired(ctx context.Context) (int64, error) {
+	partitions, err := manager.partitioner.FindMailingEntryPartitions(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("error finding mailing entry partitions: %w", err)
+	}
+
+	currentTime := now().UTC()
+	staleBefore := currentTime.Add(-maxStalenessThreshold())
+	var archivedCount int64
+	for _, partition := range partitions {
+		if partition.End.After(staleBefore) {
+			// Partitions are ordered by start, the following ones aren't expired either.
+			break
+		}
+		count, err := manager.partitioner.DropMailingEntryPartition(ctx, partition, model.ArchiveReasonStale, currentTime, batchSize())
+		if err != nil {
+			return archivedCount, fmt.Errorf("error dropping mailing entry partition %s: %w", partition.Name, err)
+		}
+		mdctx.Infof(ctx, "Dropped mailing entry partition %s after archiving its %d entries", partition.Name, count)
+		archivedCount += count
+		metrics.Add("dropped", 1)
+		metrics.Add("archived", count)
+	}
+	return archivedCount, nil
+}
+
+// intervalStart returns the start of the interval containing the time, which has to be in UTC.
+func intervalStart(t time.Time, interval string) (time.Time, error) {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	switch interval {
+	case intervalDaily:
+		return day, nil
+	case intervalWeekly:
+		daysSinceMonday := (int(day.Weekday()) + 6) % 7
+		return day.AddDate(0, 0, -daysSinceMonday), nil
+	default:
+		return time.Time{}, fmt.Errorf("unsupported partitioning interval %q", interval)
+	}
+}
+
+func nextIntervalStart(start time.Time, interval string) time.Time {
+	if interval == intervalWeekly {
+		return start.AddDate(0, 0, 7)
+	}
+	return start.AddDate(0, 0, 1)
+}
+
+func overlapsAny(partitions []model.MailingEntryPartition, start, end time.Time) bool {
+	for _, partition := range partitions {
+		if partition.Start.Before(end) && start.Before(partition.End) {
+			return true
+		}
+	}
+	return false
+}
+
+// Hook for mocking in unit tests.
+var interval = func() string {
+	return config.Get().MailingEntryPartitioning.Interval
+}
+
+// Hook for mocking in unit tests.
+var precreatedCount = func() int {
+	return config.Get().MailingEntryPartitioning.PrecreatedCount
+}
+
+// Hook for mocking in unit tests.
+// Partitions are shared by all tenants, so the longest of the global threshold and thresholds of tenants applies.
+var maxStalenessThreshold = func() time.Duration {
+	cfg := config.Get()
+	thresholdSeconds := cfg.StaleMailingEntryRemover.StalenessThresholdSeconds
+	for _, tenant := range cfg.Tenants {
+		if tenant.StalenessThresholdSeconds > thresholdSeconds {
+			thresholdSeconds = tenant.StalenessThresholdSeconds
+		}
+	}
+	return time.Duration(thresholdSeconds) * time.Second
+}
+
+// Hook for mocking in unit tests.
+// Entries of expired partitions are archived in batches of the stale entry remover's size.
+var batchSize = func() int {
+	return config.Get().StaleMailingEntryRemover.BatchSize
+}
+
+// Hook for mocking in unit tests.
+var now = time.Now
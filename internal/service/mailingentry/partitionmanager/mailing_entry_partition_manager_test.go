@@ -0,0 +1,157 @@
+package partitionmanager
+
+import (
+	"context"
+	"github.com/GeneralKenobi/mailman/internal/db/model"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCreateUpcoming(t *testing.T) {
+	// Wednesday
+	currentTime := time.Date(2022, 3, 30, 12, 0, 0, 0, time.UTC)
+	day := func(offset int) time.Time {
+		return time.Date(2022, 3, 30+offset, 0, 0, 0, 0, time.UTC)
+	}
+
+	tests := map[string]struct {
+		interval        string
+		existing        []model.MailingEntryPartition
+		expectedCreated [][2]time.Time
+		expectedErr     bool
+	}{
+		"Should create daily partitions starting with the current day": {
+			interval:        intervalDaily,
+			expectedCreated: [][2]time.Time{{day(0), day(1)}, {day(1), day(2)}, {day(2), day(3)}},
+		},
+		"Should create weekly partitions starting on Monday": {
+			interval:        intervalWeekly,
+			expectedCreated: [][2]time.Time{{day(-2), day(5)}, {day(5), day(12)}, {day(12), day(19)}},
+		},
+		"Should skip existing partitions": {
+			interval:        intervalDaily,
+			existing:        []model.MailingEntryPartition{{Name: "mailing_entry_p20220330", Start: day(0), End: day(1)}},
+			expectedCreated: [][2]time.Time{{day(1), day(2)}, {day(2), day(3)}},
+		},
+		"Should skip intervals overlapping partitions of another interval": {
+			interval:        intervalDaily,
+			existing:        []model.MailingEntryPartition{{Name: "mailing_entry_p20220328", Start: day(-2), End: day(5)}},
+			expectedCreated: nil,
+		},
+		"Should reject an unsupported interval": {
+			interval:    "hourly",
+			expectedErr: true,
+		},
+	}
+
+	originalNowHook := now
+	originalPrecreatedCountHook := precreatedCount
+	originalIntervalHook := interval
+	defer func() {
+		now = originalNowHook
+		precreatedCount = originalPrecreatedCountHook
+		interval = originalIntervalHook
+	}()
+	now = func() time.Time { return currentTime }
+	precreatedCount = func() int { return 3 }
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			interval = func() string { return test.interval }
+			partitioner := &partitionerMock{partitions: test.existing}
+
+			err := New(partitioner).CreateUpcoming(context.Background())
+
+			if (err != nil) != test.expectedErr {
+				t.Fatalf("Expected error %v but got %v", test.expectedErr, err)
+			}
+			if !reflect.DeepEqual(partitioner.created, test.expectedCreated) {
+				t.Errorf("Expected created partitions %v but got %v", test.expectedCreated, partitioner.created)
+			}
+		})
+	}
+}
+
+func TestDropExpired(t *testing.T) {
+	currentTime := time.Date(2022, 3, 30, 12, 0, 0, 0, time.UTC)
+	var partitions []model.MailingEntryPartition
+	for offset := -3; offset <= 1; offset++ {
+		start := time.Date(2022, 3, 30+offset, 0, 0, 0, 0, time.UTC)
+		partitions = append(partitions, model.MailingEntryPartition{
+			Name:  "mailing_entry_p" + start.Format("20060102"),
+			Start: start,
+			End:   start.AddDate(0, 0, 1),
+		})
+	}
+
+	tests := map[string]struct {
+		threshold       time.Duration
+		expectedDropped []string
+	}{
+		"Should drop partitions whose entries are all stale": {
+			threshold:       time.Hour,
+			expectedDropped: []string{"mailing_entry_p20220327", "mailing_entry_p20220328", "mailing_entry_p20220329"},
+		},
+		"Should keep partitions with entries that aren't stale for some tenant": {
+			threshold:       30 * time.Hour,
+			expectedDropped: []string{"mailing_entry_p20220327", "mailing_entry_p20220328"},
+		},
+	}
+
+	originalNowHook := now
+	originalMaxStalenessThresholdHook := maxStalenessThreshold
+	originalBatchSizeHook := batchSize
+	defer func() {
+		now = originalNowHook
+		maxStalenessThreshold = originalMaxStalenessThresholdHook
+		batchSize = originalBatchSizeHook
+	}()
+	now = func() time.Time { return currentTime }
+	batchSize = func() int { return 100 }
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			maxStalenessThreshold = func() time.Duration { return test.threshold }
+			partitioner := &partitionerMock{partitions: partitions}
+
+			archivedCount, err := New(partitioner).DropExpired(context.Background())
+
+			if err != nil {
+				t.Fatalf("Expected no error but got %v", err)
+			}
+			if !reflect.DeepEqual(partitioner.dropped, test.expectedDropped) {
+				t.Errorf("Expected dropped partitions %v but got %v", test.expectedDropped, partitioner.dropped)
+			}
+			if expected := int64(10 * len(test.expectedDropped)); archivedCount != expected {
+				t.Errorf("Expected %d archived entries but got %d", expected, archivedCount)
+			}
+		})
+	}
+}
+
+// partitionerMock records created and dropped partitions. Every dropped partition has 10 entries.
+type partitionerMock struct {
+	partitions []model.MailingEntryPartition
+	created    [][2]time.Time
+	dropped    []string
+}
+
+func (partitioner *partitionerMock) FindMailingEntryPartitions(context.Context) ([]model.MailingEntryPartition, error) {
+	return partitioner.partitions, nil
+}
+
+func (partitioner *partitionerMock) CreateMailingEntryPartition(_ context.Context, start, end time.Time) error {
+	partitioner.created = append(partitioner.created, [2]time.Time{start, end})
+	return nil
+}
+
+func (partitioner *partitionerMock) DropMailingEntryPartition(_ context.Context, partition model.MailingEntryPartition,
+	reason model.ArchiveReason, _ time.Time, batchSize int) (int64, error) {
+
+	if reason != model.ArchiveReasonStale || batchSize != 100 {
+		return 0, nil
+	}
+	partitioner.dropped = append(partitioner.dropped, partition.Name)
+	return 10, nil
+}
@@ -0,0 +1,52 @@
+package progress
+
+import (
+	"context"
+	"github.com/GeneralKenobi/mailman/pkg/api/apimodel"
+	"github.com/GeneralKenobi/mailman/pkg/eventbus"
+	"github.com/GeneralKenobi/mailman/pkg/mdctx"
+)
+
+// Event reports progress of sending mailing entries with a mailing ID.
+type Event struct {
+	TenantId  string
+	MailingId int
+	Type      string // One of apimodel.WebhookEventTypes
+	Data      any    // apimodel.WebhookMailingEntryEventData or apimodel.WebhookMailingCompletedEventData, depending on Type
+}
+
+// bufferSize is the number of events buffered for a subscriber. It has to fit events of a mailing sent while the subscriber is busy.
+const bufferSize = 1024
+
+func NewBus() *Bus {
+	return &Bus{bus: eventbus.New[Event](bufferSize)}
+}
+
+// Bus distributes progress of sending mailings within the process. Events are published as entries are sent, before the sending
+// transaction is committed, so they're meant for live progress reporting and not for keeping track of the mailing's state.
+type Bus struct {
+	bus *eventbus.Bus[Event]
+}
+
+// Publish publishes an event of the sender. It never fails - the error is only returned to implement the sender's interface.
+func (progressBus *Bus) Publish(ctx context.Context, tenantId, eventType string, data any) error {
+	event := Event{TenantId: tenantId, Type: eventType, Data: data}
+	switch data := data.(type) {
+	case apimodel.WebhookMailingEntryEventData:
+		event.MailingId = data.MailingId
+	case apimodel.WebhookMailingCompletedEventData:
+		event.MailingId = data.MailingId
+	default:
+		mdctx.Warnf(ctx, "Not publishing progress of unknown %s event data %T", eventType, data)
+		return nil
+	}
+	progressBus.bus.Publish(event)
+	return nil
+}
+
+// Subscribe creates a subscription to progress of the tenant's mailing. It has to be closed when it's no longer needed.
+func (progressBus *Bus) Subscribe(tenantId string, mailingId int) *eventbus.Subscription[Event] {
+	return progressBus.bus.Subscribe(func(event Event) bool {
+		return event.TenantId == tenantId && event.MailingId == mailingId
+	})
+}
@@ -0,0 +1,57 @@
+package purger
+
+import (
+	"context"
+	"fmt"
+	"github.com/GeneralKenobi/mailman/internal/api"
+	"github.com/GeneralKenobi/mailman/internal/db/model"
+	"github.com/GeneralKenobi/mailman/pkg/mdctx"
+)
+
+type Repository interface {
+	CountMailingEntries(ctx context.Context, tenantId string, filter model.MailingEntryFilter) (int, error)
+	DeleteMailingEntries(ctx context.Context, tenantId string, filter model.MailingEntryFilter) (int64, error)
+}
+
+func New(repository Repository) *Purger {
+	return &Purger{repository: repository}
+}
+
+type Purger struct {
+	repository Repository
+}
+
+// Purge deletes the tenant's mailing entries matching the filter at once and returns how many were deleted. In a dry run nothing is
+// deleted and the number of entries that would be deleted is returned instead. Returns api.StatusBadInput error if the filter doesn't
+// restrict the selection or its insert time range is empty, so that all entries of the tenant can't be purged by mistake.
+func (purger *Purger) Purge(ctx context.Context, tenantId string, filter model.MailingEntryFilter, dryRun bool) (int, error) {
+	if err := validateFilter(filter); err != nil {
+		return 0, err
+	}
+
+	if dryRun {
+		count, err := purger.repository.CountMailingEntries(ctx, tenantId, filter)
+		if err != nil {
+			return 0, fmt.Errorf("error counting mailing entries to purge: %w", err)
+		}
+		mdctx.Infof(ctx, "Dry run - %d mailing entries would be purged", count)
+		return count, nil
+	}
+
+	count, err := purger.repository.DeleteMailingEntries(ctx, tenantId, filter)
+	if err != nil {
+		return 0, fmt.Errorf("error purging mailing entries: %w", err)
+	}
+	mdctx.Infof(ctx, "Purged %d mailing entries", count)
+	return int(count), nil
+}
+
+func validateFilter(filter model.MailingEntryFilter) error {
+	if filter == (model.MailingEntryFilter{}) {
+		return api.StatusBadInput.WithMessage("at least one of mailing_id, customer_id, inserted_after and inserted_before has to be given")
+	}
+	if filter.InsertedAfter != nil && filter.InsertedBefore != nil && !filter.InsertedAfter.Before(*filter.InsertedBefore) {
+		return api.StatusBadInput.WithMessage("inserted_after has to be before inserted_before")
+	}
+	return nil
+}
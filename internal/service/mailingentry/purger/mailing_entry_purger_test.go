@@ -0,0 +1,143 @@
+package purger
+
+import (
+	"context"
+	"errors"
+	"github.com/GeneralKenobi/mailman/internal/api"
+	"github.com/GeneralKenobi/mailman/internal/db"
+	"github.com/GeneralKenobi/mailman/internal/db/memory"
+	"github.com/GeneralKenobi/mailman/internal/db/model"
+	"testing"
+	"time"
+)
+
+func TestPurge(t *testing.T) {
+	startTime := time.Date(2022, 3, 30, 12, 0, 0, 0, time.UTC)
+	mailingId := 7
+	otherMailingId := 8
+	customerId := 1
+	hourLater := startTime.Add(time.Hour)
+
+	tests := map[string]struct {
+		filter            model.MailingEntryFilter
+		dryRun            bool
+		expectedCount     int
+		expectedRemaining int
+		expectedStatus    api.Status
+	}{
+		"Should delete all entries of a mailing": {
+			filter:            model.MailingEntryFilter{MailingId: &mailingId},
+			expectedCount:     3,
+			expectedRemaining: 2,
+		},
+		"Should only count entries in a dry run": {
+			filter:            model.MailingEntryFilter{MailingId: &mailingId},
+			dryRun:            true,
+			expectedCount:     3,
+			expectedRemaining: 5,
+		},
+		"Should delete entries of a customer inserted in a time range": {
+			filter:            model.MailingEntryFilter{CustomerId: &customerId, InsertedAfter: &startTime, InsertedBefore: &hourLater},
+			expectedCount:     2,
+			expectedRemaining: 3,
+		},
+		"Should delete nothing if no entry matches": {
+			filter:            model.MailingEntryFilter{MailingId: &otherMailingId, InsertedBefore: &startTime},
+			expectedCount:     0,
+			expectedRemaining: 5,
+		},
+		"Should reject an empty filter": {
+			filter:            model.MailingEntryFilter{},
+			expectedStatus:    api.StatusBadInput,
+			expectedRemaining: 5,
+		},
+		"Should reject an empty time range": {
+			filter:            model.MailingEntryFilter{InsertedAfter: &hourLater, InsertedBefore: &startTime},
+			expectedStatus:    api.StatusBadInput,
+			expectedRemaining: 5,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			dbCtx := memory.New()
+			prepareMailingEntries(t, ctx, dbCtx, startTime)
+
+			count, err := db.InTransactionRetV(ctx, dbCtx, func(repository db.Repository) (int, error) {
+				return New(repository).Purge(ctx, "tenant-1", test.filter, test.dryRun)
+			})
+
+			if test.expectedStatus != "" {
+				var statusErr api.StatusError
+				if !errors.As(err, &statusErr) || statusErr.Status() != test.expectedStatus {
+					t.Fatalf("Expected %q error but got %v", test.expectedStatus, err)
+				}
+			} else if err != nil {
+				t.Fatalf("Expected no error but got %v", err)
+			}
+			if count != test.expectedCount {
+				t.Errorf("Expected count %d but got %d", test.expectedCount, count)
+			}
+
+			remaining, err := db.InTransactionRetV(ctx, dbCtx, func(repository db.Repository) (int, error) {
+				return repository.CountMailingEntries(ctx, "tenant-1", model.MailingEntryFilter{InsertedAfter: &time.Time{}})
+			})
+			if err != nil {
+				t.Fatalf("Error counting remaining entries: %v", err)
+			}
+			if remaining != test.expectedRemaining {
+				t.Errorf("Expected %d remaining entries but got %d", test.expectedRemaining, remaining)
+			}
+		})
+	}
+}
+
+// prepareMailingEntries inserts customers 1 and 2 of tenant-1 with mailing entries and customer 3 of another tenant with an entry:
+//   - customer 1: mailing 7 at start time and 30 minutes later, mailing 8 two hours later
+//   - customer 2: mailing 7 at start time, mailing 8 at start time
+func prepareMailingEntries(t *testing.T, ctx context.Context, dbCtx db.Transactioner, startTime time.Time) {
+	err := db.InTransaction(ctx, dbCtx, func(repository db.Repository) error {
+		customers := []model.Customer{
+			{TenantId: "tenant-1", Email: "jan.kowalski@example.com"},
+			{TenantId: "tenant-1", Email: "anna.nowak@example.com"},
+			{TenantId: "tenant-2", Email: "jan.kowalski@example.com"},
+		}
+		for _, customer := range customers {
+			if _, err := repository.InsertCustomer(ctx, customer); err != nil {
+				return err
+			}
+		}
+
+		entries := []struct {
+			tenantId   string
+			customerId int
+			mailingId  int
+			insertTime time.Time
+		}{
+			{"tenant-1", 1, 7, startTime},
+			{"tenant-1", 1, 7, startTime.Add(30 * time.Minute)},
+			{"tenant-1", 1, 8, startTime.Add(2 * time.Hour)},
+			{"tenant-1", 2, 7, startTime},
+			{"tenant-1", 2, 8, startTime},
+			{"tenant-2", 3, 7, startTime},
+		}
+		for _, entry := range entries {
+			_, err := repository.InsertMailingEntry(ctx, model.MailingEntry{
+				TenantId:   entry.tenantId,
+				CustomerId: entry.customerId,
+				MailingId:  entry.mailingId,
+				Title:      "title",
+				Content:    "content",
+				InsertTime: entry.insertTime,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Error preparing mailing entries: %v", err)
+	}
+}
@@ -9,7 +9,7 @@ import (
 )
 
 type Repository interface {
-	DeleteMailingEntryById(ctx context.Context, id int) error
+	DeleteMailingEntryById(ctx context.Context, tenantId string, id int) error
 }
 
 func New(repository Repository) *Remover {
@@ -20,9 +20,10 @@ type Remover struct {
 	repository Repository
 }
 
-func (remover *Remover) Remove(ctx context.Context, id int) error {
+// Remove deletes the tenant's mailing entry. Returns api.StatusNotFound error if the tenant doesn't have a mailing entry with the ID.
+func (remover *Remover) Remove(ctx context.Context, tenantId string, id int) error {
 	mdctx.Infof(ctx, "Deleting mailing entry %d", id)
-	err := remover.repository.DeleteMailingEntryById(ctx, id)
+	err := remover.repository.DeleteMailingEntryById(ctx, tenantId, id)
 	if err != nil && errors.Is(err, db.ErrNoRows) {
 		return api.StatusNotFound.WithMessageAndCause(err, "mailing entry with ID %d doesn't exist", id)
 	}
@@ -2,60 +2,151 @@ package sender
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/GeneralKenobi/mailman/internal/api"
 	"github.com/GeneralKenobi/mailman/internal/db/model"
+	"github.com/GeneralKenobi/mailman/internal/email"
 	"github.com/GeneralKenobi/mailman/pkg/api/apimodel"
 	"github.com/GeneralKenobi/mailman/pkg/mdctx"
+	"time"
 )
 
 type Repository interface {
-	FindMailingEntriesByMailingId(ctx context.Context, mailingId int) ([]model.MailingEntry, error)
-	FindCustomerById(ctx context.Context, id int) (model.Customer, error)
-	DeleteMailingEntryById(ctx context.Context, id int) error
+	FindMailingEntriesByMailingId(ctx context.Context, tenantId string, mailingId int) ([]model.MailingEntry, error)
+	FindCustomerById(ctx context.Context, tenantId string, id int) (model.Customer, error)
+	ArchiveMailingEntryById(ctx context.Context, tenantId string, id int, reason model.ArchiveReason, archiveTime time.Time) error
 }
 
 type Emailer interface {
 	Send(ctx context.Context, emailAddress, title, content string) error
 }
 
-func New(repository Repository, emailer Emailer) *EntrySender {
+type QuotaEnforcer interface {
+	Consume(ctx context.Context, tenantId string, count int) error
+}
+
+// EventPublisher publishes events about sent mailing entries, e.g. records webhook events in the transaction of the sender's repository.
+type EventPublisher interface {
+	Publish(ctx context.Context, tenantId, eventType string, data any) error
+}
+
+// EventPublishers publishes events with each publisher in order, stopping at the first error.
+type EventPublishers []EventPublisher
+
+func (publishers EventPublishers) Publish(ctx context.Context, tenantId, eventType string, data any) error {
+	for _, publisher := range publishers {
+		err := publisher.Publish(ctx, tenantId, eventType, data)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func New(repository Repository, emailer Emailer, quotaEnforcer QuotaEnforcer, eventPublisher EventPublisher) *EntrySender {
 	return &EntrySender{
-		repository: repository,
-		emailer:    emailer,
+		repository:     repository,
+		emailer:        emailer,
+		quotaEnforcer:  quotaEnforcer,
+		eventPublisher: eventPublisher,
 	}
 }
 
 type EntrySender struct {
-	repository Repository
-	emailer    Emailer
+	repository     Repository
+	emailer        Emailer
+	quotaEnforcer  QuotaEnforcer
+	eventPublisher EventPublisher
 }
 
-// SendMailingRequest sends email for every mailing entry with the given mailing ID and deletes them from the database.
-func (sender *EntrySender) SendMailingRequest(ctx context.Context, mailingRequest apimodel.MailingRequest) (err error) {
-	entries, err := sender.repository.FindMailingEntriesByMailingId(ctx, mailingRequest.MailingId)
+// Summary counts the outcomes of sending mailing entries with a mailing ID.
+type Summary struct {
+	MailingId    int
+	SentCount    int // Sent and archived
+	FailedCount  int // Failed to be sent and kept for sending again
+	BouncedCount int // Rejected by the recipient's server and archived
+}
+
+// Err returns api.StatusInternalError error if some of the mailing entries failed to be sent.
+func (summary Summary) Err() error {
+	if summary.FailedCount == 0 {
+		return nil
+	}
+	return api.StatusInternalError.WithMessage("%d of %d mailing entries couldn't be sent, they are kept for sending again",
+		summary.FailedCount, summary.SentCount+summary.FailedCount+summary.BouncedCount)
+}
+
+// SendMailingRequest sends email for every mailing entry of the tenant with the given mailing ID and moves them to the archive.
+// Nothing is sent if the entries don't fit in the tenant's daily send quota.
+//
+// Failing to send an entry doesn't stop sending the others - the entry is kept for sending again, unless it bounced, and the failure is
+// counted in the returned summary instead of being returned as an error. This way the transaction can be committed so that sent entries
+// aren't sent again. A webhook event is published for every entry and for the whole mailing.
+func (sender *EntrySender) SendMailingRequest(ctx context.Context, tenantId string, mailingRequest apimodel.MailingRequest) (Summary, error) {
+	summary := Summary{MailingId: mailingRequest.MailingId}
+	entries, err := sender.repository.FindMailingEntriesByMailingId(ctx, tenantId, mailingRequest.MailingId)
 	if err != nil {
-		return fmt.Errorf("error listing mailing entries: %w", err)
+		return summary, fmt.Errorf("error listing mailing entries: %w", err)
 	}
 
 	mdctx.Debugf(ctx, "Found %d mailing entries with mailing ID %d", len(entries), mailingRequest.MailingId)
 	if len(entries) == 0 {
-		return api.StatusNotFound.WithMessage("no mailing entries to send")
+		return summary, api.StatusNotFound.WithMessage("no mailing entries to send")
+	}
+
+	err = sender.quotaEnforcer.Consume(ctx, tenantId, len(entries))
+	if err != nil {
+		return summary, fmt.Errorf("can't send %d mailing entries: %w", len(entries), err)
 	}
 
 	for _, entry := range entries {
-		err = sender.Send(ctx, entry)
+		eventType := apimodel.WebhookEventMailingEntrySent
+		eventData := apimodel.WebhookMailingEntryEventData{MailingEntryId: entry.Id, MailingId: entry.MailingId, CustomerId: entry.CustomerId}
+
+		sendErr := sender.Send(ctx, entry)
+		switch {
+		case errors.Is(sendErr, email.ErrBounced):
+			mdctx.Warnf(ctx, "Mailing entry %d bounced, archiving it: %v", entry.Id, sendErr)
+			err = sender.repository.ArchiveMailingEntryById(ctx, tenantId, entry.Id, model.ArchiveReasonBounced, time.Now())
+			if err != nil {
+				return summary, fmt.Errorf("error archiving bounced mailing entry %d: %w", entry.Id, err)
+			}
+			summary.BouncedCount++
+			eventType, eventData.Error = apimodel.WebhookEventMailingEntryBounced, sendErr.Error()
+		case errors.As(sendErr, new(sendError)):
+			mdctx.Errorf(ctx, "Mailing entry %d couldn't be sent, keeping it: %v", entry.Id, sendErr)
+			summary.FailedCount++
+			eventType, eventData.Error = apimodel.WebhookEventMailingEntryFailed, sendErr.Error()
+		case sendErr != nil:
+			return summary, sendErr
+		default:
+			summary.SentCount++
+		}
+
+		err = sender.eventPublisher.Publish(ctx, tenantId, eventType, eventData)
 		if err != nil {
-			return err
+			return summary, fmt.Errorf("error publishing event for mailing entry %d: %w", entry.Id, err)
 		}
 	}
 
-	return nil
+	mdctx.Infof(ctx, "Sent %d, failed to send %d and bounced %d mailing entries with mailing ID %d",
+		summary.SentCount, summary.FailedCount, summary.BouncedCount, mailingRequest.MailingId)
+	err = sender.eventPublisher.Publish(ctx, tenantId, apimodel.WebhookEventMailingCompleted, apimodel.WebhookMailingCompletedEventData{
+		MailingId:    summary.MailingId,
+		SentCount:    summary.SentCount,
+		FailedCount:  summary.FailedCount,
+		BouncedCount: summary.BouncedCount,
+	})
+	if err != nil {
+		return summary, fmt.Errorf("error publishing mailing completed event: %w", err)
+	}
+	return summary, nil
 }
 
-// Send sends the mailing entry and deletes it from the database.
+// Send sends the mailing entry and moves it to the archive. Errors returned by the emailer are wrapped in sendError.
 func (sender *EntrySender) Send(ctx context.Context, mailingEntry model.MailingEntry) error {
-	customer, err := sender.repository.FindCustomerById(ctx, mailingEntry.CustomerId)
+	customer, err := sender.repository.FindCustomerById(ctx, mailingEntry.TenantId, mailingEntry.CustomerId)
 	if err != nil {
 		return fmt.Errorf("error finding customer %d for mailing entry %d: %w", mailingEntry.CustomerId, mailingEntry.Id, err)
 	}
@@ -63,14 +154,23 @@ func (sender *EntrySender) Send(ctx context.Context, mailingEntry model.MailingE
 	mdctx.Debugf(ctx, "Sending mailing entry with ID %d", mailingEntry.Id)
 	err = sender.emailer.Send(ctx, customer.Email, mailingEntry.Title, mailingEntry.Content)
 	if err != nil {
-		return fmt.Errorf("error sending mailing entry %d to customer %d: %w", mailingEntry.Id, mailingEntry.CustomerId, err)
+		return sendError{fmt.Errorf("error sending mailing entry %d to customer %d: %w", mailingEntry.Id, mailingEntry.CustomerId, err)}
 	}
 
-	mdctx.Infof(ctx, "Deleting mailing entry with ID %d", mailingEntry.Id)
-	err = sender.repository.DeleteMailingEntryById(ctx, mailingEntry.Id)
+	mdctx.Infof(ctx, "Archiving mailing entry with ID %d", mailingEntry.Id)
+	err = sender.repository.ArchiveMailingEntryById(ctx, mailingEntry.TenantId, mailingEntry.Id, model.ArchiveReasonSent, time.Now())
 	if err != nil {
-		return fmt.Errorf("error deleting mailing entry %d: %w", mailingEntry.Id, err)
+		return fmt.Errorf("error archiving mailing entry %d: %w", mailingEntry.Id, err)
 	}
 
 	return nil
 }
+
+// sendError distinguishes failures of the emailer, after which sending other entries can continue, from database errors.
+type sendError struct {
+	error
+}
+
+func (err sendError) Unwrap() error {
+	return err.error
+}
@@ -0,0 +1,116 @@
+package sender
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/GeneralKenobi/mailman/internal/db"
+	"github.com/GeneralKenobi/mailman/internal/db/memory"
+	"github.com/GeneralKenobi/mailman/internal/db/model"
+	"github.com/GeneralKenobi/mailman/internal/email"
+	"github.com/GeneralKenobi/mailman/pkg/api/apimodel"
+	"reflect"
+	"testing"
+)
+
+func TestSendMailingRequest(t *testing.T) {
+	ctx := context.Background()
+	dbCtx := memory.New()
+	err := db.InTransaction(ctx, dbCtx, func(repository db.Repository) error {
+		for _, address := range []string{"sent@example.com", "failed@example.com", "bounced@example.com"} {
+			customer, err := repository.InsertCustomer(ctx, model.Customer{TenantId: "tenant-1", Email: address})
+			if err != nil {
+				return err
+			}
+			_, err = repository.InsertMailingEntry(ctx, model.MailingEntry{TenantId: "tenant-1", CustomerId: customer.Id, MailingId: 7, Title: address})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Error preparing mailing entries: %v", err)
+	}
+
+	emailer := emailerMock(func(emailAddress string) error {
+		switch emailAddress {
+		case "failed@example.com":
+			return errors.New("connection refused")
+		case "bounced@example.com":
+			return fmt.Errorf("mailbox doesn't exist: %w", email.ErrBounced)
+		}
+		return nil
+	})
+	eventPublisher := eventPublisherMock{}
+	var remainingEntries []model.MailingEntry
+	var archivedEntries []model.ArchivedMailingEntry
+	summary, err := db.InTransactionRetV(ctx, dbCtx, func(repository db.Repository) (Summary, error) {
+		sender := New(repository, emailer, quotaEnforcerMock{}, &eventPublisher)
+		summary, err := sender.SendMailingRequest(ctx, "tenant-1", apimodel.MailingRequest{MailingId: 7})
+		if err != nil {
+			return summary, err
+		}
+		remainingEntries, err = repository.FindMailingEntriesByMailingId(ctx, "tenant-1", 7)
+		if err != nil {
+			return summary, err
+		}
+		archivedEntries, err = repository.FindArchivedMailingEntries(ctx, "tenant-1", model.ArchivedMailingEntryFilter{}, 10)
+		return summary, err
+	})
+
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	expectedSummary := Summary{MailingId: 7, SentCount: 1, FailedCount: 1, BouncedCount: 1}
+	if summary != expectedSummary {
+		t.Errorf("Expected summary %+v but got %+v", expectedSummary, summary)
+	}
+	if summary.Err() == nil {
+		t.Errorf("Expected summary with failures to return an error")
+	}
+	if len(remainingEntries) != 1 || remainingEntries[0].Title != "failed@example.com" {
+		t.Errorf("Expected only the entry that failed to be sent to be kept but got %+v", remainingEntries)
+	}
+	archiveReasons := make(map[string]model.ArchiveReason)
+	for _, archivedEntry := range archivedEntries {
+		archiveReasons[archivedEntry.CustomerEmail] = archivedEntry.Reason
+	}
+	expectedArchiveReasons := map[string]model.ArchiveReason{
+		"sent@example.com":    model.ArchiveReasonSent,
+		"bounced@example.com": model.ArchiveReasonBounced,
+	}
+	if !reflect.DeepEqual(archiveReasons, expectedArchiveReasons) {
+		t.Errorf("Expected archived entries %v but got %v", expectedArchiveReasons, archiveReasons)
+	}
+	expectedEventTypes := []string{
+		apimodel.WebhookEventMailingEntrySent,
+		apimodel.WebhookEventMailingEntryFailed,
+		apimodel.WebhookEventMailingEntryBounced,
+		apimodel.WebhookEventMailingCompleted,
+	}
+	if !reflect.DeepEqual(eventPublisher.eventTypes, expectedEventTypes) {
+		t.Errorf("Expected events %v but got %v", expectedEventTypes, eventPublisher.eventTypes)
+	}
+}
+
+type emailerMock func(emailAddress string) error
+
+func (mock emailerMock) Send(_ context.Context, emailAddress, _, _ string) error {
+	return mock(emailAddress)
+}
+
+type quotaEnforcerMock struct{}
+
+func (mock quotaEnforcerMock) Consume(context.Context, string, int) error {
+	return nil
+}
+
+type eventPublisherMock struct {
+	eventTypes []string
+}
+
+func (mock *eventPublisherMock) Publish(_ context.Context, _, eventType string, _ any) error {
+	mock.eventTypes = append(mock.eventTypes, eventType)
+	return nil
+}
@@ -2,62 +2,99 @@ package staleremover
 
 import (
 	"context"
+	"expvar"
 	"fmt"
 	"github.com/GeneralKenobi/mailman/internal/config"
+	"github.com/GeneralKenobi/mailman/internal/db"
 	"github.com/GeneralKenobi/mailman/internal/db/model"
 	"github.com/GeneralKenobi/mailman/pkg/mdctx"
 	"time"
 )
 
-type Repository interface {
-	FindMailingEntriesOlderThan(ctx context.Context, olderThan time.Duration) ([]model.MailingEntry, error)
-	FindMailingEntriesByMailingIdOlderThan(ctx context.Context, mailingId int, olderThan time.Duration) ([]model.MailingEntry, error)
-	DeleteMailingEntryById(ctx context.Context, id int) error
-}
+// metrics count removed stale entries, the batches they were removed in and runs stopped by the time budget. They're published with
+// expvar.
+var metrics = expvar.NewMap("staleMailingEntryRemover")
 
-func New(repository Repository) *StaleEntryRemover {
-	return &StaleEntryRemover{repository: repository}
+func New(transactioner db.Transactioner) *StaleEntryRemover {
+	return &StaleEntryRemover{transactioner: transactioner}
 }
 
+// StaleEntryRemover removes stale entries by moving them to the archive in batches, each in a separate transaction, so that removing many
+// entries neither holds locks for long nor loads the entries into memory.
 type StaleEntryRemover struct {
-	repository Repository
+	transactioner db.Transactioner
 }
 
-// RemoveByMailingId finds and removes all mailing entries with the given mailing ID that are older than the configured threshold.
-func (remover *StaleEntryRemover) RemoveByMailingId(ctx context.Context, mailingId int) error {
-	staleEntries, err := remover.repository.FindMailingEntriesByMailingIdOlderThan(ctx, mailingId, stalenessThreshold())
-	if err != nil {
-		return fmt.Errorf("error listing stale mailing entries with mailing ID %d: %w", mailingId, err)
+// RemoveByMailingId removes all mailing entries of the tenant with the given mailing ID that are older than the tenant's threshold.
+// There's no time budget, stale entries have to be removed before sending the mailing.
+func (remover *StaleEntryRemover) RemoveByMailingId(ctx context.Context, tenantId string, mailingId int) error {
+	staleBefore := now().Add(-stalenessThreshold(tenantId))
+	filter := model.MailingEntryFilter{MailingId: &mailingId, InsertedBefore: &staleBefore}
+	if _, err := remover.removeBatches(ctx, tenantId, filter, time.Time{}); err != nil {
+		return fmt.Errorf("error removing stale mailing entries with mailing ID %d: %w", mailingId, err)
 	}
-
-	return remover.removeStaleEntries(ctx, staleEntries)
+	return nil
 }
 
-// Remove finds and removes all mailing entries that are older than the configured threshold.
-func (remover *StaleEntryRemover) Remove(ctx context.Context) error {
-	staleEntries, err := remover.repository.FindMailingEntriesOlderThan(ctx, stalenessThreshold())
+// Remove removes mailing entries of the tenant that are older than the tenant's threshold until there are none left or the deadline
+// passes. Returns whether all stale entries were removed.
+func (remover *StaleEntryRemover) Remove(ctx context.Context, tenantId string, deadline time.Time) (bool, error) {
+	staleBefore := now().Add(-stalenessThreshold(tenantId))
+	filter := model.MailingEntryFilter{InsertedBefore: &staleBefore}
+	done, err := remover.removeBatches(ctx, tenantId, filter, deadline)
 	if err != nil {
-		return fmt.Errorf("error listing stale mailing entries: %w", err)
+		return false, fmt.Errorf("error removing stale mailing entries: %w", err)
 	}
-
-	return remover.removeStaleEntries(ctx, staleEntries)
+	return done, nil
 }
 
-func (remover *StaleEntryRemover) removeStaleEntries(ctx context.Context, staleEntries []model.MailingEntry) error {
-	mdctx.Infof(ctx, "Removing %d stale mailing entries", len(staleEntries))
+// removeBatches removes the entries matching the filter batch by batch until there are none left or the deadline passes (zero deadline
+// means no deadline). Returns whether all entries were removed. Batches removed before an error stay removed.
+func (remover *StaleEntryRemover) removeBatches(ctx context.Context, tenantId string, filter model.MailingEntryFilter, deadline time.Time) (bool, error) {
+	limit := batchSize()
+	var removedCount int64
+	for {
+		if !deadline.IsZero() && !now().Before(deadline) {
+			mdctx.Infof(ctx, "Time budget exhausted after removing %d stale mailing entries, the rest will be removed later", removedCount)
+			metrics.Add("budgetExhausted", 1)
+			return false, nil
+		}
 
-	for _, entry := range staleEntries {
-		mdctx.Infof(ctx, "Removing stale mailing entry %d", entry.Id)
-		err := remover.repository.DeleteMailingEntryById(ctx, entry.Id)
+		count, err := db.InTransactionRetV(ctx, remover.transactioner, func(repository db.Repository) (int64, error) {
+			return repository.ArchiveMailingEntryBatch(ctx, tenantId, filter, limit, model.ArchiveReasonStale, now())
+		}, db.WithRetries(config.Get().Database.TransactionRetries))
 		if err != nil {
-			return fmt.Errorf("error removing stale mailing entry %d: %w", entry.Id, err)
+			return false, err
+		}
+		removedCount += count
+		metrics.Add("removed", count)
+		metrics.Add("batches", 1)
+		mdctx.Debugf(ctx, "Removed a batch of %d stale mailing entries", count)
+
+		if count == 0 || count < int64(limit) {
+			if removedCount > 0 {
+				mdctx.Infof(ctx, "Removed %d stale mailing entries", removedCount)
+			}
+			return true, nil
 		}
 	}
+}
 
-	return nil
+// Hook for mocking in unit tests.
+// The tenant's threshold is used if it's configured, otherwise the global one.
+var stalenessThreshold = func(tenantId string) time.Duration {
+	cfg := config.Get()
+	thresholdSeconds := cfg.StaleMailingEntryRemover.StalenessThresholdSeconds
+	if tenantThresholdSeconds := cfg.Tenants[tenantId].StalenessThresholdSeconds; tenantThresholdSeconds > 0 {
+		thresholdSeconds = tenantThresholdSeconds
+	}
+	return time.Duration(thresholdSeconds) * time.Second
 }
 
 // Hook for mocking in unit tests.
-var stalenessThreshold = func() time.Duration {
-	return time.Duration(config.Get().StaleMailingEntryRemover.StalenessThresholdSeconds) * time.Second
+var batchSize = func() int {
+	return config.Get().StaleMailingEntryRemover.BatchSize
 }
+
+// Hook for mocking in unit tests.
+var now = time.Now
@@ -0,0 +1,135 @@
+package staleremover
+
+import (
+	"context"
+	"expvar"
+	"fmt"
+	"github.com/GeneralKenobi/mailman/internal/db"
+	"github.com/GeneralKenobi/mailman/internal/db/memory"
+	"github.com/GeneralKenobi/mailman/internal/db/model"
+	"testing"
+	"time"
+)
+
+func TestRemove(t *testing.T) {
+	currentTime := time.Date(2022, 3, 30, 12, 0, 0, 0, time.UTC)
+
+	tests := map[string]struct {
+		remove            func(ctx context.Context, remover *StaleEntryRemover) (bool, error)
+		expectedDone      bool
+		expectedRemaining int
+		expectedBatches   int64
+	}{
+		"Should remove all stale entries in batches": {
+			remove: func(ctx context.Context, remover *StaleEntryRemover) (bool, error) {
+				return remover.Remove(ctx, "tenant-1", currentTime.Add(time.Minute))
+			},
+			expectedDone:      true,
+			expectedRemaining: 1,
+			expectedBatches:   3,
+		},
+		"Should stop removing when the deadline passes": {
+			remove: func(ctx context.Context, remover *StaleEntryRemover) (bool, error) {
+				return remover.Remove(ctx, "tenant-1", currentTime)
+			},
+			expectedDone:      false,
+			expectedRemaining: 6,
+		},
+		"Should remove stale entries with a mailing ID": {
+			remove: func(ctx context.Context, remover *StaleEntryRemover) (bool, error) {
+				return true, remover.RemoveByMailingId(ctx, "tenant-1", 7)
+			},
+			expectedDone:      true,
+			expectedRemaining: 3,
+			expectedBatches:   2,
+		},
+	}
+
+	originalNowHook := now
+	originalStalenessThresholdHook := stalenessThreshold
+	originalBatchSizeHook := batchSize
+	defer func() {
+		now = originalNowHook
+		stalenessThreshold = originalStalenessThresholdHook
+		batchSize = originalBatchSizeHook
+	}()
+	now = func() time.Time { return currentTime }
+	stalenessThreshold = func(string) time.Duration { return time.Hour }
+	batchSize = func() int { return 2 }
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			dbCtx := memory.New()
+			prepareMailingEntries(t, ctx, dbCtx, currentTime)
+			batchesBefore := metricValue("batches")
+
+			done, err := test.remove(ctx, New(dbCtx))
+
+			if err != nil {
+				t.Fatalf("Expected no error but got %v", err)
+			}
+			if done != test.expectedDone {
+				t.Errorf("Expected done to be %v but got %v", test.expectedDone, done)
+			}
+			if batches := metricValue("batches") - batchesBefore; batches != test.expectedBatches {
+				t.Errorf("Expected %d batches but got %d", test.expectedBatches, batches)
+			}
+			remaining, err := db.InTransactionRetV(ctx, dbCtx, func(repository db.Repository) (int, error) {
+				return repository.CountMailingEntries(ctx, "tenant-1", model.MailingEntryFilter{InsertedAfter: &time.Time{}})
+			})
+			if err != nil {
+				t.Fatalf("Error counting remaining entries: %v", err)
+			}
+			if remaining != test.expectedRemaining {
+				t.Errorf("Expected %d remaining entries but got %d", test.expectedRemaining, remaining)
+			}
+		})
+	}
+}
+
+// prepareMailingEntries inserts a customer of tenant-1 with 5 stale entries (3 with mailing ID 7, 2 with mailing ID 8) and a fresh one
+// with mailing ID 7, and a customer of tenant-2 with a stale entry.
+func prepareMailingEntries(t *testing.T, ctx context.Context, dbCtx db.Transactioner, currentTime time.Time) {
+	err := db.InTransaction(ctx, dbCtx, func(repository db.Repository) error {
+		entries := []struct {
+			tenantId   string
+			mailingId  int
+			insertTime time.Time
+		}{
+			{"tenant-1", 7, currentTime.Add(-2 * time.Hour)},
+			{"tenant-1", 7, currentTime.Add(-2 * time.Hour)},
+			{"tenant-1", 8, currentTime.Add(-2 * time.Hour)},
+			{"tenant-1", 7, currentTime.Add(-90 * time.Minute)},
+			{"tenant-1", 8, currentTime.Add(-90 * time.Minute)},
+			{"tenant-1", 7, currentTime.Add(-10 * time.Minute)},
+			{"tenant-2", 7, currentTime.Add(-2 * time.Hour)},
+		}
+		for i, entry := range entries {
+			customer, err := repository.FindOrInsertCustomer(ctx, model.Customer{TenantId: entry.tenantId, Email: "jan.kowalski@example.com"})
+			if err != nil {
+				return err
+			}
+			_, err = repository.InsertMailingEntry(ctx, model.MailingEntry{
+				TenantId:   entry.tenantId,
+				CustomerId: customer.Id,
+				MailingId:  entry.mailingId,
+				Title:      fmt.Sprintf("title %d", i),
+				Content:    "content",
+				InsertTime: entry.insertTime,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Error preparing mailing entries: %v", err)
+	}
+}
+
+func metricValue(key string) int64 {
+	metrics.Add(key, 0) // Creates the counter if nothing was counted yet
+	return metrics.Get(key).(*expvar.Int).Value()
+}
@@ -0,0 +1,134 @@
+package updater
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/GeneralKenobi/mailman/internal/api"
+	"github.com/GeneralKenobi/mailman/internal/db"
+	"github.com/GeneralKenobi/mailman/internal/db/model"
+	"github.com/GeneralKenobi/mailman/pkg/api/apimodel"
+	"github.com/GeneralKenobi/mailman/pkg/mdctx"
+)
+
+type Repository interface {
+	FindMailingEntryById(ctx context.Context, tenantId string, id int) (model.MailingEntry, error)
+	UpdateMailingEntry(ctx context.Context, mailingEntry model.MailingEntry) (model.MailingEntry, error)
+}
+
+type CustomerResolver interface {
+	GetOrCreateCustomer(ctx context.Context, tenantId, email string) (model.Customer, error)
+}
+
+func New(repository Repository, customerResolver CustomerResolver) *Updater {
+	return &Updater{
+		repository:       repository,
+		customerResolver: customerResolver,
+	}
+}
+
+type Updater struct {
+	repository       Repository
+	customerResolver CustomerResolver
+}
+
+// UpdateFromDto applies changes from the DTO to the tenant's mailing entry that wasn't sent yet (sent entries are archived, so
+// api.StatusNotFound is returned for them). A changed email resolves the recipient like creating an entry does.
+//
+// If ifMatchVersions isn't nil the entry is only updated if its current version is one of them, otherwise api.StatusPreconditionFailed
+// is returned. If the entry is modified by someone else between reading and updating it api.StatusConflict is returned, if the update
+// would make it equal to another entry api.StatusBadInput is returned.
+func (updater *Updater) UpdateFromDto(
+	ctx context.Context, tenantId string, id int, ifMatchVersions []int, updateDto apimodel.MailingEntryUpdate) (model.MailingEntry, error) {
+
+	if err := validateUpdate(updateDto); err != nil {
+		return model.MailingEntry{}, err
+	}
+
+	mailingEntry, err := updater.findMailingEntry(ctx, tenantId, id)
+	if err != nil {
+		return model.MailingEntry{}, err
+	}
+	if ifMatchVersions != nil && !contains(ifMatchVersions, mailingEntry.Version) {
+		mdctx.Debugf(ctx, "Mailing entry %d is at version %d, expected one of %v", id, mailingEntry.Version, ifMatchVersions)
+		return model.MailingEntry{}, api.StatusPreconditionFailed.WithMessage(
+			"mailing entry with ID %d is at version %d which doesn't match If-Match", id, mailingEntry.Version)
+	}
+
+	if updateDto.Email != nil {
+		customer, err := updater.customerResolver.GetOrCreateCustomer(ctx, tenantId, *updateDto.Email)
+		if err != nil {
+			return model.MailingEntry{}, fmt.Errorf("error resolving customer for mailing entry %d: %w", id, err)
+		}
+		mailingEntry.CustomerId = customer.Id
+	}
+	if updateDto.MailingId != nil {
+		mailingEntry.MailingId = *updateDto.MailingId
+	}
+	if updateDto.Title != nil {
+		mailingEntry.Title = *updateDto.Title
+	}
+	if updateDto.Content != nil {
+		mailingEntry.Content = *updateDto.Content
+	}
+
+	return updater.update(ctx, mailingEntry)
+}
+
+func validateUpdate(updateDto apimodel.MailingEntryUpdate) error {
+	if updateDto == (apimodel.MailingEntryUpdate{}) {
+		return api.StatusBadInput.WithMessage("at least one of mailing_id, email, title and content has to be given")
+	}
+	if updateDto.Title != nil && *updateDto.Title == "" {
+		return api.StatusBadInput.WithMessage("title can't be empty")
+	}
+	if updateDto.Content != nil && *updateDto.Content == "" {
+		return api.StatusBadInput.WithMessage("content can't be empty")
+	}
+	return nil
+}
+
+func (updater *Updater) findMailingEntry(ctx context.Context, tenantId string, id int) (model.MailingEntry, error) {
+	mailingEntry, err := updater.repository.FindMailingEntryById(ctx, tenantId, id)
+	if err != nil {
+		if errors.Is(err, db.ErrNoRows) {
+			return model.MailingEntry{}, api.StatusNotFound.WithMessageAndCause(err, "mailing entry with ID %d doesn't exist", id)
+		}
+		return model.MailingEntry{}, fmt.Errorf("error finding mailing entry %d: %w", id, err)
+	}
+	return mailingEntry, nil
+}
+
+func (updater *Updater) update(ctx context.Context, mailingEntry model.MailingEntry) (model.MailingEntry, error) {
+	mdctx.Debugf(ctx, "Updating mailing entry %d at version %d", mailingEntry.Id, mailingEntry.Version)
+	updatedEntry, err := updater.repository.UpdateMailingEntry(ctx, mailingEntry)
+	if err == nil {
+		mdctx.Infof(ctx, "Updated mailing entry %d to version %d", updatedEntry.Id, updatedEntry.Version)
+		return updatedEntry, nil
+	}
+	if errors.Is(err, db.ErrDuplicate) {
+		mdctx.Debugf(ctx, "Mailing entry %d would be equal to another mailing entry", mailingEntry.Id)
+		return model.MailingEntry{}, api.StatusBadInput.WithMessageAndCause(err, "an equal mailing entry already exists")
+	}
+	if errors.Is(err, db.ErrValueTooLong) {
+		return model.MailingEntry{}, api.StatusBadInput.WithMessageAndCause(err, "a field of the mailing entry is too long")
+	}
+	if !errors.Is(err, db.ErrNoRows) {
+		return model.MailingEntry{}, fmt.Errorf("error updating mailing entry %d: %w", mailingEntry.Id, err)
+	}
+
+	// The entry was changed or archived (e.g. sent) by a concurrent transaction after it was read.
+	if _, err = updater.findMailingEntry(ctx, mailingEntry.TenantId, mailingEntry.Id); err != nil {
+		return model.MailingEntry{}, err
+	}
+	return model.MailingEntry{}, api.StatusConflict.WithMessage("mailing entry with ID %d was modified concurrently", mailingEntry.Id)
+}
+
+func contains(versions []int, version int) bool {
+	for _, v := range versions {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
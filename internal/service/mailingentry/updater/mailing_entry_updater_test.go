@@ -0,0 +1,212 @@
+package updater
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/GeneralKenobi/mailman/internal/api"
+	"github.com/GeneralKenobi/mailman/internal/db"
+	"github.com/GeneralKenobi/mailman/internal/db/memory"
+	"github.com/GeneralKenobi/mailman/internal/db/model"
+	customercreator "github.com/GeneralKenobi/mailman/internal/service/customer/creator"
+	mailingentrycreator "github.com/GeneralKenobi/mailman/internal/service/mailingentry/creator"
+	"github.com/GeneralKenobi/mailman/pkg/api/apimodel"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUpdateFromDto(t *testing.T) {
+	newTitle := "new title"
+	emptyContent := ""
+	newEmail := "new@example.com"
+	newMailingId := 8
+	insertTime := time.Date(2022, 3, 30, 15, 42, 38, 0, time.UTC)
+
+	tests := map[string]struct {
+		id                  int
+		ifMatchVersions     []int
+		update              apimodel.MailingEntryUpdate
+		concurrentUpdate    bool
+		concurrentDelete    bool
+		updateErr           error
+		expectedStatus      api.Status
+		expectedMailingId   int
+		expectedTitle       string
+		expectedNewCustomer bool
+	}{
+		"Should update the title and increment the version": {
+			id:                1,
+			update:            apimodel.MailingEntryUpdate{Title: &newTitle},
+			expectedMailingId: 7,
+			expectedTitle:     newTitle,
+		},
+		"Should update the entry if one of the If-Match versions matches": {
+			id:                1,
+			ifMatchVersions:   []int{3, 1},
+			update:            apimodel.MailingEntryUpdate{MailingId: &newMailingId},
+			expectedMailingId: newMailingId,
+			expectedTitle:     "title",
+		},
+		"Should change the recipient to a new customer": {
+			id:                  1,
+			update:              apimodel.MailingEntryUpdate{Email: &newEmail},
+			expectedMailingId:   7,
+			expectedTitle:       "title",
+			expectedNewCustomer: true,
+		},
+		"Should reject an update without changes": {
+			id:             1,
+			update:         apimodel.MailingEntryUpdate{},
+			expectedStatus: api.StatusBadInput,
+		},
+		"Should reject empty content": {
+			id:             1,
+			update:         apimodel.MailingEntryUpdate{Content: &emptyContent},
+			expectedStatus: api.StatusBadInput,
+		},
+		"Should reject an update making the entry equal to another one": {
+			id:             1,
+			update:         apimodel.MailingEntryUpdate{Title: stringPtr("other title")},
+			expectedStatus: api.StatusBadInput,
+		},
+		"Should return not found for an entry that doesn't exist": {
+			id:             100,
+			update:         apimodel.MailingEntryUpdate{Title: &newTitle},
+			expectedStatus: api.StatusNotFound,
+		},
+		"Should return precondition failed if no If-Match version matches": {
+			id:              1,
+			ifMatchVersions: []int{2},
+			update:          apimodel.MailingEntryUpdate{Title: &newTitle},
+			expectedStatus:  api.StatusPreconditionFailed,
+		},
+		"Should return precondition failed if If-Match has no valid versions": {
+			id:              1,
+			ifMatchVersions: []int{},
+			update:          apimodel.MailingEntryUpdate{Title: &newTitle},
+			expectedStatus:  api.StatusPreconditionFailed,
+		},
+		"Should return conflict if the entry is modified concurrently": {
+			id:               1,
+			update:           apimodel.MailingEntryUpdate{Title: &newTitle},
+			concurrentUpdate: true,
+			expectedStatus:   api.StatusConflict,
+		},
+		"Should reject a title too long for the database": {
+			id:             1,
+			update:         apimodel.MailingEntryUpdate{Title: stringPtr(strings.Repeat("a", 256))},
+			updateErr:      fmt.Errorf("error updating mailing entry: %w", db.ErrValueTooLong),
+			expectedStatus: api.StatusBadInput,
+		},
+		"Should return not found if the entry is sent concurrently": {
+			id:               1,
+			update:           apimodel.MailingEntryUpdate{Title: &newTitle},
+			concurrentDelete: true,
+			expectedStatus:   api.StatusNotFound,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			dbCtx := memory.New()
+			customer := prepareMailingEntries(t, ctx, dbCtx, insertTime)
+
+			updated, err := db.InTransactionRetV(ctx, dbCtx, func(repository db.Repository) (model.MailingEntry, error) {
+				repositoryWrapper := concurrentRepository{Repository: repository}
+				if test.concurrentUpdate {
+					repositoryWrapper.beforeUpdate = func(mailingEntry model.MailingEntry) error {
+						mailingEntry.Content = "changed concurrently"
+						_, err := repository.UpdateMailingEntry(ctx, mailingEntry)
+						return err
+					}
+				}
+				if test.updateErr != nil {
+					repositoryWrapper.beforeUpdate = func(model.MailingEntry) error {
+						return test.updateErr
+					}
+				}
+				if test.concurrentDelete {
+					repositoryWrapper.beforeUpdate = func(mailingEntry model.MailingEntry) error {
+						return repository.DeleteMailingEntryById(ctx, mailingEntry.TenantId, mailingEntry.Id)
+					}
+				}
+
+				customerResolver := mailingentrycreator.New(repository, customercreator.New(repository))
+				testObj := New(repositoryWrapper, customerResolver)
+				return testObj.UpdateFromDto(ctx, "tenant-1", test.id, test.ifMatchVersions, test.update)
+			})
+
+			if test.expectedStatus != "" {
+				var statusErr api.StatusError
+				if !errors.As(err, &statusErr) || statusErr.Status() != test.expectedStatus {
+					t.Fatalf("Expected %q error but got %v", test.expectedStatus, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error but got %v", err)
+			}
+			if updated.Version != 2 {
+				t.Errorf("Expected version 2 but got %d", updated.Version)
+			}
+			if updated.MailingId != test.expectedMailingId || updated.Title != test.expectedTitle {
+				t.Errorf("Expected mailing ID %d and title %q but got %+v", test.expectedMailingId, test.expectedTitle, updated)
+			}
+			if (updated.CustomerId != customer.Id) != test.expectedNewCustomer {
+				t.Errorf("Expected new customer: %v, got customer %d (original %d)", test.expectedNewCustomer, updated.CustomerId, customer.Id)
+			}
+			if !updated.InsertTime.Equal(insertTime) {
+				t.Errorf("Expected insert time to be kept, got %v", updated.InsertTime)
+			}
+		})
+	}
+}
+
+// prepareMailingEntries inserts a customer (ID 1), mailing entry 1 titled "title" and an entry titled "other title" that is otherwise equal.
+func prepareMailingEntries(t *testing.T, ctx context.Context, dbCtx db.Transactioner, insertTime time.Time) model.Customer {
+	customer, err := db.InTransactionRetV(ctx, dbCtx, func(repository db.Repository) (model.Customer, error) {
+		customer, err := repository.InsertCustomer(ctx, model.Customer{TenantId: "tenant-1", Email: "customer@example.com"})
+		if err != nil {
+			return model.Customer{}, err
+		}
+		for _, title := range []string{"title", "other title"} {
+			mailingEntry := model.MailingEntry{
+				TenantId:   "tenant-1",
+				CustomerId: customer.Id,
+				MailingId:  7,
+				Title:      title,
+				Content:    "content",
+				InsertTime: insertTime,
+			}
+			if _, err = repository.InsertMailingEntry(ctx, mailingEntry); err != nil {
+				return model.Customer{}, err
+			}
+		}
+		return customer, nil
+	})
+	if err != nil {
+		t.Fatalf("Error preparing mailing entries: %v", err)
+	}
+	return customer
+}
+
+// concurrentRepository simulates a concurrent transaction changing the mailing entry right before it's updated.
+type concurrentRepository struct {
+	db.Repository
+	beforeUpdate func(mailingEntry model.MailingEntry) error
+}
+
+func (repository concurrentRepository) UpdateMailingEntry(ctx context.Context, mailingEntry model.MailingEntry) (model.MailingEntry, error) {
+	if repository.beforeUpdate != nil {
+		if err := repository.beforeUpdate(mailingEntry); err != nil {
+			return model.MailingEntry{}, err
+		}
+	}
+	return repository.Repository.UpdateMailingEntry(ctx, mailingEntry)
+}
+
+func stringPtr(value string) *string {
+	return &value
+}
@@ -0,0 +1,61 @@
+package quota
+
+import (
+	"context"
+	"fmt"
+	"github.com/GeneralKenobi/mailman/internal/api"
+	"github.com/GeneralKenobi/mailman/internal/config"
+	"github.com/GeneralKenobi/mailman/pkg/mdctx"
+	"time"
+)
+
+type Repository interface {
+	IncrementDailySendCount(ctx context.Context, tenantId string, day time.Time, count int) (int, error)
+}
+
+func New(repository Repository) *SendQuotaEnforcer {
+	return &SendQuotaEnforcer{repository: repository}
+}
+
+type SendQuotaEnforcer struct {
+	repository Repository
+}
+
+// Consume records that the tenant is about to send count mailing entries today (UTC). It returns api.StatusTooManyRequests error if
+// that would exceed the tenant's daily send quota. The usage is recorded even then, so it has to be called within a transaction that
+// is rolled back on error.
+func (enforcer *SendQuotaEnforcer) Consume(ctx context.Context, tenantId string, count int) error {
+	quota := dailySendQuota(tenantId)
+	if quota <= 0 {
+		return nil
+	}
+
+	today := currentTime().UTC()
+	sentCount, err := enforcer.repository.IncrementDailySendCount(ctx, tenantId, today, count)
+	if err != nil {
+		return fmt.Errorf("error updating daily send count: %w", err)
+	}
+
+	mdctx.Debugf(ctx, "Daily send count including %d new entries is %d out of %d", count, sentCount, quota)
+	if sentCount > quota {
+		remaining := quota - (sentCount - count)
+		if remaining < 0 {
+			remaining = 0
+		}
+		return api.StatusTooManyRequests.WithMessage("daily send quota of %d exceeded, %d entries can still be sent today", quota, remaining)
+	}
+	return nil
+}
+
+// Hook for mocking in unit tests.
+// The tenant's quota is used if it's configured, otherwise the global one.
+var dailySendQuota = func(tenantId string) int {
+	cfg := config.Get()
+	if tenantQuota := cfg.Tenants[tenantId].DailySendQuota; tenantQuota > 0 {
+		return tenantQuota
+	}
+	return cfg.MailingEntrySender.DailySendQuota
+}
+
+// Hook for mocking in unit tests.
+var currentTime = time.Now
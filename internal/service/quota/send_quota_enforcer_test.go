@@ -0,0 +1,83 @@
+package quota
+
+import (
+	"context"
+	"errors"
+	"github.com/GeneralKenobi/mailman/internal/api"
+	"testing"
+	"time"
+)
+
+func TestConsume(t *testing.T) {
+	tests := map[string]struct {
+		quota          int
+		alreadySent    int
+		toSend         int
+		expectedStatus api.Status
+	}{
+		"Should allow sending within quota": {
+			quota:       10,
+			alreadySent: 5,
+			toSend:      5,
+		},
+		"Should reject sending over quota": {
+			quota:          10,
+			alreadySent:    5,
+			toSend:         6,
+			expectedStatus: api.StatusTooManyRequests,
+		},
+		"Should allow sending anything if there's no quota": {
+			quota:       0,
+			alreadySent: 1000,
+			toSend:      1000,
+		},
+	}
+
+	originalDailySendQuotaHook := dailySendQuota
+	originalCurrentTimeHook := currentTime
+	defer func() {
+		dailySendQuota = originalDailySendQuotaHook
+		currentTime = originalCurrentTimeHook
+	}()
+	now := time.Date(2022, 3, 13, 23, 59, 48, 0, time.FixedZone("UTC-2", -2*60*60))
+	currentTime = func() time.Time {
+		return now
+	}
+
+	for title, test := range tests {
+		t.Run(title, func(t *testing.T) {
+			dailySendQuota = func(tenantId string) int {
+				return test.quota
+			}
+			repository := repositoryMock{
+				incrementDailySendCount: func(ctx context.Context, tenantId string, day time.Time, count int) (int, error) {
+					if tenantId != "tenant-1" || count != test.toSend {
+						t.Fatalf("Expected increment by %d for tenant-1 but got %d for %q", test.toSend, count, tenantId)
+					}
+					if day.Format("2006-01-02") != "2022-03-14" {
+						t.Errorf("Expected the UTC day 2022-03-14 but got %v", day)
+					}
+					return test.alreadySent + count, nil
+				},
+			}
+
+			err := New(repository).Consume(context.TODO(), "tenant-1", test.toSend)
+
+			if test.expectedStatus == "" && err != nil {
+				t.Errorf("Expected no error but got %v", err)
+			}
+			var statusErr api.StatusError
+			if test.expectedStatus != "" && (!errors.As(err, &statusErr) || statusErr.Status() != test.expectedStatus) {
+				t.Errorf("Expected %v status error but got %v", test.expectedStatus, err)
+			}
+		})
+	}
+}
+
+type repositoryMock struct {
+	incrementDailySendCount func(ctx context.Context, tenantId string, day time.Time, count int) (int, error)
+}
+
+func (mock repositoryMock) IncrementDailySendCount(ctx context.Context, tenantId string, day time.Time, count int) (int, error) {
+	return mock.incrementDailySendCount(ctx, tenantId, day, count)
+}
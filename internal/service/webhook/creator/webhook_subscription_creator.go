@@ -0,0 +1,67 @@
+package creator
+
+import (
+	"context"
+	"fmt"
+	"github.com/GeneralKenobi/mailman/internal/api"
+	"github.com/GeneralKenobi/mailman/internal/db/model"
+	"github.com/GeneralKenobi/mailman/internal/service/webhook/destination"
+	"github.com/GeneralKenobi/mailman/pkg/api/apimodel"
+	"github.com/GeneralKenobi/mailman/pkg/mdctx"
+	"net/url"
+	"time"
+)
+
+type Repository interface {
+	InsertWebhookSubscription(ctx context.Context, subscription model.WebhookSubscription) (model.WebhookSubscription, error)
+}
+
+func New(repository Repository) *Creator {
+	return &Creator{repository: repository}
+}
+
+type Creator struct {
+	repository Repository
+}
+
+// CreateFromDto creates a webhook subscription of the tenant. Returns api.StatusBadInput error if the URL isn't an HTTP(S) URL or if its
+// host is an internal address (see destination.Check).
+func (creator *Creator) CreateFromDto(ctx context.Context, tenantId string, subscriptionDto apimodel.WebhookSubscription) (model.WebhookSubscription, error) {
+	subscriptionUrl, err := url.Parse(subscriptionDto.Url)
+	if err != nil || (subscriptionUrl.Scheme != "http" && subscriptionUrl.Scheme != "https") || subscriptionUrl.Host == "" {
+		return model.WebhookSubscription{}, api.StatusBadInput.WithMessage("webhook URL %q isn't an HTTP or HTTPS URL", subscriptionDto.Url)
+	}
+	if err = destination.Check(ctx, subscriptionUrl.Hostname()); err != nil {
+		return model.WebhookSubscription{}, api.StatusBadInput.WithMessage("webhook URL %q isn't allowed: %v", subscriptionDto.Url, err)
+	}
+
+	subscription := model.WebhookSubscription{
+		TenantId:   tenantId,
+		Url:        subscriptionDto.Url,
+		EventTypes: distinct(subscriptionDto.EventTypes),
+		Secret:     subscriptionDto.Secret,
+		CreateTime: currentTime().UTC(),
+	}
+	subscription, err = creator.repository.InsertWebhookSubscription(ctx, subscription)
+	if err != nil {
+		return model.WebhookSubscription{}, fmt.Errorf("error inserting webhook subscription: %w", err)
+	}
+
+	mdctx.Infof(ctx, "Created webhook subscription %d to %v", subscription.Id, subscription.EventTypes)
+	return subscription, nil
+}
+
+func distinct(values []string) []string {
+	seen := make(map[string]bool)
+	var result []string
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
+// Hook for mocking in unit tests.
+var currentTime = time.Now
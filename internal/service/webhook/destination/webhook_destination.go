@@ -0,0 +1,86 @@
+package destination
+
+import (
+	"context"
+	"fmt"
+	"github.com/GeneralKenobi/mailman/internal/config"
+	"github.com/GeneralKenobi/mailman/pkg/mdctx"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// Check returns an error if webhooks mustn't be delivered to the host, i.e. if it is or resolves to a loopback, private, link-local or
+// unspecified address that isn't in webhooks.allowedNetworks. Tenants could otherwise make mailman send requests to its own services or
+// to the metadata service of the cloud provider. Hosts that can't be resolved are rejected too.
+func Check(ctx context.Context, host string) error {
+	if ip := net.ParseIP(host); ip != nil {
+		return checkIp(ip)
+	}
+	addresses, err := lookupIp(ctx, host)
+	if err != nil {
+		return fmt.Errorf("error resolving host %q: %w", host, err)
+	}
+	for _, address := range addresses {
+		if err = checkIp(address.IP); err != nil {
+			return fmt.Errorf("host %q resolves to a forbidden address: %w", host, err)
+		}
+	}
+	return nil
+}
+
+// NewHttpClient creates a client delivering webhooks. The address of every connection is checked when it's dialed, so that hosts that
+// resolve to a forbidden address after they were checked with Check are rejected too. Redirects aren't followed, they would bypass the
+// check of the subscribed URL, the redirect response is returned instead.
+func NewHttpClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 30 * time.Second,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil {
+				return fmt.Errorf("dialed address %q isn't an IP address", address)
+			}
+			return checkIp(ip)
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil // A proxy would connect to the destination instead of mailman, bypassing the check
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Transport: transport,
+		Timeout:   time.Duration(config.Get().Webhooks.TimeoutSeconds) * time.Second,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+func checkIp(ip net.IP) error {
+	if !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() && !ip.IsUnspecified() {
+		return nil
+	}
+	for _, network := range allowedNetworks() {
+		_, allowedNetwork, err := net.ParseCIDR(network)
+		if err != nil {
+			mdctx.Errorf(nil, "Ignoring invalid network %q allowed for webhooks: %v", network, err)
+			continue
+		}
+		if allowedNetwork.Contains(ip) {
+			return nil
+		}
+	}
+	return fmt.Errorf("webhooks can't be delivered to internal address %s", ip)
+}
+
+// Hook for mocking in unit tests.
+var allowedNetworks = func() []string {
+	return config.Get().Webhooks.AllowedNetworks
+}
+
+// Hook for mocking in unit tests.
+var lookupIp = net.DefaultResolver.LookupIPAddr
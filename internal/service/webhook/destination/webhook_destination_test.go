@@ -0,0 +1,135 @@
+package destination
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	tests := map[string]struct {
+		host            string
+		allowedNetworks []string
+		expectedErr     bool
+	}{
+		"Should allow a public address": {
+			host: "93.184.216.34",
+		},
+		"Should allow a host resolving to public addresses": {
+			host: "public.example.com",
+		},
+		"Should reject a loopback address": {
+			host:        "127.0.0.1",
+			expectedErr: true,
+		},
+		"Should reject an IPv6 loopback address": {
+			host:        "::1",
+			expectedErr: true,
+		},
+		"Should reject a private address": {
+			host:        "10.1.2.3",
+			expectedErr: true,
+		},
+		"Should reject the cloud metadata address": {
+			host:        "169.254.169.254",
+			expectedErr: true,
+		},
+		"Should reject an unspecified address": {
+			host:        "0.0.0.0",
+			expectedErr: true,
+		},
+		"Should reject a host resolving to a private address": {
+			host:        "internal.example.com",
+			expectedErr: true,
+		},
+		"Should reject a host that can't be resolved": {
+			host:        "unknown.example.com",
+			expectedErr: true,
+		},
+		"Should allow a private address of an allowed network": {
+			host:            "10.1.2.3",
+			allowedNetworks: []string{"192.168.0.0/16", "10.0.0.0/8"},
+		},
+		"Should allow a host resolving to addresses of an allowed network": {
+			host:            "internal.example.com",
+			allowedNetworks: []string{"10.0.0.0/8"},
+		},
+		"Should ignore an invalid allowed network": {
+			host:            "10.1.2.3",
+			allowedNetworks: []string{"10.0.0.0"},
+			expectedErr:     true,
+		},
+	}
+
+	originalAllowedNetworksHook := allowedNetworks
+	originalLookupIpHook := lookupIp
+	defer func() {
+		allowedNetworks = originalAllowedNetworksHook
+		lookupIp = originalLookupIpHook
+	}()
+	lookupIp = func(_ context.Context, host string) ([]net.IPAddr, error) {
+		switch host {
+		case "public.example.com":
+			return []net.IPAddr{{IP: net.ParseIP("93.184.216.34")}, {IP: net.ParseIP("2606:2800:220:1:248:1893:25c8:1946")}}, nil
+		case "internal.example.com":
+			return []net.IPAddr{{IP: net.ParseIP("93.184.216.34")}, {IP: net.ParseIP("10.0.0.7")}}, nil
+		default:
+			return nil, errors.New("no such host")
+		}
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			allowedNetworks = func() []string { return test.allowedNetworks }
+
+			err := Check(context.Background(), test.host)
+
+			if (err != nil) != test.expectedErr {
+				t.Errorf("Expected error %v but got %v", test.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestHttpClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if request.URL.Path == "/redirect" {
+			http.Redirect(writer, request, "/hook", http.StatusFound)
+			return
+		}
+		writer.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	originalAllowedNetworksHook := allowedNetworks
+	defer func() {
+		allowedNetworks = originalAllowedNetworksHook
+	}()
+
+	allowedNetworks = func() []string { return nil }
+	if _, err := NewHttpClient().Post(server.URL+"/hook", "application/json", nil); err == nil {
+		t.Errorf("Expected an error delivering to a loopback address")
+	}
+
+	allowedNetworks = func() []string { return []string{"127.0.0.0/8"} }
+	response, err := NewHttpClient().Post(server.URL+"/hook", "application/json", nil)
+	if err != nil {
+		t.Fatalf("Expected no error delivering to an allowed network but got %v", err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusNoContent {
+		t.Errorf("Expected status %d but got %d", http.StatusNoContent, response.StatusCode)
+	}
+
+	response, err = NewHttpClient().Post(server.URL+"/redirect", "application/json", nil)
+	if err != nil {
+		t.Fatalf("Expected no error for a redirect but got %v", err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusFound {
+		t.Errorf("Expected the redirect not to be followed but got status %d", response.StatusCode)
+	}
+}
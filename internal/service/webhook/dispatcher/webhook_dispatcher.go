@@ -0,0 +1,201 @@
+package dispatcher
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/GeneralKenobi/mailman/internal/config"
+	"github.com/GeneralKenobi/mailman/internal/db"
+	"github.com/GeneralKenobi/mailman/internal/db/model"
+	"github.com/GeneralKenobi/mailman/pkg/api/apimodel"
+	"github.com/GeneralKenobi/mailman/pkg/mdctx"
+	"github.com/GeneralKenobi/mailman/pkg/webhook"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type HttpClient interface {
+	Do(request *http.Request) (*http.Response, error)
+}
+
+func New(transactioner db.Transactioner, httpClient HttpClient) *Dispatcher {
+	return &Dispatcher{
+		transactioner: transactioner,
+		httpClient:    httpClient,
+	}
+}
+
+type Dispatcher struct {
+	transactioner db.Transactioner
+	httpClient    HttpClient
+}
+
+// claimedDelivery is a delivery leased by a dispatcher, with the subscription and the event it delivers.
+type claimedDelivery struct {
+	delivery     model.WebhookDelivery
+	subscription model.WebhookSubscription
+	event        model.WebhookEvent
+}
+
+// Dispatch attempts a batch of the tenant's due deliveries and records their outcome. Deliveries answered with a 2xx status succeed,
+// others are retried with exponential backoff until the configured number of attempts is reached. Returns the number of attempted
+// deliveries.
+//
+// The deliveries are claimed in a short transaction and attempted outside of any transaction, so that no connection or lock is held
+// while waiting for receivers. The outcome of each attempt is recorded in its own transaction. If the dispatcher stops before recording
+// an outcome, the delivery is attempted again after its lease expires.
+func (dispatcher *Dispatcher) Dispatch(ctx context.Context, tenantId string) (int, error) {
+	claimedDeliveries, err := dispatcher.claim(ctx, tenantId)
+	if err != nil {
+		return 0, err
+	}
+
+	mdctx.Debugf(ctx, "Attempting %d webhook deliveries", len(claimedDeliveries))
+	for _, claimed := range claimedDeliveries {
+		err = dispatcher.attempt(ctx, claimed)
+		if err != nil {
+			return 0, err
+		}
+	}
+	return len(claimedDeliveries), nil
+}
+
+// claim leases a batch of due deliveries by moving their next attempt time past the time needed to attempt all of them, so that
+// concurrent dispatchers don't attempt them in the meantime.
+func (dispatcher *Dispatcher) claim(ctx context.Context, tenantId string) ([]claimedDelivery, error) {
+	cfg := config.Get().Webhooks
+	return db.InTransactionRetV(ctx, dispatcher.transactioner, func(repository db.Repository) ([]claimedDelivery, error) {
+		now := currentTime().UTC()
+		deliveries, err := repository.FindDueWebhookDeliveriesForUpdate(ctx, tenantId, now, cfg.BatchSize)
+		if err != nil {
+			return nil, fmt.Errorf("error listing due webhook deliveries: %w", err)
+		}
+
+		// Each attempt takes at most the timeout, the lease leaves as much time again for recording outcomes.
+		lease := 2 * time.Duration(len(deliveries)) * time.Duration(cfg.TimeoutSeconds) * time.Second
+		claimedDeliveries := make([]claimedDelivery, len(deliveries))
+		for i, delivery := range deliveries {
+			subscription, err := repository.FindWebhookSubscriptionById(ctx, tenantId, delivery.SubscriptionId)
+			if err != nil {
+				return nil, fmt.Errorf("error finding webhook subscription %d of delivery %d: %w", delivery.SubscriptionId, delivery.Id, err)
+			}
+			event, err := repository.FindWebhookEventById(ctx, tenantId, delivery.EventId)
+			if err != nil {
+				return nil, fmt.Errorf("error finding webhook event %d of delivery %d: %w", delivery.EventId, delivery.Id, err)
+			}
+
+			leased := delivery
+			leased.NextAttemptTime = now.Add(lease)
+			if err = repository.UpdateWebhookDelivery(ctx, leased); err != nil {
+				return nil, fmt.Errorf("error leasing webhook delivery %d: %w", delivery.Id, err)
+			}
+			claimedDeliveries[i] = claimedDelivery{delivery: delivery, subscription: subscription, event: event}
+		}
+		return claimedDeliveries, nil
+	}, db.WithRetries(config.Get().Database.TransactionRetries))
+}
+
+// attempt delivers the event to the subscription and records the result of the attempt.
+func (dispatcher *Dispatcher) attempt(ctx context.Context, claimed claimedDelivery) error {
+	delivery, subscription, event := claimed.delivery, claimed.subscription, claimed.event
+	responseCode, deliveryErr := dispatcher.post(ctx, subscription, event, delivery.Id)
+
+	cfg := config.Get().Webhooks
+	now := currentTime().UTC()
+	delivery.AttemptCount++
+	delivery.LastAttemptTime = &now
+	delivery.LastResponseCode = responseCode
+	switch {
+	case deliveryErr == nil:
+		mdctx.Infof(ctx, "Delivered webhook event %d to subscription %d", event.Id, subscription.Id)
+		delivery.Status = model.WebhookDeliveryStatusSucceeded
+		delivery.LastError = nil
+	case delivery.AttemptCount >= cfg.MaxAttempts:
+		mdctx.Warnf(ctx, "Giving up delivering webhook event %d to subscription %d after %d attempts: %v",
+			event.Id, subscription.Id, delivery.AttemptCount, deliveryErr)
+		delivery.Status = model.WebhookDeliveryStatusFailed
+		delivery.LastError = errorMessage(deliveryErr)
+	default:
+		delivery.NextAttemptTime = now.Add(backoff(delivery.AttemptCount))
+		mdctx.Warnf(ctx, "Error delivering webhook event %d to subscription %d, retrying at %v: %v",
+			event.Id, subscription.Id, delivery.NextAttemptTime, deliveryErr)
+		delivery.LastError = errorMessage(deliveryErr)
+	}
+
+	err := db.InTransaction(ctx, dispatcher.transactioner, func(repository db.Repository) error {
+		return repository.UpdateWebhookDelivery(ctx, delivery)
+	}, db.WithRetries(config.Get().Database.TransactionRetries))
+	if err != nil {
+		return fmt.Errorf("error recording attempt of webhook delivery %d: %w", delivery.Id, err)
+	}
+	return nil
+}
+
+// post sends the event to the subscription's URL. It returns the response status, if a response was received, and an error if the
+// event wasn't accepted.
+func (dispatcher *Dispatcher) post(ctx context.Context, subscription model.WebhookSubscription, event model.WebhookEvent, deliveryId int) (*int, error) {
+	body, err := json.Marshal(apimodel.WebhookEvent{
+		Id:         event.Id,
+		Type:       event.EventType,
+		CreateTime: event.CreateTime,
+		Data:       json.RawMessage(event.Payload),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error encoding event: %w", err)
+	}
+
+	timeout := time.Duration(config.Get().Webhooks.TimeoutSeconds) * time.Second
+	requestCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	request, err := http.NewRequestWithContext(requestCtx, http.MethodPost, subscription.Url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(webhook.EventTypeHeader, event.EventType)
+	request.Header.Set(webhook.DeliveryIdHeader, strconv.Itoa(deliveryId))
+	request.Header.Set(webhook.SignatureHeader, webhook.Sign(subscription.Secret, currentTime(), body))
+
+	response, err := dispatcher.httpClient.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("error sending request: %w", err)
+	}
+	defer response.Body.Close()
+	// Drain (a limited part of) the body so that the connection can be reused.
+	_, _ = io.Copy(io.Discard, io.LimitReader(response.Body, 64*1024))
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return &response.StatusCode, fmt.Errorf("response status %d", response.StatusCode)
+	}
+	return &response.StatusCode, nil
+}
+
+// backoff returns the delay before the next attempt after attemptCount attempts - the minimum backoff doubled with every attempt, capped at
+// the maximum backoff, with up to 10% jitter so that retries of many deliveries are spread out.
+func backoff(attemptCount int) time.Duration {
+	cfg := config.Get().Webhooks
+	delay := time.Duration(cfg.MinBackoffSeconds) * time.Second
+	maxDelay := time.Duration(cfg.MaxBackoffSeconds) * time.Second
+	for i := 1; i < attemptCount && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+	return delay + time.Duration(jitter()*0.1*float64(delay))
+}
+
+func errorMessage(err error) *string {
+	message := err.Error()
+	return &message
+}
+
+// Hooks for mocking in unit tests.
+var (
+	currentTime = time.Now
+	jitter      = rand.Float64
+)
@@ -0,0 +1,203 @@
+package dispatcher
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/GeneralKenobi/mailman/internal/db"
+	"github.com/GeneralKenobi/mailman/internal/db/memory"
+	"github.com/GeneralKenobi/mailman/internal/db/model"
+	"github.com/GeneralKenobi/mailman/internal/service/webhook/publisher"
+	"github.com/GeneralKenobi/mailman/pkg/api/apimodel"
+	"github.com/GeneralKenobi/mailman/pkg/webhook"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestDispatch(t *testing.T) {
+	tests := map[string]struct {
+		responseStatuses     []int
+		expectedStatus       string
+		expectedAttemptCount int
+	}{
+		"Should deliver event accepted by the receiver": {
+			responseStatuses:     []int{http.StatusOK},
+			expectedStatus:       model.WebhookDeliveryStatusSucceeded,
+			expectedAttemptCount: 1,
+		},
+		"Should retry delivery until the receiver accepts the event": {
+			responseStatuses:     []int{http.StatusInternalServerError, http.StatusServiceUnavailable, http.StatusNoContent},
+			expectedStatus:       model.WebhookDeliveryStatusSucceeded,
+			expectedAttemptCount: 3,
+		},
+		"Should give up delivery after the maximum number of attempts": {
+			responseStatuses: []int{
+				http.StatusInternalServerError, http.StatusInternalServerError, http.StatusInternalServerError, http.StatusBadRequest,
+				http.StatusInternalServerError, http.StatusInternalServerError, http.StatusInternalServerError, http.StatusNotFound,
+			},
+			expectedStatus:       model.WebhookDeliveryStatusFailed,
+			expectedAttemptCount: 8,
+		},
+	}
+
+	originalCurrentTimeHook := currentTime
+	originalJitterHook := jitter
+	defer func() {
+		currentTime = originalCurrentTimeHook
+		jitter = originalJitterHook
+	}()
+	jitter = func() float64 {
+		return 0
+	}
+
+	for title, test := range tests {
+		t.Run(title, func(t *testing.T) {
+			var now time.Time
+			currentTime = func() time.Time {
+				return now
+			}
+
+			var receivedEvents []apimodel.WebhookEvent
+			receiver := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+				body, err := io.ReadAll(request.Body)
+				if err != nil {
+					t.Fatalf("Error reading request body: %v", err)
+				}
+				err = webhook.Verify("0123456789abcdef", request.Header.Get(webhook.SignatureHeader), body, now, time.Minute)
+				if err != nil {
+					t.Errorf("Expected valid signature but got %v", err)
+				}
+				var event apimodel.WebhookEvent
+				err = json.Unmarshal(body, &event)
+				if err != nil {
+					t.Fatalf("Error decoding event: %v", err)
+				}
+				receivedEvents = append(receivedEvents, event)
+				writer.WriteHeader(test.responseStatuses[len(receivedEvents)-1])
+			}))
+			defer receiver.Close()
+
+			dbCtx := memory.New()
+			subscription := publishEvent(t, dbCtx, receiver.URL)
+			// The publisher uses the real time, the event is due right after it's published.
+			now = time.Now()
+
+			for attempt := 1; attempt <= test.expectedAttemptCount; attempt++ {
+				if attemptedCount := dispatch(t, dbCtx, receiver.Client()); attemptedCount != 1 {
+					t.Fatalf("Expected 1 delivery to be attempted in attempt %d but got %d", attempt, attemptedCount)
+				}
+				// Retries aren't due before the backoff elapses.
+				if attemptedCount := dispatch(t, dbCtx, receiver.Client()); attemptedCount != 0 {
+					t.Fatalf("Expected no deliveries to be attempted right after attempt %d but got %d", attempt, attemptedCount)
+				}
+				now = now.Add(backoff(attempt))
+			}
+			if attemptedCount := dispatch(t, dbCtx, receiver.Client()); attemptedCount != 0 {
+				t.Fatalf("Expected no deliveries to be attempted after the last attempt but got %d", attemptedCount)
+			}
+
+			if len(receivedEvents) != test.expectedAttemptCount {
+				t.Fatalf("Expected %d requests but got %d", test.expectedAttemptCount, len(receivedEvents))
+			}
+			for _, event := range receivedEvents {
+				if event.Type != apimodel.WebhookEventMailingEntrySent || string(event.Data) != `{"mailing_entry_id":1,"mailing_id":2,"customer_id":3}` {
+					t.Errorf("Unexpected event %s with data %s", event.Type, event.Data)
+				}
+			}
+
+			deliveries := findDeliveries(t, dbCtx, subscription.Id)
+			if len(deliveries) != 1 {
+				t.Fatalf("Expected 1 delivery but got %d", len(deliveries))
+			}
+			delivery := deliveries[0]
+			if delivery.Status != test.expectedStatus || delivery.AttemptCount != test.expectedAttemptCount {
+				t.Errorf("Expected delivery %s after %d attempts but got %s after %d attempts",
+					test.expectedStatus, test.expectedAttemptCount, delivery.Status, delivery.AttemptCount)
+			}
+			lastResponseStatus := test.responseStatuses[len(test.responseStatuses)-1]
+			if delivery.LastResponseCode == nil || *delivery.LastResponseCode != lastResponseStatus {
+				t.Errorf("Expected last response code %d but got %v", lastResponseStatus, delivery.LastResponseCode)
+			}
+		})
+	}
+}
+
+func TestDispatchShouldLeaseDeliveriesWhileAttemptingThem(t *testing.T) {
+	originalCurrentTimeHook := currentTime
+	defer func() {
+		currentTime = originalCurrentTimeHook
+	}()
+	now := time.Now()
+	currentTime = func() time.Time {
+		return now
+	}
+
+	dbCtx := memory.New()
+	requestCount := 0
+	var concurrentAttemptedCount int
+	receiver := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		requestCount++
+		// No transaction is open while the request is sent, another dispatcher skips the leased delivery
+		concurrentAttemptedCount = dispatch(t, dbCtx, http.DefaultClient)
+		writer.WriteHeader(http.StatusOK)
+	}))
+	defer receiver.Close()
+	subscription := publishEvent(t, dbCtx, receiver.URL)
+	now = time.Now()
+
+	if attemptedCount := dispatch(t, dbCtx, receiver.Client()); attemptedCount != 1 {
+		t.Fatalf("Expected 1 delivery to be attempted but got %d", attemptedCount)
+	}
+	if requestCount != 1 || concurrentAttemptedCount != 0 {
+		t.Errorf("Expected 1 request and no concurrent attempts but got %d requests and %d concurrent attempts",
+			requestCount, concurrentAttemptedCount)
+	}
+	deliveries := findDeliveries(t, dbCtx, subscription.Id)
+	if len(deliveries) != 1 || deliveries[0].Status != model.WebhookDeliveryStatusSucceeded {
+		t.Errorf("Expected a succeeded delivery but got %+v", deliveries)
+	}
+}
+
+func publishEvent(t *testing.T, dbCtx *memory.Context, url string) model.WebhookSubscription {
+	ctx := context.Background()
+	subscription, err := db.InTransactionRetV(ctx, dbCtx, func(repository db.Repository) (model.WebhookSubscription, error) {
+		subscription, err := repository.InsertWebhookSubscription(ctx, model.WebhookSubscription{
+			TenantId:   "tenant-1",
+			Url:        url,
+			EventTypes: []string{apimodel.WebhookEventMailingEntrySent},
+			Secret:     "0123456789abcdef",
+		})
+		if err != nil {
+			return model.WebhookSubscription{}, err
+		}
+		eventPublisher := publisher.New(repository)
+		err = eventPublisher.Publish(ctx, "tenant-1", apimodel.WebhookEventMailingEntrySent,
+			apimodel.WebhookMailingEntryEventData{MailingEntryId: 1, MailingId: 2, CustomerId: 3})
+		return subscription, err
+	})
+	if err != nil {
+		t.Fatalf("Error publishing event: %v", err)
+	}
+	return subscription
+}
+
+func dispatch(t *testing.T, dbCtx *memory.Context, httpClient HttpClient) int {
+	attemptedCount, err := New(dbCtx, httpClient).Dispatch(context.Background(), "tenant-1")
+	if err != nil {
+		t.Fatalf("Error dispatching: %v", err)
+	}
+	return attemptedCount
+}
+
+func findDeliveries(t *testing.T, dbCtx *memory.Context, subscriptionId int) []model.WebhookDelivery {
+	ctx := context.Background()
+	deliveries, err := db.InTransactionRetV(ctx, dbCtx, func(repository db.Repository) ([]model.WebhookDelivery, error) {
+		return repository.FindWebhookDeliveriesBySubscriptionId(ctx, "tenant-1", subscriptionId, 10)
+	})
+	if err != nil {
+		t.Fatalf("Error finding deliveries: %v", err)
+	}
+	return deliveries
+}
@@ -0,0 +1,42 @@
+package eventremover
+
+import (
+	"context"
+	"fmt"
+	"github.com/GeneralKenobi/mailman/internal/config"
+	"github.com/GeneralKenobi/mailman/pkg/mdctx"
+	"time"
+)
+
+type Repository interface {
+	DeleteFinishedWebhookEventsCreatedBefore(ctx context.Context, tenantId string, createdBefore time.Time) (int64, error)
+}
+
+func New(repository Repository) *OldEventRemover {
+	return &OldEventRemover{repository: repository}
+}
+
+type OldEventRemover struct {
+	repository Repository
+}
+
+// Remove removes the tenant's webhook events older than the retention period, together with their delivery log. Events that still have
+// pending deliveries are kept.
+func (remover *OldEventRemover) Remove(ctx context.Context, tenantId string) error {
+	createdBefore := currentTime().UTC().Add(-retention())
+	removedCount, err := remover.repository.DeleteFinishedWebhookEventsCreatedBefore(ctx, tenantId, createdBefore)
+	if err != nil {
+		return fmt.Errorf("error removing webhook events created before %v: %w", createdBefore, err)
+	}
+
+	mdctx.Infof(ctx, "Removed %d webhook events created before %v", removedCount, createdBefore)
+	return nil
+}
+
+// Hook for mocking in unit tests.
+var retention = func() time.Duration {
+	return time.Duration(config.Get().Webhooks.RetentionSeconds) * time.Second
+}
+
+// Hook for mocking in unit tests.
+var currentTime = time.Now
@@ -0,0 +1,54 @@
+package finder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/GeneralKenobi/mailman/internal/api"
+	"github.com/GeneralKenobi/mailman/internal/db"
+	"github.com/GeneralKenobi/mailman/internal/db/model"
+)
+
+type Repository interface {
+	FindWebhookSubscriptions(ctx context.Context, tenantId string) ([]model.WebhookSubscription, error)
+	FindWebhookSubscriptionById(ctx context.Context, tenantId string, id int) (model.WebhookSubscription, error)
+	FindWebhookDeliveriesBySubscriptionId(ctx context.Context, tenantId string, subscriptionId, limit int) ([]model.WebhookDelivery, error)
+}
+
+func New(repository Repository) *Finder {
+	return &Finder{repository: repository}
+}
+
+type Finder struct {
+	repository Repository
+}
+
+// deliveryLogLimit is the maximum number of deliveries returned by FindDeliveries.
+const deliveryLogLimit = 100
+
+// FindAll returns the tenant's webhook subscriptions.
+func (finder *Finder) FindAll(ctx context.Context, tenantId string) ([]model.WebhookSubscription, error) {
+	subscriptions, err := finder.repository.FindWebhookSubscriptions(ctx, tenantId)
+	if err != nil {
+		return nil, fmt.Errorf("error finding webhook subscriptions: %w", err)
+	}
+	return subscriptions, nil
+}
+
+// FindDeliveries returns the most recent deliveries to the tenant's webhook subscription, newest first. Returns api.StatusNotFound error if
+// the tenant doesn't have a subscription with the ID.
+func (finder *Finder) FindDeliveries(ctx context.Context, tenantId string, subscriptionId int) ([]model.WebhookDelivery, error) {
+	_, err := finder.repository.FindWebhookSubscriptionById(ctx, tenantId, subscriptionId)
+	if err != nil {
+		if errors.Is(err, db.ErrNoRows) {
+			return nil, api.StatusNotFound.WithMessageAndCause(err, "webhook subscription with ID %d doesn't exist", subscriptionId)
+		}
+		return nil, fmt.Errorf("error finding webhook subscription %d: %w", subscriptionId, err)
+	}
+
+	deliveries, err := finder.repository.FindWebhookDeliveriesBySubscriptionId(ctx, tenantId, subscriptionId, deliveryLogLimit)
+	if err != nil {
+		return nil, fmt.Errorf("error finding deliveries to webhook subscription %d: %w", subscriptionId, err)
+	}
+	return deliveries, nil
+}
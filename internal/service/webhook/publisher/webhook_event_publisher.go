@@ -0,0 +1,101 @@
+package publisher
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/GeneralKenobi/mailman/internal/db/model"
+	"github.com/GeneralKenobi/mailman/pkg/mdctx"
+	"time"
+)
+
+type Repository interface {
+	FindWebhookSubscriptions(ctx context.Context, tenantId string) ([]model.WebhookSubscription, error)
+	InsertWebhookEvent(ctx context.Context, event model.WebhookEvent) (model.WebhookEvent, error)
+	InsertWebhookDelivery(ctx context.Context, delivery model.WebhookDelivery) (model.WebhookDelivery, error)
+}
+
+// New creates a publisher that records events in the outbox using the repository. It's meant to be used within a single transaction -
+// subscriptions are loaded once and reused for every event.
+func New(repository Repository) *EventPublisher {
+	return &EventPublisher{
+		repository:    repository,
+		subscriptions: make(map[string][]model.WebhookSubscription),
+	}
+}
+
+type EventPublisher struct {
+	repository    Repository
+	subscriptions map[string][]model.WebhookSubscription // Keyed by tenant ID
+}
+
+// Publish records the event in the outbox with a pending delivery for every subscription of the tenant to the event type. It has to be
+// called in the transaction making the change described by the event, so that the event is delivered only if the change is committed.
+// Nothing is recorded if no subscription wants the event.
+func (publisher *EventPublisher) Publish(ctx context.Context, tenantId, eventType string, data any) error {
+	subscriptions, err := publisher.subscriptionsTo(ctx, tenantId, eventType)
+	if err != nil {
+		return err
+	}
+	if len(subscriptions) == 0 {
+		return nil
+	}
+
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("error encoding %s event: %w", eventType, err)
+	}
+	now := currentTime().UTC()
+	event := model.WebhookEvent{
+		TenantId:   tenantId,
+		EventType:  eventType,
+		Payload:    string(payload),
+		CreateTime: now,
+	}
+	event, err = publisher.repository.InsertWebhookEvent(ctx, event)
+	if err != nil {
+		return fmt.Errorf("error recording %s event: %w", eventType, err)
+	}
+
+	mdctx.Debugf(ctx, "Recorded %s event %d for %d webhook subscriptions", eventType, event.Id, len(subscriptions))
+	for _, subscription := range subscriptions {
+		delivery := model.WebhookDelivery{
+			TenantId:        tenantId,
+			SubscriptionId:  subscription.Id,
+			EventId:         event.Id,
+			Status:          model.WebhookDeliveryStatusPending,
+			NextAttemptTime: now,
+		}
+		_, err = publisher.repository.InsertWebhookDelivery(ctx, delivery)
+		if err != nil {
+			return fmt.Errorf("error recording delivery of event %d to webhook subscription %d: %w", event.Id, subscription.Id, err)
+		}
+	}
+	return nil
+}
+
+func (publisher *EventPublisher) subscriptionsTo(ctx context.Context, tenantId, eventType string) ([]model.WebhookSubscription, error) {
+	tenantSubscriptions, loaded := publisher.subscriptions[tenantId]
+	if !loaded {
+		var err error
+		tenantSubscriptions, err = publisher.repository.FindWebhookSubscriptions(ctx, tenantId)
+		if err != nil {
+			return nil, fmt.Errorf("error listing webhook subscriptions: %w", err)
+		}
+		publisher.subscriptions[tenantId] = tenantSubscriptions
+	}
+
+	var result []model.WebhookSubscription
+	for _, subscription := range tenantSubscriptions {
+		for _, subscribedEventType := range subscription.EventTypes {
+			if subscribedEventType == eventType {
+				result = append(result, subscription)
+				break
+			}
+		}
+	}
+	return result, nil
+}
+
+// Hook for mocking in unit tests.
+var currentTime = time.Now
@@ -0,0 +1,32 @@
+package remover
+
+import (
+	"context"
+	"errors"
+	"github.com/GeneralKenobi/mailman/internal/api"
+	"github.com/GeneralKenobi/mailman/internal/db"
+	"github.com/GeneralKenobi/mailman/pkg/mdctx"
+)
+
+type Repository interface {
+	DeleteWebhookSubscriptionById(ctx context.Context, tenantId string, id int) error
+}
+
+func New(repository Repository) *Remover {
+	return &Remover{repository: repository}
+}
+
+type Remover struct {
+	repository Repository
+}
+
+// Remove deletes the tenant's webhook subscription together with its pending deliveries and delivery log. Returns api.StatusNotFound error
+// if the tenant doesn't have a subscription with the ID.
+func (remover *Remover) Remove(ctx context.Context, tenantId string, id int) error {
+	mdctx.Infof(ctx, "Deleting webhook subscription %d", id)
+	err := remover.repository.DeleteWebhookSubscriptionById(ctx, tenantId, id)
+	if err != nil && errors.Is(err, db.ErrNoRows) {
+		return api.StatusNotFound.WithMessageAndCause(err, "webhook subscription with ID %d doesn't exist", id)
+	}
+	return err
+}
@@ -0,0 +1,12 @@
+package apimodel
+
+// Customer defines a customer to create.
+type Customer struct {
+	Email string `json:"email" validate:"required,email"` // Email address of the customer
+}
+
+// CustomerDetails describes an existing customer.
+type CustomerDetails struct {
+	Id    int    `json:"id"`    // ID of the customer
+	Email string `json:"email"` // Email address of the customer
+}
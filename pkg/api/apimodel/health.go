@@ -0,0 +1,14 @@
+package apimodel
+
+// Health describes whether the application is able to serve requests.
+type Health struct {
+	Status       string                      `json:"status"`                 // up or down
+	Dependencies map[string]DependencyHealth `json:"dependencies,omitempty"` // Dependencies keyed by name: db, dbPool and email
+}
+
+// DependencyHealth describes whether a dependency of the application is available.
+type DependencyHealth struct {
+	Status  string         `json:"status"`            // up or down
+	Error   string         `json:"error,omitempty"`   // Why the dependency is down
+	Details map[string]any `json:"details,omitempty"` // State of the dependency, e.g. connection pool statistics
+}
@@ -4,11 +4,11 @@ import "time"
 
 // MailingEntry defines a mailing entry to create.
 type MailingEntry struct {
-	MailingId  int       `json:"mailing_id" validate:"required"`            // ID of the mailing list
-	Email      string    `json:"email,omitempty" validate:"required,email"` // Email address of the recipient
-	Title      string    `json:"title,omitempty" validate:"required"`       // Message title
-	Content    string    `json:"content,omitempty" validate:"required"`     // Message con
-	InsertTime time.Time `json:"insert_time,omitempty" validate:"required"` // Message creation time
+	MailingId  int       `json:"mailing_id" validate:"required"`                         // ID of the mailing list
+	Email      string    `json:"email,omitempty" validate:"required,email"`              // Email address of the recipient
+	Title      string    `json:"title,omitempty" validate:"required,max=255"`            // Message title
+	Content    string    `json:"content,omitempty" validate:"required" sensitive:"true"` // Message con
+	InsertTime time.Time `json:"insert_time,omitempty" validate:"required"`              // Message creation time
 }
 
 // MailingEntryCreated is returned after successfully creating a mailing entry from a MailingEntry.
@@ -20,3 +20,78 @@ type MailingEntryCreated struct {
 type MailingRequest struct {
 	MailingId int `json:"mailing_id" validate:"required"` // ID of the mailing list
 }
+
+// MailingEntryBatch defines mailing entries to create at once. Either all of them are created or none.
+type MailingEntryBatch struct {
+	Entries []MailingEntry `json:"entries" validate:"required,min=1,max=100,dive"` // Mailing entries to create
+}
+
+// MailingEntryBatchCreated is returned after successfully creating mailing entries from a MailingEntryBatch.
+type MailingEntryBatchCreated struct {
+	Ids []int `json:"ids"` // IDs of the created entries in the order of MailingEntryBatch.Entries
+}
+
+// MailingEntryDetails describes an existing mailing entry.
+type MailingEntryDetails struct {
+	Id         int       `json:"id"`          // ID of the mailing entry
+	CustomerId int       `json:"customer_id"` // ID of the recipient
+	MailingId  int       `json:"mailing_id"`  // ID of the mailing list
+	Title      string    `json:"title"`       // Message title
+	Content    string    `json:"content"`     // Message content
+	InsertTime time.Time `json:"insert_time"` // Message creation time
+	Version    int       `json:"version"`     // Version of the mailing entry, also returned as its ETag
+}
+
+// MailingEntryUpdate defines changes to a mailing entry that wasn't sent yet. Only the set fields are changed, title and content
+// can't be changed to empty strings.
+type MailingEntryUpdate struct {
+	MailingId *int    `json:"mailing_id,omitempty" validate:"omitempty,ne=0"`     // ID of the mailing list
+	Email     *string `json:"email,omitempty" validate:"omitempty,email"`         // Email address of the recipient
+	Title     *string `json:"title,omitempty" validate:"omitempty,min=1,max=255"` // Message title
+	Content   *string `json:"content,omitempty" sensitive:"true"`                 // Message content
+}
+
+// MailingEntriesDeleted is returned after deleting mailing entries in bulk.
+type MailingEntriesDeleted struct {
+	DeletedCount int  `json:"deleted_count"` // Number of deleted mailing entries, or of entries that would be deleted in a dry run
+	DryRun       bool `json:"dry_run"`       // True if nothing was deleted because it was a dry run
+}
+
+// MailingEntryList is a list of mailing entries matching a query.
+type MailingEntryList struct {
+	Entries []MailingEntryDetails `json:"entries"`
+}
+
+// ArchivedMailingEntryDetails describes a mailing entry moved to the archive after it was sent, bounced or became stale.
+type ArchivedMailingEntryDetails struct {
+	Id            int       `json:"id"`             // ID the mailing entry had before it was archived
+	CustomerId    int       `json:"customer_id"`    // ID of the recipient, the customer may have been deleted since
+	CustomerEmail string    `json:"customer_email"` // Email of the recipient when the entry was archived
+	MailingId     int       `json:"mailing_id"`     // ID of the mailing list
+	Title         string    `json:"title"`          // Message title
+	Content       string    `json:"content"`        // Message content
+	InsertTime    time.Time `json:"insert_time"`    // Message creation time
+	ArchiveTime   time.Time `json:"archive_time"`   // Time the entry was archived
+	Reason        string    `json:"reason"`         // Why the entry was archived: sent, bounced or stale
+}
+
+// ArchivedMailingEntryList is a list of archived mailing entries matching a query, most recently archived first.
+type ArchivedMailingEntryList struct {
+	Entries []ArchivedMailingEntryDetails `json:"entries"`
+}
+
+// Mailing summarizes the mailing entries waiting to be sent with a mailing ID.
+type Mailing struct {
+	Id            int `json:"id"`             // ID of the mailing list
+	EntryCount    int `json:"entry_count"`    // Number of mailing entries
+	CustomerCount int `json:"customer_count"` // Number of distinct recipients
+}
+
+// MailingProgress counts mailing entries with a mailing ID sent since the progress stream was opened.
+type MailingProgress struct {
+	MailingId    int `json:"mailing_id"`    // ID of the mailing list
+	SentCount    int `json:"sent_count"`    // Number of sent mailing entries
+	FailedCount  int `json:"failed_count"`  // Number of mailing entries that failed to be sent and were kept
+	BouncedCount int `json:"bounced_count"` // Number of mailing entries that bounced and were archived
+	PendingCount int `json:"pending_count"` // Number of mailing entries waiting to be sent
+}
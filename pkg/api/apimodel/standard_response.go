@@ -6,3 +6,17 @@ type Error struct {
 	Message     string `json:"message,omitempty"`     // Message describing the problem
 	OperationId string `json:"operationId,omitempty"` // ID for identifying relevant logs
 }
+
+// ValidationError models an error response to a request with invalid fields.
+type ValidationError struct {
+	Error
+	Fields []FieldError `json:"fields"` // Invalid fields of the request
+}
+
+// FieldError describes a field of a request that failed validation.
+type FieldError struct {
+	Path          string `json:"path"`                    // Path of the field in the request body, e.g. "entries[0].email"
+	Rule          string `json:"rule"`                    // Validation rule the field doesn't satisfy, e.g. "email"
+	Message       string `json:"message"`                 // Description of the problem in the language requested with Accept-Language
+	RejectedValue any    `json:"rejectedValue,omitempty"` // Value of the field, left out for sensitive fields, long strings are truncated
+}
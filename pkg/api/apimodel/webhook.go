@@ -0,0 +1,86 @@
+package apimodel
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Types of events delivered to webhook subscriptions.
+const (
+	WebhookEventMailingEntrySent    = "mailing_entry.sent"    // Data is WebhookMailingEntryEventData
+	WebhookEventMailingEntryFailed  = "mailing_entry.failed"  // Data is WebhookMailingEntryEventData, the entry is kept for sending again
+	WebhookEventMailingEntryBounced = "mailing_entry.bounced" // Data is WebhookMailingEntryEventData, the entry is archived
+	WebhookEventMailingCompleted    = "mailing.completed"     // Data is WebhookMailingCompletedEventData
+)
+
+// WebhookEventTypes lists every type of webhook events.
+var WebhookEventTypes = []string{
+	WebhookEventMailingEntrySent,
+	WebhookEventMailingEntryFailed,
+	WebhookEventMailingEntryBounced,
+	WebhookEventMailingCompleted,
+}
+
+// WebhookSubscription defines a webhook subscription to create.
+type WebhookSubscription struct {
+	// URL receiving events in POST requests
+	Url string `json:"url" validate:"required,url,max=2048"`
+	// Types of events to receive
+	EventTypes []string `json:"event_types" validate:"required,min=1,dive,oneof=mailing_entry.sent mailing_entry.failed mailing_entry.bounced mailing.completed"`
+	// Key for signing the events, at least 16 characters
+	Secret string `json:"secret" validate:"required,min=16,max=255" sensitive:"true"`
+}
+
+// WebhookSubscriptionDetails describes an existing webhook subscription. The secret isn't returned.
+type WebhookSubscriptionDetails struct {
+	Id         int       `json:"id"`          // ID of the subscription
+	Url        string    `json:"url"`         // URL receiving events in POST requests
+	EventTypes []string  `json:"event_types"` // Types of events to receive
+	CreateTime time.Time `json:"create_time"` // Subscription creation time
+}
+
+// WebhookSubscriptionList is a list of webhook subscriptions.
+type WebhookSubscriptionList struct {
+	Subscriptions []WebhookSubscriptionDetails `json:"subscriptions"`
+}
+
+// WebhookDeliveryDetails describes delivering an event to a webhook subscription.
+type WebhookDeliveryDetails struct {
+	Id               int        `json:"id"`                           // ID of the delivery, sent in the X-Mailman-Delivery header
+	EventId          int        `json:"event_id"`                     // ID of the delivered event
+	Status           string     `json:"status"`                       // pending, succeeded or failed (won't be retried)
+	AttemptCount     int        `json:"attempt_count"`                // Number of attempts made so far
+	NextAttemptTime  *time.Time `json:"next_attempt_time,omitempty"`  // When the next attempt is due if the delivery is pending
+	LastAttemptTime  *time.Time `json:"last_attempt_time,omitempty"`  // When the last attempt was made
+	LastResponseCode *int       `json:"last_response_code,omitempty"` // HTTP status of the last response
+	LastError        *string    `json:"last_error,omitempty"`         // Reason of the last failed attempt
+}
+
+// WebhookDeliveryList is a list of the most recent deliveries to a webhook subscription, newest first.
+type WebhookDeliveryList struct {
+	Deliveries []WebhookDeliveryDetails `json:"deliveries"`
+}
+
+// WebhookEvent is the body of requests sent to webhook subscriptions.
+type WebhookEvent struct {
+	Id         int             `json:"id"`          // ID of the event, the same for every subscription
+	Type       string          `json:"type"`        // Type of the event, determines the type of Data
+	CreateTime time.Time       `json:"create_time"` // When the event occurred
+	Data       json.RawMessage `json:"data"`        // Event details
+}
+
+// WebhookMailingEntryEventData describes a mailing entry in mailing_entry.* events.
+type WebhookMailingEntryEventData struct {
+	MailingEntryId int    `json:"mailing_entry_id"` // ID of the mailing entry
+	MailingId      int    `json:"mailing_id"`       // ID of the mailing list
+	CustomerId     int    `json:"customer_id"`      // ID of the recipient
+	Error          string `json:"error,omitempty"`  // Reason of failure or bounce
+}
+
+// WebhookMailingCompletedEventData summarizes sending mailing entries with a mailing ID in mailing.completed events.
+type WebhookMailingCompletedEventData struct {
+	MailingId    int `json:"mailing_id"`    // ID of the mailing list
+	SentCount    int `json:"sent_count"`    // Number of sent mailing entries
+	FailedCount  int `json:"failed_count"`  // Number of mailing entries that failed to be sent and were kept
+	BouncedCount int `json:"bounced_count"` // Number of mailing entries that bounced and were archived
+}
@@ -0,0 +1,944 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.31.0
+// 	protoc        (unknown)
+// source: mailman/v1/mailing_entry_service.proto
+
+package mailmanpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// MailingEntry defines a mailing entry to create. All fields are required.
+type MailingEntry struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	MailingId  int32                  `protobuf:"varint,1,opt,name=mailing_id,json=mailingId,proto3" json:"mailing_id,omitempty"`   // ID of the mailing list
+	Email      string                 `protobuf:"bytes,2,opt,name=email,proto3" json:"email,omitempty"`                             // Email address of the recipient
+	Title      string                 `protobuf:"bytes,3,opt,name=title,proto3" json:"title,omitempty"`                             // Message title
+	Content    string                 `protobuf:"bytes,4,opt,name=content,proto3" json:"content,omitempty"`                         // Message content
+	InsertTime *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=insert_time,json=insertTime,proto3" json:"insert_time,omitempty"` // Message creation time
+}
+
+func (x *MailingEntry) Reset() {
+	*x = MailingEntry{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_mailman_v1_mailing_entry_service_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *MailingEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MailingEntry) ProtoMessage() {}
+
+func (x *MailingEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_mailman_v1_mailing_entry_service_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MailingEntry.ProtoReflect.Descriptor instead.
+func (*MailingEntry) Descriptor() ([]byte, []int) {
+	return file_mailman_v1_mailing_entry_service_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *MailingEntry) GetMailingId() int32 {
+	if x != nil {
+		return x.MailingId
+	}
+	return 0
+}
+
+func (x *MailingEntry) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+func (x *MailingEntry) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *MailingEntry) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+func (x *MailingEntry) GetInsertTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.InsertTime
+	}
+	return nil
+}
+
+type CreateMailingEntryRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Entry *MailingEntry `protobuf:"bytes,1,opt,name=entry,proto3" json:"entry,omitempty"`
+}
+
+func (x *CreateMailingEntryRequest) Reset() {
+	*x = CreateMailingEntryRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_mailman_v1_mailing_entry_service_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CreateMailingEntryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateMailingEntryRequest) ProtoMessage() {}
+
+func (x *CreateMailingEntryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_mailman_v1_mailing_entry_service_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateMailingEntryRequest.ProtoReflect.Descriptor instead.
+func (*CreateMailingEntryRequest) Descriptor() ([]byte, []int) {
+	return file_mailman_v1_mailing_entry_service_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *CreateMailingEntryRequest) GetEntry() *MailingEntry {
+	if x != nil {
+		return x.Entry
+	}
+	return nil
+}
+
+type CreateMailingEntryResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id int32 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"` // ID of the created entry
+}
+
+func (x *CreateMailingEntryResponse) Reset() {
+	*x = CreateMailingEntryResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_mailman_v1_mailing_entry_service_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CreateMailingEntryResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateMailingEntryResponse) ProtoMessage() {}
+
+func (x *CreateMailingEntryResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_mailman_v1_mailing_entry_service_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateMailingEntryResponse.ProtoReflect.Descriptor instead.
+func (*CreateMailingEntryResponse) Descriptor() ([]byte, []int) {
+	return file_mailman_v1_mailing_entry_service_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *CreateMailingEntryResponse) GetId() int32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type CreateMailingEntriesRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Entries []*MailingEntry `protobuf:"bytes,1,rep,name=entries,proto3" json:"entries,omitempty"` // 1 to 100 entries
+}
+
+func (x *CreateMailingEntriesRequest) Reset() {
+	*x = CreateMailingEntriesRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_mailman_v1_mailing_entry_service_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CreateMailingEntriesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateMailingEntriesRequest) ProtoMessage() {}
+
+func (x *CreateMailingEntriesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_mailman_v1_mailing_entry_service_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateMailingEntriesRequest.ProtoReflect.Descriptor instead.
+func (*CreateMailingEntriesRequest) Descriptor() ([]byte, []int) {
+	return file_mailman_v1_mailing_entry_service_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *CreateMailingEntriesRequest) GetEntries() []*MailingEntry {
+	if x != nil {
+		return x.Entries
+	}
+	return nil
+}
+
+type CreateMailingEntriesResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Ids []int32 `protobuf:"varint,1,rep,packed,name=ids,proto3" json:"ids,omitempty"` // IDs of the created entries in the order of the request's entries
+}
+
+func (x *CreateMailingEntriesResponse) Reset() {
+	*x = CreateMailingEntriesResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_mailman_v1_mailing_entry_service_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CreateMailingEntriesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateMailingEntriesResponse) ProtoMessage() {}
+
+func (x *CreateMailingEntriesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_mailman_v1_mailing_entry_service_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateMailingEntriesResponse.ProtoReflect.Descriptor instead.
+func (*CreateMailingEntriesResponse) Descriptor() ([]byte, []int) {
+	return file_mailman_v1_mailing_entry_service_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *CreateMailingEntriesResponse) GetIds() []int32 {
+	if x != nil {
+		return x.Ids
+	}
+	return nil
+}
+
+type DeleteMailingEntryRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id int32 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+}
+
+func (x *DeleteMailingEntryRequest) Reset() {
+	*x = DeleteMailingEntryRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_mailman_v1_mailing_entry_service_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DeleteMailingEntryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteMailingEntryRequest) ProtoMessage() {}
+
+func (x *DeleteMailingEntryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_mailman_v1_mailing_entry_service_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteMailingEntryRequest.ProtoReflect.Descriptor instead.
+func (*DeleteMailingEntryRequest) Descriptor() ([]byte, []int) {
+	return file_mailman_v1_mailing_entry_service_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *DeleteMailingEntryRequest) GetId() int32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type DeleteMailingEntryResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *DeleteMailingEntryResponse) Reset() {
+	*x = DeleteMailingEntryResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_mailman_v1_mailing_entry_service_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DeleteMailingEntryResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteMailingEntryResponse) ProtoMessage() {}
+
+func (x *DeleteMailingEntryResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_mailman_v1_mailing_entry_service_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteMailingEntryResponse.ProtoReflect.Descriptor instead.
+func (*DeleteMailingEntryResponse) Descriptor() ([]byte, []int) {
+	return file_mailman_v1_mailing_entry_service_proto_rawDescGZIP(), []int{6}
+}
+
+type SendMailingRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	MailingId int32 `protobuf:"varint,1,opt,name=mailing_id,json=mailingId,proto3" json:"mailing_id,omitempty"`
+}
+
+func (x *SendMailingRequest) Reset() {
+	*x = SendMailingRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_mailman_v1_mailing_entry_service_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SendMailingRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SendMailingRequest) ProtoMessage() {}
+
+func (x *SendMailingRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_mailman_v1_mailing_entry_service_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SendMailingRequest.ProtoReflect.Descriptor instead.
+func (*SendMailingRequest) Descriptor() ([]byte, []int) {
+	return file_mailman_v1_mailing_entry_service_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *SendMailingRequest) GetMailingId() int32 {
+	if x != nil {
+		return x.MailingId
+	}
+	return 0
+}
+
+type SendMailingResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *SendMailingResponse) Reset() {
+	*x = SendMailingResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_mailman_v1_mailing_entry_service_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SendMailingResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SendMailingResponse) ProtoMessage() {}
+
+func (x *SendMailingResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_mailman_v1_mailing_entry_service_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SendMailingResponse.ProtoReflect.Descriptor instead.
+func (*SendMailingResponse) Descriptor() ([]byte, []int) {
+	return file_mailman_v1_mailing_entry_service_proto_rawDescGZIP(), []int{8}
+}
+
+// ListMailingEntriesRequest selects mailing entries to list, exactly one filter has to be set.
+type ListMailingEntriesRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Types that are assignable to Filter:
+	//	*ListMailingEntriesRequest_MailingId
+	//	*ListMailingEntriesRequest_CustomerId
+	Filter isListMailingEntriesRequest_Filter `protobuf_oneof:"filter"`
+}
+
+func (x *ListMailingEntriesRequest) Reset() {
+	*x = ListMailingEntriesRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_mailman_v1_mailing_entry_service_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListMailingEntriesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListMailingEntriesRequest) ProtoMessage() {}
+
+func (x *ListMailingEntriesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_mailman_v1_mailing_entry_service_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListMailingEntriesRequest.ProtoReflect.Descriptor instead.
+func (*ListMailingEntriesRequest) Descriptor() ([]byte, []int) {
+	return file_mailman_v1_mailing_entry_service_proto_rawDescGZIP(), []int{9}
+}
+
+func (m *ListMailingEntriesRequest) GetFilter() isListMailingEntriesRequest_Filter {
+	if m != nil {
+		return m.Filter
+	}
+	return nil
+}
+
+func (x *ListMailingEntriesRequest) GetMailingId() int32 {
+	if x, ok := x.GetFilter().(*ListMailingEntriesRequest_MailingId); ok {
+		return x.MailingId
+	}
+	return 0
+}
+
+func (x *ListMailingEntriesRequest) GetCustomerId() int32 {
+	if x, ok := x.GetFilter().(*ListMailingEntriesRequest_CustomerId); ok {
+		return x.CustomerId
+	}
+	return 0
+}
+
+type isListMailingEntriesRequest_Filter interface {
+	isListMailingEntriesRequest_Filter()
+}
+
+type ListMailingEntriesRequest_MailingId struct {
+	MailingId int32 `protobuf:"varint,1,opt,name=mailing_id,json=mailingId,proto3,oneof"`
+}
+
+type ListMailingEntriesRequest_CustomerId struct {
+	CustomerId int32 `protobuf:"varint,2,opt,name=customer_id,json=customerId,proto3,oneof"`
+}
+
+func (*ListMailingEntriesRequest_MailingId) isListMailingEntriesRequest_Filter() {}
+
+func (*ListMailingEntriesRequest_CustomerId) isListMailingEntriesRequest_Filter() {}
+
+// MailingEntryDetails describes an existing mailing entry.
+type MailingEntryDetails struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id         int32                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	CustomerId int32                  `protobuf:"varint,2,opt,name=customer_id,json=customerId,proto3" json:"customer_id,omitempty"`
+	MailingId  int32                  `protobuf:"varint,3,opt,name=mailing_id,json=mailingId,proto3" json:"mailing_id,omitempty"`
+	Title      string                 `protobuf:"bytes,4,opt,name=title,proto3" json:"title,omitempty"`
+	Content    string                 `protobuf:"bytes,5,opt,name=content,proto3" json:"content,omitempty"`
+	InsertTime *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=insert_time,json=insertTime,proto3" json:"insert_time,omitempty"`
+}
+
+func (x *MailingEntryDetails) Reset() {
+	*x = MailingEntryDetails{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_mailman_v1_mailing_entry_service_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *MailingEntryDetails) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MailingEntryDetails) ProtoMessage() {}
+
+func (x *MailingEntryDetails) ProtoReflect() protoreflect.Message {
+	mi := &file_mailman_v1_mailing_entry_service_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MailingEntryDetails.ProtoReflect.Descriptor instead.
+func (*MailingEntryDetails) Descriptor() ([]byte, []int) {
+	return file_mailman_v1_mailing_entry_service_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *MailingEntryDetails) GetId() int32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *MailingEntryDetails) GetCustomerId() int32 {
+	if x != nil {
+		return x.CustomerId
+	}
+	return 0
+}
+
+func (x *MailingEntryDetails) GetMailingId() int32 {
+	if x != nil {
+		return x.MailingId
+	}
+	return 0
+}
+
+func (x *MailingEntryDetails) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *MailingEntryDetails) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+func (x *MailingEntryDetails) GetInsertTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.InsertTime
+	}
+	return nil
+}
+
+var File_mailman_v1_mailing_entry_service_proto protoreflect.FileDescriptor
+
+var file_mailman_v1_mailing_entry_service_proto_rawDesc = []byte{
+	0x0a, 0x26, 0x6d, 0x61, 0x69, 0x6c, 0x6d, 0x61, 0x6e, 0x2f, 0x76, 0x31, 0x2f, 0x6d, 0x61, 0x69,
+	0x6c, 0x69, 0x6e, 0x67, 0x5f, 0x65, 0x6e, 0x74, 0x72, 0x79, 0x5f, 0x73, 0x65, 0x72, 0x76, 0x69,
+	0x63, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x0a, 0x6d, 0x61, 0x69, 0x6c, 0x6d, 0x61,
+	0x6e, 0x2e, 0x76, 0x31, 0x1a, 0x1f, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0xb0, 0x01, 0x0a, 0x0c, 0x4d, 0x61, 0x69, 0x6c, 0x69, 0x6e,
+	0x67, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x1d, 0x0a, 0x0a, 0x6d, 0x61, 0x69, 0x6c, 0x69, 0x6e,
+	0x67, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x09, 0x6d, 0x61, 0x69, 0x6c,
+	0x69, 0x6e, 0x67, 0x49, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x65, 0x6d, 0x61, 0x69, 0x6c, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x65, 0x6d, 0x61, 0x69, 0x6c, 0x12, 0x14, 0x0a, 0x05, 0x74,
+	0x69, 0x74, 0x6c, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x74, 0x69, 0x74, 0x6c,
+	0x65, 0x12, 0x18, 0x0a, 0x07, 0x63, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x18, 0x04, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x07, 0x63, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x12, 0x3b, 0x0a, 0x0b, 0x69,
+	0x6e, 0x73, 0x65, 0x72, 0x74, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62,
+	0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x0a, 0x69, 0x6e,
+	0x73, 0x65, 0x72, 0x74, 0x54, 0x69, 0x6d, 0x65, 0x22, 0x4b, 0x0a, 0x19, 0x43, 0x72, 0x65, 0x61,
+	0x74, 0x65, 0x4d, 0x61, 0x69, 0x6c, 0x69, 0x6e, 0x67, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x2e, 0x0a, 0x05, 0x65, 0x6e, 0x74, 0x72, 0x79, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x6d, 0x61, 0x69, 0x6c, 0x6d, 0x61, 0x6e, 0x2e, 0x76,
+	0x31, 0x2e, 0x4d, 0x61, 0x69, 0x6c, 0x69, 0x6e, 0x67, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x05,
+	0x65, 0x6e, 0x74, 0x72, 0x79, 0x22, 0x2c, 0x0a, 0x1a, 0x43, 0x72, 0x65, 0x61, 0x74, 0x65, 0x4d,
+	0x61, 0x69, 0x6c, 0x69, 0x6e, 0x67, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x65, 0x73, 0x70, 0x6f,
+	0x6e, 0x73, 0x65, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52,
+	0x02, 0x69, 0x64, 0x22, 0x51, 0x0a, 0x1b, 0x43, 0x72, 0x65, 0x61, 0x74, 0x65, 0x4d, 0x61, 0x69,
+	0x6c, 0x69, 0x6e, 0x67, 0x45, 0x6e, 0x74, 0x72, 0x69, 0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x12, 0x32, 0x0a, 0x07, 0x65, 0x6e, 0x74, 0x72, 0x69, 0x65, 0x73, 0x18, 0x01, 0x20,
+	0x03, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x6d, 0x61, 0x69, 0x6c, 0x6d, 0x61, 0x6e, 0x2e, 0x76, 0x31,
+	0x2e, 0x4d, 0x61, 0x69, 0x6c, 0x69, 0x6e, 0x67, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x07, 0x65,
+	0x6e, 0x74, 0x72, 0x69, 0x65, 0x73, 0x22, 0x30, 0x0a, 0x1c, 0x43, 0x72, 0x65, 0x61, 0x74, 0x65,
+	0x4d, 0x61, 0x69, 0x6c, 0x69, 0x6e, 0x67, 0x45, 0x6e, 0x74, 0x72, 0x69, 0x65, 0x73, 0x52, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x10, 0x0a, 0x03, 0x69, 0x64, 0x73, 0x18, 0x01, 0x20,
+	0x03, 0x28, 0x05, 0x52, 0x03, 0x69, 0x64, 0x73, 0x22, 0x2b, 0x0a, 0x19, 0x44, 0x65, 0x6c, 0x65,
+	0x74, 0x65, 0x4d, 0x61, 0x69, 0x6c, 0x69, 0x6e, 0x67, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x05, 0x52, 0x02, 0x69, 0x64, 0x22, 0x1c, 0x0a, 0x1a, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x4d,
+	0x61, 0x69, 0x6c, 0x69, 0x6e, 0x67, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x65, 0x73, 0x70, 0x6f,
+	0x6e, 0x73, 0x65, 0x22, 0x33, 0x0a, 0x12, 0x53, 0x65, 0x6e, 0x64, 0x4d, 0x61, 0x69, 0x6c, 0x69,
+	0x6e, 0x67, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1d, 0x0a, 0x0a, 0x6d, 0x61, 0x69,
+	0x6c, 0x69, 0x6e, 0x67, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x09, 0x6d,
+	0x61, 0x69, 0x6c, 0x69, 0x6e, 0x67, 0x49, 0x64, 0x22, 0x15, 0x0a, 0x13, 0x53, 0x65, 0x6e, 0x64,
+	0x4d, 0x61, 0x69, 0x6c, 0x69, 0x6e, 0x67, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22,
+	0x69, 0x0a, 0x19, 0x4c, 0x69, 0x73, 0x74, 0x4d, 0x61, 0x69, 0x6c, 0x69, 0x6e, 0x67, 0x45, 0x6e,
+	0x74, 0x72, 0x69, 0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1f, 0x0a, 0x0a,
+	0x6d, 0x61, 0x69, 0x6c, 0x69, 0x6e, 0x67, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05,
+	0x48, 0x00, 0x52, 0x09, 0x6d, 0x61, 0x69, 0x6c, 0x69, 0x6e, 0x67, 0x49, 0x64, 0x12, 0x21, 0x0a,
+	0x0b, 0x63, 0x75, 0x73, 0x74, 0x6f, 0x6d, 0x65, 0x72, 0x5f, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x05, 0x48, 0x00, 0x52, 0x0a, 0x63, 0x75, 0x73, 0x74, 0x6f, 0x6d, 0x65, 0x72, 0x49, 0x64,
+	0x42, 0x08, 0x0a, 0x06, 0x66, 0x69, 0x6c, 0x74, 0x65, 0x72, 0x22, 0xd2, 0x01, 0x0a, 0x13, 0x4d,
+	0x61, 0x69, 0x6c, 0x69, 0x6e, 0x67, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x44, 0x65, 0x74, 0x61, 0x69,
+	0x6c, 0x73, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x02,
+	0x69, 0x64, 0x12, 0x1f, 0x0a, 0x0b, 0x63, 0x75, 0x73, 0x74, 0x6f, 0x6d, 0x65, 0x72, 0x5f, 0x69,
+	0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0a, 0x63, 0x75, 0x73, 0x74, 0x6f, 0x6d, 0x65,
+	0x72, 0x49, 0x64, 0x12, 0x1d, 0x0a, 0x0a, 0x6d, 0x61, 0x69, 0x6c, 0x69, 0x6e, 0x67, 0x5f, 0x69,
+	0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x09, 0x6d, 0x61, 0x69, 0x6c, 0x69, 0x6e, 0x67,
+	0x49, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x74, 0x69, 0x74, 0x6c, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x05, 0x74, 0x69, 0x74, 0x6c, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x63, 0x6f, 0x6e, 0x74,
+	0x65, 0x6e, 0x74, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x63, 0x6f, 0x6e, 0x74, 0x65,
+	0x6e, 0x74, 0x12, 0x3b, 0x0a, 0x0b, 0x69, 0x6e, 0x73, 0x65, 0x72, 0x74, 0x5f, 0x74, 0x69, 0x6d,
+	0x65, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74,
+	0x61, 0x6d, 0x70, 0x52, 0x0a, 0x69, 0x6e, 0x73, 0x65, 0x72, 0x74, 0x54, 0x69, 0x6d, 0x65, 0x32,
+	0xfa, 0x03, 0x0a, 0x13, 0x4d, 0x61, 0x69, 0x6c, 0x69, 0x6e, 0x67, 0x45, 0x6e, 0x74, 0x72, 0x79,
+	0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x63, 0x0a, 0x12, 0x43, 0x72, 0x65, 0x61, 0x74,
+	0x65, 0x4d, 0x61, 0x69, 0x6c, 0x69, 0x6e, 0x67, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x25, 0x2e,
+	0x6d, 0x61, 0x69, 0x6c, 0x6d, 0x61, 0x6e, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x72, 0x65, 0x61, 0x74,
+	0x65, 0x4d, 0x61, 0x69, 0x6c, 0x69, 0x6e, 0x67, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x1a, 0x26, 0x2e, 0x6d, 0x61, 0x69, 0x6c, 0x6d, 0x61, 0x6e, 0x2e, 0x76,
+	0x31, 0x2e, 0x43, 0x72, 0x65, 0x61, 0x74, 0x65, 0x4d, 0x61, 0x69, 0x6c, 0x69, 0x6e, 0x67, 0x45,
+	0x6e, 0x74, 0x72, 0x79, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x69, 0x0a, 0x14,
+	0x43, 0x72, 0x65, 0x61, 0x74, 0x65, 0x4d, 0x61, 0x69, 0x6c, 0x69, 0x6e, 0x67, 0x45, 0x6e, 0x74,
+	0x72, 0x69, 0x65, 0x73, 0x12, 0x27, 0x2e, 0x6d, 0x61, 0x69, 0x6c, 0x6d, 0x61, 0x6e, 0x2e, 0x76,
+	0x31, 0x2e, 0x43, 0x72, 0x65, 0x61, 0x74, 0x65, 0x4d, 0x61, 0x69, 0x6c, 0x69, 0x6e, 0x67, 0x45,
+	0x6e, 0x74, 0x72, 0x69, 0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x28, 0x2e,
+	0x6d, 0x61, 0x69, 0x6c, 0x6d, 0x61, 0x6e, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x72, 0x65, 0x61, 0x74,
+	0x65, 0x4d, 0x61, 0x69, 0x6c, 0x69, 0x6e, 0x67, 0x45, 0x6e, 0x74, 0x72, 0x69, 0x65, 0x73, 0x52,
+	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x63, 0x0a, 0x12, 0x44, 0x65, 0x6c, 0x65, 0x74,
+	0x65, 0x4d, 0x61, 0x69, 0x6c, 0x69, 0x6e, 0x67, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x25, 0x2e,
+	0x6d, 0x61, 0x69, 0x6c, 0x6d, 0x61, 0x6e, 0x2e, 0x76, 0x31, 0x2e, 0x44, 0x65, 0x6c, 0x65, 0x74,
+	0x65, 0x4d, 0x61, 0x69, 0x6c, 0x69, 0x6e, 0x67, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x1a, 0x26, 0x2e, 0x6d, 0x61, 0x69, 0x6c, 0x6d, 0x61, 0x6e, 0x2e, 0x76,
+	0x31, 0x2e, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x4d, 0x61, 0x69, 0x6c, 0x69, 0x6e, 0x67, 0x45,
+	0x6e, 0x74, 0x72, 0x79, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x4e, 0x0a, 0x0b,
+	0x53, 0x65, 0x6e, 0x64, 0x4d, 0x61, 0x69, 0x6c, 0x69, 0x6e, 0x67, 0x12, 0x1e, 0x2e, 0x6d, 0x61,
+	0x69, 0x6c, 0x6d, 0x61, 0x6e, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x65, 0x6e, 0x64, 0x4d, 0x61, 0x69,
+	0x6c, 0x69, 0x6e, 0x67, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1f, 0x2e, 0x6d, 0x61,
+	0x69, 0x6c, 0x6d, 0x61, 0x6e, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x65, 0x6e, 0x64, 0x4d, 0x61, 0x69,
+	0x6c, 0x69, 0x6e, 0x67, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x5e, 0x0a, 0x12,
+	0x4c, 0x69, 0x73, 0x74, 0x4d, 0x61, 0x69, 0x6c, 0x69, 0x6e, 0x67, 0x45, 0x6e, 0x74, 0x72, 0x69,
+	0x65, 0x73, 0x12, 0x25, 0x2e, 0x6d, 0x61, 0x69, 0x6c, 0x6d, 0x61, 0x6e, 0x2e, 0x76, 0x31, 0x2e,
+	0x4c, 0x69, 0x73, 0x74, 0x4d, 0x61, 0x69, 0x6c, 0x69, 0x6e, 0x67, 0x45, 0x6e, 0x74, 0x72, 0x69,
+	0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1f, 0x2e, 0x6d, 0x61, 0x69, 0x6c,
+	0x6d, 0x61, 0x6e, 0x2e, 0x76, 0x31, 0x2e, 0x4d, 0x61, 0x69, 0x6c, 0x69, 0x6e, 0x67, 0x45, 0x6e,
+	0x74, 0x72, 0x79, 0x44, 0x65, 0x74, 0x61, 0x69, 0x6c, 0x73, 0x30, 0x01, 0x42, 0x34, 0x5a, 0x32,
+	0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x47, 0x65, 0x6e, 0x65, 0x72,
+	0x61, 0x6c, 0x4b, 0x65, 0x6e, 0x6f, 0x62, 0x69, 0x2f, 0x6d, 0x61, 0x69, 0x6c, 0x6d, 0x61, 0x6e,
+	0x2f, 0x70, 0x6b, 0x67, 0x2f, 0x61, 0x70, 0x69, 0x2f, 0x6d, 0x61, 0x69, 0x6c, 0x6d, 0x61, 0x6e,
+	0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_mailman_v1_mailing_entry_service_proto_rawDescOnce sync.Once
+	file_mailman_v1_mailing_entry_service_proto_rawDescData = file_mailman_v1_mailing_entry_service_proto_rawDesc
+)
+
+func file_mailman_v1_mailing_entry_service_proto_rawDescGZIP() []byte {
+	file_mailman_v1_mailing_entry_service_proto_rawDescOnce.Do(func() {
+		file_mailman_v1_mailing_entry_service_proto_rawDescData = protoimpl.X.CompressGZIP(file_mailman_v1_mailing_entry_service_proto_rawDescData)
+	})
+	return file_mailman_v1_mailing_entry_service_proto_rawDescData
+}
+
+var file_mailman_v1_mailing_entry_service_proto_msgTypes = make([]protoimpl.MessageInfo, 11)
+var file_mailman_v1_mailing_entry_service_proto_goTypes = []interface{}{
+	(*MailingEntry)(nil),                 // 0: mailman.v1.MailingEntry
+	(*CreateMailingEntryRequest)(nil),    // 1: mailman.v1.CreateMailingEntryRequest
+	(*CreateMailingEntryResponse)(nil),   // 2: mailman.v1.CreateMailingEntryResponse
+	(*CreateMailingEntriesRequest)(nil),  // 3: mailman.v1.CreateMailingEntriesRequest
+	(*CreateMailingEntriesResponse)(nil), // 4: mailman.v1.CreateMailingEntriesResponse
+	(*DeleteMailingEntryRequest)(nil),    // 5: mailman.v1.DeleteMailingEntryRequest
+	(*DeleteMailingEntryResponse)(nil),   // 6: mailman.v1.DeleteMailingEntryResponse
+	(*SendMailingRequest)(nil),           // 7: mailman.v1.SendMailingRequest
+	(*SendMailingResponse)(nil),          // 8: mailman.v1.SendMailingResponse
+	(*ListMailingEntriesRequest)(nil),    // 9: mailman.v1.ListMailingEntriesRequest
+	(*MailingEntryDetails)(nil),          // 10: mailman.v1.MailingEntryDetails
+	(*timestamppb.Timestamp)(nil),        // 11: google.protobuf.Timestamp
+}
+var file_mailman_v1_mailing_entry_service_proto_depIdxs = []int32{
+	11, // 0: mailman.v1.MailingEntry.insert_time:type_name -> google.protobuf.Timestamp
+	0,  // 1: mailman.v1.CreateMailingEntryRequest.entry:type_name -> mailman.v1.MailingEntry
+	0,  // 2: mailman.v1.CreateMailingEntriesRequest.entries:type_name -> mailman.v1.MailingEntry
+	11, // 3: mailman.v1.MailingEntryDetails.insert_time:type_name -> google.protobuf.Timestamp
+	1,  // 4: mailman.v1.MailingEntryService.CreateMailingEntry:input_type -> mailman.v1.CreateMailingEntryRequest
+	3,  // 5: mailman.v1.MailingEntryService.CreateMailingEntries:input_type -> mailman.v1.CreateMailingEntriesRequest
+	5,  // 6: mailman.v1.MailingEntryService.DeleteMailingEntry:input_type -> mailman.v1.DeleteMailingEntryRequest
+	7,  // 7: mailman.v1.MailingEntryService.SendMailing:input_type -> mailman.v1.SendMailingRequest
+	9,  // 8: mailman.v1.MailingEntryService.ListMailingEntries:input_type -> mailman.v1.ListMailingEntriesRequest
+	2,  // 9: mailman.v1.MailingEntryService.CreateMailingEntry:output_type -> mailman.v1.CreateMailingEntryResponse
+	4,  // 10: mailman.v1.MailingEntryService.CreateMailingEntries:output_type -> mailman.v1.CreateMailingEntriesResponse
+	6,  // 11: mailman.v1.MailingEntryService.DeleteMailingEntry:output_type -> mailman.v1.DeleteMailingEntryResponse
+	8,  // 12: mailman.v1.MailingEntryService.SendMailing:output_type -> mailman.v1.SendMailingResponse
+	10, // 13: mailman.v1.MailingEntryService.ListMailingEntries:output_type -> mailman.v1.MailingEntryDetails
+	9,  // [9:14] is the sub-list for method output_type
+	4,  // [4:9] is the sub-list for method input_type
+	4,  // [4:4] is the sub-list for extension type_name
+	4,  // [4:4] is the sub-list for extension extendee
+	0,  // [0:4] is the sub-list for field type_name
+}
+
+func init() { file_mailman_v1_mailing_entry_service_proto_init() }
+func file_mailman_v1_mailing_entry_service_proto_init() {
+	if File_mailman_v1_mailing_entry_service_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_mailman_v1_mailing_entry_service_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*MailingEntry); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_mailman_v1_mailing_entry_service_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CreateMailingEntryRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_mailman_v1_mailing_entry_service_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CreateMailingEntryResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_mailman_v1_mailing_entry_service_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CreateMailingEntriesRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_mailman_v1_mailing_entry_service_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CreateMailingEntriesResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_mailman_v1_mailing_entry_service_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DeleteMailingEntryRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_mailman_v1_mailing_entry_service_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DeleteMailingEntryResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_mailman_v1_mailing_entry_service_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SendMailingRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_mailman_v1_mailing_entry_service_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SendMailingResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_mailman_v1_mailing_entry_service_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ListMailingEntriesRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_mailman_v1_mailing_entry_service_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*MailingEntryDetails); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	file_mailman_v1_mailing_entry_service_proto_msgTypes[9].OneofWrappers = []interface{}{
+		(*ListMailingEntriesRequest_MailingId)(nil),
+		(*ListMailingEntriesRequest_CustomerId)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_mailman_v1_mailing_entry_service_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   11,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_mailman_v1_mailing_entry_service_proto_goTypes,
+		DependencyIndexes: file_mailman_v1_mailing_entry_service_proto_depIdxs,
+		MessageInfos:      file_mailman_v1_mailing_entry_service_proto_msgTypes,
+	}.Build()
+	File_mailman_v1_mailing_entry_service_proto = out.File
+	file_mailman_v1_mailing_entry_service_proto_rawDesc = nil
+	file_mailman_v1_mailing_entry_service_proto_goTypes = nil
+	file_mailman_v1_mailing_entry_service_proto_depIdxs = nil
+}
@@ -0,0 +1,295 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: mailman/v1/mailing_entry_service.proto
+
+package mailmanpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	MailingEntryService_CreateMailingEntry_FullMethodName   = "/mailman.v1.MailingEntryService/CreateMailingEntry"
+	MailingEntryService_CreateMailingEntries_FullMethodName = "/mailman.v1.MailingEntryService/CreateMailingEntries"
+	MailingEntryService_DeleteMailingEntry_FullMethodName   = "/mailman.v1.MailingEntryService/DeleteMailingEntry"
+	MailingEntryService_SendMailing_FullMethodName          = "/mailman.v1.MailingEntryService/SendMailing"
+	MailingEntryService_ListMailingEntries_FullMethodName   = "/mailman.v1.MailingEntryService/ListMailingEntries"
+)
+
+// MailingEntryServiceClient is the client API for MailingEntryService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type MailingEntryServiceClient interface {
+	// Creates a mailing entry. Requires messages:write scope.
+	CreateMailingEntry(ctx context.Context, in *CreateMailingEntryRequest, opts ...grpc.CallOption) (*CreateMailingEntryResponse, error)
+	// Creates multiple mailing entries at once, either all of them are created or none. Requires messages:write scope.
+	CreateMailingEntries(ctx context.Context, in *CreateMailingEntriesRequest, opts ...grpc.CallOption) (*CreateMailingEntriesResponse, error)
+	// Deletes a mailing entry. Requires messages:write scope.
+	DeleteMailingEntry(ctx context.Context, in *DeleteMailingEntryRequest, opts ...grpc.CallOption) (*DeleteMailingEntryResponse, error)
+	// Sends all mailing entries with a mailing ID and deletes them. Requires messages:send scope.
+	SendMailing(ctx context.Context, in *SendMailingRequest, opts ...grpc.CallOption) (*SendMailingResponse, error)
+	// Streams mailing entries with a mailing ID or of a customer. Requires messages:read scope.
+	ListMailingEntries(ctx context.Context, in *ListMailingEntriesRequest, opts ...grpc.CallOption) (MailingEntryService_ListMailingEntriesClient, error)
+}
+
+type mailingEntryServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewMailingEntryServiceClient(cc grpc.ClientConnInterface) MailingEntryServiceClient {
+	return &mailingEntryServiceClient{cc}
+}
+
+func (c *mailingEntryServiceClient) CreateMailingEntry(ctx context.Context, in *CreateMailingEntryRequest, opts ...grpc.CallOption) (*CreateMailingEntryResponse, error) {
+	out := new(CreateMailingEntryResponse)
+	err := c.cc.Invoke(ctx, MailingEntryService_CreateMailingEntry_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *mailingEntryServiceClient) CreateMailingEntries(ctx context.Context, in *CreateMailingEntriesRequest, opts ...grpc.CallOption) (*CreateMailingEntriesResponse, error) {
+	out := new(CreateMailingEntriesResponse)
+	err := c.cc.Invoke(ctx, MailingEntryService_CreateMailingEntries_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *mailingEntryServiceClient) DeleteMailingEntry(ctx context.Context, in *DeleteMailingEntryRequest, opts ...grpc.CallOption) (*DeleteMailingEntryResponse, error) {
+	out := new(DeleteMailingEntryResponse)
+	err := c.cc.Invoke(ctx, MailingEntryService_DeleteMailingEntry_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *mailingEntryServiceClient) SendMailing(ctx context.Context, in *SendMailingRequest, opts ...grpc.CallOption) (*SendMailingResponse, error) {
+	out := new(SendMailingResponse)
+	err := c.cc.Invoke(ctx, MailingEntryService_SendMailing_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *mailingEntryServiceClient) ListMailingEntries(ctx context.Context, in *ListMailingEntriesRequest, opts ...grpc.CallOption) (MailingEntryService_ListMailingEntriesClient, error) {
+	stream, err := c.cc.NewStream(ctx, &MailingEntryService_ServiceDesc.Streams[0], MailingEntryService_ListMailingEntries_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &mailingEntryServiceListMailingEntriesClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type MailingEntryService_ListMailingEntriesClient interface {
+	Recv() (*MailingEntryDetails, error)
+	grpc.ClientStream
+}
+
+type mailingEntryServiceListMailingEntriesClient struct {
+	grpc.ClientStream
+}
+
+func (x *mailingEntryServiceListMailingEntriesClient) Recv() (*MailingEntryDetails, error) {
+	m := new(MailingEntryDetails)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// MailingEntryServiceServer is the server API for MailingEntryService service.
+// All implementations must embed UnimplementedMailingEntryServiceServer
+// for forward compatibility
+type MailingEntryServiceServer interface {
+	// Creates a mailing entry. Requires messages:write scope.
+	CreateMailingEntry(context.Context, *CreateMailingEntryRequest) (*CreateMailingEntryResponse, error)
+	// Creates multiple mailing entries at once, either all of them are created or none. Requires messages:write scope.
+	CreateMailingEntries(context.Context, *CreateMailingEntriesRequest) (*CreateMailingEntriesResponse, error)
+	// Deletes a mailing entry. Requires messages:write scope.
+	DeleteMailingEntry(context.Context, *DeleteMailingEntryRequest) (*DeleteMailingEntryResponse, error)
+	// Sends all mailing entries with a mailing ID and deletes them. Requires messages:send scope.
+	SendMailing(context.Context, *SendMailingRequest) (*SendMailingResponse, error)
+	// Streams mailing entries with a mailing ID or of a customer. Requires messages:read scope.
+	ListMailingEntries(*ListMailingEntriesRequest, MailingEntryService_ListMailingEntriesServer) error
+	mustEmbedUnimplementedMailingEntryServiceServer()
+}
+
+// UnimplementedMailingEntryServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedMailingEntryServiceServer struct {
+}
+
+func (UnimplementedMailingEntryServiceServer) CreateMailingEntry(context.Context, *CreateMailingEntryRequest) (*CreateMailingEntryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateMailingEntry not implemented")
+}
+func (UnimplementedMailingEntryServiceServer) CreateMailingEntries(context.Context, *CreateMailingEntriesRequest) (*CreateMailingEntriesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateMailingEntries not implemented")
+}
+func (UnimplementedMailingEntryServiceServer) DeleteMailingEntry(context.Context, *DeleteMailingEntryRequest) (*DeleteMailingEntryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteMailingEntry not implemented")
+}
+func (UnimplementedMailingEntryServiceServer) SendMailing(context.Context, *SendMailingRequest) (*SendMailingResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SendMailing not implemented")
+}
+func (UnimplementedMailingEntryServiceServer) ListMailingEntries(*ListMailingEntriesRequest, MailingEntryService_ListMailingEntriesServer) error {
+	return status.Errorf(codes.Unimplemented, "method ListMailingEntries not implemented")
+}
+func (UnimplementedMailingEntryServiceServer) mustEmbedUnimplementedMailingEntryServiceServer() {}
+
+// UnsafeMailingEntryServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to MailingEntryServiceServer will
+// result in compilation errors.
+type UnsafeMailingEntryServiceServer interface {
+	mustEmbedUnimplementedMailingEntryServiceServer()
+}
+
+func RegisterMailingEntryServiceServer(s grpc.ServiceRegistrar, srv MailingEntryServiceServer) {
+	s.RegisterService(&MailingEntryService_ServiceDesc, srv)
+}
+
+func _MailingEntryService_CreateMailingEntry_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateMailingEntryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MailingEntryServiceServer).CreateMailingEntry(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MailingEntryService_CreateMailingEntry_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MailingEntryServiceServer).CreateMailingEntry(ctx, req.(*CreateMailingEntryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MailingEntryService_CreateMailingEntries_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateMailingEntriesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MailingEntryServiceServer).CreateMailingEntries(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MailingEntryService_CreateMailingEntries_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MailingEntryServiceServer).CreateMailingEntries(ctx, req.(*CreateMailingEntriesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MailingEntryService_DeleteMailingEntry_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteMailingEntryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MailingEntryServiceServer).DeleteMailingEntry(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MailingEntryService_DeleteMailingEntry_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MailingEntryServiceServer).DeleteMailingEntry(ctx, req.(*DeleteMailingEntryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MailingEntryService_SendMailing_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SendMailingRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MailingEntryServiceServer).SendMailing(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MailingEntryService_SendMailing_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MailingEntryServiceServer).SendMailing(ctx, req.(*SendMailingRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MailingEntryService_ListMailingEntries_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListMailingEntriesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(MailingEntryServiceServer).ListMailingEntries(m, &mailingEntryServiceListMailingEntriesServer{stream})
+}
+
+type MailingEntryService_ListMailingEntriesServer interface {
+	Send(*MailingEntryDetails) error
+	grpc.ServerStream
+}
+
+type mailingEntryServiceListMailingEntriesServer struct {
+	grpc.ServerStream
+}
+
+func (x *mailingEntryServiceListMailingEntriesServer) Send(m *MailingEntryDetails) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// MailingEntryService_ServiceDesc is the grpc.ServiceDesc for MailingEntryService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var MailingEntryService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "mailman.v1.MailingEntryService",
+	HandlerType: (*MailingEntryServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreateMailingEntry",
+			Handler:    _MailingEntryService_CreateMailingEntry_Handler,
+		},
+		{
+			MethodName: "CreateMailingEntries",
+			Handler:    _MailingEntryService_CreateMailingEntries_Handler,
+		},
+		{
+			MethodName: "DeleteMailingEntry",
+			Handler:    _MailingEntryService_DeleteMailingEntry_Handler,
+		},
+		{
+			MethodName: "SendMailing",
+			Handler:    _MailingEntryService_SendMailing_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ListMailingEntries",
+			Handler:       _MailingEntryService_ListMailingEntries_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "mailman/v1/mailing_entry_service.proto",
+}
@@ -0,0 +1,248 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/GeneralKenobi/mailman/pkg/mdctx"
+	"github.com/GeneralKenobi/mailman/pkg/util"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	correlationIdHeader  = "X-Correlation-ID"
+	idempotencyKeyHeader = "Idempotency-Key"
+	apiKeyHeader         = "X-API-Key"
+
+	idempotencyKeyLength = 32
+)
+
+// New creates a client of the mailman API served at baseUrl (e.g. http://mailman:8080). By default, it doesn't authenticate and retries
+// failed requests 3 times.
+func New(baseUrl string, options ...Option) *Client {
+	client := Client{
+		baseUrl:         strings.TrimSuffix(baseUrl, "/"),
+		httpClient:      http.DefaultClient,
+		maxRetries:      3,
+		minRetryBackoff: 100 * time.Millisecond,
+		maxRetryBackoff: 5 * time.Second,
+	}
+	for _, option := range options {
+		option(&client)
+	}
+	return &client
+}
+
+// Client calls the mailman API. It's safe for concurrent use.
+//
+// Requests are sent with the correlation ID from the context (see mdctx.WithCorrelationId) in the X-Correlation-ID header. Requests
+// modifying data are sent with a random Idempotency-Key header, which is reused when they're retried, so they're safe to retry.
+// Requests are retried after network errors and HTTP429, HTTP502, HTTP503 and HTTP504 responses.
+//
+// Error responses are returned as *Error.
+type Client struct {
+	baseUrl         string
+	httpClient      *http.Client
+	apiKey          string
+	tokenSource     func(ctx context.Context) (string, error)
+	maxRetries      int
+	minRetryBackoff time.Duration
+	maxRetryBackoff time.Duration
+}
+
+// Option customizes a Client.
+type Option func(client *Client)
+
+// WithHttpClient sets the HTTP client used to send requests.
+func WithHttpClient(httpClient *http.Client) Option {
+	return func(client *Client) {
+		client.httpClient = httpClient
+	}
+}
+
+// WithApiKey authenticates requests with a static API key.
+func WithApiKey(apiKey string) Option {
+	return func(client *Client) {
+		client.apiKey = apiKey
+	}
+}
+
+// WithBearerToken authenticates requests with a bearer token (JWT) returned by tokenSource. It's called before each request, so it can
+// refresh expired tokens.
+func WithBearerToken(tokenSource func(ctx context.Context) (string, error)) Option {
+	return func(client *Client) {
+		client.tokenSource = tokenSource
+	}
+}
+
+// WithRetries sets how many times a failed request is retried (0 disables retries) and the bounds of exponential backoff between
+// attempts. Retry-After headers of responses take precedence over the backoff.
+func WithRetries(maxRetries int, minBackoff, maxBackoff time.Duration) Option {
+	return func(client *Client) {
+		client.maxRetries = maxRetries
+		client.minRetryBackoff = minBackoff
+		client.maxRetryBackoff = maxBackoff
+	}
+}
+
+// do sends a request with requestBody marshaled to JSON (unless it's nil) and unmarshals the response body into responseBody (unless it's
+// nil). The request is retried according to the client's retry settings.
+func (client *Client) do(ctx context.Context, method, path string, query url.Values, requestBody, responseBody any) error {
+	var body []byte
+	if requestBody != nil {
+		var err error
+		body, err = json.Marshal(requestBody)
+		if err != nil {
+			return fmt.Errorf("error marshaling request body: %w", err)
+		}
+	}
+
+	requestUrl := client.baseUrl + path
+	if len(query) > 0 {
+		requestUrl += "?" + query.Encode()
+	}
+	var idempotencyKey string
+	if method != http.MethodGet {
+		idempotencyKey = util.RandomAlphanumericString(idempotencyKeyLength)
+	}
+
+	for attempt := 0; ; attempt++ {
+		response, err := client.send(ctx, method, requestUrl, idempotencyKey, body)
+		if err == nil && response.StatusCode < http.StatusBadRequest {
+			return decodeResponse(response, responseBody)
+		}
+
+		var retryAfter time.Duration
+		if err == nil {
+			retryAfter = retryAfterHeader(response)
+			err = decodeError(response)
+		}
+		if attempt >= client.maxRetries || !isRetryable(ctx, err) {
+			return err
+		}
+
+		backoff := client.backoff(attempt)
+		if retryAfter > backoff {
+			backoff = retryAfter
+		}
+		if deadline, found := ctx.Deadline(); found && time.Until(deadline) < backoff {
+			// Retrying would exceed the deadline anyway.
+			return err
+		}
+		mdctx.Debugf(ctx, "%s %s failed, retrying in %v: %v", method, path, backoff, err)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return err
+		}
+	}
+}
+
+func (client *Client) send(ctx context.Context, method, requestUrl, idempotencyKey string, body []byte) (*http.Response, error) {
+	var bodyReader io.Reader
+	if body != nil {
+		bodyReader = bytes.NewReader(body)
+	}
+	request, err := http.NewRequestWithContext(ctx, method, requestUrl, bodyReader)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+
+	request.Header.Set("Accept", "application/json")
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+	if correlationId := mdctx.CorrelationId(ctx); correlationId != "" {
+		request.Header.Set(correlationIdHeader, correlationId)
+	}
+	if idempotencyKey != "" {
+		request.Header.Set(idempotencyKeyHeader, idempotencyKey)
+	}
+	if client.apiKey != "" {
+		request.Header.Set(apiKeyHeader, client.apiKey)
+	}
+	if client.tokenSource != nil {
+		token, err := client.tokenSource(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("error getting bearer token: %w", err)
+		}
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	response, err := client.httpClient.Do(request)
+	if err != nil {
+		return nil, &transportError{cause: err}
+	}
+	return response, nil
+}
+
+func decodeResponse(response *http.Response, responseBody any) error {
+	defer response.Body.Close()
+	if responseBody == nil {
+		_, _ = io.Copy(io.Discard, response.Body)
+		return nil
+	}
+	if err := json.NewDecoder(response.Body).Decode(responseBody); err != nil {
+		return fmt.Errorf("error decoding response body: %w", err)
+	}
+	return nil
+}
+
+// backoff returns exponential backoff with full jitter for the attempt.
+func (client *Client) backoff(attempt int) time.Duration {
+	backoff := client.minRetryBackoff << attempt
+	if backoff > client.maxRetryBackoff || backoff <= 0 {
+		backoff = client.maxRetryBackoff
+	}
+	if backoff <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(backoff)) + 1)
+}
+
+func retryAfterHeader(response *http.Response) time.Duration {
+	seconds, err := strconv.Atoi(response.Header.Get("Retry-After"))
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+func isRetryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var transportErr *transportError
+	if errors.As(err, &transportErr) {
+		return true
+	}
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		switch apiErr.StatusCode {
+		case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+	}
+	return false
+}
+
+// transportError is returned if a request couldn't be sent or a response couldn't be received.
+type transportError struct {
+	cause error
+}
+
+func (transportErr *transportError) Error() string {
+	return transportErr.cause.Error()
+}
+
+func (transportErr *transportError) Unwrap() error {
+	return transportErr.cause
+}
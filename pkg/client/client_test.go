@@ -0,0 +1,368 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/GeneralKenobi/mailman/internal/api/httpgin"
+	"github.com/GeneralKenobi/mailman/internal/auth"
+	"github.com/GeneralKenobi/mailman/internal/auth/apikey"
+	"github.com/GeneralKenobi/mailman/internal/config"
+	"github.com/GeneralKenobi/mailman/internal/db/memory"
+	"github.com/GeneralKenobi/mailman/internal/service/mailingentry/progress"
+	"github.com/GeneralKenobi/mailman/pkg/api/apimodel"
+	"github.com/GeneralKenobi/mailman/pkg/mdctx"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testApiKey = "test-key"
+
+func TestMailingEntryLifecycle(t *testing.T) {
+	emailer := &emailerMock{}
+	server := newTestServer(t, emailer, nil)
+	testObj := New(server.URL, WithApiKey(testApiKey))
+	ctx := context.Background()
+
+	customer, err := testObj.CreateCustomer(ctx, "existing@example.com")
+	if err != nil {
+		t.Fatalf("Error creating customer: %v", err)
+	}
+	if got, err := testObj.GetCustomer(ctx, customer.Id); err != nil || got != customer {
+		t.Fatalf("Expected customer %v, got %v and error %v", customer, got, err)
+	}
+
+	id, err := testObj.CreateMailingEntry(ctx, mailingEntry("existing@example.com", 7, "first"))
+	if err != nil {
+		t.Fatalf("Error creating mailing entry: %v", err)
+	}
+	ids, err := testObj.CreateMailingEntries(ctx, []apimodel.MailingEntry{
+		mailingEntry("new@example.com", 7, "second"),
+		mailingEntry("existing@example.com", 8, "third"),
+	})
+	if err != nil {
+		t.Fatalf("Error creating mailing entry batch: %v", err)
+	}
+	if len(ids) != 2 || ids[0] == id || ids[1] == id {
+		t.Fatalf("Expected 2 new IDs, got %v", ids)
+	}
+
+	entries, err := testObj.ListMailingEntries(ctx, ListMailingEntriesQuery{MailingId: 7})
+	if err != nil || len(entries) != 2 {
+		t.Fatalf("Expected 2 entries with mailing ID 7, got %v and error %v", entries, err)
+	}
+	entries, err = testObj.ListMailingEntries(ctx, ListMailingEntriesQuery{CustomerId: customer.Id})
+	if err != nil || len(entries) != 2 {
+		t.Fatalf("Expected 2 entries of customer %d, got %v and error %v", customer.Id, entries, err)
+	}
+	mailing, err := testObj.GetMailing(ctx, 7)
+	if expected := (apimodel.Mailing{Id: 7, EntryCount: 2, CustomerCount: 2}); err != nil || mailing != expected {
+		t.Fatalf("Expected mailing %v, got %v and error %v", expected, mailing, err)
+	}
+
+	if err = testObj.SendMailing(ctx, 7); err != nil {
+		t.Fatalf("Error sending mailing: %v", err)
+	}
+	if len(emailer.sent) != 2 {
+		t.Errorf("Expected 2 sent emails, got %v", emailer.sent)
+	}
+	if _, err = testObj.GetMailing(ctx, 7); !IsNotFound(err) {
+		t.Errorf("Expected sent mailing to be not found, got %v", err)
+	}
+	archived, err := testObj.ListArchivedMailingEntries(ctx, ListArchivedMailingEntriesQuery{MailingId: 7, Email: "new@example.com"})
+	if err != nil || len(archived) != 1 || archived[0].Id != ids[0] || archived[0].Reason != "sent" {
+		t.Errorf("Expected entry %d to be archived as sent, got %v and error %v", ids[0], archived, err)
+	}
+	if _, err = testObj.ListArchivedMailingEntries(ctx, ListArchivedMailingEntriesQuery{Limit: 1001}); !IsStatus(err, http.StatusBadRequest) {
+		t.Errorf("Expected listing archived entries above the limit to be rejected, got %v", err)
+	}
+
+	if err = testObj.DeleteCustomer(ctx, customer.Id); !IsStatus(err, http.StatusBadRequest) {
+		t.Errorf("Expected customer with entries not to be deleted, got %v", err)
+	}
+	if err = testObj.DeleteMailingEntry(ctx, ids[1]); err != nil {
+		t.Fatalf("Error deleting mailing entry: %v", err)
+	}
+	if err = testObj.DeleteCustomer(ctx, customer.Id); err != nil {
+		t.Errorf("Error deleting customer: %v", err)
+	}
+	if _, err = testObj.GetCustomer(ctx, customer.Id); !IsNotFound(err) {
+		t.Errorf("Expected deleted customer to be not found, got %v", err)
+	}
+}
+
+func TestBulkDelete(t *testing.T) {
+	server := newTestServer(t, &emailerMock{}, nil)
+	testObj := New(server.URL, WithApiKey(testApiKey))
+	ctx := context.Background()
+
+	_, err := testObj.CreateMailingEntries(ctx, []apimodel.MailingEntry{
+		mailingEntry("first@example.com", 7, "first"),
+		mailingEntry("second@example.com", 7, "second"),
+		mailingEntry("first@example.com", 8, "third"),
+	})
+	if err != nil {
+		t.Fatalf("Error creating mailing entries: %v", err)
+	}
+
+	if count, err := testObj.DeleteMailing(ctx, 7, true); err != nil || count != 2 {
+		t.Fatalf("Expected 2 entries to be deleted in a dry run, got %d and error %v", count, err)
+	}
+	if mailing, err := testObj.GetMailing(ctx, 7); err != nil || mailing.EntryCount != 2 {
+		t.Fatalf("Expected a dry run not to delete entries, got %v and error %v", mailing, err)
+	}
+	if count, err := testObj.DeleteMailing(ctx, 7, false); err != nil || count != 2 {
+		t.Fatalf("Expected 2 entries to be deleted, got %d and error %v", count, err)
+	}
+	if _, err = testObj.GetMailing(ctx, 7); !IsNotFound(err) {
+		t.Errorf("Expected deleted mailing to be not found, got %v", err)
+	}
+
+	if _, err = testObj.PurgeMailingEntries(ctx, PurgeMailingEntriesQuery{}); !IsStatus(err, http.StatusBadRequest) {
+		t.Errorf("Expected purge without filters to be rejected, got %v", err)
+	}
+	count, err := testObj.PurgeMailingEntries(ctx, PurgeMailingEntriesQuery{InsertedBefore: time.Now().Add(time.Minute)})
+	if err != nil || count != 1 {
+		t.Errorf("Expected the remaining entry to be purged, got %d and error %v", count, err)
+	}
+}
+
+func TestCreateMailingEntriesShouldNotCreateAnyIfOneIsInvalid(t *testing.T) {
+	server := newTestServer(t, &emailerMock{}, nil)
+	testObj := New(server.URL, WithApiKey(testApiKey))
+	ctx := context.Background()
+
+	duplicate := mailingEntry("test@example.com", 3, "duplicate")
+	_, err := testObj.CreateMailingEntries(ctx, []apimodel.MailingEntry{mailingEntry("test@example.com", 3, "valid"), duplicate, duplicate})
+
+	var apiErr *Error
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest || apiErr.OperationId == "" {
+		t.Fatalf("Expected HTTP400 error with operation ID, got %v", err)
+	}
+	if entries, err := testObj.ListMailingEntries(ctx, ListMailingEntriesQuery{MailingId: 3}); err != nil || len(entries) != 0 {
+		t.Errorf("Expected no entries to be created, got %v and error %v", entries, err)
+	}
+}
+
+func TestShouldReturnErrorForInvalidCredentials(t *testing.T) {
+	server := newTestServer(t, &emailerMock{}, nil)
+	testObj := New(server.URL, WithApiKey("invalid"))
+
+	_, err := testObj.GetMailing(context.Background(), 1)
+	if !IsStatus(err, http.StatusUnauthorized) {
+		t.Errorf("Expected HTTP401 error, got %v", err)
+	}
+}
+
+// The first response is lost, the retried request has to get the original response instead of creating another entry.
+func TestShouldRetryWithSameIdempotencyKey(t *testing.T) {
+	var mutex sync.Mutex
+	var idempotencyKeys []string
+	var replayed []string
+	server := newTestServer(t, &emailerMock{}, func(engine http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			if request.Method != http.MethodPost {
+				engine.ServeHTTP(writer, request)
+				return
+			}
+
+			idempotencyKeys = append(idempotencyKeys, request.Header.Get(idempotencyKeyHeader))
+			recorder := httptest.NewRecorder()
+			engine.ServeHTTP(recorder, request)
+			replayed = append(replayed, recorder.Header().Get("Idempotent-Replayed"))
+			if len(idempotencyKeys) == 1 {
+				writer.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			writer.Header().Set("Content-Type", recorder.Header().Get("Content-Type"))
+			writer.WriteHeader(recorder.Code)
+			_, _ = writer.Write(recorder.Body.Bytes())
+		})
+	})
+	testObj := New(server.URL, WithApiKey(testApiKey), WithRetries(2, time.Millisecond, time.Millisecond))
+	ctx := context.Background()
+
+	id, err := testObj.CreateMailingEntry(ctx, mailingEntry("test@example.com", 5, "title"))
+	if err != nil {
+		t.Fatalf("Error creating mailing entry: %v", err)
+	}
+
+	if len(idempotencyKeys) != 2 || idempotencyKeys[0] == "" || idempotencyKeys[0] != idempotencyKeys[1] {
+		t.Errorf("Expected 2 attempts with the same idempotency key, got %v", idempotencyKeys)
+	}
+	if replayed[1] != "true" {
+		t.Errorf("Expected the retried request to get the replayed response")
+	}
+	entries, err := testObj.ListMailingEntries(ctx, ListMailingEntriesQuery{MailingId: 5})
+	if err != nil || len(entries) != 1 || entries[0].Id != id {
+		t.Errorf("Expected only entry %d to be created, got %v and error %v", id, entries, err)
+	}
+}
+
+func TestShouldPropagateCorrelationId(t *testing.T) {
+	var correlationId string
+	server := newTestServer(t, &emailerMock{}, func(engine http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			correlationId = request.Header.Get(correlationIdHeader)
+			engine.ServeHTTP(writer, request)
+		})
+	})
+	testObj := New(server.URL, WithApiKey(testApiKey))
+
+	ctx := mdctx.WithCorrelationId(context.Background(), "correlation-1")
+	_, _ = testObj.GetMailing(ctx, 1)
+	if correlationId != "correlation-1" {
+		t.Errorf("Expected correlation ID %q, got %q", "correlation-1", correlationId)
+	}
+}
+
+func TestCreateMailingEntryShouldReturnInvalidFields(t *testing.T) {
+	server := newTestServer(t, &emailerMock{}, nil)
+	testObj := New(server.URL, WithApiKey(testApiKey))
+
+	_, err := testObj.CreateMailingEntry(context.Background(), mailingEntry("not an email", 3, "invalid"))
+
+	var apiErr *Error
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Fatalf("Expected HTTP400 error, got %v", err)
+	}
+	expected := []apimodel.FieldError{{Path: "email", Rule: "email", Message: "email must be a valid email address", RejectedValue: "not an email"}}
+	if !reflect.DeepEqual(apiErr.Fields, expected) {
+		t.Errorf("Expected fields %+v, got %+v", expected, apiErr.Fields)
+	}
+}
+
+func TestShouldDecodeErrorResponses(t *testing.T) {
+	manyFields := make([]apimodel.FieldError, 21)
+	for i := range manyFields {
+		manyFields[i] = apimodel.FieldError{Path: fmt.Sprintf("entries[%d].email", i), Rule: "email", Message: "must be an email", RejectedValue: "x"}
+	}
+	manyFieldsJson, _ := json.Marshal(manyFields)
+
+	tests := map[string]struct {
+		status      int
+		contentType string
+		body        string
+		expected    Error
+	}{
+		"Should decode mailman error": {
+			status:      http.StatusNotFound,
+			contentType: "application/json",
+			body:        `{"status":404,"message":"not here","operationId":"op-1"}`,
+			expected:    Error{StatusCode: http.StatusNotFound, Message: "not here", OperationId: "op-1"},
+		},
+		"Should use body of non-JSON error": {
+			status:      http.StatusBadGateway,
+			contentType: "text/plain",
+			body:        "upstream unavailable",
+			expected:    Error{StatusCode: http.StatusBadGateway, Message: "upstream unavailable"},
+		},
+		"Should decode mailman error larger than the message of non-JSON errors": {
+			status:      http.StatusBadRequest,
+			contentType: "application/json",
+			body:        `{"status":400,"message":"invalid fields","operationId":"op-1","fields":` + string(manyFieldsJson) + `}`,
+			expected:    Error{StatusCode: http.StatusBadRequest, Message: "invalid fields", OperationId: "op-1", Fields: manyFields},
+		},
+		"Should truncate body of large non-JSON error": {
+			status:      http.StatusBadGateway,
+			contentType: "text/html",
+			body:        strings.Repeat("x", 2*maxErrorBodyLength),
+			expected:    Error{StatusCode: http.StatusBadGateway, Message: strings.Repeat("x", maxErrorBodyLength)},
+		},
+		"Should use status text of error without body": {
+			status:   http.StatusServiceUnavailable,
+			expected: Error{StatusCode: http.StatusServiceUnavailable, Message: "Service Unavailable"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+				writer.Header().Set("Content-Type", test.contentType)
+				writer.WriteHeader(test.status)
+				_, _ = writer.Write([]byte(test.body))
+			}))
+			defer server.Close()
+			testObj := New(server.URL, WithRetries(0, 0, 0))
+
+			_, err := testObj.GetMailing(context.Background(), 1)
+			var apiErr *Error
+			if !errors.As(err, &apiErr) || !reflect.DeepEqual(*apiErr, test.expected) {
+				t.Errorf("Expected %#v, got %#v", test.expected, err)
+			}
+		})
+	}
+}
+
+func TestShouldStopRetryingWhenContextIsDone(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		attempts++
+		writer.Header().Set("Retry-After", "60")
+		writer.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+	testObj := New(server.URL)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, err := testObj.GetMailing(ctx, 1)
+	if !IsStatus(err, http.StatusTooManyRequests) {
+		t.Errorf("Expected HTTP429 error, got %v", err)
+	}
+	if attempts != 1 {
+		t.Errorf("Expected 1 attempt because Retry-After exceeds the deadline, got %d", attempts)
+	}
+}
+
+func newTestServer(t *testing.T, emailer *emailerMock, wrapEngine func(engine http.Handler) http.Handler) *httptest.Server {
+	gin.SetMode(gin.TestMode)
+	authenticator, err := apikey.New([]config.ApiKey{{Name: "test", Key: testApiKey, Tenant: "tenant-1", Scopes: scopes()}})
+	if err != nil {
+		t.Fatalf("Error creating authenticator: %v", err)
+	}
+
+	var handler http.Handler = httpgin.NewServer(memory.New(), emailer, auth.NewChain(authenticator), progress.NewBus(), nil).Handler()
+	if wrapEngine != nil {
+		handler = wrapEngine(handler)
+	}
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func scopes() []string {
+	var scopes []string
+	for _, scope := range auth.AllScopes {
+		scopes = append(scopes, string(scope))
+	}
+	return scopes
+}
+
+func mailingEntry(email string, mailingId int, title string) apimodel.MailingEntry {
+	return apimodel.MailingEntry{
+		MailingId:  mailingId,
+		Email:      email,
+		Title:      title,
+		Content:    "content",
+		InsertTime: time.Now().UTC().Truncate(time.Second),
+	}
+}
+
+type emailerMock struct {
+	sent []string
+}
+
+func (emailer *emailerMock) Send(_ context.Context, emailAddress, _, _ string) error {
+	emailer.sent = append(emailer.sent, emailAddress)
+	return nil
+}
@@ -0,0 +1,27 @@
+package client
+
+import (
+	"context"
+	"github.com/GeneralKenobi/mailman/pkg/api/apimodel"
+	"net/http"
+	"strconv"
+)
+
+// CreateCustomer creates a customer with the email.
+func (client *Client) CreateCustomer(ctx context.Context, email string) (apimodel.CustomerDetails, error) {
+	var customer apimodel.CustomerDetails
+	err := client.do(ctx, http.MethodPost, "/api/v1/customers", nil, apimodel.Customer{Email: email}, &customer)
+	return customer, err
+}
+
+// GetCustomer gets a customer.
+func (client *Client) GetCustomer(ctx context.Context, id int) (apimodel.CustomerDetails, error) {
+	var customer apimodel.CustomerDetails
+	err := client.do(ctx, http.MethodGet, "/api/v1/customers/"+strconv.Itoa(id), nil, nil, &customer)
+	return customer, err
+}
+
+// DeleteCustomer deletes a customer. Customers with mailing entries can't be deleted.
+func (client *Client) DeleteCustomer(ctx context.Context, id int) error {
+	return client.do(ctx, http.MethodDelete, "/api/v1/customers/"+strconv.Itoa(id), nil, nil, nil)
+}
@@ -0,0 +1,77 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/GeneralKenobi/mailman/pkg/api/apimodel"
+	"io"
+	"net/http"
+)
+
+// Error is returned if mailman responds with an error status.
+type Error struct {
+	StatusCode  int    // HTTP status code of the response
+	Message     string // Message describing the problem
+	OperationId string // ID of the request in mailman's logs, empty if the response didn't come from mailman (e.g. from a proxy)
+	// Invalid fields of the request if it was rejected with HTTP400 because of them, see apimodel.ValidationError
+	Fields []apimodel.FieldError
+}
+
+func (apiErr *Error) Error() string {
+	if apiErr.OperationId == "" {
+		return fmt.Sprintf("mailman responded with %d: %s", apiErr.StatusCode, apiErr.Message)
+	}
+	return fmt.Sprintf("mailman responded with %d: %s (operation ID %s)", apiErr.StatusCode, apiErr.Message, apiErr.OperationId)
+}
+
+// IsStatus checks if err is an *Error with the HTTP status code.
+func IsStatus(err error, statusCode int) bool {
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.StatusCode == statusCode
+}
+
+// IsNotFound checks if err is an *Error with HTTP404 status.
+func IsNotFound(err error) bool {
+	return IsStatus(err, http.StatusNotFound)
+}
+
+const (
+	// maxErrorBodySize limits how much of an error response is read, error responses of mailman are much smaller.
+	maxErrorBodySize = 1 << 20 // 1 MiB
+	// maxErrorBodyLength limits how much of a non-JSON error response is included in the error message.
+	maxErrorBodyLength = 512
+)
+
+// decodeError converts an error response into *Error, with the invalid fields of apimodel.ValidationError. Responses that don't contain
+// apimodel.Error (e.g. from proxies) are converted
+// based on their status and body.
+func decodeError(response *http.Response) error {
+	defer response.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(response.Body, maxErrorBodySize))
+	if err != nil {
+		return &transportError{cause: fmt.Errorf("error reading error response body with status %d: %w", response.StatusCode, err)}
+	}
+
+	var errorDto apimodel.ValidationError
+	if err = json.Unmarshal(body, &errorDto); err == nil && errorDto.Message != "" {
+		return &Error{
+			StatusCode:  response.StatusCode,
+			Message:     errorDto.Message,
+			OperationId: errorDto.OperationId,
+			Fields:      errorDto.Fields,
+		}
+	}
+
+	if len(body) > maxErrorBodyLength {
+		body = body[:maxErrorBodyLength]
+	}
+	message := string(body)
+	if message == "" {
+		message = http.StatusText(response.StatusCode)
+	}
+	return &Error{
+		StatusCode: response.StatusCode,
+		Message:    message,
+	}
+}
@@ -0,0 +1,25 @@
+package client
+
+import (
+	"context"
+	"github.com/GeneralKenobi/mailman/pkg/api/apimodel"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// GetMailing summarizes mailing entries with the mailing ID.
+func (client *Client) GetMailing(ctx context.Context, mailingId int) (apimodel.Mailing, error) {
+	var mailing apimodel.Mailing
+	err := client.do(ctx, http.MethodGet, "/api/v1/mailings/"+strconv.Itoa(mailingId), nil, nil, &mailing)
+	return mailing, err
+}
+
+// DeleteMailing deletes all mailing entries with the mailing ID at once and returns how many were deleted. In a dry run nothing is
+// deleted and the number of entries that would be deleted is returned.
+func (client *Client) DeleteMailing(ctx context.Context, mailingId int, dryRun bool) (int, error) {
+	var deleted apimodel.MailingEntriesDeleted
+	query := url.Values{"dry_run": {strconv.FormatBool(dryRun)}}
+	err := client.do(ctx, http.MethodDelete, "/api/v1/mailings/"+strconv.Itoa(mailingId)+"/entries", query, nil, &deleted)
+	return deleted.DeletedCount, err
+}
@@ -0,0 +1,120 @@
+package client
+
+import (
+	"context"
+	"github.com/GeneralKenobi/mailman/pkg/api/apimodel"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// CreateMailingEntry creates a mailing entry and returns its ID.
+func (client *Client) CreateMailingEntry(ctx context.Context, mailingEntry apimodel.MailingEntry) (int, error) {
+	var created apimodel.MailingEntryCreated
+	err := client.do(ctx, http.MethodPost, "/api/v1/messages", nil, mailingEntry, &created)
+	return created.Id, err
+}
+
+// CreateMailingEntries creates multiple mailing entries at once and returns their IDs in the order of mailingEntries. Either all of
+// them are created or none.
+func (client *Client) CreateMailingEntries(ctx context.Context, mailingEntries []apimodel.MailingEntry) ([]int, error) {
+	var created apimodel.MailingEntryBatchCreated
+	err := client.do(ctx, http.MethodPost, "/api/v1/messages/batch", nil, apimodel.MailingEntryBatch{Entries: mailingEntries}, &created)
+	return created.Ids, err
+}
+
+// DeleteMailingEntry deletes a mailing entry.
+func (client *Client) DeleteMailingEntry(ctx context.Context, id int) error {
+	return client.do(ctx, http.MethodDelete, "/api/v1/messages/"+strconv.Itoa(id), nil, nil, nil)
+}
+
+// SendMailing sends all mailing entries with the mailing ID and deletes them.
+func (client *Client) SendMailing(ctx context.Context, mailingId int) error {
+	return client.do(ctx, http.MethodPost, "/api/v1/messages/send", nil, apimodel.MailingRequest{MailingId: mailingId}, nil)
+}
+
+// ListMailingEntriesQuery selects mailing entries to list. Exactly one of the IDs has to be set.
+type ListMailingEntriesQuery struct {
+	MailingId  int // Lists entries with the mailing ID if it's not 0
+	CustomerId int // Lists entries of the customer if it's not 0
+}
+
+// ListMailingEntries lists mailing entries matching the query.
+func (client *Client) ListMailingEntries(ctx context.Context, query ListMailingEntriesQuery) ([]apimodel.MailingEntryDetails, error) {
+	queryParams := url.Values{}
+	if query.MailingId != 0 {
+		queryParams.Set("mailing_id", strconv.Itoa(query.MailingId))
+	}
+	if query.CustomerId != 0 {
+		queryParams.Set("customer_id", strconv.Itoa(query.CustomerId))
+	}
+
+	var list apimodel.MailingEntryList
+	err := client.do(ctx, http.MethodGet, "/api/v1/messages", queryParams, nil, &list)
+	return list.Entries, err
+}
+
+// ListArchivedMailingEntriesQuery selects archived mailing entries to list. Unset filters don't restrict the selection.
+type ListArchivedMailingEntriesQuery struct {
+	MailingId      int       // Lists entries with the mailing ID if it's not 0
+	Email          string    // Lists entries sent to the email if it's not empty
+	ArchivedAfter  time.Time // Lists entries archived at or after the time if it's not zero
+	ArchivedBefore time.Time // Lists entries archived before the time if it's not zero
+	Limit          int       // Maximum number of listed entries, the server's default if 0
+}
+
+// ListArchivedMailingEntries lists archived mailing entries matching the query, most recently archived first.
+func (client *Client) ListArchivedMailingEntries(ctx context.Context, query ListArchivedMailingEntriesQuery) ([]apimodel.ArchivedMailingEntryDetails, error) {
+	queryParams := url.Values{}
+	if query.MailingId != 0 {
+		queryParams.Set("mailing_id", strconv.Itoa(query.MailingId))
+	}
+	if query.Email != "" {
+		queryParams.Set("email", query.Email)
+	}
+	if !query.ArchivedAfter.IsZero() {
+		queryParams.Set("archived_after", query.ArchivedAfter.Format(time.RFC3339Nano))
+	}
+	if !query.ArchivedBefore.IsZero() {
+		queryParams.Set("archived_before", query.ArchivedBefore.Format(time.RFC3339Nano))
+	}
+	if query.Limit != 0 {
+		queryParams.Set("limit", strconv.Itoa(query.Limit))
+	}
+
+	var list apimodel.ArchivedMailingEntryList
+	err := client.do(ctx, http.MethodGet, "/api/v1/archive/messages", queryParams, nil, &list)
+	return list.Entries, err
+}
+
+// PurgeMailingEntriesQuery selects mailing entries to delete at once. At least one of the filters has to be set.
+type PurgeMailingEntriesQuery struct {
+	MailingId      int       // Deletes entries with the mailing ID if it's not 0
+	CustomerId     int       // Deletes entries of the customer if it's not 0
+	InsertedAfter  time.Time // Deletes entries inserted at or after the time if it's not zero
+	InsertedBefore time.Time // Deletes entries inserted before the time if it's not zero
+	DryRun         bool      // Only counts the entries that would be deleted
+}
+
+// PurgeMailingEntries deletes mailing entries matching the query at once and returns how many were deleted, or would be deleted in a
+// dry run.
+func (client *Client) PurgeMailingEntries(ctx context.Context, query PurgeMailingEntriesQuery) (int, error) {
+	queryParams := url.Values{"dry_run": {strconv.FormatBool(query.DryRun)}}
+	if query.MailingId != 0 {
+		queryParams.Set("mailing_id", strconv.Itoa(query.MailingId))
+	}
+	if query.CustomerId != 0 {
+		queryParams.Set("customer_id", strconv.Itoa(query.CustomerId))
+	}
+	if !query.InsertedAfter.IsZero() {
+		queryParams.Set("inserted_after", query.InsertedAfter.Format(time.RFC3339Nano))
+	}
+	if !query.InsertedBefore.IsZero() {
+		queryParams.Set("inserted_before", query.InsertedBefore.Format(time.RFC3339Nano))
+	}
+
+	var deleted apimodel.MailingEntriesDeleted
+	err := client.do(ctx, http.MethodDelete, "/api/v1/messages", queryParams, nil, &deleted)
+	return deleted.DeletedCount, err
+}
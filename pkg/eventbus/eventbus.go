@@ -0,0 +1,85 @@
+// Package eventbus distributes events between goroutines of a single process.
+package eventbus
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// New creates an event bus. Every subscriber has a buffer of bufferSize events. Events published when a subscriber's buffer is full are
+// dropped for that subscriber, so that slow subscribers don't block publishers.
+func New[T any](bufferSize int) *Bus[T] {
+	return &Bus[T]{
+		bufferSize:    bufferSize,
+		subscriptions: make(map[*Subscription[T]]struct{}),
+	}
+}
+
+// Bus delivers published events to subscribers. It's safe for concurrent use.
+type Bus[T any] struct {
+	bufferSize int
+
+	mutex         sync.RWMutex
+	subscriptions map[*Subscription[T]]struct{}
+}
+
+// Subscription receives events accepted by its filter. It has to be closed when it's no longer needed.
+type Subscription[T any] struct {
+	droppedCount int64 // Accessed atomically
+	bus          *Bus[T]
+	filter       func(event T) bool
+	events       chan T
+	closeOnce    sync.Once
+}
+
+// Subscribe creates a subscription to events for which filter returns true. A nil filter accepts every event.
+func (bus *Bus[T]) Subscribe(filter func(event T) bool) *Subscription[T] {
+	subscription := &Subscription[T]{
+		bus:    bus,
+		filter: filter,
+		events: make(chan T, bus.bufferSize),
+	}
+
+	bus.mutex.Lock()
+	defer bus.mutex.Unlock()
+	bus.subscriptions[subscription] = struct{}{}
+	return subscription
+}
+
+// Publish delivers the event to every subscription that accepts it, without waiting for subscribers.
+func (bus *Bus[T]) Publish(event T) {
+	bus.mutex.RLock()
+	defer bus.mutex.RUnlock()
+
+	for subscription := range bus.subscriptions {
+		if subscription.filter != nil && !subscription.filter(event) {
+			continue
+		}
+		select {
+		case subscription.events <- event:
+		default:
+			atomic.AddInt64(&subscription.droppedCount, 1)
+		}
+	}
+}
+
+// Events returns the channel of received events. It's closed when the subscription is closed.
+func (subscription *Subscription[T]) Events() <-chan T {
+	return subscription.events
+}
+
+// DroppedCount returns the number of events dropped because the subscription's buffer was full.
+func (subscription *Subscription[T]) DroppedCount() int64 {
+	return atomic.LoadInt64(&subscription.droppedCount)
+}
+
+// Close stops delivering events to the subscription. It can be called multiple times.
+func (subscription *Subscription[T]) Close() {
+	subscription.closeOnce.Do(func() {
+		bus := subscription.bus
+		bus.mutex.Lock()
+		defer bus.mutex.Unlock()
+		delete(bus.subscriptions, subscription)
+		close(subscription.events)
+	})
+}
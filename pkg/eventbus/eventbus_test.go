@@ -0,0 +1,60 @@
+package eventbus
+
+import (
+	"testing"
+)
+
+func TestPublish(t *testing.T) {
+	bus := New[int](2)
+	all := bus.Subscribe(nil)
+	defer all.Close()
+	even := bus.Subscribe(func(event int) bool {
+		return event%2 == 0
+	})
+	defer even.Close()
+
+	for event := 1; event <= 4; event++ {
+		bus.Publish(event)
+	}
+
+	assertEvents(t, all, []int{1, 2}, 2)
+	assertEvents(t, even, []int{2, 4}, 0)
+}
+
+func TestClose(t *testing.T) {
+	bus := New[int](1)
+	subscription := bus.Subscribe(nil)
+
+	subscription.Close()
+	subscription.Close()
+	bus.Publish(1)
+
+	if _, open := <-subscription.Events(); open {
+		t.Errorf("Expected events channel to be closed")
+	}
+	if subscription.DroppedCount() != 0 {
+		t.Errorf("Expected no events to be dropped after closing but got %d", subscription.DroppedCount())
+	}
+}
+
+func assertEvents(t *testing.T, subscription *Subscription[int], expectedEvents []int, expectedDroppedCount int64) {
+	t.Helper()
+	for _, expectedEvent := range expectedEvents {
+		select {
+		case event := <-subscription.Events():
+			if event != expectedEvent {
+				t.Errorf("Expected event %d but got %d", expectedEvent, event)
+			}
+		default:
+			t.Fatalf("Expected event %d but there are no more events", expectedEvent)
+		}
+	}
+	select {
+	case event := <-subscription.Events():
+		t.Errorf("Expected no more events but got %d", event)
+	default:
+	}
+	if subscription.DroppedCount() != expectedDroppedCount {
+		t.Errorf("Expected %d dropped events but got %d", expectedDroppedCount, subscription.DroppedCount())
+	}
+}
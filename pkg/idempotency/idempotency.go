@@ -0,0 +1,126 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// New creates a store of responses to requests with idempotency keys. Responses are kept for ttl after they're completed.
+func New(ttl time.Duration) *Store {
+	return &Store{
+		ttl:     ttl,
+		entries: make(map[string]*entry),
+	}
+}
+
+// Store remembers responses to requests identified by idempotency keys, so that retried requests get the original response instead of
+// being processed again. It's safe for concurrent use. Responses are kept in memory, so a store only deduplicates requests reaching the
+// process it belongs to, not requests retried against other replicas.
+type Store struct {
+	ttl time.Duration
+
+	mutex         sync.Mutex
+	entries       map[string]*entry
+	lastEvictTime time.Time
+}
+
+// Response is a recorded response replayed to retried requests.
+type Response struct {
+	Status      int
+	ContentType string
+	Body        []byte
+}
+
+type entry struct {
+	fingerprint string
+	done        chan struct{} // Closed when the request is completed or abandoned
+	response    *Response     // Set if the request was completed
+	expiryTime  time.Time
+}
+
+// ErrFingerprintMismatch is returned if an idempotency key is reused for a different request.
+var ErrFingerprintMismatch = errors.New("idempotency key was already used for a different request")
+
+// Begin starts processing of a request with the key. fingerprint identifies the request (e.g. a hash of its method, URI and body), a key
+// can't be reused for requests with different fingerprints.
+//
+// If a request with the key was already completed then its response is returned. If one is being processed then Begin waits until it's
+// completed or abandoned, or until ctx is done. Otherwise, it returns nil response and the caller has to process the request and then
+// call Complete or Abandon.
+func (store *Store) Begin(ctx context.Context, key, fingerprint string) (*Response, error) {
+	for {
+		store.mutex.Lock()
+		now := currentTime()
+		store.evictExpired(now)
+
+		existing, found := store.entries[key]
+		if !found {
+			store.entries[key] = &entry{fingerprint: fingerprint, done: make(chan struct{})}
+			store.mutex.Unlock()
+			return nil, nil
+		}
+		store.mutex.Unlock()
+
+		if existing.fingerprint != fingerprint {
+			return nil, ErrFingerprintMismatch
+		}
+		select {
+		case <-existing.done:
+			if existing.response != nil {
+				return existing.response, nil
+			}
+			// The request was abandoned - try to take over processing.
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// Complete records the response to the request with the key, it's returned by Begin until the ttl passes.
+func (store *Store) Complete(key string, response Response) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	existing, found := store.entries[key]
+	if !found {
+		return
+	}
+	existing.response = &response
+	existing.expiryTime = currentTime().Add(store.ttl)
+	close(existing.done)
+}
+
+// Abandon forgets the request with the key, e.g. because it failed with an error that may be transient. A retried request will be
+// processed again.
+func (store *Store) Abandon(key string) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	existing, found := store.entries[key]
+	if !found {
+		return
+	}
+	delete(store.entries, key)
+	close(existing.done)
+}
+
+// evictExpired removes completed entries older than ttl. It's a no-op if eviction was done recently.
+func (store *Store) evictExpired(now time.Time) {
+	if now.Sub(store.lastEvictTime) < evictionPeriod {
+		return
+	}
+	store.lastEvictTime = now
+
+	for key, existing := range store.entries {
+		if existing.response != nil && now.After(existing.expiryTime) {
+			delete(store.entries, key)
+		}
+	}
+}
+
+const evictionPeriod = time.Minute
+
+// Hook for mocking in unit tests.
+var currentTime = time.Now
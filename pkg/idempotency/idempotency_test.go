@@ -0,0 +1,110 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestBeginShouldReplayCompletedResponse(t *testing.T) {
+	testObj := New(time.Hour)
+	response, err := testObj.Begin(context.Background(), "key-1", "fingerprint-1")
+	if response != nil || err != nil {
+		t.Fatalf("Expected a new request, got response %v and error %v", response, err)
+	}
+
+	expected := Response{Status: 200, ContentType: "application/json", Body: []byte(`{"id":1}`)}
+	testObj.Complete("key-1", expected)
+
+	response, err = testObj.Begin(context.Background(), "key-1", "fingerprint-1")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if response == nil || !reflect.DeepEqual(*response, expected) {
+		t.Errorf("Expected response %v, got %v", expected, response)
+	}
+}
+
+func TestBeginShouldRejectDifferentRequestWithSameKey(t *testing.T) {
+	testObj := New(time.Hour)
+	_, _ = testObj.Begin(context.Background(), "key-1", "fingerprint-1")
+	testObj.Complete("key-1", Response{Status: 200})
+
+	_, err := testObj.Begin(context.Background(), "key-1", "fingerprint-2")
+	if !errors.Is(err, ErrFingerprintMismatch) {
+		t.Errorf("Expected ErrFingerprintMismatch, got %v", err)
+	}
+}
+
+func TestBeginShouldWaitForRequestInProgress(t *testing.T) {
+	testObj := New(time.Hour)
+	_, _ = testObj.Begin(context.Background(), "key-1", "fingerprint-1")
+
+	type result struct {
+		response *Response
+		err      error
+	}
+	results := make(chan result)
+	go func() {
+		response, err := testObj.Begin(context.Background(), "key-1", "fingerprint-1")
+		results <- result{response, err}
+	}()
+
+	select {
+	case <-results:
+		t.Fatalf("Expected Begin to wait until the request in progress is completed")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	testObj.Complete("key-1", Response{Status: 201})
+	got := <-results
+	if got.err != nil || got.response == nil || got.response.Status != 201 {
+		t.Errorf("Expected the completed response, got %v and error %v", got.response, got.err)
+	}
+}
+
+func TestBeginShouldProcessAbandonedRequestAgain(t *testing.T) {
+	testObj := New(time.Hour)
+	_, _ = testObj.Begin(context.Background(), "key-1", "fingerprint-1")
+	testObj.Abandon("key-1")
+
+	response, err := testObj.Begin(context.Background(), "key-1", "fingerprint-1")
+	if response != nil || err != nil {
+		t.Errorf("Expected a new request, got response %v and error %v", response, err)
+	}
+}
+
+func TestBeginShouldReturnErrorIfContextIsDoneWhileWaiting(t *testing.T) {
+	testObj := New(time.Hour)
+	_, _ = testObj.Begin(context.Background(), "key-1", "fingerprint-1")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := testObj.Begin(ctx, "key-1", "fingerprint-1")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestBeginShouldEvictExpiredResponses(t *testing.T) {
+	originalCurrentTimeHook := currentTime
+	defer func() {
+		currentTime = originalCurrentTimeHook
+	}()
+	now := time.Date(2022, 3, 13, 20, 59, 48, 0, time.UTC)
+	currentTime = func() time.Time {
+		return now
+	}
+
+	testObj := New(time.Hour)
+	_, _ = testObj.Begin(context.Background(), "key-1", "fingerprint-1")
+	testObj.Complete("key-1", Response{Status: 200})
+
+	now = now.Add(time.Hour + evictionPeriod)
+	response, err := testObj.Begin(context.Background(), "key-1", "fingerprint-1")
+	if response != nil || err != nil {
+		t.Errorf("Expected the expired response to be evicted, got response %v and error %v", response, err)
+	}
+}
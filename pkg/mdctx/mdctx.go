@@ -26,8 +26,12 @@ func SetLogLevelFromString(value string) error {
 // New returns a context with a random operation ID. The context should be used throughout single request processing to correlate all logs
 // and actions using its operation ID.
 func New() context.Context {
-	ctx := context.Background()
-	return withValue(ctx, operationIdKey, util.RandomAlphanumericString(operationIdLength))
+	return NewFromContext(context.Background())
+}
+
+// NewFromContext returns a copy of parent with a random operation ID, like New. It keeps parent's deadline and cancellation.
+func NewFromContext(parent context.Context) context.Context {
+	return withValue(parent, operationIdKey, util.RandomAlphanumericString(operationIdLength))
 }
 
 // WithOperationName returns a copy of context with added operation name (e.g. "create mailing entry").
@@ -56,6 +60,16 @@ func WithClientIp(ctx context.Context, clientIp string) context.Context {
 	return withValue(ctx, clientIpKey, clientIp)
 }
 
+// WithTenantId returns a copy of context with added tenant ID.
+func WithTenantId(ctx context.Context, tenantId string) context.Context {
+	return withValue(ctx, tenantIdKey, tenantId)
+}
+
+// WithSubject returns a copy of context with added authenticated subject (e.g. API key name or JWT subject).
+func WithSubject(ctx context.Context, subject string) context.Context {
+	return withValue(ctx, subjectKey, subject)
+}
+
 func withValue(ctx context.Context, key mdcKey, value string) context.Context {
 	return context.WithValue(ctx, key, mdcValue(value))
 }
@@ -171,6 +185,8 @@ const (
 	requestMethodKey  mdcKey = "http"
 	requestUriKey     mdcKey = "uri"
 	clientIpKey       mdcKey = "client-ip"
+	subjectKey        mdcKey = "subject"
+	tenantIdKey       mdcKey = "tenant"
 
 	logLevelDebug logLevel = iota
 	logLevelInfo
@@ -227,6 +243,8 @@ var (
 		requestMethodKey,
 		requestUriKey,
 		clientIpKey,
+		subjectKey,
+		tenantIdKey,
 	}
 	logger             = log.New(os.Stderr, "", 0)
 	configuredLogLevel = logLevelInfo
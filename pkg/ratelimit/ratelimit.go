@@ -0,0 +1,95 @@
+package ratelimit
+
+import (
+	"golang.org/x/time/rate"
+	"sync"
+	"time"
+)
+
+// New creates a token bucket rate limiter that tracks a separate bucket for each key (e.g. client). Each bucket is refilled with
+// requestsPerSecond tokens every second and holds at most burst tokens.
+func New(requestsPerSecond float64, burst int) *KeyedLimiter {
+	return &KeyedLimiter{
+		limit:    rate.Limit(requestsPerSecond),
+		burst:    burst,
+		limiters: make(map[string]*limiterEntry),
+	}
+}
+
+// KeyedLimiter is a rate limiter with a separate limit for each key. It's safe for concurrent use.
+type KeyedLimiter struct {
+	limit rate.Limit
+	burst int
+
+	mutex         sync.Mutex
+	limiters      map[string]*limiterEntry
+	lastEvictTime time.Time
+}
+
+type limiterEntry struct {
+	limiter      *rate.Limiter
+	lastUsedTime time.Time
+}
+
+// Allow consumes a token from the key's bucket. If the bucket is empty then it returns false and the time after which a token will be
+// available.
+func (limiter *KeyedLimiter) Allow(key string) (allowed bool, retryAfter time.Duration) {
+	now := currentTime()
+	keyLimiter := limiter.keyLimiter(key, now)
+
+	reservation := keyLimiter.ReserveN(now, 1)
+	if !reservation.OK() {
+		// Burst is 0 - requests are never allowed.
+		return false, 0
+	}
+	delay := reservation.DelayFrom(now)
+	if delay > 0 {
+		reservation.CancelAt(now)
+		return false, delay
+	}
+	return true, 0
+}
+
+func (limiter *KeyedLimiter) keyLimiter(key string, now time.Time) *rate.Limiter {
+	limiter.mutex.Lock()
+	defer limiter.mutex.Unlock()
+
+	limiter.evictIdle(now)
+
+	entry, found := limiter.limiters[key]
+	if !found {
+		entry = &limiterEntry{limiter: rate.NewLimiter(limiter.limit, limiter.burst)}
+		limiter.limiters[key] = entry
+	}
+	entry.lastUsedTime = now
+	return entry.limiter
+}
+
+// evictIdle removes limiters that haven't been used for so long that their buckets are full again, so that memory usage doesn't grow
+// with every client ever seen. It's a no-op if eviction was done recently.
+func (limiter *KeyedLimiter) evictIdle(now time.Time) {
+	if now.Sub(limiter.lastEvictTime) < evictionPeriod {
+		return
+	}
+	limiter.lastEvictTime = now
+
+	timeout := idleTimeout
+	if limiter.limit > 0 {
+		if refillTime := time.Duration(float64(limiter.burst) / float64(limiter.limit) * float64(time.Second)); refillTime > timeout {
+			timeout = refillTime
+		}
+	}
+	for key, entry := range limiter.limiters {
+		if now.Sub(entry.lastUsedTime) > timeout {
+			delete(limiter.limiters, key)
+		}
+	}
+}
+
+const (
+	evictionPeriod = time.Minute
+	idleTimeout    = 10 * time.Minute
+)
+
+// Hook for mocking in unit tests.
+var currentTime = time.Now
@@ -0,0 +1,68 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAllow(t *testing.T) {
+	originalCurrentTimeHook := currentTime
+	defer func() {
+		currentTime = originalCurrentTimeHook
+	}()
+	now := time.Date(2022, 3, 13, 20, 59, 48, 0, time.UTC)
+	currentTime = func() time.Time {
+		return now
+	}
+
+	testObj := New(2, 3)
+
+	for i := 0; i < 3; i++ {
+		if allowed, _ := testObj.Allow("client-1"); !allowed {
+			t.Fatalf("Expected request %d within burst to be allowed", i+1)
+		}
+	}
+
+	allowed, retryAfter := testObj.Allow("client-1")
+	if allowed {
+		t.Fatalf("Expected request exceeding burst to be rejected")
+	}
+	if retryAfter != 500*time.Millisecond {
+		t.Errorf("Expected retry after 500ms but got %v", retryAfter)
+	}
+
+	if allowed, _ = testObj.Allow("client-2"); !allowed {
+		t.Errorf("Expected another client's request to be allowed")
+	}
+
+	now = now.Add(500 * time.Millisecond)
+	if allowed, _ = testObj.Allow("client-1"); !allowed {
+		t.Errorf("Expected request to be allowed after a token was replenished")
+	}
+	if allowed, _ = testObj.Allow("client-1"); allowed {
+		t.Errorf("Expected request to be rejected after using the replenished token")
+	}
+}
+
+func TestAllowShouldEvictIdleClients(t *testing.T) {
+	originalCurrentTimeHook := currentTime
+	defer func() {
+		currentTime = originalCurrentTimeHook
+	}()
+	now := time.Date(2022, 3, 13, 20, 59, 48, 0, time.UTC)
+	currentTime = func() time.Time {
+		return now
+	}
+
+	testObj := New(1, 1)
+	testObj.Allow("idle-client")
+	now = now.Add(idleTimeout + evictionPeriod)
+	testObj.Allow("active-client")
+
+	if _, found := testObj.limiters["idle-client"]; found {
+		t.Errorf("Expected idle client to be evicted")
+	}
+	if _, found := testObj.limiters["active-client"]; !found {
+		t.Errorf("Expected active client to be tracked")
+	}
+}
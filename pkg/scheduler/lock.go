@@ -0,0 +1,35 @@
+package scheduler
+
+import (
+	"context"
+	"expvar"
+)
+
+// lockMetrics count acquired and lost leadership, ticks skipped because another replica leads and errors acquiring locks. They're published
+// with expvar.
+var lockMetrics = expvar.NewMap("schedulerLocks")
+
+// Locker grants locks shared by all replicas of the application, e.g. advisory locks of their DB. A scheduler with a locker only runs its
+// function while it holds the lock named after its operation, so that the function runs on one replica at a time.
+type Locker interface {
+	// TryLock acquires the lock if it's free and returns whether it was acquired. It doesn't wait for the lock.
+	TryLock(ctx context.Context, name string) (lock Lock, acquired bool, err error)
+}
+
+// Lock is a lock acquired with Locker. It's held until it's released or lost.
+type Lock interface {
+	// Lost returns a channel that's closed when the lock is lost before it's released, e.g. because the connection holding it broke.
+	// Another replica may acquire it from then on.
+	Lost() <-chan struct{}
+	// Release releases the lock.
+	Release(ctx context.Context) error
+}
+
+// WithLocker makes the scheduler run its function only while it leads, i.e. holds the lock named after its operation. Followers try to
+// acquire the lock on every tick, so one of them takes over once the leader releases or loses it. A function running when the lock is lost
+// has its context canceled.
+func WithLocker(locker Locker) Option {
+	return func(scheduler *Scheduler) {
+		scheduler.locker = locker
+	}
+}
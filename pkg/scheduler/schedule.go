@@ -0,0 +1,236 @@
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule decides when a scheduler runs its function.
+type Schedule interface {
+	// Next returns the first time after the given one the function should run at, or the zero time if it shouldn't run anymore.
+	Next(after time.Time) time.Time
+}
+
+// Every returns a schedule of runs separated by period.
+func Every(period time.Duration) Schedule {
+	return periodSchedule(period)
+}
+
+type periodSchedule time.Duration
+
+func (schedule periodSchedule) Next(after time.Time) time.Time {
+	return after.Add(time.Duration(schedule))
+}
+
+// cronSearchLimit is how far ahead the next run of a cron schedule is searched for. Expressions that match no time within it, e.g. February
+// 30th, never run.
+const cronSearchLimit = 5 // Years
+
+// cronDescriptors are shorthands of common cron expressions.
+var cronDescriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var (
+	cronMinutes     = cronField{name: "minute", min: 0, max: 59}
+	cronHours       = cronField{name: "hour", min: 0, max: 23}
+	cronDaysOfMonth = cronField{name: "day of month", min: 1, max: 31}
+	cronMonths      = cronField{
+		name:  "month",
+		min:   1,
+		max:   12,
+		names: []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"},
+	}
+	cronDaysOfWeek = cronField{name: "day of week", min: 0, max: 7, names: []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}}
+)
+
+// ParseCron parses a standard cron expression with 5 fields - minute, hour, day of month, month and day of week. Fields are lists of
+// values, ranges (1-5) and steps (*/15, 0-30/10), months and days of week can be given with 3-letter English names. Sunday is 0 or 7.
+// If both day of month and day of week are restricted, i.e. don't start with *, a day matching either of them matches. The descriptors
+// @yearly, @annually, @monthly, @weekly, @daily, @midnight and @hourly are supported as well.
+//
+// Times are matched in location. A time skipped by a DST transition doesn't match on that day and a time repeated by one matches at
+// both of its occurrences.
+func ParseCron(expression string, location *time.Location) (Schedule, error) {
+	if location == nil {
+		return nil, fmt.Errorf("no location for cron expression %q", expression)
+	}
+	fields := strings.Fields(expression)
+	if len(fields) == 1 {
+		descriptor, ok := cronDescriptors[strings.ToLower(fields[0])]
+		if !ok {
+			return nil, fmt.Errorf("unknown cron descriptor %q", fields[0])
+		}
+		fields = strings.Fields(descriptor)
+	}
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression %q has %d fields instead of 5", expression, len(fields))
+	}
+
+	schedule := &cronSchedule{location: location}
+	var err error
+	if schedule.minutes, err = cronMinutes.parse(fields[0]); err != nil {
+		return nil, err
+	}
+	if schedule.hours, err = cronHours.parse(fields[1]); err != nil {
+		return nil, err
+	}
+	if schedule.daysOfMonth, err = cronDaysOfMonth.parse(fields[2]); err != nil {
+		return nil, err
+	}
+	if schedule.months, err = cronMonths.parse(fields[3]); err != nil {
+		return nil, err
+	}
+	if schedule.daysOfWeek, err = cronDaysOfWeek.parse(fields[4]); err != nil {
+		return nil, err
+	}
+	// Sunday can be given as 7, it's matched as 0
+	if schedule.daysOfWeek&(1<<7) != 0 {
+		schedule.daysOfWeek |= 1
+	}
+	schedule.anyDayOfMonth = strings.HasPrefix(fields[2], "*")
+	schedule.anyDayOfWeek = strings.HasPrefix(fields[4], "*")
+	return schedule, nil
+}
+
+// cronSchedule is a parsed cron expression. Each field is a set of the values it matches, with the bit of each value set.
+type cronSchedule struct {
+	minutes       uint64
+	hours         uint64
+	daysOfMonth   uint64
+	months        uint64
+	daysOfWeek    uint64
+	anyDayOfMonth bool
+	anyDayOfWeek  bool
+	location      *time.Location
+}
+
+// Next searches for the first matching time by skipping the largest non-matching unit, from months to minutes. Hours and minutes are
+// skipped by adding elapsed time, so that a DST transition doesn't move the search back.
+func (schedule *cronSchedule) Next(after time.Time) time.Time {
+	after = after.In(schedule.location)
+	next := after.Add(time.Minute - time.Duration(after.Second())*time.Second - time.Duration(after.Nanosecond()))
+	limit := next.AddDate(cronSearchLimit, 0, 0)
+
+	for next.Before(limit) {
+		switch {
+		case !matches(schedule.months, int(next.Month())):
+			next = time.Date(next.Year(), next.Month()+1, 1, 0, 0, 0, 0, schedule.location)
+		case !schedule.matchesDay(next):
+			next = time.Date(next.Year(), next.Month(), next.Day()+1, 0, 0, 0, 0, schedule.location)
+		case !matches(schedule.hours, next.Hour()):
+			next = next.Add(time.Duration(60-next.Minute()) * time.Minute)
+		case !matches(schedule.minutes, next.Minute()):
+			next = next.Add(time.Minute)
+		default:
+			return next
+		}
+	}
+	return time.Time{}
+}
+
+func (schedule *cronSchedule) matchesDay(t time.Time) bool {
+	dayOfMonth := matches(schedule.daysOfMonth, t.Day())
+	dayOfWeek := matches(schedule.daysOfWeek, int(t.Weekday()))
+	switch {
+	case schedule.anyDayOfMonth:
+		return dayOfWeek
+	case schedule.anyDayOfWeek:
+		return dayOfMonth
+	default:
+		return dayOfMonth || dayOfWeek
+	}
+}
+
+func matches(values uint64, value int) bool {
+	return values&(1<<value) != 0
+}
+
+// cronField describes the values of a field of cron expressions. names, if given, are names of values starting with min.
+type cronField struct {
+	name  string
+	min   int
+	max   int
+	names []string
+}
+
+// parse returns the set of values matched by a field of a cron expression.
+func (field cronField) parse(expression string) (uint64, error) {
+	var values uint64
+	for _, item := range strings.Split(expression, ",") {
+		itemValues, err := field.parseItem(item)
+		if err != nil {
+			return 0, fmt.Errorf("invalid %s %q: %w", field.name, expression, err)
+		}
+		values |= itemValues
+	}
+	return values, nil
+}
+
+// parseItem parses a single value, range or step of a list.
+func (field cronField) parseItem(item string) (uint64, error) {
+	rangeExpression, stepExpression, hasStep := strings.Cut(item, "/")
+	step := 1
+	if hasStep {
+		var err error
+		step, err = strconv.Atoi(stepExpression)
+		if err != nil || step <= 0 {
+			return 0, fmt.Errorf("step %q isn't a positive number", stepExpression)
+		}
+	}
+
+	var start, end int
+	if rangeExpression == "*" {
+		start, end = field.min, field.max
+	} else {
+		startExpression, endExpression, isRange := strings.Cut(rangeExpression, "-")
+		var err error
+		if start, err = field.parseValue(startExpression); err != nil {
+			return 0, err
+		}
+		switch {
+		case isRange:
+			if end, err = field.parseValue(endExpression); err != nil {
+				return 0, err
+			}
+			if end < start {
+				return 0, fmt.Errorf("range %q ends before it starts", rangeExpression)
+			}
+		case hasStep:
+			// A single value with a step, e.g. 5/15, starts a range ending with the maximum value
+			end = field.max
+		default:
+			end = start
+		}
+	}
+
+	var values uint64
+	for value := start; value <= end; value += step {
+		values |= 1 << value
+	}
+	return values, nil
+}
+
+func (field cronField) parseValue(expression string) (int, error) {
+	for i, name := range field.names {
+		if strings.EqualFold(expression, name) {
+			return field.min + i, nil
+		}
+	}
+	value, err := strconv.Atoi(expression)
+	if err != nil {
+		return 0, fmt.Errorf("%q isn't a number", expression)
+	}
+	if value < field.min || value > field.max {
+		return 0, fmt.Errorf("%d is out of range %d-%d", value, field.min, field.max)
+	}
+	return value, nil
+}
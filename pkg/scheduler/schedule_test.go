@@ -0,0 +1,195 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata" // Locations don't depend on the system's time zone database
+)
+
+func TestCronNext(t *testing.T) {
+	warsaw, err := time.LoadLocation("Europe/Warsaw")
+	if err != nil {
+		t.Fatalf("Error loading location: %v", err)
+	}
+
+	tests := map[string]struct {
+		expression string
+		location   *time.Location
+		after      time.Time
+		expected   []time.Time // Consecutive runs
+	}{
+		"Should run every minute": {
+			expression: "* * * * *",
+			location:   time.UTC,
+			after:      time.Date(2022, 3, 30, 10, 15, 30, 0, time.UTC),
+			expected: []time.Time{
+				time.Date(2022, 3, 30, 10, 16, 0, 0, time.UTC),
+				time.Date(2022, 3, 30, 10, 17, 0, 0, time.UTC),
+			},
+		},
+		"Should run at the next full minute when started exactly at a run": {
+			expression: "*/15 * * * *",
+			location:   time.UTC,
+			after:      time.Date(2022, 3, 30, 10, 15, 0, 0, time.UTC),
+			expected: []time.Time{
+				time.Date(2022, 3, 30, 10, 30, 0, 0, time.UTC),
+				time.Date(2022, 3, 30, 10, 45, 0, 0, time.UTC),
+				time.Date(2022, 3, 30, 11, 0, 0, 0, time.UTC),
+			},
+		},
+		"Should run with lists, ranges and steps": {
+			expression: "5,50 8-17/4 * * *",
+			location:   time.UTC,
+			after:      time.Date(2022, 3, 30, 12, 10, 0, 0, time.UTC),
+			expected: []time.Time{
+				time.Date(2022, 3, 30, 12, 50, 0, 0, time.UTC),
+				time.Date(2022, 3, 30, 16, 5, 0, 0, time.UTC),
+				time.Date(2022, 3, 30, 16, 50, 0, 0, time.UTC),
+				time.Date(2022, 3, 31, 8, 5, 0, 0, time.UTC),
+			},
+		},
+		"Should run on weekdays given with names": {
+			expression: "0 9 * * MON-fri",
+			location:   time.UTC,
+			after:      time.Date(2022, 4, 1, 10, 0, 0, 0, time.UTC), // Friday
+			expected: []time.Time{
+				time.Date(2022, 4, 4, 9, 0, 0, 0, time.UTC),
+				time.Date(2022, 4, 5, 9, 0, 0, 0, time.UTC),
+			},
+		},
+		"Should run on Sunday given as 7": {
+			expression: "30 6 * * 7",
+			location:   time.UTC,
+			after:      time.Date(2022, 3, 30, 0, 0, 0, 0, time.UTC),
+			expected: []time.Time{
+				time.Date(2022, 4, 3, 6, 30, 0, 0, time.UTC),
+				time.Date(2022, 4, 10, 6, 30, 0, 0, time.UTC),
+			},
+		},
+		"Should run on days matching either day of month or day of week if both are restricted": {
+			expression: "0 0 1 * sun",
+			location:   time.UTC,
+			after:      time.Date(2022, 3, 25, 0, 0, 0, 0, time.UTC),
+			expected: []time.Time{
+				time.Date(2022, 3, 27, 0, 0, 0, 0, time.UTC),
+				time.Date(2022, 4, 1, 0, 0, 0, 0, time.UTC),
+				time.Date(2022, 4, 3, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		"Should run on the last day of February of leap years only": {
+			expression: "0 12 29 feb *",
+			location:   time.UTC,
+			after:      time.Date(2022, 3, 30, 0, 0, 0, 0, time.UTC),
+			expected: []time.Time{
+				time.Date(2024, 2, 29, 12, 0, 0, 0, time.UTC),
+				time.Date(2028, 2, 29, 12, 0, 0, 0, time.UTC),
+			},
+		},
+		"Should never run on a day that doesn't exist": {
+			expression: "0 0 30 2 *",
+			location:   time.UTC,
+			after:      time.Date(2022, 3, 30, 0, 0, 0, 0, time.UTC),
+			expected:   []time.Time{{}},
+		},
+		"Should run with a descriptor": {
+			expression: "@monthly",
+			location:   time.UTC,
+			after:      time.Date(2022, 12, 15, 0, 0, 0, 0, time.UTC),
+			expected: []time.Time{
+				time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+				time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		"Should run at local time of the location": {
+			expression: "0 9 * * *",
+			location:   warsaw,
+			after:      time.Date(2022, 3, 25, 12, 0, 0, 0, time.UTC),
+			expected: []time.Time{
+				time.Date(2022, 3, 26, 8, 0, 0, 0, time.UTC), // CET, UTC+1
+				time.Date(2022, 3, 27, 7, 0, 0, 0, time.UTC), // CEST, UTC+2
+			},
+		},
+		"Should skip a time that doesn't exist because of a DST transition": {
+			expression: "30 2 * * *",
+			location:   warsaw,
+			after:      time.Date(2022, 3, 26, 12, 0, 0, 0, time.UTC),
+			expected: []time.Time{
+				time.Date(2022, 3, 28, 0, 30, 0, 0, time.UTC),
+			},
+		},
+		"Should run at both occurrences of a time repeated because of a DST transition": {
+			expression: "30 2 * * *",
+			location:   warsaw,
+			after:      time.Date(2022, 10, 29, 12, 0, 0, 0, time.UTC),
+			expected: []time.Time{
+				time.Date(2022, 10, 30, 0, 30, 0, 0, time.UTC), // CEST, UTC+2
+				time.Date(2022, 10, 30, 1, 30, 0, 0, time.UTC), // CET, UTC+1
+				time.Date(2022, 10, 31, 1, 30, 0, 0, time.UTC),
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			schedule, err := ParseCron(test.expression, test.location)
+			if err != nil {
+				t.Fatalf("Error parsing %q: %v", test.expression, err)
+			}
+
+			after := test.after
+			for i, expected := range test.expected {
+				next := schedule.Next(after)
+				if !next.Equal(expected) {
+					t.Fatalf("Expected run %d at %v but got %v", i, expected, next)
+				}
+				after = next
+			}
+		})
+	}
+}
+
+func TestParseCronShouldRejectInvalidExpressions(t *testing.T) {
+	expressions := map[string]string{
+		"Should reject too few fields":                  "* * * *",
+		"Should reject too many fields":                 "* * * * * *",
+		"Should reject an unknown descriptor":           "@fortnightly",
+		"Should reject a value out of range":            "60 * * * *",
+		"Should reject a day of month of 0":             "0 0 0 * *",
+		"Should reject a reversed range":                "0 17-8 * * *",
+		"Should reject a step of 0":                     "*/0 * * * *",
+		"Should reject a negative step":                 "*/-5 * * * *",
+		"Should reject an unknown name":                 "0 0 * * mon-fry",
+		"Should reject names in fields without them":    "0 mon * * *",
+		"Should reject an empty item of a list":         "0,,30 * * * *",
+		"Should reject a value that isn't a number":     "x * * * *",
+		"Should reject an empty expression":             "",
+		"Should reject a range without its end":         "0 8- * * *",
+		"Should reject a day of week greater than 7":    "0 0 * * 8",
+		"Should reject a month given with a day's name": "0 0 * sun *",
+	}
+
+	for name, expression := range expressions {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ParseCron(expression, time.UTC); err == nil {
+				t.Errorf("Expected an error parsing %q", expression)
+			}
+		})
+	}
+}
+
+func TestParseCronShouldRequireLocation(t *testing.T) {
+	if _, err := ParseCron("* * * * *", nil); err == nil {
+		t.Errorf("Expected an error parsing without location")
+	}
+}
+
+func TestEvery(t *testing.T) {
+	after := time.Date(2022, 3, 30, 10, 15, 30, 0, time.UTC)
+
+	next := Every(time.Hour).Next(after)
+
+	expected := time.Date(2022, 3, 30, 11, 15, 30, 0, time.UTC)
+	if !next.Equal(expected) {
+		t.Errorf("Expected %v but got %v", expected, next)
+	}
+}
@@ -2,40 +2,130 @@ package scheduler
 
 import (
 	"context"
+	"errors"
+	"expvar"
 	"github.com/GeneralKenobi/mailman/pkg/mdctx"
 	"github.com/GeneralKenobi/mailman/pkg/shutdown"
+	"math/rand"
+	"sync"
 	"time"
 )
 
+// runMetrics count runs skipped or queued because the previous run was still executing, runs that timed out and runs that failed. They're
+// published with expvar.
+var runMetrics = expvar.NewMap("scheduledRuns")
+
+// Option configures a Scheduler.
+type Option func(scheduler *Scheduler)
+
+// OverlapPolicy decides what happens to a run that's due while the previous run is still executing.
+type OverlapPolicy int
+
+const (
+	// SkipOverlapping skips the run. It's the default policy.
+	SkipOverlapping OverlapPolicy = iota
+	// QueueOverlapping starts the run as soon as the previous one completes. At most one run is queued, further ones are skipped.
+	QueueOverlapping
+)
+
+// WithRunOnStart makes the scheduler run its function as soon as it starts, in addition to the runs of its schedule.
+func WithRunOnStart() Option {
+	return func(scheduler *Scheduler) {
+		scheduler.runOnStart = true
+	}
+}
+
+// WithJitter delays every scheduled run by a random duration up to maxJitter, so that replicas and jobs with the same schedule don't run
+// at the same time. It should be shorter than the time between runs.
+func WithJitter(maxJitter time.Duration) Option {
+	return func(scheduler *Scheduler) {
+		scheduler.maxJitter = maxJitter
+	}
+}
+
+// WithTimeout cancels the context of a run that takes longer than timeout.
+func WithTimeout(timeout time.Duration) Option {
+	return func(scheduler *Scheduler) {
+		scheduler.timeout = timeout
+	}
+}
+
+// WithOverlapPolicy sets what happens to a run that's due while the previous run is still executing.
+func WithOverlapPolicy(policy OverlapPolicy) Option {
+	return func(scheduler *Scheduler) {
+		scheduler.overlapPolicy = policy
+	}
+}
+
 // New creates a scheduler for a function.
 // operationName is used for logging and enhancing MDC in contexts passed to the scheduled function.
 //
 // Scheduler recovers from scheduled function panics and logs errors from its execution.
-func New(operationName string, todo func(ctx context.Context) error) *Scheduler {
-	return &Scheduler{
+func New(operationName string, todo func(ctx context.Context) error, options ...Option) *Scheduler {
+	random := rand.New(rand.NewSource(time.Now().UnixNano()))
+	scheduler := &Scheduler{
 		operationName: operationName,
 		todo:          todo,
+		clock:         realClock{},
+		randomDuration: func(max time.Duration) time.Duration {
+			return time.Duration(random.Int63n(int64(max)))
+		},
 	}
+	for _, option := range options {
+		option(scheduler)
+	}
+	return scheduler
 }
 
 type Scheduler struct {
-	operationName string
-	todo          func(ctx context.Context) error
+	operationName  string
+	todo           func(ctx context.Context) error
+	locker         Locker // Runs the function on every tick if nil
+	lock           Lock   // Held while this scheduler leads
+	runOnStart     bool
+	maxJitter      time.Duration
+	timeout        time.Duration // No timeout if 0
+	overlapPolicy  OverlapPolicy
+	clock          clock
+	randomDuration func(max time.Duration) time.Duration // Returns a random duration in [0, max)
+
+	mutex   sync.Mutex // Guards running, queued and stopped
+	running bool       // A run is executing
+	queued  bool       // A run starts when the executing one completes
+	stopped bool       // No more runs start
+	runs    sync.WaitGroup
 }
 
-// RunPeriodically runs this scheduler's function every period until the context is canceled. The first execution occurs after period has
-// elapsed.
+// RunPeriodically runs this scheduler's function every period until the context is canceled. The first scheduled execution occurs after
+// period has elapsed.
 func (scheduler *Scheduler) RunPeriodically(ctx shutdown.Context, period time.Duration) {
-	defer ctx.Notify()
-	mdctx.Infof(nil, "Starting scheduled execution of %q every %v", scheduler.operationName, period)
+	scheduler.Run(ctx, Every(period))
+}
 
-	ticker := time.NewTicker(period)
-	defer ticker.Stop()
+// Run runs this scheduler's function at the times of schedule until the context is canceled. Runs execute in the background, so that a
+// long run doesn't delay the schedule, and the overlap policy decides about runs due while the previous one is executing. A run executing
+// when the context is canceled is waited for.
+func (scheduler *Scheduler) Run(ctx shutdown.Context, schedule Schedule) {
+	defer ctx.Notify()
+	mdctx.Infof(nil, "Starting scheduled execution of %q", scheduler.operationName)
+	defer scheduler.stop()
 
+	if scheduler.runOnStart {
+		scheduler.dispatch()
+	}
+	scheduled := scheduler.clock.Now()
 	for {
+		scheduled = scheduler.next(schedule, scheduled)
+		if scheduled.IsZero() {
+			mdctx.Warnf(nil, "Schedule of %q has no more runs", scheduler.operationName)
+			<-ctx.Done()
+			return
+		}
+
+		delay := scheduled.Sub(scheduler.clock.Now()) + scheduler.jitter()
 		select {
-		case <-ticker.C:
-			scheduler.execute()
+		case <-scheduler.clock.After(delay):
+			scheduler.dispatch()
 		case <-ctx.Done():
 			mdctx.Infof(nil, "Context canceled - stopping scheduled execution of %q", scheduler.operationName)
 			return
@@ -43,19 +133,179 @@ func (scheduler *Scheduler) RunPeriodically(ctx shutdown.Context, period time.Du
 	}
 }
 
-func (scheduler *Scheduler) execute() {
+// next returns the time of the run following the previous scheduled one. Runs that should've happened already, e.g. because jitter
+// exceeded the time between runs, are skipped.
+func (scheduler *Scheduler) next(schedule Schedule, previous time.Time) time.Time {
+	next := schedule.Next(previous)
+	if now := scheduler.clock.Now(); !next.IsZero() && next.Before(now) {
+		return schedule.Next(now)
+	}
+	return next
+}
+
+func (scheduler *Scheduler) jitter() time.Duration {
+	if scheduler.maxJitter <= 0 {
+		return 0
+	}
+	return scheduler.randomDuration(scheduler.maxJitter)
+}
+
+// dispatch starts a run in the background, unless one is executing - then the run is queued or skipped, depending on the overlap policy.
+func (scheduler *Scheduler) dispatch() {
+	scheduler.mutex.Lock()
+	defer scheduler.mutex.Unlock()
+
+	ctx := mdctx.WithOperationName(mdctx.New(), scheduler.operationName)
+	switch {
+	case scheduler.stopped:
+	case !scheduler.running:
+		scheduler.running = true
+		scheduler.runs.Add(1)
+		go scheduler.runUntilIdle()
+	case scheduler.overlapPolicy == QueueOverlapping && !scheduler.queued:
+		mdctx.Infof(ctx, "Previous scheduled execution is still running - queueing the next one")
+		runMetrics.Add("queued", 1)
+		scheduler.queued = true
+	default:
+		mdctx.Warnf(ctx, "Previous scheduled execution is still running - skipping the next one")
+		runMetrics.Add("skipped", 1)
+	}
+}
+
+// runUntilIdle runs the function, and then the queued runs, until none is queued.
+func (scheduler *Scheduler) runUntilIdle() {
+	defer scheduler.runs.Done()
+	for {
+		scheduler.tick()
+
+		scheduler.mutex.Lock()
+		if !scheduler.queued || scheduler.stopped {
+			scheduler.running = false
+			scheduler.queued = false
+			scheduler.mutex.Unlock()
+			return
+		}
+		scheduler.queued = false
+		scheduler.mutex.Unlock()
+	}
+}
+
+// stop prevents new runs from starting, waits for the executing one and releases the lock.
+func (scheduler *Scheduler) stop() {
+	scheduler.mutex.Lock()
+	scheduler.stopped = true
+	scheduler.mutex.Unlock()
+	scheduler.runs.Wait()
+	scheduler.releaseLock()
+}
+
+// tick runs the function if this scheduler leads.
+func (scheduler *Scheduler) tick() {
 	ctx := mdctx.New()
 	ctx = mdctx.WithOperationName(ctx, scheduler.operationName)
+	if !scheduler.lead(ctx) {
+		return
+	}
+	scheduler.execute(ctx)
+}
+
+// lead checks that the scheduler still holds its lock, or tries to acquire it if it doesn't. Schedulers without a locker always lead.
+func (scheduler *Scheduler) lead(ctx context.Context) bool {
+	if scheduler.locker == nil {
+		return true
+	}
+	if scheduler.lock != nil {
+		select {
+		case <-scheduler.lock.Lost():
+			mdctx.Warnf(ctx, "Lost the lock of scheduled execution, another replica may take over")
+			lockMetrics.Add("lost", 1)
+			scheduler.lock = nil
+		default:
+			return true
+		}
+	}
+
+	lock, acquired, err := scheduler.locker.TryLock(ctx, scheduler.operationName)
+	if err != nil {
+		mdctx.Errorf(ctx, "Error acquiring the lock of scheduled execution: %v", err)
+		lockMetrics.Add("errors", 1)
+		return false
+	}
+	if !acquired {
+		mdctx.Debugf(ctx, "Skipping scheduled execution, another replica holds its lock")
+		lockMetrics.Add("skipped", 1)
+		return false
+	}
+	mdctx.Infof(ctx, "Acquired the lock of scheduled execution, this replica runs it until the lock is released or lost")
+	lockMetrics.Add("acquired", 1)
+	scheduler.lock = lock
+	return true
+}
+
+// execute runs the function. Its context is canceled if the lock is lost in the meantime or the run times out.
+func (scheduler *Scheduler) execute(ctx context.Context) {
 	mdctx.Debugf(ctx, "Starting scheduled execution")
+	defer func() {
+		if panicErr := recover(); panicErr != nil {
+			mdctx.Errorf(ctx, "Recovered from panic in scheduled execution: %v", panicErr)
+			runMetrics.Add("failed", 1)
+		}
+	}()
+
+	var cancel context.CancelFunc
+	if scheduler.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, scheduler.timeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+	defer cancel()
+	if scheduler.lock != nil {
+		go func(lost <-chan struct{}) {
+			select {
+			case <-lost:
+				mdctx.Warnf(ctx, "Lost the lock during scheduled execution - canceling it")
+				cancel()
+			case <-ctx.Done():
+			}
+		}(scheduler.lock.Lost())
+	}
 
 	err := scheduler.todo(ctx)
-	if panicErr := recover(); panicErr != nil {
-		mdctx.Errorf(ctx, "Recovered from panic in scheduled execution: %v", panicErr)
-		return
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		mdctx.Warnf(ctx, "Scheduled execution timed out after %v", scheduler.timeout)
+		runMetrics.Add("timedOut", 1)
 	}
 	if err != nil {
 		mdctx.Errorf(ctx, "Scheduled execution ended with error: %v", err)
+		runMetrics.Add("failed", 1)
 		return
 	}
 	mdctx.Debugf(ctx, "Scheduled execution completed with success")
 }
+
+func (scheduler *Scheduler) releaseLock() {
+	if scheduler.lock == nil {
+		return
+	}
+	ctx := mdctx.WithOperationName(mdctx.New(), scheduler.operationName)
+	if err := scheduler.lock.Release(ctx); err != nil {
+		mdctx.Errorf(ctx, "Error releasing the lock of scheduled execution: %v", err)
+	}
+	scheduler.lock = nil
+}
+
+// clock tells the time and waits for it. It's replaced with a fake clock in unit tests.
+type clock interface {
+	Now() time.Time
+	After(duration time.Duration) <-chan time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) After(duration time.Duration) <-chan time.Time {
+	return time.After(duration)
+}
@@ -0,0 +1,463 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"github.com/GeneralKenobi/mailman/pkg/shutdown"
+	"sync"
+	"testing"
+	"time"
+)
+
+var start = time.Date(2022, 3, 30, 10, 15, 30, 0, time.UTC)
+
+func TestRun(t *testing.T) {
+	tests := map[string]struct {
+		options      []Option
+		schedule     Schedule
+		advances     []time.Duration // Advances of the clock, each after the scheduler starts waiting
+		expectedRuns []time.Time
+	}{
+		"Should run after every period": {
+			schedule:     Every(time.Minute),
+			advances:     []time.Duration{30 * time.Second, 30 * time.Second, time.Minute},
+			expectedRuns: []time.Time{start.Add(time.Minute), start.Add(2 * time.Minute)},
+		},
+		"Should run on start": {
+			options:      []Option{WithRunOnStart()},
+			schedule:     Every(time.Minute),
+			advances:     []time.Duration{time.Minute},
+			expectedRuns: []time.Time{start, start.Add(time.Minute)},
+		},
+		"Should delay runs by jitter without moving the schedule": {
+			options:  []Option{WithJitter(20 * time.Second)},
+			schedule: Every(time.Minute),
+			advances: []time.Duration{time.Minute, 10 * time.Second, 50 * time.Second, 10 * time.Second},
+			expectedRuns: []time.Time{
+				start.Add(time.Minute + 10*time.Second),
+				start.Add(2*time.Minute + 10*time.Second),
+			},
+		},
+		"Should run on a cron schedule": {
+			schedule: mustParseCron(t, "*/15 * * * *", time.FixedZone("UTC+2", 2*60*60)),
+			advances: []time.Duration{14*time.Minute + 30*time.Second, 15 * time.Minute},
+			expectedRuns: []time.Time{
+				time.Date(2022, 3, 30, 10, 30, 0, 0, time.UTC),
+				time.Date(2022, 3, 30, 10, 45, 0, 0, time.UTC),
+			},
+		},
+		"Should skip runs missed because of jitter": {
+			options:      []Option{WithJitter(time.Minute)},
+			schedule:     Every(30 * time.Second),
+			advances:     []time.Duration{time.Minute, time.Minute},
+			expectedRuns: []time.Time{start.Add(time.Minute), start.Add(2 * time.Minute)},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var mutex sync.Mutex
+			var runs []time.Time
+			clock := &clockMock{now: start}
+			scheduler := New("test", func(context.Context) error {
+				mutex.Lock()
+				defer mutex.Unlock()
+				runs = append(runs, clock.Now())
+				return nil
+			}, test.options...)
+			scheduler.clock = clock
+			scheduler.randomDuration = func(max time.Duration) time.Duration { return max / 2 }
+
+			stop := startScheduler(scheduler, test.schedule)
+			for _, advance := range test.advances {
+				clock.waitForTimer(t)
+				waitForIdle(t, scheduler)
+				clock.advance(advance)
+			}
+			clock.waitForTimer(t)
+			waitForIdle(t, scheduler)
+			stop()
+
+			if fmt.Sprint(runs) != fmt.Sprint(test.expectedRuns) {
+				t.Errorf("Expected runs at %v but got %v", test.expectedRuns, runs)
+			}
+		})
+	}
+}
+
+func TestOverlappingRuns(t *testing.T) {
+	tests := map[string]struct {
+		policy          OverlapPolicy
+		expectedRuns    int
+		expectedSkipped int64
+		expectedQueued  int64
+	}{
+		"Should skip runs while the previous one is executing": {
+			policy:          SkipOverlapping,
+			expectedRuns:    1,
+			expectedSkipped: 2,
+		},
+		"Should queue one run while the previous one is executing": {
+			policy:          QueueOverlapping,
+			expectedRuns:    2,
+			expectedSkipped: 1,
+			expectedQueued:  1,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			skippedBefore, queuedBefore := metricValue(runMetrics, "skipped"), metricValue(runMetrics, "queued")
+			release := make(chan struct{})
+			started := make(chan struct{}, 10)
+			clock := &clockMock{now: start}
+			scheduler := New("test", func(context.Context) error {
+				started <- struct{}{}
+				<-release
+				return nil
+			}, WithOverlapPolicy(test.policy))
+			scheduler.clock = clock
+
+			stop := startScheduler(scheduler, Every(time.Minute))
+			clock.waitForTimer(t)
+			clock.advance(time.Minute)
+			<-started
+			// Both runs are due while the first one executes
+			for i := 0; i < 2; i++ {
+				clock.waitForTimer(t)
+				clock.advance(time.Minute)
+			}
+			clock.waitForTimer(t)
+			close(release)
+			waitForIdle(t, scheduler)
+			stop()
+
+			if len(started) != test.expectedRuns-1 {
+				t.Errorf("Expected %d runs but got %d", test.expectedRuns, len(started)+1)
+			}
+			if skipped := metricValue(runMetrics, "skipped") - skippedBefore; skipped != test.expectedSkipped {
+				t.Errorf("Expected %d skipped runs but got %d", test.expectedSkipped, skipped)
+			}
+			if queued := metricValue(runMetrics, "queued") - queuedBefore; queued != test.expectedQueued {
+				t.Errorf("Expected %d queued runs but got %d", test.expectedQueued, queued)
+			}
+		})
+	}
+}
+
+func TestShouldWaitForExecutingRunOnShutdown(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{})
+	clock := &clockMock{now: start}
+	scheduler := New("test", func(context.Context) error {
+		close(started)
+		<-release
+		return nil
+	}, WithRunOnStart())
+	scheduler.clock = clock
+
+	parent := shutdown.NewParentContext(10 * time.Second)
+	stopped := make(chan struct{})
+	go func() {
+		scheduler.Run(parent.NewContext("test"), Every(time.Minute))
+		close(stopped)
+	}()
+	<-started
+	go parent.Cancel()
+
+	select {
+	case <-stopped:
+		t.Fatalf("Expected the scheduler to wait for the executing run")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+	<-stopped
+}
+
+func TestShouldTimeOutRun(t *testing.T) {
+	timedOutBefore := metricValue(runMetrics, "timedOut")
+	var runErr error
+	scheduler := New("test", func(ctx context.Context) error {
+		<-ctx.Done()
+		runErr = ctx.Err()
+		return runErr
+	}, WithTimeout(10*time.Millisecond))
+
+	scheduler.tick()
+
+	if !errors.Is(runErr, context.DeadlineExceeded) {
+		t.Errorf("Expected the run to time out but got %v", runErr)
+	}
+	if timedOut := metricValue(runMetrics, "timedOut") - timedOutBefore; timedOut != 1 {
+		t.Errorf("Expected 1 timed out run but got %d", timedOut)
+	}
+}
+
+func TestLeadership(t *testing.T) {
+	tests := map[string]struct {
+		locker           *lockerMock
+		ticks            func(scheduler *Scheduler, locker *lockerMock)
+		expectedRuns     int
+		expectedTryLocks int
+	}{
+		"Should run on every tick without a locker": {
+			ticks: func(scheduler *Scheduler, _ *lockerMock) {
+				scheduler.tick()
+				scheduler.tick()
+			},
+			expectedRuns: 2,
+		},
+		"Should keep running while holding the lock": {
+			locker: &lockerMock{free: true},
+			ticks: func(scheduler *Scheduler, _ *lockerMock) {
+				scheduler.tick()
+				scheduler.tick()
+			},
+			expectedRuns:     2,
+			expectedTryLocks: 1,
+		},
+		"Should skip ticks while another replica holds the lock": {
+			locker: &lockerMock{free: false},
+			ticks: func(scheduler *Scheduler, _ *lockerMock) {
+				scheduler.tick()
+				scheduler.tick()
+			},
+			expectedRuns:     0,
+			expectedTryLocks: 2,
+		},
+		"Should skip ticks when acquiring the lock fails": {
+			locker: &lockerMock{err: errors.New("connection refused")},
+			ticks: func(scheduler *Scheduler, _ *lockerMock) {
+				scheduler.tick()
+			},
+			expectedRuns:     0,
+			expectedTryLocks: 1,
+		},
+		"Should try to acquire the lock again after losing it": {
+			locker: &lockerMock{free: true},
+			ticks: func(scheduler *Scheduler, locker *lockerMock) {
+				scheduler.tick()
+				locker.loseLock()
+				locker.free = false
+				scheduler.tick()
+				locker.free = true
+				scheduler.tick()
+			},
+			expectedRuns:     2,
+			expectedTryLocks: 3,
+		},
+		"Should take over after the leader releases the lock": {
+			locker: &lockerMock{free: false},
+			ticks: func(scheduler *Scheduler, locker *lockerMock) {
+				scheduler.tick()
+				locker.free = true
+				scheduler.tick()
+			},
+			expectedRuns:     1,
+			expectedTryLocks: 2,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			runs := 0
+			var options []Option
+			if test.locker != nil {
+				options = append(options, WithLocker(test.locker))
+			}
+			scheduler := New("test", func(context.Context) error {
+				runs++
+				return nil
+			}, options...)
+
+			test.ticks(scheduler, test.locker)
+
+			if runs != test.expectedRuns {
+				t.Errorf("Expected %d runs but got %d", test.expectedRuns, runs)
+			}
+			if test.locker != nil && test.locker.tryLocks != test.expectedTryLocks {
+				t.Errorf("Expected %d attempts to acquire the lock but got %d", test.expectedTryLocks, test.locker.tryLocks)
+			}
+		})
+	}
+}
+
+func TestShouldCancelExecutionWhenLockIsLost(t *testing.T) {
+	locker := &lockerMock{free: true}
+	var runErr error
+	scheduler := New("test", func(ctx context.Context) error {
+		locker.loseLock()
+		<-ctx.Done()
+		runErr = ctx.Err()
+		return runErr
+	}, WithLocker(locker))
+
+	scheduler.tick()
+
+	if !errors.Is(runErr, context.Canceled) {
+		t.Errorf("Expected the execution to be canceled but got %v", runErr)
+	}
+}
+
+func TestShouldReleaseLock(t *testing.T) {
+	locker := &lockerMock{free: true}
+	scheduler := New("test", func(context.Context) error { return nil }, WithLocker(locker))
+	scheduler.tick()
+
+	scheduler.releaseLock()
+
+	if locker.lock == nil || !locker.lock.released {
+		t.Errorf("Expected the lock to be released")
+	}
+	if scheduler.lock != nil {
+		t.Errorf("Expected the scheduler not to hold the lock")
+	}
+}
+
+func TestShouldRecoverFromPanic(t *testing.T) {
+	scheduler := New("test", func(context.Context) error {
+		panic("panic in scheduled function")
+	})
+
+	scheduler.tick()
+}
+
+// lockerMock grants a lockMock if free is true.
+type lockerMock struct {
+	free     bool
+	err      error
+	tryLocks int
+	lock     *lockMock // The last granted lock
+}
+
+func (locker *lockerMock) TryLock(context.Context, string) (Lock, bool, error) {
+	locker.tryLocks++
+	if locker.err != nil || !locker.free {
+		return nil, false, locker.err
+	}
+	locker.lock = &lockMock{lost: make(chan struct{})}
+	return locker.lock, true, nil
+}
+
+func (locker *lockerMock) loseLock() {
+	close(locker.lock.lost)
+}
+
+type lockMock struct {
+	lost     chan struct{}
+	released bool
+}
+
+func (lock *lockMock) Lost() <-chan struct{} {
+	return lock.lost
+}
+
+func (lock *lockMock) Release(context.Context) error {
+	lock.released = true
+	return nil
+}
+
+// startScheduler runs the scheduler in the background. The returned function stops it and waits until it stops.
+func startScheduler(scheduler *Scheduler, schedule Schedule) (stop func()) {
+	parent := shutdown.NewParentContext(10 * time.Second)
+	stopped := make(chan struct{})
+	go func() {
+		scheduler.Run(parent.NewContext("test"), schedule)
+		close(stopped)
+	}()
+	return func() {
+		parent.Cancel()
+		<-stopped
+	}
+}
+
+// waitForIdle waits until the scheduler has no executing or queued run.
+func waitForIdle(t *testing.T, scheduler *Scheduler) {
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		scheduler.mutex.Lock()
+		running := scheduler.running
+		scheduler.mutex.Unlock()
+		if !running {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("Timed out waiting for the scheduler to be idle")
+}
+
+func mustParseCron(t *testing.T, expression string, location *time.Location) Schedule {
+	schedule, err := ParseCron(expression, location)
+	if err != nil {
+		t.Fatalf("Error parsing %q: %v", expression, err)
+	}
+	return schedule
+}
+
+func metricValue(metrics *expvar.Map, key string) int64 {
+	metrics.Add(key, 0) // Creates the counter if nothing was counted yet
+	return metrics.Get(key).(*expvar.Int).Value()
+}
+
+// clockMock is a clock whose time only moves when it's advanced.
+type clockMock struct {
+	mutex  sync.Mutex
+	now    time.Time
+	timers []timerMock
+}
+
+type timerMock struct {
+	deadline time.Time
+	channel  chan time.Time
+}
+
+func (clock *clockMock) Now() time.Time {
+	clock.mutex.Lock()
+	defer clock.mutex.Unlock()
+	return clock.now
+}
+
+func (clock *clockMock) After(duration time.Duration) <-chan time.Time {
+	clock.mutex.Lock()
+	defer clock.mutex.Unlock()
+	channel := make(chan time.Time, 1)
+	if duration <= 0 {
+		channel <- clock.now
+		return channel
+	}
+	clock.timers = append(clock.timers, timerMock{deadline: clock.now.Add(duration), channel: channel})
+	return channel
+}
+
+// advance moves the time forward and fires the timers that are due.
+func (clock *clockMock) advance(duration time.Duration) {
+	clock.mutex.Lock()
+	defer clock.mutex.Unlock()
+	clock.now = clock.now.Add(duration)
+	var pending []timerMock
+	for _, timer := range clock.timers {
+		if timer.deadline.After(clock.now) {
+			pending = append(pending, timer)
+			continue
+		}
+		timer.channel <- clock.now
+	}
+	clock.timers = pending
+}
+
+// waitForTimer waits until someone waits for the clock.
+func (clock *clockMock) waitForTimer(t *testing.T) {
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		clock.mutex.Lock()
+		waiting := len(clock.timers) > 0
+		clock.mutex.Unlock()
+		if waiting {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("Timed out waiting for a timer")
+}
@@ -0,0 +1,82 @@
+// Package webhook signs webhook payloads sent by mailman and verifies the signatures on the receiving end.
+//
+// The signature header has the form "t=<unix timestamp>,v1=<hex-encoded HMAC-SHA256>". The HMAC is computed with the subscription's secret
+// over the timestamp, a dot and the request body, so that captured requests can't be replayed with a different timestamp.
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	SignatureHeader  = "X-Mailman-Signature" // Signature of the request body
+	EventTypeHeader  = "X-Mailman-Event"     // Type of the delivered event
+	DeliveryIdHeader = "X-Mailman-Delivery"  // ID of the delivery, the same in every attempt
+)
+
+var (
+	// ErrInvalidSignature is returned by Verify if the signature header is malformed or doesn't match the body.
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	// ErrSignatureExpired is returned by Verify if the signature's timestamp is outside the tolerance.
+	ErrSignatureExpired = errors.New("webhook signature timestamp outside tolerance")
+)
+
+// Sign computes the signature header value for the body sent at timestamp.
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	unixTimestamp := strconv.FormatInt(timestamp.Unix(), 10)
+	return fmt.Sprintf("t=%s,v1=%s", unixTimestamp, hex.EncodeToString(mac(secret, unixTimestamp, body)))
+}
+
+// Verify checks that the signature header value was computed with the secret for the body and that its timestamp differs from now by at
+// most tolerance.
+func Verify(secret, signature string, body []byte, now time.Time, tolerance time.Duration) error {
+	var unixTimestamp string
+	var signatures [][]byte
+	for _, part := range strings.Split(signature, ",") {
+		key, value, _ := strings.Cut(part, "=")
+		switch key {
+		case "t":
+			unixTimestamp = value
+		case "v1":
+			decoded, err := hex.DecodeString(value)
+			if err != nil {
+				return ErrInvalidSignature
+			}
+			signatures = append(signatures, decoded)
+		}
+	}
+
+	seconds, err := strconv.ParseInt(unixTimestamp, 10, 64)
+	if err != nil || len(signatures) == 0 {
+		return ErrInvalidSignature
+	}
+	expected := mac(secret, unixTimestamp, body)
+	valid := false
+	for _, candidate := range signatures {
+		valid = valid || hmac.Equal(candidate, expected)
+	}
+	if !valid {
+		return ErrInvalidSignature
+	}
+
+	age := now.Sub(time.Unix(seconds, 0))
+	if age > tolerance || age < -tolerance {
+		return ErrSignatureExpired
+	}
+	return nil
+}
+
+func mac(secret, unixTimestamp string, body []byte) []byte {
+	hash := hmac.New(sha256.New, []byte(secret))
+	hash.Write([]byte(unixTimestamp))
+	hash.Write([]byte("."))
+	hash.Write(body)
+	return hash.Sum(nil)
+}
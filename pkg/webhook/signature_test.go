@@ -0,0 +1,79 @@
+package webhook
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestVerify(t *testing.T) {
+	body := []byte(`{"id":1}`)
+	signTime := time.Date(2022, 3, 30, 15, 42, 38, 0, time.UTC)
+	signature := Sign("secret", signTime, body)
+
+	tests := map[string]struct {
+		secret    string
+		signature string
+		body      []byte
+		now       time.Time
+		expected  error
+	}{
+		"Should accept valid signature": {
+			secret:    "secret",
+			signature: signature,
+			body:      body,
+			now:       signTime.Add(time.Minute),
+		},
+		"Should accept signature with multiple versions if one of them matches": {
+			secret:    "secret",
+			signature: "t=1648654958,v1=00ff," + signature[len("t=1648654958,"):],
+			body:      body,
+			now:       signTime,
+		},
+		"Should reject signature computed with different secret": {
+			secret:    "other secret",
+			signature: signature,
+			body:      body,
+			now:       signTime,
+			expected:  ErrInvalidSignature,
+		},
+		"Should reject signature of different body": {
+			secret:    "secret",
+			signature: signature,
+			body:      []byte(`{"id":2}`),
+			now:       signTime,
+			expected:  ErrInvalidSignature,
+		},
+		"Should reject signature with replaced timestamp": {
+			secret:    "secret",
+			signature: "t=1648654959" + signature[len("t=1648654958"):],
+			body:      body,
+			now:       signTime,
+			expected:  ErrInvalidSignature,
+		},
+		"Should reject malformed signature": {
+			secret:    "secret",
+			signature: "v1=zz",
+			body:      body,
+			now:       signTime,
+			expected:  ErrInvalidSignature,
+		},
+		"Should reject expired signature": {
+			secret:    "secret",
+			signature: signature,
+			body:      body,
+			now:       signTime.Add(6 * time.Minute),
+			expected:  ErrSignatureExpired,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := Verify(test.secret, test.signature, test.body, test.now, 5*time.Minute)
+
+			if !errors.Is(err, test.expected) {
+				t.Errorf("Expected error %v, got %v", test.expected, err)
+			}
+		})
+	}
+}